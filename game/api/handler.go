@@ -4,27 +4,70 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/game/service"
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/game/syncer"
 	"github.com/Ftotnem/GO-SERVICES/shared/api"
-	"github.com/google/uuid"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
 	"github.com/gorilla/mux"
 )
 
+// onlineRetryAfterSeconds is the Retry-After hint sent when PlayerOnline is refused with 503
+// (fetch queue full or player profile temporarily unavailable).
+const onlineRetryAfterSeconds = "2"
+
+// Default and maximum number of entries returned by the playtime audit endpoint.
+const (
+	defaultPlaytimeAuditLimit = 100
+	maxPlaytimeAuditLimit     = 1000
+)
+
+// Default and maximum number of entries returned by the top teams and top players endpoints.
+const (
+	defaultTopTeamsLimit   = 10
+	maxTopTeamsLimit       = 100
+	defaultTopPlayersLimit = 10
+	maxTopPlayersLimit     = 100
+)
+
+// maxBatchSize caps the number of UUIDs accepted by the batch online/offline endpoints.
+const maxBatchSize = 1000
+
+// RingInspector exposes the sharding ring and task ownership for diagnostics. It is implemented by
+// cluster.ServiceAssignmentManager.
+type RingInspector interface {
+	RingMembers() []string
+	ModuloEnabled() bool
+	IsResponsible(entityID string) (bool, error)
+}
+
 // GameAPIHandlers holds references to the services that handle business logic for the game service.
 type GameAPIHandlers struct {
-	GameService *service.GameService // Assuming you have a game service business logic layer
+	GameService      *service.GameService // Assuming you have a game service business logic layer
+	BatchConcurrency int                  // Players processed in parallel by the batch endpoints
+	MaxBanDuration   time.Duration        // Longest temporary ban accepted; 0 means no limit. Permanent bans are always allowed.
+	Ring             RingInspector        // Optional; backs the /game/debug/ring and /game/debug/is-leader endpoints
+	InstanceID       string               // This instance's registry ID, reported by the debug endpoints
+	PlaytimeDecimals int                  // Decimal places playtime values in responses are rounded to; negative disables rounding
+	AdminToken       string               // Required by admin-only routes outside /game/admin/; without one they reject every request
 }
 
 // NewGameAPIHandlers is the constructor for your Game API handlers.
 // It takes the actual GameService (business logic) as a dependency.
-func NewGameAPIHandlers(gs *service.GameService) *GameAPIHandlers {
+func NewGameAPIHandlers(gs *service.GameService, batchConcurrency int, maxBanDuration time.Duration) *GameAPIHandlers {
 	return &GameAPIHandlers{
-		GameService: gs,
+		GameService:      gs,
+		BatchConcurrency: batchConcurrency,
+		MaxBanDuration:   maxBanDuration,
+		PlaytimeDecimals: DefaultPlaytimeDecimals,
 	}
 }
 
@@ -36,9 +79,37 @@ type PlayerUUIDRequest struct {
 	UUID string `json:"uuid"`
 }
 
+// PlaytimeAuditResponse is the structure for the JSON response for a player's playtime audit log.
+type PlaytimeAuditResponse struct {
+	UUID    string                     `json:"uuid"`
+	Entries []store.PlaytimeAuditEntry `json:"entries"` // Newest first
+}
+
+// GhostSessionsResponse is the structure for the JSON response listing ghost online sessions.
+type GhostSessionsResponse struct {
+	Sessions []store.GhostSession `json:"sessions"`
+	Count    int                  `json:"count"`
+	Cleaned  int                  `json:"cleaned"` // Sessions ended; only non-zero with ?cleanup=true
+}
+
+// RefreshOnlineBatchItem is the outcome for a single player in a batch heartbeat.
+type RefreshOnlineBatchItem struct {
+	UUID    string `json:"uuid"`
+	Present bool   `json:"present"` // False if the session had expired; the player must go online again
+	Error   string `json:"error,omitempty"`
+}
+
+// RefreshOnlineBatchResponse is the structure for the JSON response of a batch heartbeat.
+type RefreshOnlineBatchResponse struct {
+	Refreshed int                      `json:"refreshed"`
+	Missing   int                      `json:"missing"` // Expired sessions, invalid UUIDs and sessions that could not be refreshed
+	Results   []RefreshOnlineBatchItem `json:"results"`
+}
+
 // PlaytimeResponse is the structure for the JSON response for playtime requests.
 type PlaytimeResponse struct {
-	Playtime float64 `json:"playtime"`
+	Playtime  float64 `json:"playtime"`
+	InSession bool    `json:"inSession,omitempty"` // With includeLive: the playtime comes from an active session rather than the profile
 }
 
 // DeltaPlaytimeResponse is the structure for the JSON response for delta playtime requests.
@@ -58,11 +129,140 @@ type PlayerOnlineStatusResponse struct {
 	IsOnline bool   `json:"isOnline"`
 }
 
+// PlayerExistsResponse defines the structure for the JSON response for a player data presence check.
+type PlayerExistsResponse struct {
+	UUID   string `json:"uuid"`
+	Exists bool   `json:"exists"` // True if any session or playtime data for the player is in Redis
+}
+
+// SessionStartResponse defines the structure for the JSON response for a player's session start.
+type SessionStartResponse struct {
+	UUID         string `json:"uuid"`
+	SessionStart int64  `json:"sessionStart"` // Unix timestamp of when the current session began
+}
+
+// PlayerTeamResponse defines the structure for the JSON response for a player's current team.
+type PlayerTeamResponse struct {
+	UUID string `json:"uuid"`
+	Team string `json:"team"`
+}
+
+// RingResponse defines the structure for the JSON response of the ring diagnostic endpoint.
+type RingResponse struct {
+	InstanceID    string   `json:"instanceId"`
+	Members       []string `json:"members"`
+	Count         int      `json:"count"`
+	ModuloEnabled bool     `json:"moduloEnabled"` // If true, assignment ignores the ring
+}
+
+// IsLeaderResponse defines the structure for the JSON response of the sync leader diagnostic endpoint.
+type IsLeaderResponse struct {
+	InstanceID string `json:"instanceId"`
+	TaskKey    string `json:"taskKey"`
+	IsLeader   bool   `json:"isLeader"`
+}
+
+// TeamPlaytimeBatchRequest is the structure for the request body for fetching several teams' playtimes.
+type TeamPlaytimeBatchRequest struct {
+	TeamIDs []string `json:"teamIds"`
+}
+
+// TeamPlaytimeBatchResponse defines the structure for the JSON response for several teams' total playtimes.
+type TeamPlaytimeBatchResponse struct {
+	Teams map[string]float64 `json:"teams"` // Team ID -> total playtime; 0 for teams with no recorded playtime
+}
+
+// TopTeamsResponse defines the structure for the JSON response for the team leaderboard.
+type TopTeamsResponse struct {
+	Teams []store.TeamPlaytime `json:"teams"` // Highest playtime first
+}
+
+// TopPlayersResponse defines the structure for the JSON response for the player leaderboard.
+type TopPlayersResponse struct {
+	Players []store.PlayerPlaytime `json:"players"` // Highest playtime first; only players with a session in Redis
+}
+
+// ResetPlaytimeRequest is the structure for the request body for resetting a player's playtime.
+type ResetPlaytimeRequest struct {
+	AdjustTeamTotal bool `json:"adjust_team_total,omitempty"` // If true, the removed playtime is subtracted from the team total right away
+}
+
+// ResetPlaytimeResponse is the structure for the JSON response after resetting a player's playtime.
+type ResetPlaytimeResponse struct {
+	Message         string  `json:"message"`
+	UUID            string  `json:"uuid"`
+	RemovedPlaytime float64 `json:"removed_playtime"`
+	Team            string  `json:"team,omitempty"`
+	TeamAdjusted    bool    `json:"team_adjusted"`
+	ProfileUpdated  bool    `json:"profile_updated"`
+	ProfileError    string  `json:"profile_error,omitempty"`
+}
+
+// SetPlaytimeMultiplierRequest is the structure for the request body for setting a player's playtime multiplier.
+type SetPlaytimeMultiplierRequest struct {
+	Multiplier float64 `json:"multiplier"`
+}
+
+// SetPlaytimeMultiplierResponse is the structure for the JSON response after setting a player's playtime multiplier.
+type SetPlaytimeMultiplierResponse struct {
+	Message    string  `json:"message"`
+	UUID       string  `json:"uuid"`
+	Multiplier float64 `json:"multiplier"`
+	Applied    bool    `json:"applied"` // The player had a live session, which uses the multiplier from now on
+}
+
+// PlayerDebugResponse is the structure for the JSON response of the player debug endpoint.
+// Fields for Redis keys that don't exist are omitted.
+type PlayerDebugResponse struct {
+	UUID            string         `json:"uuid"`
+	Online          bool           `json:"online"`
+	OnlineTTLMillis int64          `json:"online_ttl_ms,omitempty"`
+	PendingOffline  bool           `json:"pending_offline"`
+	SessionStart    int64          `json:"session_start,omitempty"` // Unix timestamp
+	TotalPlaytime   *float64       `json:"total_playtime,omitempty"`
+	DeltaPlaytime   *float64       `json:"delta_playtime,omitempty"`
+	Team            string         `json:"team,omitempty"`
+	Ban             *store.BanInfo `json:"ban,omitempty"`
+}
+
+// RenameTeamRequest is the structure for the request body for renaming a team.
+type RenameTeamRequest struct {
+	NewTeam string `json:"new_team"`
+}
+
+// RenameTeamResponse is the structure for the JSON response after renaming a team.
+type RenameTeamResponse struct {
+	Message           string  `json:"message"`
+	OldTeam           string  `json:"old_team"`
+	NewTeam           string  `json:"new_team"`
+	PlayersReassigned int     `json:"players_reassigned"`
+	MovedPlaytime     float64 `json:"moved_playtime"`
+}
+
+// OnlineCountResponse defines the structure for the JSON response for the online player count.
+type OnlineCountResponse struct {
+	Online int `json:"online"`
+}
+
+// OnlineStatsResponse defines the structure for the JSON response for aggregate online stats.
+type OnlineStatsResponse struct {
+	TotalOnline int            `json:"totalOnline"`
+	Teams       map[string]int `json:"teams"`      // Team ID -> online player count
+	Unassigned  int            `json:"unassigned"` // Online players without a team
+	ComputedAt  int64          `json:"computedAt"` // Unix timestamp of the (possibly cached) snapshot
+}
+
+// AssignTeamRequest is the structure for the request body for assigning a player's team.
+type AssignTeamRequest struct {
+	Team string `json:"team"`
+}
+
 // BanRequest is the structure for the request body for banning.
 type BanRequest struct {
 	UUID        string `json:"uuid"`
 	DurationSec int64  `json:"duration_seconds"` // Duration in seconds. 0 for permanent.
 	Reason      string `json:"reason,omitempty"`
+	Force       bool   `json:"force,omitempty"` // If true, replaces an existing ban even if it lasts longer
 }
 
 // BanResponse is the structure for the JSON response after a ban operation.
@@ -73,9 +273,58 @@ type BanResponse struct {
 	IsPermanent bool   `json:"is_permanent"`
 }
 
+// PlayerOnlineResponse is the response to a successful POST /game/player/online.
+type PlayerOnlineResponse struct {
+	Message       string `json:"message"`
+	UUID          string `json:"uuid"`
+	AlreadyOnline bool   `json:"alreadyOnline"` // The player was online before the request; their session was re-initialized
+}
+
+// PlayerBannedResponse is the 403 response when a banned player tries to go online.
+// It carries the ban so the caller can show the player why they were refused.
+type PlayerBannedResponse struct {
+	api.JSONErrorResponse
+	UUID        string `json:"uuid"`
+	Reason      string `json:"reason,omitempty"`
+	ExpiresAt   int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 for permanent
+	IsPermanent bool   `json:"is_permanent"`
+}
+
+// ModifyBanRequest is the structure for the request body for modifying an active ban.
+type ModifyBanRequest struct {
+	UUID        string `json:"uuid"`
+	DurationSec int64  `json:"duration_seconds"` // New duration in seconds, counted from now. 0 for permanent.
+}
+
+// ModifyBanResponse is the structure for the JSON response after modifying a ban.
+type ModifyBanResponse struct {
+	Message        string `json:"message"`
+	UUID           string `json:"uuid"`
+	ExpiresAt      int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 for permanent
+	IsPermanent    bool   `json:"is_permanent"`
+	ProfileUpdated bool   `json:"profile_updated"`
+	ProfileError   string `json:"profile_error,omitempty"`
+}
+
+// BanAndKickResponse is the structure for the JSON response after a coordinated ban-and-kick.
+// Each step is reported separately so partial failures are visible to the caller.
+type BanAndKickResponse struct {
+	Message        string `json:"message"`
+	UUID           string `json:"uuid"`
+	ExpiresAt      int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 for permanent
+	IsPermanent    bool   `json:"is_permanent"`
+	RedisBanned    bool   `json:"redis_banned"`
+	ProfileUpdated bool   `json:"profile_updated"`
+	ProfileError   string `json:"profile_error,omitempty"`
+	WasOnline      bool   `json:"was_online"`
+	Kicked         bool   `json:"kicked"`
+	KickError      string `json:"kick_error,omitempty"`
+}
+
 // --- Handler Methods ---
 
 // HandlePlayerOnline handles requests to mark a player as online and load their data.
+// A player who is already online gets 200 with alreadyOnline set, or 409 if such requests are refused.
 // POST /game/player/online
 // Body: { "uuid": "<player_uuid>" }
 func (gah *GameAPIHandlers) HandlePlayerOnline(w http.ResponseWriter, r *http.Request) {
@@ -85,29 +334,59 @@ func (gah *GameAPIHandlers) HandlePlayerOnline(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	playerUUID, err := uuid.Parse(req.UUID)
+	playerUUID, err := api.NormalizeUUID(req.UUID)
 	if err != nil {
 		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second) // Increased timeout for external service call
-	defer cancel()
+	ctx := r.Context()
 
-	err = gah.GameService.PlayerOnline(ctx, playerUUID.String())
+	alreadyOnline, err := gah.GameService.PlayerOnline(ctx, playerUUID)
 	if err != nil {
-		log.Printf("Error processing player %s online: %v", playerUUID.String(), err)
+		log.Printf("Error processing player %s online: %v", playerUUID, err)
 		// Specific error handling for banned players
-		if err.Error() == fmt.Sprintf("player %s is currently banned and cannot go online", playerUUID.String()) {
-			api.WriteError(w, http.StatusForbidden, err.Error())
+		var bannedErr *service.PlayerBannedError
+		if errors.As(err, &bannedErr) {
+			writePlayerBanned(w, bannedErr)
+		} else if errors.Is(err, service.ErrAlreadyOnline) {
+			api.WriteJSON(w, http.StatusConflict, api.JSONErrorResponse{
+				Message:   "Player is already online",
+				Code:      http.StatusConflict,
+				ErrorCode: api.ErrorCodePlayerAlreadyOnline,
+			})
+		} else if errors.Is(err, service.ErrOnlineQueueFull) {
+			w.Header().Set("Retry-After", onlineRetryAfterSeconds)
+			api.WriteError(w, http.StatusServiceUnavailable, "Too many players connecting, please retry shortly")
+		} else if errors.Is(err, service.ErrProfileUnavailable) {
+			w.Header().Set("Retry-After", onlineRetryAfterSeconds)
+			api.WriteError(w, http.StatusServiceUnavailable, "Player profile temporarily unavailable, please retry shortly")
 		} else {
 			api.WriteError(w, http.StatusInternalServerError, "Failed to set player online status")
 		}
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player set online and data loaded", "uuid": playerUUID.String()})
-	log.Printf("Player %s is now online.", playerUUID.String())
+	api.WriteJSON(w, http.StatusOK, PlayerOnlineResponse{Message: "Player set online and data loaded", UUID: playerUUID, AlreadyOnline: alreadyOnline})
+	log.Printf("Player %s is now online.", playerUUID)
+}
+
+// writePlayerBanned writes the 403 response for a banned player's online attempt.
+func writePlayerBanned(w http.ResponseWriter, bannedErr *service.PlayerBannedError) {
+	resp := PlayerBannedResponse{
+		JSONErrorResponse: api.JSONErrorResponse{
+			Message:   bannedErr.Error(),
+			Code:      http.StatusForbidden,
+			ErrorCode: api.ErrorCodePlayerBanned,
+		},
+		UUID:        bannedErr.Ban.PlayerUUID,
+		Reason:      bannedErr.Ban.Reason,
+		IsPermanent: bannedErr.Ban.IsPermanent,
+	}
+	if bannedErr.Ban.ExpiresAt != nil {
+		resp.ExpiresAt = bannedErr.Ban.ExpiresAt.Unix()
+	}
+	api.WriteJSON(w, http.StatusForbidden, resp)
 }
 
 // HandlePlayerOffline handles requests to mark a player as offline and persist playtime.
@@ -120,24 +399,101 @@ func (gah *GameAPIHandlers) HandlePlayerOffline(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	playerUUID, err := uuid.Parse(req.UUID)
+	playerUUID, err := api.NormalizeUUID(req.UUID)
 	if err != nil {
 		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second) // Increased timeout for external service call
-	defer cancel()
+	ctx := r.Context()
 
-	err = gah.GameService.PlayerOffline(ctx, playerUUID.String())
+	err = gah.GameService.PlayerOffline(ctx, playerUUID)
 	if err != nil {
-		log.Printf("Error processing player %s offline: %v", playerUUID.String(), err)
+		log.Printf("Error processing player %s offline: %v", playerUUID, err)
 		api.WriteError(w, http.StatusInternalServerError, "Failed to set player offline status or persist data")
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player set offline, data persisted and Redis keys cleaned", "uuid": playerUUID.String()})
-	log.Printf("Player %s is now offline. Data persisted and Redis session keys cleared.", playerUUID.String())
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player set offline, data persisted and Redis keys cleaned", "uuid": playerUUID})
+	log.Printf("Player %s is now offline. Data persisted and Redis session keys cleared.", playerUUID)
+}
+
+// HandlePlayerOnlineBatch marks a list of players online, e.g., after a Minecraft server restart.
+// POST /game/player/online/batch
+// Body: { "uuids": ["<player_uuid>", ...] }
+func (gah *GameAPIHandlers) HandlePlayerOnlineBatch(w http.ResponseWriter, r *http.Request) {
+	gah.handleBatch(w, r, "online", gah.GameService.PlayerOnlineBatch)
+}
+
+// HandlePlayerOfflineBatch marks a list of players offline, e.g., before a Minecraft server restart.
+// POST /game/player/offline/batch
+// Body: { "uuids": ["<player_uuid>", ...] }
+func (gah *GameAPIHandlers) HandlePlayerOfflineBatch(w http.ResponseWriter, r *http.Request) {
+	gah.handleBatch(w, r, "offline", gah.GameService.PlayerOfflineBatch)
+}
+
+// validateBatchSize adds a problem if a batch of n items (described by item) is empty or larger than maxBatchSize.
+func validateBatchSize(n int, item string, problems *api.ValidationErrors) {
+	if n == 0 {
+		problems.Add("At least one %s is required", item)
+	} else if n > maxBatchSize {
+		problems.Add("Batch size %d exceeds the maximum of %d", n, maxBatchSize)
+	}
+}
+
+// batchRetryable reports whether a player's failure in a batch may go away on a retry. Refusals such
+// as a ban or an unknown team won't.
+func batchRetryable(err error) bool {
+	return !errors.Is(err, service.ErrPlayerBanned) &&
+		!errors.Is(err, service.ErrUnknownTeam) &&
+		!errors.Is(err, service.ErrNoActiveSession) &&
+		!errors.Is(err, service.ErrAlreadyOnline) &&
+		!errors.Is(err, store.ErrMaxSessionDurationExceeded)
+}
+
+// handleBatch decodes an api.BatchUUIDRequest, runs op over the valid UUIDs and writes per-UUID results.
+// Invalid UUIDs are reported as failures without failing the whole batch.
+func (gah *GameAPIHandlers) handleBatch(w http.ResponseWriter, r *http.Request, action string, op func(context.Context, []string, int) []service.BatchResult) {
+	var req api.BatchUUIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var problems api.ValidationErrors
+	validateBatchSize(len(req.UUIDs), "UUID", &problems)
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
+		return
+	}
+
+	results := make([]api.BatchItemResult, len(req.UUIDs))
+	validUUIDs := make([]string, 0, len(req.UUIDs))
+	validIdx := make([]int, 0, len(req.UUIDs))
+	for i, raw := range req.UUIDs {
+		playerUUID, err := api.NormalizeUUID(raw)
+		if err != nil {
+			results[i] = api.BatchItemResult{UUID: raw, Error: "Invalid UUID format"}
+			continue
+		}
+		validUUIDs = append(validUUIDs, playerUUID)
+		validIdx = append(validIdx, i)
+	}
+
+	ctx := r.Context()
+
+	for j, result := range op(ctx, validUUIDs, gah.BatchConcurrency) {
+		item := api.BatchItemResult{UUID: result.UUID, Success: result.Err == nil}
+		if result.Err != nil {
+			log.Printf("Error processing player %s %s in batch: %v", result.UUID, action, result.Err)
+			item.Error = result.Err.Error()
+			item.Retryable = batchRetryable(result.Err)
+		}
+		results[validIdx[j]] = item
+	}
+
+	resp := api.NewBatchResponse(results)
+	log.Printf("Batch %s processed: %d succeeded, %d failed.", action, resp.Succeeded, resp.Failed)
+	api.WriteJSON(w, http.StatusOK, resp)
 }
 
 // HandleRefreshOnline handles requests to refresh a player's online status (heartbeat).
@@ -150,28 +506,122 @@ func (gah *GameAPIHandlers) HandleRefreshOnline(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	playerUUID, err := uuid.Parse(req.UUID)
+	playerUUID, err := api.NormalizeUUID(req.UUID)
 	if err != nil {
 		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	err = gah.GameService.RefreshPlayerOnlineStatus(ctx, playerUUID.String())
+	err = gah.GameService.RefreshPlayerOnlineStatus(ctx, playerUUID)
 	if err != nil {
-		log.Printf("Error refreshing online status for player %s: %v", playerUUID.String(), err)
+		if errors.Is(err, store.ErrMaxSessionDurationExceeded) {
+			api.WriteError(w, http.StatusConflict, "Session exceeded maximum duration; player must go online again")
+			return
+		}
+		log.Printf("Error refreshing online status for player %s: %v", playerUUID, err)
 		api.WriteError(w, http.StatusInternalServerError, "Failed to refresh player online status")
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player online status refreshed", "uuid": playerUUID.String()})
-	log.Printf("Player %s online status refreshed.", playerUUID.String())
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player online status refreshed", "uuid": playerUUID})
+	log.Printf("Player %s online status refreshed.", playerUUID)
+}
+
+// HandleRefreshOnlineBatch refreshes the online status of many players at once, so a server can send
+// one heartbeat for all its players. Expired sessions are reported as not present rather than revived.
+// POST /game/player/refresh-online/batch
+// Body: { "uuids": ["<player_uuid>", ...] }
+func (gah *GameAPIHandlers) HandleRefreshOnlineBatch(w http.ResponseWriter, r *http.Request) {
+	var req api.BatchUUIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var problems api.ValidationErrors
+	validateBatchSize(len(req.UUIDs), "UUID", &problems)
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
+		return
+	}
+
+	items := make([]RefreshOnlineBatchItem, len(req.UUIDs))
+	validUUIDs := make([]string, 0, len(req.UUIDs))
+	validIdx := make([]int, 0, len(req.UUIDs))
+	for i, raw := range req.UUIDs {
+		playerUUID, err := api.NormalizeUUID(raw)
+		if err != nil {
+			items[i] = RefreshOnlineBatchItem{UUID: raw, Error: "Invalid UUID format"}
+			continue
+		}
+		validUUIDs = append(validUUIDs, playerUUID)
+		validIdx = append(validIdx, i)
+	}
+
+	ctx := r.Context()
+
+	results, err := gah.GameService.RefreshPlayerOnlineStatuses(ctx, validUUIDs)
+	if err != nil {
+		log.Printf("Error refreshing online status of %d players: %v", len(validUUIDs), err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to refresh player online statuses")
+		return
+	}
+	for j, result := range results {
+		item := RefreshOnlineBatchItem{UUID: result.UUID, Present: result.Present}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+		}
+		items[validIdx[j]] = item
+	}
+
+	resp := RefreshOnlineBatchResponse{Results: items}
+	for _, item := range items {
+		if item.Present {
+			resp.Refreshed++
+		} else {
+			resp.Missing++
+		}
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
+// HandlePlayerActivity handles activity heartbeats, which keep an online player from being treated as idle.
+// POST /game/player/{uuid}/activity
+func (gah *GameAPIHandlers) HandlePlayerActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	err = gah.GameService.RecordPlayerActivity(ctx, playerUUIDStr)
+	if errors.Is(err, service.ErrNoActiveSession) {
+		api.WriteError(w, http.StatusNotFound, "Player is not online")
+		return
+	}
+	if err != nil {
+		log.Printf("Error recording activity for player %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to record player activity")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player activity recorded", "uuid": playerUUIDStr})
 }
 
 // GetPlayerTotalPlaytime handles requests to retrieve a player's total playtime from Redis.
-// GET /game/player/{uuid}/playtime
+// GET /game/player/{uuid}/playtime[?includeLive=true]
+// With includeLive the player's effective playtime is returned, from their session if they have one
+// and from their profile otherwise; see GameService.GetPlayerLivePlaytime.
 func (gah *GameAPIHandlers) GetPlayerTotalPlaytime(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	playerUUIDStr := vars["uuid"]
@@ -180,13 +630,36 @@ func (gah *GameAPIHandlers) GetPlayerTotalPlaytime(w http.ResponseWriter, r *htt
 		return
 	}
 
-	if _, err := uuid.Parse(playerUUIDStr); err != nil {
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
 		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	includeLive := false
+	if raw := r.URL.Query().Get("includeLive"); raw != "" {
+		if includeLive, err = strconv.ParseBool(raw); err != nil {
+			api.WriteError(w, http.StatusBadRequest, "includeLive must be true or false")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	if includeLive {
+		playtime, inSession, err := gah.GameService.GetPlayerLivePlaytime(ctx, playerUUIDStr)
+		if errors.Is(err, api.ErrNotFound) {
+			api.WriteError(w, http.StatusNotFound, "Player profile not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Error getting live playtime for %s: %v", playerUUIDStr, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve total playtime")
+			return
+		}
+		api.WriteJSON(w, http.StatusOK, PlaytimeResponse{Playtime: gah.roundPlaytime(playtime), InSession: inSession})
+		return
+	}
 
 	playtime, err := gah.GameService.GetPlayerTotalPlaytime(ctx, playerUUIDStr)
 	if err != nil {
@@ -195,7 +668,7 @@ func (gah *GameAPIHandlers) GetPlayerTotalPlaytime(w http.ResponseWriter, r *htt
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, PlaytimeResponse{Playtime: playtime})
+	api.WriteJSON(w, http.StatusOK, PlaytimeResponse{Playtime: gah.roundPlaytime(playtime)})
 }
 
 // GetPlayerDeltaPlaytime handles requests to retrieve a player's delta playtime from Redis.
@@ -208,13 +681,13 @@ func (gah *GameAPIHandlers) GetPlayerDeltaPlaytime(w http.ResponseWriter, r *htt
 		return
 	}
 
-	if _, err := uuid.Parse(playerUUIDStr); err != nil {
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
 		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	deltaPlaytime, err := gah.GameService.GetPlayerDeltaPlaytime(ctx, playerUUIDStr)
 	if err != nil {
@@ -226,11 +699,46 @@ func (gah *GameAPIHandlers) GetPlayerDeltaPlaytime(w http.ResponseWriter, r *htt
 	api.WriteJSON(w, http.StatusOK, DeltaPlaytimeResponse{Deltatime: deltaPlaytime})
 }
 
+// GetPlayerPlaytimeAudit handles admin requests for a player's recent playtime changes. RegisterRoutes
+// wraps it in the admin token check, since it sits outside /game/admin/.
+// GET /game/player/{uuid}/playtime-audit?limit=<n>
+func (gah *GameAPIHandlers) GetPlayerPlaytimeAudit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr, err := api.NormalizeUUID(vars["uuid"])
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	limit := defaultPlaytimeAuditLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxPlaytimeAuditLimit {
+			api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxPlaytimeAuditLimit))
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	entries, err := gah.GameService.GetPlaytimeAudit(ctx, playerUUIDStr, limit)
+	if errors.Is(err, service.ErrPlaytimeAuditDisabled) {
+		api.WriteError(w, http.StatusNotFound, "Playtime audit log is not enabled")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting playtime audit log for %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve playtime audit log")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, PlaytimeAuditResponse{UUID: playerUUIDStr, Entries: entries})
+}
+
 // GetTeamTotalPlaytime handles requests to retrieve the total playtime for a specific team.
 // GET /game/team/{teamId}/playtime
 func (gah *GameAPIHandlers) GetTeamTotalPlaytime(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	vars := mux.Vars(r)
 	teamID := vars["teamId"] // Changed from "team" to "teamId" for clarity
@@ -241,6 +749,10 @@ func (gah *GameAPIHandlers) GetTeamTotalPlaytime(w http.ResponseWriter, r *http.
 	}
 
 	totalPlaytime, err := gah.GameService.GetTeamTotalPlaytime(ctx, teamID)
+	if errors.Is(err, service.ErrUnknownTeam) {
+		api.WriteError(w, http.StatusNotFound, fmt.Sprintf("Team '%s' not found", teamID))
+		return
+	}
 	if err != nil {
 		log.Printf("Error retrieving total playtime for team '%s': %v", teamID, err)
 		api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve team total playtime")
@@ -249,143 +761,869 @@ func (gah *GameAPIHandlers) GetTeamTotalPlaytime(w http.ResponseWriter, r *http.
 
 	response := TeamTotalPlaytimeResponse{
 		TeamID:        teamID,
-		TotalPlaytime: totalPlaytime,
+		TotalPlaytime: gah.roundPlaytime(totalPlaytime),
 	}
 
 	api.WriteJSON(w, http.StatusOK, response)
 }
 
-// GetPlayerOnlineStatus handles requests to check player online status.
-// GET /game/player/{uuid}/is-online
-func (gah *GameAPIHandlers) GetPlayerOnlineStatus(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	playerUUIDStr := vars["uuid"]
-	if playerUUIDStr == "" {
-		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+// GetTeamTotalPlaytimeBatch handles requests for the total playtime of several teams at once, e.g., for scoreboards.
+// POST /game/team/playtime/batch
+// Body: { "teamIds": ["AQUA_CREEPERS", "PURPLE_AXOLOTLS"] }
+func (gah *GameAPIHandlers) GetTeamTotalPlaytimeBatch(w http.ResponseWriter, r *http.Request) {
+	var req TeamPlaytimeBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-
-	if _, err := uuid.Parse(playerUUIDStr); err != nil {
-		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+	var problems api.ValidationErrors
+	validateBatchSize(len(req.TeamIDs), "team ID", &problems)
+	for i, teamID := range req.TeamIDs {
+		if teamID == "" {
+			problems.Add("Team ID at index %d must not be empty", i)
+		}
+	}
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	isOnline, err := gah.GameService.IsPlayerOnline(ctx, playerUUIDStr)
+	playtimes, err := gah.GameService.GetTeamTotalPlaytimes(ctx, req.TeamIDs)
+	if errors.Is(err, service.ErrUnknownTeam) {
+		api.WriteError(w, http.StatusNotFound, fmt.Sprintf("Team not found: %v", err))
+		return
+	}
 	if err != nil {
-		log.Printf("Error checking online status for %s: %v", playerUUIDStr, err)
-		api.WriteError(w, http.StatusInternalServerError, "Failed to check player online status")
+		log.Printf("Error retrieving total playtime for %d teams: %v", len(req.TeamIDs), err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve team total playtimes")
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, PlayerOnlineStatusResponse{
-		UUID:     playerUUIDStr,
-		IsOnline: isOnline,
-	})
+	api.WriteJSON(w, http.StatusOK, TeamPlaytimeBatchResponse{Teams: gah.roundPlaytimes(playtimes)})
 }
 
-// HandleBanPlayer handles requests to ban a player.
-// POST /game/admin/ban
-// Body: { "uuid": "<player_uuid>", "duration_seconds": <seconds>, "reason": "..." }
-func (gah *GameAPIHandlers) HandleBanPlayer(w http.ResponseWriter, r *http.Request) {
-	var req BanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
-		return
+// GetTopTeams handles requests for the teams with the most playtime, e.g., for a leaderboard.
+// GET /game/team/top?limit=<n>
+func (gah *GameAPIHandlers) GetTopTeams(w http.ResponseWriter, r *http.Request) {
+	limit := defaultTopTeamsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxTopTeamsLimit {
+			api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxTopTeamsLimit))
+			return
+		}
 	}
 
-	playerUUID, err := uuid.Parse(req.UUID)
+	teams, err := gah.GameService.GetTopTeams(r.Context(), limit)
 	if err != nil {
-		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
-		return
-		// Handle unban via separate endpoint as per discussion
-	} else if req.DurationSec == -1 {
-		api.WriteError(w, http.StatusBadRequest, "Use /game/admin/unban to unban a player")
+		log.Printf("Error retrieving top %d teams: %v", limit, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve top teams")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	api.WriteJSON(w, http.StatusOK, TopTeamsResponse{Teams: gah.roundTeamPlaytimes(teams)})
+}
 
-	var banExpiresAt *time.Time
-	isPermanent := false
+// GetTopPlayers handles requests for the players with the most playtime among those with a session.
+// GET /game/player/top?limit=<n>
+func (gah *GameAPIHandlers) GetTopPlayers(w http.ResponseWriter, r *http.Request) {
+	limit := defaultTopPlayersLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxTopPlayersLimit {
+			api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxTopPlayersLimit))
+			return
+		}
+	}
 
-	if req.DurationSec == 0 {
-		isPermanent = true
-		banExpiresAt = nil // Explicitly nil for permanent ban
-	} else {
-		expires := time.Now().Add(time.Duration(req.DurationSec) * time.Second)
-		banExpiresAt = &expires
+	players, err := gah.GameService.GetTopPlayers(r.Context(), limit)
+	if err != nil {
+		log.Printf("Error retrieving top %d players: %v", limit, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve top players")
+		return
 	}
 
-	err = gah.GameService.BanPlayer(ctx, playerUUID.String(), banExpiresAt, req.Reason)
+	api.WriteJSON(w, http.StatusOK, TopPlayersResponse{Players: gah.roundPlayerPlaytimes(players)})
+}
+
+// GetOnlineCount handles requests for the number of online players. Unlike /game/stats/online it reads
+// a counter instead of scanning, so it is cheap enough to poll.
+// GET /game/online-count
+func (gah *GameAPIHandlers) GetOnlineCount(w http.ResponseWriter, r *http.Request) {
+	count, err := gah.GameService.GetOnlinePlayerCount(r.Context())
 	if err != nil {
-		log.Printf("Error banning player %s: %v", playerUUID.String(), err)
-		api.WriteError(w, http.StatusInternalServerError, "Failed to ban player")
+		log.Printf("Error getting online player count: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to get online player count")
 		return
 	}
+	api.WriteJSON(w, http.StatusOK, OnlineCountResponse{Online: count})
+}
 
-	responseMsg := fmt.Sprintf("Player %s banned", playerUUID.String())
-	var expiresAtUnix int64 = 0
-	if !isPermanent {
-		responseMsg = fmt.Sprintf("Player %s banned until %v", playerUUID.String(), banExpiresAt.Format(time.RFC3339))
-		expiresAtUnix = banExpiresAt.Unix()
+// GetOnlineStats handles requests for the total online count and per-team breakdown.
+// GET /game/stats/online
+func (gah *GameAPIHandlers) GetOnlineStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	stats, err := gah.GameService.GetOnlineStats(ctx)
+	if err != nil {
+		log.Printf("Error retrieving online stats: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to retrieve online stats")
+		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, BanResponse{
-		Message:     responseMsg,
-		UUID:        playerUUID.String(),
-		ExpiresAt:   expiresAtUnix,
-		IsPermanent: isPermanent,
+	api.WriteJSON(w, http.StatusOK, OnlineStatsResponse{
+		TotalOnline: stats.TotalOnline,
+		Teams:       stats.Teams,
+		Unassigned:  stats.Unassigned,
+		ComputedAt:  stats.ComputedAt.Unix(),
 	})
 }
 
-// HandleUnbanPlayer handles requests to unban a player.
-// POST /game/admin/unban
-// Body: { "uuid": "<player_uuid>" }
-func (gah *GameAPIHandlers) HandleUnbanPlayer(w http.ResponseWriter, r *http.Request) {
-	var req PlayerUUIDRequest // Re-use PlayerUUIDRequest as it only needs UUID
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+// GetPlayerSessionStart handles requests for the absolute start time of a player's current session.
+// GET /game/player/{uuid}/session-start
+func (gah *GameAPIHandlers) GetPlayerSessionStart(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
 
-	playerUUID, err := uuid.Parse(req.UUID)
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
 	if err != nil {
 		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	err = gah.GameService.UnbanPlayer(ctx, playerUUID.String())
+	sessionStart, err := gah.GameService.GetSessionStart(ctx, playerUUIDStr)
+	if errors.Is(err, service.ErrNoActiveSession) {
+		api.WriteError(w, http.StatusNotFound, "Player is not online")
+		return
+	}
 	if err != nil {
-		log.Printf("Error unbanning player %s: %v", playerUUID.String(), err)
-		api.WriteError(w, http.StatusInternalServerError, "Failed to unban player")
+		log.Printf("Error getting session start for %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to get session start")
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player unbanned", "uuid": playerUUID.String()})
+	api.WriteJSON(w, http.StatusOK, SessionStartResponse{
+		UUID:         playerUUIDStr,
+		SessionStart: sessionStart.Unix(),
+	})
 }
 
-// RegisterRoutes registers all API endpoints for the Game Service.
-// This method is called from main.go to set up the HTTP routes.
-func (gah *GameAPIHandlers) RegisterRoutes(router *mux.Router) {
-	// Player status and playtime
-	router.HandleFunc("/game/player/online", gah.HandlePlayerOnline).Methods("POST")
-	router.HandleFunc("/game/player/offline", gah.HandlePlayerOffline).Methods("POST")
-	router.HandleFunc("/game/player/refresh-online", gah.HandleRefreshOnline).Methods("POST") // New endpoint for heartbeat
-	router.HandleFunc("/game/player/{uuid}/playtime", gah.GetPlayerTotalPlaytime).Methods("GET")
-	router.HandleFunc("/game/player/{uuid}/deltatime", gah.GetPlayerDeltaPlaytime).Methods("GET")
-	router.HandleFunc("/game/player/{uuid}/is-online", gah.GetPlayerOnlineStatus).Methods("GET")
+// GetPlayerOnlineStatus handles requests to check player online status.
+// GET /game/player/{uuid}/is-online
+func (gah *GameAPIHandlers) GetPlayerOnlineStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
 
-	// Team playtime
-	router.HandleFunc("/game/team/{teamId}/playtime", gah.GetTeamTotalPlaytime).Methods("GET") // Changed path variable name
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	isOnline, err := gah.GameService.IsPlayerOnline(ctx, playerUUIDStr)
+	if err != nil {
+		log.Printf("Error checking online status for %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to check player online status")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, PlayerOnlineStatusResponse{
+		UUID:     playerUUIDStr,
+		IsOnline: isOnline,
+	})
+}
+
+// GetPlayerExists handles requests to check whether any session or playtime data exists for a player.
+// GET /game/player/{uuid}/exists
+func (gah *GameAPIHandlers) GetPlayerExists(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	exists, err := gah.GameService.PlayerDataExists(ctx, playerUUIDStr)
+	if err != nil {
+		log.Printf("Error checking Redis data for %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to check player data")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, PlayerExistsResponse{
+		UUID:   playerUUIDStr,
+		Exists: exists,
+	})
+}
+
+// GetPlayerTeam handles requests for the team a player is currently assigned to in Redis.
+// GET /game/player/{uuid}/team
+func (gah *GameAPIHandlers) GetPlayerTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	teamID, err := gah.GameService.GetPlayerTeam(ctx, playerUUIDStr)
+	if errors.Is(err, service.ErrNoTeamAssigned) {
+		api.WriteError(w, http.StatusNotFound, "Player has no team assigned")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting team for player %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to get player team")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, PlayerTeamResponse{UUID: playerUUIDStr, Team: teamID})
+}
+
+// HandleAssignPlayerTeam handles requests to change an online player's team, e.g., after a team transfer.
+// PUT /game/player/{uuid}/team
+// Body: { "team": "<team_id>" }
+func (gah *GameAPIHandlers) HandleAssignPlayerTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+
+	var req AssignTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var problems api.ValidationErrors
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
+		problems.Add("Invalid UUID format")
+	}
+	if req.Team == "" {
+		problems.Add("Team is required")
+	}
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
+		return
+	}
+
+	ctx := r.Context()
+
+	assigned, err := gah.GameService.AssignPlayerTeam(ctx, playerUUIDStr, req.Team)
+	if errors.Is(err, service.ErrUnknownTeam) {
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Team '%s' is not a known team", req.Team))
+		return
+	}
+	if err != nil {
+		log.Printf("Error assigning team %s to player %s: %v", req.Team, playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to assign player team")
+		return
+	}
+	if !assigned {
+		api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player is offline; team will be loaded from their profile", "uuid": playerUUIDStr})
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player team assigned", "uuid": playerUUIDStr, "team": req.Team})
+}
+
+// HandleUnassignPlayerTeam handles requests to clear a player's team assignment.
+// DELETE /game/player/{uuid}/team
+func (gah *GameAPIHandlers) HandleUnassignPlayerTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr := vars["uuid"]
+	if playerUUIDStr == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+
+	playerUUIDStr, err := api.NormalizeUUID(playerUUIDStr)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	removed, err := gah.GameService.UnassignPlayerTeam(ctx, playerUUIDStr)
+	if err != nil {
+		log.Printf("Error unassigning team for player %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to unassign player team")
+		return
+	}
+	if !removed {
+		api.WriteError(w, http.StatusNotFound, "Player has no team assigned")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player team unassigned", "uuid": playerUUIDStr})
+}
+
+// HandleBanPlayer handles requests to ban a player.
+// POST /game/admin/ban
+// Body: { "uuid": "<player_uuid>", "duration_seconds": <seconds>, "reason": "...", "force": false }
+// An existing longer ban is kept unless force is true; the response reports the ban in effect.
+func (gah *GameAPIHandlers) HandleBanPlayer(w http.ResponseWriter, r *http.Request) {
+	var req BanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var problems api.ValidationErrors
+	playerUUID, banExpiresAt := gah.validateBan(req.UUID, req.DurationSec, &problems)
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
+		return
+	}
+
+	ctx := r.Context()
+
+	// An existing longer ban stays in effect unless forced, so report the effective expiry.
+	banExpiresAt, err := gah.GameService.BanPlayer(ctx, playerUUID, banExpiresAt, req.Reason, req.Force)
+	if err != nil {
+		log.Printf("Error banning player %s: %v", playerUUID, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to ban player")
+		return
+	}
+	isPermanent := banExpiresAt == nil
+
+	responseMsg := fmt.Sprintf("Player %s banned", playerUUID)
+	var expiresAtUnix int64 = 0
+	if !isPermanent {
+		responseMsg = fmt.Sprintf("Player %s banned until %v", playerUUID, banExpiresAt.Format(time.RFC3339))
+		expiresAtUnix = banExpiresAt.Unix()
+	}
+
+	api.WriteJSON(w, http.StatusOK, BanResponse{
+		Message:     responseMsg,
+		UUID:        playerUUID,
+		ExpiresAt:   expiresAtUnix,
+		IsPermanent: isPermanent,
+	})
+}
+
+// validateBan checks the UUID and duration of a ban request, adding every problem to problems.
+// It returns the normalized UUID and the ban's expiry (nil for a permanent ban, durationSec 0);
+// both are only meaningful if no problem was added.
+func (gah *GameAPIHandlers) validateBan(rawUUID string, durationSec int64, problems *api.ValidationErrors) (string, *time.Time) {
+	playerUUID, err := api.NormalizeUUID(rawUUID)
+	if err != nil {
+		problems.Add("Invalid UUID format")
+	}
+	if durationSec < 0 {
+		// Unbanning has its own endpoint.
+		problems.Add("Use /game/admin/unban to unban a player")
+		return playerUUID, nil
+	}
+	return playerUUID, gah.banExpiry(durationSec, problems)
+}
+
+// banExpiry converts a requested ban duration into an expiry time (nil for a permanent ban, durationSec 0).
+// Temporary bans longer than MaxBanDuration add a problem instead.
+func (gah *GameAPIHandlers) banExpiry(durationSec int64, problems *api.ValidationErrors) *time.Time {
+	if durationSec == 0 {
+		return nil
+	}
+	// Compared in seconds so absurd durations are rejected before they can overflow time.Duration.
+	if maxSec := int64(gah.MaxBanDuration / time.Second); gah.MaxBanDuration > 0 && durationSec > maxSec {
+		problems.Add("Ban duration exceeds the maximum of %d seconds; use 0 for a permanent ban", maxSec)
+		return nil
+	}
+	expires := time.Now().Add(time.Duration(durationSec) * time.Second)
+	return &expires
+}
+
+// HandleBanAndKickPlayer handles requests to ban a player in Redis and on their profile, and kick them if online.
+// POST /game/admin/ban-and-kick
+// Body: { "uuid": "<player_uuid>", "duration_seconds": <seconds>, "reason": "...", "force": false }
+// Responds 200 when every step succeeded and 207 with the per-step status when only some did.
+func (gah *GameAPIHandlers) HandleBanAndKickPlayer(w http.ResponseWriter, r *http.Request) {
+	var req BanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var problems api.ValidationErrors
+	playerUUID, banExpiresAt := gah.validateBan(req.UUID, req.DurationSec, &problems)
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
+		return
+	}
+
+	ctx := r.Context()
+
+	result, err := gah.GameService.BanAndKickPlayer(ctx, playerUUID, banExpiresAt, req.Reason, req.Force)
+	if err != nil {
+		log.Printf("Error banning player %s: %v", playerUUID, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to ban player")
+		return
+	}
+	banExpiresAt = result.ExpiresAt // An existing longer ban stays in effect unless forced
+	isPermanent := banExpiresAt == nil
+
+	resp := BanAndKickResponse{
+		UUID:           playerUUID,
+		IsPermanent:    isPermanent,
+		RedisBanned:    result.RedisBanned,
+		ProfileUpdated: result.ProfileUpdated,
+		ProfileError:   result.ProfileError,
+		WasOnline:      result.WasOnline,
+		Kicked:         result.Kicked,
+		KickError:      result.KickError,
+	}
+	if !isPermanent {
+		resp.ExpiresAt = banExpiresAt.Unix()
+	}
+
+	status := http.StatusOK
+	resp.Message = fmt.Sprintf("Player %s banned and kicked", playerUUID)
+	if !result.Complete() {
+		status = http.StatusMultiStatus
+		resp.Message = fmt.Sprintf("Player %s banned, but some steps failed", playerUUID)
+	}
+	api.WriteJSON(w, status, resp)
+}
+
+// HandleModifyBan handles requests to extend or reduce an active ban without unbanning and rebanning.
+// POST /game/admin/ban/modify
+// Body: { "uuid": "<player_uuid>", "duration_seconds": <seconds> }
+// Responds 404 if the player is not banned, and 207 if Redis was updated but the profile was not.
+func (gah *GameAPIHandlers) HandleModifyBan(w http.ResponseWriter, r *http.Request) {
+	var req ModifyBanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var problems api.ValidationErrors
+	playerUUID, banExpiresAt := gah.validateBan(req.UUID, req.DurationSec, &problems)
+	if len(problems) > 0 {
+		api.WriteValidationErrors(w, problems)
+		return
+	}
+
+	ctx := r.Context()
+
+	result, err := gah.GameService.ModifyBan(ctx, playerUUID, banExpiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrNotBanned) {
+			api.WriteError(w, http.StatusNotFound, "Player is not banned")
+			return
+		}
+		log.Printf("Error modifying ban for player %s: %v", playerUUID, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to modify ban")
+		return
+	}
+
+	resp := ModifyBanResponse{
+		Message:        fmt.Sprintf("Ban for player %s is now permanent", playerUUID),
+		UUID:           playerUUID,
+		IsPermanent:    result.ExpiresAt == nil,
+		ProfileUpdated: result.ProfileUpdated,
+		ProfileError:   result.ProfileError,
+	}
+	if result.ExpiresAt != nil {
+		resp.Message = fmt.Sprintf("Ban for player %s now expires at %v", playerUUID, result.ExpiresAt.Format(time.RFC3339))
+		resp.ExpiresAt = result.ExpiresAt.Unix()
+	}
+
+	status := http.StatusOK
+	if !result.ProfileUpdated {
+		status = http.StatusMultiStatus
+	}
+	api.WriteJSON(w, status, resp)
+}
+
+// HandleUnbanPlayer handles requests to unban a player.
+// POST /game/admin/unban
+// Body: { "uuid": "<player_uuid>" }
+func (gah *GameAPIHandlers) HandleUnbanPlayer(w http.ResponseWriter, r *http.Request) {
+	var req PlayerUUIDRequest // Re-use PlayerUUIDRequest as it only needs UUID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	playerUUID, err := api.NormalizeUUID(req.UUID)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	err = gah.GameService.UnbanPlayer(ctx, playerUUID)
+	if err != nil {
+		log.Printf("Error unbanning player %s: %v", playerUUID, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to unban player")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Player unbanned", "uuid": playerUUID})
+}
+
+// GetGhostSessions handles admin requests to list online sessions that look stale, e.g., from clients
+// that never sent offline. With cleanup=true the listed sessions are ended and their playtime persisted.
+// GET /game/admin/ghost-sessions?max_age_seconds=<n>&cleanup=true
+func (gah *GameAPIHandlers) GetGhostSessions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var maxAge time.Duration // 0 uses the store's default
+	if raw := query.Get("max_age_seconds"); raw != "" {
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || seconds <= 0 || seconds > int64(math.MaxInt64/time.Second) {
+			api.WriteError(w, http.StatusBadRequest, "max_age_seconds must be a positive integer")
+			return
+		}
+		maxAge = time.Duration(seconds) * time.Second
+	}
+	cleanup := false
+	if raw := query.Get("cleanup"); raw != "" {
+		var err error
+		if cleanup, err = strconv.ParseBool(raw); err != nil {
+			api.WriteError(w, http.StatusBadRequest, "cleanup must be true or false")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	ghosts, err := gah.GameService.FindGhostSessions(ctx, maxAge)
+	if err != nil {
+		log.Printf("Error finding ghost sessions: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to find ghost sessions")
+		return
+	}
+	if ghosts == nil {
+		ghosts = []store.GhostSession{}
+	}
+
+	resp := GhostSessionsResponse{Sessions: ghosts, Count: len(ghosts)}
+	if cleanup && len(ghosts) > 0 {
+		resp.Cleaned = gah.GameService.EndGhostSessions(ctx, ghosts)
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
+// HandleResetTeamPlaytime handles admin requests to reset a team's total playtime in Redis.
+// POST /game/admin/team/{teamId}/reset-playtime
+func (gah *GameAPIHandlers) HandleResetTeamPlaytime(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+	if teamID == "" {
+		api.WriteError(w, http.StatusBadRequest, "Team ID is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	err := gah.GameService.ResetTeamPlaytime(ctx, teamID)
+	if errors.Is(err, service.ErrUnknownTeam) {
+		api.WriteError(w, http.StatusNotFound, fmt.Sprintf("Team '%s' not found", teamID))
+		return
+	}
+	if err != nil {
+		log.Printf("Error resetting playtime for team %s: %v", teamID, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to reset team playtime")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": "Team playtime reset", "teamId": teamID})
+}
+
+// HandleRenameTeam handles requests to move a renamed team's data in Redis to its new ID.
+// POST /game/admin/team/{teamId}/rename
+// Body: { "new_team": "NEW_NAME" }
+// Called by the Player Service after renaming the team in MongoDB.
+func (gah *GameAPIHandlers) HandleRenameTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+	if teamID == "" {
+		api.WriteError(w, http.StatusBadRequest, "Team ID is required")
+		return
+	}
+
+	var req RenameTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.NewTeam == "" || req.NewTeam == teamID {
+		api.WriteError(w, http.StatusBadRequest, "A new team ID different from the current one is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	result, err := gah.GameService.RenameTeam(ctx, teamID, req.NewTeam)
+	if errors.Is(err, service.ErrUnknownTeam) { // Only the new team ID is validated
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("New team ID '%s' is not a known team", req.NewTeam))
+		return
+	}
+	if err != nil {
+		log.Printf("Error renaming team %s to %s: %v", teamID, req.NewTeam, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to rename team")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, RenameTeamResponse{
+		Message:           fmt.Sprintf("Team %s renamed to %s", teamID, req.NewTeam),
+		OldTeam:           teamID,
+		NewTeam:           req.NewTeam,
+		PlayersReassigned: result.PlayersReassigned,
+		MovedPlaytime:     gah.roundPlaytime(result.MovedPlaytime),
+	})
+}
+
+// HandleResetPlayerPlaytime handles admin requests to reset a player's total playtime to zero.
+// POST /game/admin/player/{uuid}/reset-playtime
+// Body (optional): { "adjust_team_total": false }
+// Team totals are only reduced immediately with adjust_team_total; otherwise at the next team sync.
+// Responds 404 if the player has no profile, and 207 if Redis was reset but the profile was not.
+func (gah *GameAPIHandlers) HandleResetPlayerPlaytime(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr, err := api.NormalizeUUID(vars["uuid"])
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	var req ResetPlaytimeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	result, err := gah.GameService.ResetPlayerPlaytime(ctx, playerUUIDStr, req.AdjustTeamTotal)
+	if errors.Is(err, api.ErrNotFound) {
+		api.WriteError(w, http.StatusNotFound, "Player profile not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error resetting playtime for player %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to reset player playtime")
+		return
+	}
+
+	resp := ResetPlaytimeResponse{
+		Message:         fmt.Sprintf("Playtime of player %s reset", playerUUIDStr),
+		UUID:            playerUUIDStr,
+		RemovedPlaytime: gah.roundPlaytime(result.RemovedPlaytime),
+		Team:            result.Team,
+		TeamAdjusted:    result.TeamAdjusted,
+		ProfileUpdated:  result.ProfileUpdated,
+		ProfileError:    result.ProfileError,
+	}
+	status := http.StatusOK
+	if !result.ProfileUpdated {
+		status = http.StatusMultiStatus
+		resp.Message = fmt.Sprintf("Playtime of player %s reset in Redis, but the profile update failed", playerUUIDStr)
+	}
+	api.WriteJSON(w, status, resp)
+}
+
+// HandleSetPlaytimeMultiplier handles admin requests to set a player's permanent playtime multiplier.
+// PUT /game/admin/player/{uuid}/playtime-multiplier
+// Body: { "multiplier": 1.5 }
+// The multiplier is stored in the player's profile and applied to their session right away if they are online.
+func (gah *GameAPIHandlers) HandleSetPlaytimeMultiplier(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr, err := api.NormalizeUUID(vars["uuid"])
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	var req SetPlaytimeMultiplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+
+	applied, err := gah.GameService.SetPlaytimeMultiplier(ctx, playerUUIDStr, req.Multiplier)
+	if errors.Is(err, service.ErrInvalidPlaytimeMultiplier) {
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("multiplier must be greater than 0 and at most %v", models.MaxPlaytimeMultiplier))
+		return
+	}
+	if errors.Is(err, api.ErrNotFound) {
+		api.WriteError(w, http.StatusNotFound, "Player profile not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting playtime multiplier for player %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to set playtime multiplier")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, SetPlaytimeMultiplierResponse{
+		Message:    fmt.Sprintf("Playtime multiplier of player %s set to %v", playerUUIDStr, req.Multiplier),
+		UUID:       playerUUIDStr,
+		Multiplier: req.Multiplier,
+		Applied:    applied,
+	})
+}
+
+// GetRing handles requests for the current consistent hash ring members, for debugging sharding.
+// GET /game/debug/ring
+func (gah *GameAPIHandlers) GetRing(w http.ResponseWriter, r *http.Request) {
+	if gah.Ring == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "Sharding ring is not available")
+		return
+	}
+
+	members := gah.Ring.RingMembers()
+	api.WriteJSON(w, http.StatusOK, RingResponse{
+		InstanceID:    gah.InstanceID,
+		Members:       members,
+		Count:         len(members),
+		ModuloEnabled: gah.Ring.ModuloEnabled(),
+	})
+}
+
+// GetIsLeader reports whether this instance currently owns the global sync task, i.e. is the sync
+// leader, so operators can find the instance performing backups and team syncs.
+// GET /game/debug/is-leader
+func (gah *GameAPIHandlers) GetIsLeader(w http.ResponseWriter, r *http.Request) {
+	if gah.Ring == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "Assignment manager is not available")
+		return
+	}
+
+	isLeader, err := gah.Ring.IsResponsible(syncer.GlobalSyncTaskKey)
+	if err != nil {
+		log.Printf("Error checking leadership for task '%s': %v", syncer.GlobalSyncTaskKey, err)
+		api.WriteError(w, http.StatusServiceUnavailable, "Failed to determine sync leadership")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, IsLeaderResponse{
+		InstanceID: gah.InstanceID,
+		TaskKey:    syncer.GlobalSyncTaskKey,
+		IsLeader:   isLeader,
+	})
+}
+
+// GetPlayerDebugState handles admin requests for everything held in Redis about a player at once:
+// online status, session start, total and delta playtime, team and ban.
+// GET /game/admin/player/{uuid}/debug
+func (gah *GameAPIHandlers) GetPlayerDebugState(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	playerUUIDStr, err := api.NormalizeUUID(vars["uuid"])
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	state, err := gah.GameService.GetPlayerDebugState(ctx, playerUUIDStr)
+	if err != nil {
+		log.Printf("Error reading debug state for player %s: %v", playerUUIDStr, err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to read player state")
+		return
+	}
+
+	resp := PlayerDebugResponse{
+		UUID:            playerUUIDStr,
+		Online:          state.Online,
+		OnlineTTLMillis: state.OnlineTTL.Milliseconds(),
+		PendingOffline:  state.PendingOffline,
+		TotalPlaytime:   state.TotalPlaytime,
+		DeltaPlaytime:   state.DeltaPlaytime,
+		Team:            state.Team,
+		Ban:             state.Ban,
+	}
+	if !state.SessionStart.IsZero() {
+		resp.SessionStart = state.SessionStart.Unix()
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
+// RegisterRoutes registers all API endpoints for the Game Service.
+// This method is called from main.go to set up the HTTP routes.
+func (gah *GameAPIHandlers) RegisterRoutes(router *mux.Router) {
+	// Player status and playtime
+	router.HandleFunc("/game/player/online", api.WithTimeout(15*time.Second, gah.HandlePlayerOnline)).Methods("POST")            // Calls the Player Service for the profile
+	router.HandleFunc("/game/player/offline", api.WithTimeout(15*time.Second, gah.HandlePlayerOffline)).Methods("POST")          // Calls the Player Service to persist playtime
+	router.HandleFunc("/game/player/online/batch", api.WithTimeout(60*time.Second, gah.HandlePlayerOnlineBatch)).Methods("POST") // Large batches take a while even with concurrency
+	router.HandleFunc("/game/player/offline/batch", api.WithTimeout(60*time.Second, gah.HandlePlayerOfflineBatch)).Methods("POST")
+	router.HandleFunc("/game/player/refresh-online", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleRefreshOnline)).Methods("POST") // New endpoint for heartbeat
+	router.HandleFunc("/game/player/refresh-online/batch", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleRefreshOnlineBatch)).Methods("POST")
+	router.HandleFunc("/game/player/top", api.WithTimeout(api.DefaultRequestTimeout, gah.GetTopPlayers)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/activity", api.WithTimeout(api.DefaultRequestTimeout, gah.HandlePlayerActivity)).Methods("POST")
+	router.HandleFunc("/game/player/{uuid}/playtime", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerTotalPlaytime)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/deltatime", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerDeltaPlaytime)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/playtime-audit", api.WithTimeout(api.DefaultRequestTimeout, api.RequireToken(gah.AdminToken, gah.GetPlayerPlaytimeAudit))).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/is-online", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerOnlineStatus)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/exists", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerExists)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/session-start", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerSessionStart)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/team", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerTeam)).Methods("GET")
+	router.HandleFunc("/game/player/{uuid}/team", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleAssignPlayerTeam)).Methods("PUT")
+	router.HandleFunc("/game/player/{uuid}/team", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleUnassignPlayerTeam)).Methods("DELETE")
+
+	// Team playtime
+	router.HandleFunc("/game/team/{teamId}/playtime", api.WithTimeout(api.DefaultRequestTimeout, gah.GetTeamTotalPlaytime)).Methods("GET") // Changed path variable name
+	router.HandleFunc("/game/team/top", api.WithTimeout(api.DefaultRequestTimeout, gah.GetTopTeams)).Methods("GET")
+	router.HandleFunc("/game/team/playtime/batch", api.WithTimeout(api.DefaultRequestTimeout, gah.GetTeamTotalPlaytimeBatch)).Methods("POST")
+
+	// Aggregate stats
+	router.HandleFunc("/game/online-count", api.WithTimeout(api.DefaultRequestTimeout, gah.GetOnlineCount)).Methods("GET")
+	router.HandleFunc("/game/stats/online", api.WithTimeout(api.DefaultRequestTimeout, gah.GetOnlineStats)).Methods("GET")
 
 	// Admin (ban/unban)
-	router.HandleFunc("/game/admin/ban", gah.HandleBanPlayer).Methods("POST")
-	router.HandleFunc("/game/admin/unban", gah.HandleUnbanPlayer).Methods("POST")
+	router.HandleFunc("/game/admin/ban", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleBanPlayer)).Methods("POST")
+	router.HandleFunc("/game/admin/ban-and-kick", api.WithTimeout(15*time.Second, gah.HandleBanAndKickPlayer)).Methods("POST") // Covers the Player Service calls for the ban and the kick
+	router.HandleFunc("/game/admin/ban/modify", api.WithTimeout(10*time.Second, gah.HandleModifyBan)).Methods("POST")          // Covers the Player Service call for the profile
+	router.HandleFunc("/game/admin/unban", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleUnbanPlayer)).Methods("POST")
+	router.HandleFunc("/game/admin/team/{teamId}/reset-playtime", api.WithTimeout(api.DefaultRequestTimeout, gah.HandleResetTeamPlaytime)).Methods("POST")
+	router.HandleFunc("/game/admin/team/{teamId}/rename", api.WithTimeout(60*time.Second, gah.HandleRenameTeam)).Methods("POST")                  // Covers a cluster-wide scan of team assignments
+	router.HandleFunc("/game/admin/ghost-sessions", api.WithTimeout(30*time.Second, gah.GetGhostSessions)).Methods("GET")                         // Covers a cluster-wide scan and, with cleanup, a persist per session
+	router.HandleFunc("/game/admin/player/{uuid}/reset-playtime", api.WithTimeout(10*time.Second, gah.HandleResetPlayerPlaytime)).Methods("POST") // Covers the Player Service calls for the profile
+	router.HandleFunc("/game/admin/player/{uuid}/playtime-multiplier", api.WithTimeout(10*time.Second, gah.HandleSetPlaytimeMultiplier)).Methods("PUT")
+	router.HandleFunc("/game/admin/player/{uuid}/debug", api.WithTimeout(api.DefaultRequestTimeout, gah.GetPlayerDebugState)).Methods("GET")
+	router.HandleFunc("/game/debug/ring", gah.GetRing).Methods("GET")
+	router.HandleFunc("/game/debug/is-leader", gah.GetIsLeader).Methods("GET")
 }
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPlaytimeAuditRequiresAdminToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminToken string
+		header     string
+	}{
+		{"missing token", "secret", ""},
+		{"wrong token", "secret", "Bearer other"},
+		{"no token configured", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			(&GameAPIHandlers{AdminToken: tt.adminToken}).RegisterRoutes(router)
+			req := httptest.NewRequest(http.MethodGet, "/game/player/069a79f4-44e9-4726-a5be-fca90e38aaf5/playtime-audit", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// game/api/playtime_rounding.go
+package api
+
+import (
+	"math"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+)
+
+// DefaultPlaytimeDecimals is the number of decimal places playtime values in responses are rounded to
+// unless GameAPIHandlers.PlaytimeDecimals says otherwise.
+const DefaultPlaytimeDecimals = 2
+
+// roundPlaytime rounds a playtime value for a response to PlaytimeDecimals decimal places, so the
+// float artifacts of summing many ticks (e.g., 1234.5600000000001) don't reach clients. A negative
+// PlaytimeDecimals returns the value unchanged.
+func (gah *GameAPIHandlers) roundPlaytime(playtime float64) float64 {
+	if gah.PlaytimeDecimals < 0 {
+		return playtime
+	}
+	scale := math.Pow10(gah.PlaytimeDecimals)
+	rounded := math.Round(playtime*scale) / scale
+	if math.IsInf(rounded, 0) || math.IsNaN(rounded) {
+		return playtime // Too large to scale; it has no fractional digits left to round anyway
+	}
+	return rounded
+}
+
+// roundPlaytimes rounds every value of a team ID -> playtime map in place, see roundPlaytime.
+func (gah *GameAPIHandlers) roundPlaytimes(playtimes map[string]float64) map[string]float64 {
+	for id, playtime := range playtimes {
+		playtimes[id] = gah.roundPlaytime(playtime)
+	}
+	return playtimes
+}
+
+// roundTeamPlaytimes rounds the playtime of every leaderboard entry in place, see roundPlaytime.
+func (gah *GameAPIHandlers) roundTeamPlaytimes(teams []store.TeamPlaytime) []store.TeamPlaytime {
+	for i := range teams {
+		teams[i].Playtime = gah.roundPlaytime(teams[i].Playtime)
+	}
+	return teams
+}
+
+// roundPlayerPlaytimes rounds the playtime of every leaderboard entry in place, see roundPlaytime.
+func (gah *GameAPIHandlers) roundPlayerPlaytimes(players []store.PlayerPlaytime) []store.PlayerPlaytime {
+	for i := range players {
+		players[i].Playtime = gah.roundPlaytime(players[i].Playtime)
+	}
+	return players
+}
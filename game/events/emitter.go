@@ -0,0 +1,70 @@
+// game/events/emitter.go
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Event types published by the game service.
+const (
+	TypePlayerOnline  = "player_online"
+	TypePlayerOffline = "player_offline"
+)
+
+// streamMaxLen caps the Redis stream so unconsumed events don't grow it without bound (trimmed approximately).
+const streamMaxLen = 100000
+
+// PlayerEvent describes a change in a player's session for downstream consumers (e.g., analytics).
+type PlayerEvent struct {
+	Type            string        // TypePlayerOnline or TypePlayerOffline
+	UUID            string        // Player UUID
+	Team            string        // Player's team ID; empty if unassigned
+	SessionDuration time.Duration // Length of the session that ended; 0 for player_online
+	Timestamp       time.Time     // When the event occurred
+}
+
+// Emitter publishes player events to a message bus. Implementations must be safe for concurrent use.
+type Emitter interface {
+	Emit(ctx context.Context, event PlayerEvent) error
+}
+
+// NoopEmitter discards every event. It is used when no event bus is configured.
+type NoopEmitter struct{}
+
+// Emit does nothing.
+func (NoopEmitter) Emit(ctx context.Context, event PlayerEvent) error { return nil }
+
+// RedisStreamEmitter publishes events to a Redis stream with XADD, reusing the game service's Redis cluster.
+type RedisStreamEmitter struct {
+	client *redis.ClusterClient
+	stream string
+}
+
+// NewRedisStreamEmitter creates an Emitter that appends events to the given Redis stream.
+func NewRedisStreamEmitter(client *redis.ClusterClient, stream string) *RedisStreamEmitter {
+	return &RedisStreamEmitter{client: client, stream: stream}
+}
+
+// Emit appends the event to the stream.
+func (e *RedisStreamEmitter) Emit(ctx context.Context, event PlayerEvent) error {
+	err := e.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: e.stream,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":                     event.Type,
+			"uuid":                     event.UUID,
+			"team":                     event.Team,
+			"session_duration_seconds": event.SessionDuration.Seconds(),
+			"timestamp":                event.Timestamp.Unix(),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event for player %s to stream %s: %w", event.Type, event.UUID, e.stream, err)
+	}
+	return nil
+}
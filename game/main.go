@@ -6,16 +6,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	gameapi "github.com/Ftotnem/GO-SERVICES/game/api" // Assuming you have a game API package
-	"github.com/Ftotnem/GO-SERVICES/game/service"     // The game service business logic
-	"github.com/Ftotnem/GO-SERVICES/game/store"       // The Redis-only stores
+	"github.com/Ftotnem/GO-SERVICES/game/events"
+	"github.com/Ftotnem/GO-SERVICES/game/persistence"
+	"github.com/Ftotnem/GO-SERVICES/game/service" // The game service business logic
+	"github.com/Ftotnem/GO-SERVICES/game/store"   // The Redis-only stores
 	"github.com/Ftotnem/GO-SERVICES/game/syncer"
 	"github.com/Ftotnem/GO-SERVICES/game/updater"
 	"github.com/Ftotnem/GO-SERVICES/shared/api"
+	"github.com/Ftotnem/GO-SERVICES/shared/cluster"
 	"github.com/Ftotnem/GO-SERVICES/shared/config"
+	"github.com/Ftotnem/GO-SERVICES/shared/logging"
 	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // For Redis client utility
 	"github.com/Ftotnem/GO-SERVICES/shared/registry"     // For service registration
 	playerserviceclient "github.com/Ftotnem/GO-SERVICES/shared/service"
@@ -27,29 +32,111 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
-	log.Printf("Configuration loaded for Game Service. Listening on: %s", cfg.ListenAddr)
+	if err := logging.Setup(cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to configure logging: %v", err)
+	}
+	log.Printf("Configuration loaded for Game Service. Listening on: %s", cfg.ListenAddr) // Only now in the configured format
 
 	// --- 2. Connect to Redis Cluster ---
 	redisClient, err := redisu.NewRedisClusterClient(cfg.RedisAddrs, cfg.RedisPassword)
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis Cluster: %v", err)
 	}
-	defer func() {
-		if err = redisClient.Close(); err != nil {
-			log.Fatalf("Error closing Redis client: %v", err)
-		}
-		log.Println("Redis Client closed.")
-	}()
 	log.Println("Connected to Redis Cluster.")
 
 	// --- 3. Initialize Data Stores (Redis-only) ---
 	// These are the stores that interact directly with Redis
 	playerPlaytimeStore := store.NewPlayerPlaytimeStore(redisClient)
-	onlinePlayersStore := store.NewOnlinePlayersStore(redisClient, cfg.RedisOnlineTTL) // Assuming this store exists and is Redis-only
+	if cfg.PlaytimeAuditEnabled {
+		playerPlaytimeStore.EnableAudit(cfg.PlaytimeAuditMaxEntries, cfg.PlaytimeAuditRetention)
+		log.Printf("Playtime audit log enabled (max %d entries per player, retention %v).", cfg.PlaytimeAuditMaxEntries, cfg.PlaytimeAuditRetention)
+	}
+	if cfg.MaxConcurrentScans > 0 {
+		playerPlaytimeStore.SetMaxConcurrentScans(cfg.MaxConcurrentScans)
+		log.Printf("Playtime scans limited to %d Redis nodes at a time.", cfg.MaxConcurrentScans)
+	}
+	onlinePlayersStore := store.NewOnlinePlayersStore(redisClient, cfg.RedisOnlineTTL, cfg.MaxSessionDuration) // Assuming this store exists and is Redis-only
+	if cfg.IdleTimeout > 0 {
+		onlinePlayersStore.SetIdleTimeout(cfg.IdleTimeout)
+		log.Printf("Idle detection enabled: players without activity for %v stop accruing playtime.", cfg.IdleTimeout)
+	}
+	if cfg.TeamPlaytimeDisabled {
+		playerPlaytimeStore.DisableTeamPlaytime()
+		log.Println("Team playtime accrual disabled: playtime ticks credit players only.")
+	}
 	teamPlaytimeStore := store.NewTeamPlaytimeStore(redisClient)
+	if cfg.AllowNegativeTeamPlaytime {
+		teamPlaytimeStore.AllowNegativeTotals()
+		log.Println("Negative team playtime totals allowed: corrections are not floored at zero.")
+	}
+	if cfg.ResetCorruptTeamPlaytime {
+		teamPlaytimeStore.ResetCorruptTotals()
+	}
+	if cfg.PlaytimeMillis {
+		playerPlaytimeStore.SetPlaytimeUnit(redisu.PlaytimeMillis)
+		teamPlaytimeStore.SetPlaytimeUnit(redisu.PlaytimeMillis)
+		log.Println("Playtime totals are kept in Redis as whole milliseconds.")
+	}
+	if cfg.TeamPlaytimeCap > 0 {
+		teamCap := store.NewTeamPlaytimeCap(cfg.TeamPlaytimeCap)
+		playerPlaytimeStore.SetTeamPlaytimeCap(teamCap)
+		teamPlaytimeStore.SetTeamPlaytimeCap(teamCap)
+		log.Printf("Team playtime capped at %.0f.", cfg.TeamPlaytimeCap)
+	}
+	if cfg.TeamLeaderboardIndex {
+		// Seeded from a full scan so teams that haven't changed since the last start are ranked too.
+		leaderboard := store.NewTeamLeaderboard(redisClient)
+		totals, err := teamPlaytimeStore.GetAllTeamPlaytimes(context.Background())
+		if err == nil {
+			err = leaderboard.Rebuild(context.Background(), totals)
+		}
+		if err != nil {
+			log.Fatalf("Failed to build team leaderboard index: %v", err)
+		}
+		playerPlaytimeStore.SetTeamLeaderboard(leaderboard)
+		teamPlaytimeStore.SetTeamLeaderboard(leaderboard)
+		log.Printf("Team leaderboard index enabled (%d teams).", len(totals))
+	}
+	if cfg.PlayerLeaderboardIndex {
+		// Seeded here and rebuilt by the leader on every sync.
+		leaderboard := store.NewPlayerLeaderboard(redisClient)
+		playtimes, err := playerPlaytimeStore.GetAllPlayerPlaytimes(context.Background())
+		if err == nil {
+			err = leaderboard.Rebuild(context.Background(), playtimes)
+		}
+		if err != nil {
+			log.Fatalf("Failed to build player leaderboard index: %v", err)
+		}
+		playerPlaytimeStore.SetPlayerLeaderboard(leaderboard)
+		log.Printf("Player leaderboard index enabled (%d players).", len(playtimes))
+	}
 	banStore := store.NewBanStore(redisClient) // Assuming this store exists and is Redis-only
+	if cfg.BanClockSkewTolerance > 0 {
+		banStore.SetClockSkewTolerance(cfg.BanClockSkewTolerance)
+	}
 
 	playerserviceclient := playerserviceclient.NewPlayerClient(cfg.PlayerServiceURL)
+	if cfg.ProfileCacheTTL > 0 {
+		playerserviceclient.EnableProfileCache(redisClient, cfg.ProfileCacheTTL)
+		log.Printf("Player profile cache enabled with TTL %v.", cfg.ProfileCacheTTL)
+	}
+	playerserviceclient.SetMessagePack(cfg.MessagePack)
+	// Playtime and bans are persisted through the Player Service HTTP API by default.
+	persister := persistence.NewHTTPPlaytimePersister(playerserviceclient)
+
+	// Team IDs are validated against the configured teams plus those known to the Player Service.
+	teamAllowList := service.NewTeamAllowList(playerserviceclient, cfg.AllowedTeams, cfg.TeamRefreshInterval)
+	go teamAllowList.Start()
+	// Every team write goes through these stores, so checking there covers all of them.
+	playerPlaytimeStore.SetTeamValidator(teamAllowList.Validate)
+	teamPlaytimeStore.SetTeamValidator(teamAllowList.Validate)
+
+	// Player session events are only published when a stream is configured.
+	var eventEmitter events.Emitter = events.NoopEmitter{}
+	if cfg.EventsStream != "" {
+		eventEmitter = events.NewRedisStreamEmitter(redisClient, cfg.EventsStream)
+		log.Printf("Publishing player session events to Redis stream '%s'.", cfg.EventsStream)
+	}
 
 	// --- 4. Initialize Business Logic Service (passing stores) ---
 	// The GameService handles all real-time game logic using Redis-backed data.
@@ -60,34 +147,160 @@ func main() {
 		banStore,
 		redisClient, // Pass the main Redis client for direct lookups (e.g., player team)
 		playerserviceclient,
+		persister,
+		eventEmitter,
+		teamAllowList,
+		cfg.MaxConcurrentOnline,
+		cfg.OnlineQueueSize,
+		cfg.OfflineGracePeriod,
+		cfg.ProfileFetchRetries,
+		cfg.ProfileFetchRetryBackoff,
 	)
+	if cfg.RejectAlreadyOnline {
+		gameService.RejectAlreadyOnline()
+		log.Println("Players who are already online are refused with 409 instead of having their session re-initialized.")
+	}
 	log.Println("Game Service business logic initialized.")
 
+	// Team renames made through any instance drop this instance's cached assignments to the old team.
+	teamRenameSubscriber := service.NewTeamRenameSubscriber(gameService, redisClient)
+	go teamRenameSubscriber.Start()
+
 	// --- 5. Initialize API Handlers (passing business logic services) ---
 	// Assuming gameapi.NewGameAPIHandlers and its RegisterRoutes method exist.
-	gameAPIHandlers := gameapi.NewGameAPIHandlers(gameService)
+	gameAPIHandlers := gameapi.NewGameAPIHandlers(gameService, cfg.BatchConcurrency, cfg.MaxBanDuration)
+	gameAPIHandlers.PlaytimeDecimals = cfg.PlaytimeDecimals
+	gameAPIHandlers.AdminToken = cfg.AdminToken // The playtime audit route sits outside /game/admin/
 
 	// --- 6. Initialize and Start Service Registrar ---
 	// The Game Service registers itself with the service discovery system.
-	registrar := registry.NewServiceRegistrar(redisClient, "game-service", &cfg.CommonConfig)
-	go registrar.Start()   // Start the heartbeating goroutine
-	defer registrar.Stop() // Ensure registrar stops on shutdown
+	var registrationMetadata map[string]string
+	if cfg.ShardingMode == config.ShardingModeModulo {
+		registrationMetadata = map[string]string{"shard_index": strconv.Itoa(cfg.GameServiceInstanceID)}
+	}
+	registrar := registry.NewServiceRegistrar(redisClient, "game-service", &cfg.CommonConfig, registrationMetadata)
+	// Start blocks until the first registration succeeds, then heartbeats in the background; stopped explicitly during shutdown.
+	if err := registrar.Start(); err != nil {
+		log.Fatalf("Failed to register game-service: %v", err)
+	}
 	log.Printf("Service registrar started for 'game-service' with Address: %s", cfg.ListenAddr)
 
 	// The serviceTimeout for RegistryClient should be related to HeartbeatTTL from CommonConfig
 	registryClient := registry.NewRegistryClient(redisClient, cfg.HeartbeatTTL)
 
-	updater := updater.NewGameUpdater(cfg, registryClient, onlinePlayersStore, playerPlaytimeStore, registrar)
+	// A single assignment manager serves the updater, the syncer and the ban cleaner, so the registry is
+	// queried once per interval and every component sees the same ring. It is stopped after all of them.
+	assignmentManager := cluster.NewServiceAssignmentManager(registryClient, registrar, cfg.HeartbeatInterval)
+	if cfg.ShardingMode == config.ShardingModeModulo {
+		if err := assignmentManager.EnableModuloSharding(cfg.GameServiceInstanceID, cfg.TotalGameServiceInstances); err != nil {
+			log.Fatalf("Failed to enable modulo sharding: %v", err)
+		}
+	}
+	go assignmentManager.Start()
+	// Only the leader cleans stale game-service registry entries instead of every instance at once.
+	registrar.SetCleanupLeader(func() (bool, error) {
+		return assignmentManager.IsResponsible(registry.CleanupTaskKey)
+	})
+
+	// Profile changes published by the Player Service invalidate cached teams and sync bans edited outside
+	// the game service; the instance responsible for the player applies the ban.
+	var profileChangeSubscriber *service.ProfileChangeSubscriber
+	if cfg.ProfileChangesChannel != "" {
+		profileChangeSubscriber = service.NewProfileChangeSubscriber(gameService, redisClient, cfg.ProfileChangesChannel, assignmentManager.IsResponsible)
+		go profileChangeSubscriber.Start()
+	}
+
+	updater := updater.NewGameUpdater(cfg, assignmentManager, onlinePlayersStore, playerPlaytimeStore, persister)
 	go updater.Start()
-	defer updater.Stop()
+	// Heartbeats report the players this instance updates as its load, for load-aware routing, and the
+	// cluster-wide online count straight from the counter, so neither adds a scan to the heartbeat.
+	registrar.SetLoadReporter(func() (float64, error) {
+		return float64(updater.AssignedPlayers()), nil
+	})
+	registrar.SetHeartbeatMetadata(registry.OnlinePlayersMetadataKey, func(ctx context.Context) (string, error) {
+		count, err := onlinePlayersStore.PeekOnlinePlayerCount(ctx)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(count), nil
+	})
+	gameAPIHandlers.Ring = assignmentManager
+	gameAPIHandlers.InstanceID = registrar.GetServiceID()
+
+	// Expired bans are removed in batches by the leader instead of on every read.
+	var banCleaner *syncer.BanCleaner
+	if cfg.BanCleanupInterval > 0 {
+		banCleaner = syncer.NewBanCleaner(banStore, assignmentManager, cfg.BanCleanupInterval, cfg.BanCleanupBatchSize)
+		go banCleaner.Start()
+	}
+	// The online counter drifts as online keys expire, so the leader periodically recounts them.
+	var onlineCountReconciler *syncer.OnlineCountReconciler
+	if cfg.OnlineRecountInterval > 0 {
+		onlineCountReconciler = syncer.NewOnlineCountReconciler(onlinePlayersStore, assignmentManager, cfg.OnlineRecountInterval)
+		go onlineCountReconciler.Start()
+	}
+	// Sessions that were never ended (e.g., after a crash) get their total persisted and their delta
+	// cleared by the leader.
+	var deltaCleaner *syncer.DeltaCleaner
+	if cfg.DeltaCleanupInterval > 0 {
+		deltaCleaner = syncer.NewDeltaCleaner(playerPlaytimeStore, persister, assignmentManager, cfg.DeltaCleanupInterval, cfg.DeltaMaxAge)
+		go deltaCleaner.Start()
+	}
 
-	syncer := syncer.NewPlaytimeSyncer(cfg, playerPlaytimeStore, teamPlaytimeStore, *playerserviceclient, registryClient, registrar)
+	syncer := syncer.NewPlaytimeSyncer(cfg, playerPlaytimeStore, teamPlaytimeStore, *playerserviceclient, persister, assignmentManager)
+	if cfg.SnapshotBackupDir != "" {
+		snapshotBackup, err := persistence.NewFileSnapshotBackup(cfg.SnapshotBackupDir, cfg.SnapshotBackupRetain)
+		if err != nil {
+			// The secondary backup is optional; the primary keeps working without it.
+			log.Printf("WARNING: Secondary playtime backup disabled: %v", err)
+		} else {
+			syncer.SetSecondaryBackup(snapshotBackup)
+			log.Printf("Secondary playtime backup enabled: snapshots in %s (keeping %d).", cfg.SnapshotBackupDir, cfg.SnapshotBackupRetain)
+		}
+	}
 	go syncer.Start()
-	defer syncer.Stop()
 
 	// --- 7. Setup HTTP Server and Register Routes ---
-	baseServer := api.NewBaseServer(cfg.ListenAddr, log.Default()) // Assumes NewBaseServer takes address and sets up mux.Router
+	baseServer := api.NewBaseServer(cfg.ListenAddr, log.Default(), api.ServerOptions{
+		ReadTimeout:    cfg.HTTPReadTimeout,
+		WriteTimeout:   cfg.HTTPWriteTimeout,
+		IdleTimeout:    cfg.HTTPIdleTimeout,
+		MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
+	})
 	gameAPIHandlers.RegisterRoutes(baseServer.Router)
+	// Going online needs the player service, but it can be made advisory so a player service outage
+	// doesn't take every game instance out of rotation as well.
+	baseServer.UseReadinessChecks(
+		api.ReadinessCheck{Name: "redis", Required: true, Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}},
+		api.ReadinessCheck{Name: "player-service", Required: cfg.RequirePlayerServiceReady, Check: playerserviceclient.CheckHealth},
+	)
+	// Endpoints that scan every online key get the stricter scan limit, checked before the admin prefix.
+	// Top teams and top players only scan when their leaderboard index is disabled.
+	scanLimiter := api.NewRateLimiter(cfg.ScanRateLimit.RPS, cfg.ScanRateLimit.Burst)
+	rateLimitRules := []api.RateLimitRule{
+		{PathPrefix: "/game/admin/ghost-sessions", Limiter: scanLimiter},
+		{PathPrefix: "/game/stats/", Limiter: scanLimiter},
+	}
+	if !cfg.TeamLeaderboardIndex {
+		rateLimitRules = append(rateLimitRules, api.RateLimitRule{PathPrefix: "/game/team/top", Limiter: scanLimiter})
+	}
+	if !cfg.PlayerLeaderboardIndex {
+		rateLimitRules = append(rateLimitRules, api.RateLimitRule{PathPrefix: "/game/player/top", Limiter: scanLimiter})
+	}
+	rateLimitRules = append(rateLimitRules,
+		api.RateLimitRule{PathPrefix: "/game/admin/", Limiter: api.NewRateLimiter(cfg.AdminRateLimit.RPS, cfg.AdminRateLimit.Burst)},
+		api.RateLimitRule{PathPrefix: "/", Limiter: api.NewRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst)},
+	)
+	baseServer.UseRateLimits(cfg.AdminToken, rateLimitRules...)
+	// Authentication runs after the rate limiter so guessing tokens is limited like any other request.
+	baseServer.RequireAdminToken(cfg.AdminToken, "/game/admin/")
+	// Compression runs after the rate limiter so rejected requests are not buffered.
+	baseServer.UseCompression(cfg.CompressionMinSize)
+	if cfg.MessagePack {
+		baseServer.UseMessagePack()
+	}
 	log.Println("HTTP routes registered.")
 
 	// --- 8. Start HTTP Server ---
@@ -109,12 +322,52 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
-	// Perform graceful shutdown of the HTTP server
+	// Shutdown runs in a fixed order rather than via defers so each stage can rely on the ones after it:
+	// 1. Stop accepting HTTP requests so no new sessions or writes arrive.
 	if err := baseServer.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("HTTP server graceful shutdown failed: %v", err)
+		log.Printf("HTTP server graceful shutdown failed: %v", err)
 	}
 	log.Println("Game Service HTTP server gracefully stopped.")
-	// defer registrar.Stop() // This will be called via defer
-	// defer redisClient.Close() // This will be called via defer
+	// Profile changes can end sessions too (via bans), so they stop arriving along with HTTP requests.
+	if profileChangeSubscriber != nil {
+		profileChangeSubscriber.Stop()
+	}
+	teamRenameSubscriber.Stop()
+
+	// End sessions still inside the reconnect grace window; nothing will be left to clean them up later.
+	gameService.FlushPendingOffline(shutdownCtx)
+
+	if banCleaner != nil {
+		banCleaner.Stop()
+	}
+	if onlineCountReconciler != nil {
+		onlineCountReconciler.Stop()
+	}
+	if deltaCleaner != nil {
+		deltaCleaner.Stop()
+	}
+
+	// 2. Drain the updater so no tick is mid-flight while the final sync reads playtimes.
+	updater.Stop()
+	log.Println("Game Updater stopped.")
+	// Persist what this instance's players accrued since their last persist; their sessions continue elsewhere.
+	updater.PersistResponsiblePlayers(shutdownCtx)
+
+	// 3. Stop the syncer, which runs one final backup/team sync (needs Redis and leadership).
+	syncer.Stop()
+	log.Println("Playtime Syncer stopped.")
+	assignmentManager.Stop()
+
+	teamAllowList.Stop()
+
+	// 4. Deregister from the service registry (needs Redis).
+	registrar.Stop()
+
+	// 5. Close Redis last.
+	if err := redisClient.Close(); err != nil {
+		log.Printf("Error closing Redis client: %v", err)
+	} else {
+		log.Println("Redis Client closed.")
+	}
 	log.Println("Game Service gracefully shut down.")
 }
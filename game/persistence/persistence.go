@@ -0,0 +1,46 @@
+// game/persistence/persistence.go
+package persistence
+
+import (
+	"context"
+	"time"
+
+	playerserviceclient "github.com/Ftotnem/GO-SERVICES/shared/service"
+)
+
+// PlaytimePersister is where the game service writes session data that must outlive Redis.
+// The default implementation calls the Player Service over HTTP; alternatives (event emission,
+// direct Mongo, gRPC) can be plugged into GameService and PlaytimeSyncer without changing them.
+type PlaytimePersister interface {
+	// PersistPlaytime stores a player's total accumulated playtime.
+	PersistPlaytime(ctx context.Context, playerUUID string, totalPlaytime float64) error
+	// PersistBan stores a player's ban status. A nil expiresAt means the ban is permanent.
+	PersistBan(ctx context.Context, playerUUID string, banned bool, expiresAt *time.Time) error
+	// PersistMultiplier stores a player's permanent playtime multiplier.
+	PersistMultiplier(ctx context.Context, playerUUID string, multiplier float64) error
+}
+
+// HTTPPlaytimePersister persists playtime through the Player Service HTTP API.
+type HTTPPlaytimePersister struct {
+	client *playerserviceclient.PlayerServiceClient
+}
+
+// NewHTTPPlaytimePersister creates a PlaytimePersister backed by the given Player Service client.
+func NewHTTPPlaytimePersister(client *playerserviceclient.PlayerServiceClient) *HTTPPlaytimePersister {
+	return &HTTPPlaytimePersister{client: client}
+}
+
+// PersistPlaytime calls PUT /profiles/{uuid}/playtime on the Player Service.
+func (p *HTTPPlaytimePersister) PersistPlaytime(ctx context.Context, playerUUID string, totalPlaytime float64) error {
+	return p.client.UpdatePlayerPlaytime(ctx, playerUUID, totalPlaytime)
+}
+
+// PersistBan calls PUT /profiles/{uuid}/ban on the Player Service.
+func (p *HTTPPlaytimePersister) PersistBan(ctx context.Context, playerUUID string, banned bool, expiresAt *time.Time) error {
+	return p.client.UpdatePlayerBanStatus(ctx, playerUUID, banned, expiresAt)
+}
+
+// PersistMultiplier calls PUT /profiles/{uuid}/playtime-multiplier on the Player Service.
+func (p *HTTPPlaytimePersister) PersistMultiplier(ctx context.Context, playerUUID string, multiplier float64) error {
+	return p.client.UpdatePlayerPlaytimeMultiplier(ctx, playerUUID, multiplier)
+}
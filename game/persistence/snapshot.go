@@ -0,0 +1,109 @@
+// game/persistence/snapshot.go
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PlaytimeSnapshot is every player playtime read from Redis by one sync run.
+type PlaytimeSnapshot struct {
+	TakenAt   time.Time          `json:"taken_at"`
+	Partial   bool               `json:"partial"`   // Some Redis nodes could not be scanned, so players are missing
+	Playtimes map[string]float64 `json:"playtimes"` // Player UUID -> total playtime
+}
+
+// SnapshotBackup is an optional secondary target the syncer writes each playtime snapshot to, in
+// addition to the primary PlaytimePersister, for disaster recovery. Failures are logged by the caller
+// and never affect the primary backup.
+type SnapshotBackup interface {
+	BackupSnapshot(ctx context.Context, snapshot PlaytimeSnapshot) error
+}
+
+// snapshotFilePrefix and snapshotFileSuffix frame the timestamp in snapshot file names, which sort by age.
+const (
+	snapshotFilePrefix = "playtime-snapshot-"
+	snapshotFileSuffix = ".json"
+)
+
+// FileSnapshotBackup writes each snapshot as a JSON file into a directory, e.g. a mounted volume that
+// is itself backed up or synced to an object store, and keeps only the newest retain files.
+type FileSnapshotBackup struct {
+	dir    string
+	retain int
+}
+
+// NewFileSnapshotBackup creates a FileSnapshotBackup writing into dir, which is created if missing.
+// retain is the number of snapshot files kept; 0 keeps all of them.
+func NewFileSnapshotBackup(dir string, retain int) (*FileSnapshotBackup, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory %s: %w", dir, err)
+	}
+	return &FileSnapshotBackup{dir: dir, retain: max(retain, 0)}, nil
+}
+
+// BackupSnapshot writes the snapshot to a temporary file and renames it into place, so a crash never
+// leaves a truncated snapshot behind, then removes the oldest snapshots beyond the retention.
+func (b *FileSnapshotBackup) BackupSnapshot(ctx context.Context, snapshot PlaytimeSnapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name := snapshotFilePrefix + snapshot.TakenAt.UTC().Format("20060102T150405.000Z") + snapshotFileSuffix
+
+	tmp, err := os.CreateTemp(b.dir, name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file in %s: %w", b.dir, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if err := json.NewEncoder(tmp).Encode(snapshot); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot %s: %w", name, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to flush snapshot %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(b.dir, name)); err != nil {
+		return fmt.Errorf("failed to move snapshot %s into place: %w", name, err)
+	}
+
+	return b.prune()
+}
+
+// prune removes the oldest snapshot files beyond the retention.
+func (b *FileSnapshotBackup) prune() error {
+	if b.retain == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshot directory %s: %w", b.dir, err)
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, snapshotFilePrefix) && strings.HasSuffix(name, snapshotFileSuffix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	if len(snapshots) <= b.retain {
+		return nil
+	}
+	slices.Sort(snapshots) // Oldest first
+	for _, name := range snapshots[:len(snapshots)-b.retain] {
+		if err := os.Remove(filepath.Join(b.dir, name)); err != nil {
+			return fmt.Errorf("failed to remove old snapshot %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -3,27 +3,109 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Ftotnem/GO-SERVICES/game/events"
+	"github.com/Ftotnem/GO-SERVICES/game/persistence"
 	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/api"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
 	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
 	playerserviceclient "github.com/Ftotnem/GO-SERVICES/shared/service" // This is your gRPC/HTTP client for Player Service
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+// onlineStatsCacheTTL bounds how long a computed OnlineStats snapshot is served before it is recomputed.
+// Computing it scans every online key, so scoreboard polling should not hit Redis on every request.
+const onlineStatsCacheTTL = 2 * time.Second
+
+// OnlineStats is a snapshot of how many players are online, broken down by team.
+type OnlineStats struct {
+	TotalOnline int
+	Teams       map[string]int // team ID -> online players on that team
+	Unassigned  int            // online players without a team
+	ComputedAt  time.Time
+}
+
+// ErrOnlineQueueFull is returned by PlayerOnline when every profile-fetch slot is busy
+// and the wait queue is already at capacity. Callers should retry later.
+var ErrOnlineQueueFull = errors.New("too many concurrent player online requests")
+
+// ErrProfileUnavailable is returned by PlayerOnline when the player's profile could not be loaded
+// for a reason other than it not existing (e.g., the Player Service returned 5xx). The player is not
+// brought online, so their Redis playtime is never overwritten with defaults.
+var ErrProfileUnavailable = errors.New("player profile temporarily unavailable")
+
+// ErrAlreadyOnline is returned by PlayerOnline for a player who is already online when
+// RejectAlreadyOnline is enabled. Their existing session is left untouched.
+var ErrAlreadyOnline = errors.New("player is already online")
+
+// ErrNoActiveSession is returned when a player is not online or has no recorded session start.
+var ErrNoActiveSession = errors.New("player has no active session")
+
+// ErrPlayerBanned is returned (as a *PlayerBannedError) when a banned player tries to go online.
+var ErrPlayerBanned = errors.New("player is banned")
+
+// PlayerBannedError carries the ban that stopped a player from going online.
+type PlayerBannedError struct {
+	Ban *store.BanInfo
+}
+
+func (e *PlayerBannedError) Error() string {
+	return fmt.Sprintf("player %s is currently banned and cannot go online", e.Ban.PlayerUUID)
+}
+
+func (e *PlayerBannedError) Unwrap() error {
+	return ErrPlayerBanned
+}
+
+// ErrNotBanned is returned when modifying the ban of a player who is not currently banned.
+var ErrNotBanned = errors.New("player is not banned")
+
+// ErrNoTeamAssigned is returned when a player has no team assignment in Redis.
+var ErrNoTeamAssigned = errors.New("player has no team assigned")
+
+// ErrPlaytimeAuditDisabled is returned when the playtime audit log is requested but auditing is not enabled.
+var ErrPlaytimeAuditDisabled = errors.New("playtime audit log is not enabled")
+
 // GameService holds references to the data stores and other dependencies needed
 // for game-related business logic. This service now primarily interacts with Redis
 // for real-time, in-session data, and delegates long-term persistence
 // to other microservices (e.g., Player Service, Team Stats Service) via periodic updates.
 type GameService struct {
-	PlayerPlaytimeStore *store.PlayerPlaytimeStore // For managing player playtime in Redis
-	OnlinePlayersStore  *store.OnlinePlayersStore  // For managing online status and delta playtime in Redis
-	TeamPlaytimeStore   *store.TeamPlaytimeStore   // For managing team total playtimes in Redis
-	BanStore            *store.BanStore            // For managing player bans in Redis
-	RedisClient         *redis.ClusterClient       // Direct Redis client for player team lookup
-	PlayerServiceClient *playerserviceclient.PlayerServiceClient
+	PlayerPlaytimeStore *store.PlayerPlaytimeStore               // For managing player playtime in Redis
+	OnlinePlayersStore  *store.OnlinePlayersStore                // For managing online status and delta playtime in Redis
+	TeamPlaytimeStore   *store.TeamPlaytimeStore                 // For managing team total playtimes in Redis
+	BanStore            *store.BanStore                          // For managing player bans in Redis
+	RedisClient         *redis.ClusterClient                     // Direct Redis client for player team lookup
+	PlayerServiceClient *playerserviceclient.PlayerServiceClient // Used to load profiles when players come online
+	Persister           persistence.PlaytimePersister            // Where playtime and bans are persisted beyond Redis
+	TeamAllowList       *TeamAllowList                           // Known teams, refreshed before renames; validation itself is set on the stores
+	EventEmitter        events.Emitter                           // Publishes player_online/player_offline events; no-op when unconfigured
+
+	// Bounds the profile-fetch portion of PlayerOnline so a mass reconnect cannot flood the Player Service.
+	profileFetchSlots     chan struct{} // nil when the limit is disabled
+	profileFetchQueueSize int64
+	profileFetchWaiting   atomic.Int64
+
+	profileFetchRetries      int
+	profileFetchRetryBackoff time.Duration
+
+	// Offline cleanup is delayed by offlineGracePeriod so a quick reconnect resumes the existing session.
+	offlineGracePeriod time.Duration // 0 cleans up immediately
+	pendingOfflineMu   sync.Mutex
+	pendingOffline     map[string]*pendingCleanup // player UUID -> cleanup scheduled by this instance
+
+	onlineStatsMu     sync.Mutex
+	cachedOnlineStats *OnlineStats
+
+	rejectAlreadyOnline bool // PlayerOnline fails with ErrAlreadyOnline instead of re-initializing an online player
 }
 
 // NewGameService is the constructor for GameService.
@@ -34,92 +116,475 @@ func NewGameService(
 	banStore *store.BanStore,
 	redisClient *redis.ClusterClient,
 	playerServiceClient *playerserviceclient.PlayerServiceClient,
+	persister persistence.PlaytimePersister,
+	eventEmitter events.Emitter, // nil disables event emission
+	teamAllowList *TeamAllowList, // nil skips refreshing the known teams before renames
+	maxConcurrentOnline int, // 0 disables the limit
+	onlineQueueSize int,
+	offlineGracePeriod time.Duration, // 0 disables the reconnect grace window
+	profileFetchRetries int,
+	profileFetchRetryBackoff time.Duration,
 ) *GameService {
-	return &GameService{
-		PlayerPlaytimeStore: playerPlaytimeStore,
-		OnlinePlayersStore:  onlinePlayersStore,
-		TeamPlaytimeStore:   teamPlaytimeStore,
-		BanStore:            banStore,
-		RedisClient:         redisClient,
-		PlayerServiceClient: playerServiceClient,
+	gs := &GameService{
+		PlayerPlaytimeStore:   playerPlaytimeStore,
+		OnlinePlayersStore:    onlinePlayersStore,
+		TeamPlaytimeStore:     teamPlaytimeStore,
+		BanStore:              banStore,
+		RedisClient:           redisClient,
+		PlayerServiceClient:   playerServiceClient,
+		Persister:             persister,
+		EventEmitter:          eventEmitter,
+		TeamAllowList:         teamAllowList,
+		profileFetchQueueSize: int64(onlineQueueSize),
+		offlineGracePeriod:    offlineGracePeriod,
+		pendingOffline:        make(map[string]*pendingCleanup),
+
+		profileFetchRetries:      profileFetchRetries,
+		profileFetchRetryBackoff: profileFetchRetryBackoff,
+	}
+	if gs.EventEmitter == nil {
+		gs.EventEmitter = events.NoopEmitter{}
+	}
+	if maxConcurrentOnline > 0 {
+		gs.profileFetchSlots = make(chan struct{}, maxConcurrentOnline)
+	}
+	return gs
+}
+
+// RejectAlreadyOnline makes PlayerOnline refuse players who are already online with ErrAlreadyOnline,
+// keeping their session, instead of re-initializing it from their profile.
+func (gs *GameService) RejectAlreadyOnline() {
+	gs.rejectAlreadyOnline = true
+}
+
+// acquireProfileFetchSlot blocks until a profile-fetch slot is free and returns a function that releases it.
+// If no slot is immediately free and the wait queue is full, it returns ErrOnlineQueueFull without waiting.
+func (gs *GameService) acquireProfileFetchSlot(ctx context.Context) (func(), error) {
+	if gs.profileFetchSlots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-gs.profileFetchSlots }
+
+	select {
+	case gs.profileFetchSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if gs.profileFetchWaiting.Add(1) > gs.profileFetchQueueSize {
+		gs.profileFetchWaiting.Add(-1)
+		return nil, ErrOnlineQueueFull
+	}
+	defer gs.profileFetchWaiting.Add(-1)
+
+	select {
+	case gs.profileFetchSlots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for a profile fetch slot: %w", ctx.Err())
 	}
 }
 
 // PlayerOnline marks a player as online, loads their profile, and initializes Redis data.
-func (gs *GameService) PlayerOnline(ctx context.Context, playerUUID string) error {
+// It reports whether the player was already online, in which case their session is re-initialized,
+// or refused with ErrAlreadyOnline if RejectAlreadyOnline is enabled. A reconnect within the offline
+// grace period resumes the session and does not count as already online.
+func (gs *GameService) PlayerOnline(ctx context.Context, playerUUID string) (alreadyOnline bool, err error) {
 	// 1. Check if player is banned
 	isBanned, err := gs.BanStore.IsPlayerBanned(ctx, playerUUID)
 	if err != nil {
-		return fmt.Errorf("failed to check ban status for player %s: %w", playerUUID, err)
+		return false, fmt.Errorf("failed to check ban status for player %s: %w", playerUUID, err)
 	}
 	if isBanned {
-		return fmt.Errorf("player %s is currently banned and cannot go online", playerUUID)
+		// The details are only needed to tell the player why; the login is refused either way.
+		banInfo, err := gs.BanStore.GetBanInfo(ctx, playerUUID)
+		if err != nil || banInfo == nil {
+			banInfo = &store.BanInfo{PlayerUUID: playerUUID, IsActive: true}
+		}
+		return false, &PlayerBannedError{Ban: banInfo}
 	}
 
-	// 2. Load player profile from Player Service (MongoDB)
-	playerProfile, err := gs.PlayerServiceClient.GetPlayerProfile(ctx, playerUUID)
+	// Reconnect within the grace window: keep the existing Redis session instead of reloading it.
+	resumed, err := gs.cancelPendingOffline(ctx, playerUUID)
 	if err != nil {
-		log.Printf("Warning: Could not fetch player profile for %s from Player Service: %v. Initializing with default values.", playerUUID, err)
-		// If profile not found or error, initialize with default values
-		// total playtime 0.0, delta playtime 1.0 (as per requirement), no team initially in Redis
-		if err = gs.PlayerPlaytimeStore.SetPlayerPlaytime(ctx, playerUUID, 0.0); err != nil {
-			return fmt.Errorf("failed to initialize total playtime for %s: %w", playerUUID, err)
+		return false, err
+	}
+	if resumed {
+		err := gs.OnlinePlayersStore.RefreshPlayerOnlineStatus(ctx, playerUUID)
+		if err == nil {
+			// Reconnecting counts as activity, as going online does, so the player isn't skipped as idle.
+			if err := gs.OnlinePlayersStore.RecordActivity(ctx, playerUUID); err != nil {
+				log.Printf("WARNING: Failed to record activity for resumed player %s: %v", playerUUID, err)
+			}
+			log.Printf("Service: Player %s reconnected within grace period; existing session resumed.", playerUUID)
+			return false, nil
 		}
-		if err = gs.PlayerPlaytimeStore.SetPlayerDeltaPlaytime(ctx, playerUUID, 1.0); err != nil {
-			return fmt.Errorf("failed to initialize delta playtime for %s: %w", playerUUID, err)
+		// The old session can't be resumed (e.g., it hit the session cap), so close it out and start fresh.
+		log.Printf("Service: Could not resume session for player %s, starting a new one: %v", playerUUID, err)
+		if err := gs.endSession(ctx, playerUUID); err != nil {
+			return false, fmt.Errorf("failed to end previous session for player %s: %w", playerUUID, err)
 		}
-		// No team key set if profile not found
 	} else {
-		// Profile found, set values from DB
-		if err = gs.PlayerPlaytimeStore.SetPlayerPlaytime(ctx, playerUUID, playerProfile.CurrentPlaytime); err != nil {
-			return fmt.Errorf("failed to set total playtime for %s from profile: %w", playerUUID, err)
+		alreadyOnline, err = gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check online status for player %s: %w", playerUUID, err)
 		}
-		// Delta playtime is always 1.0 on going online, according to previous logic
-		if err = gs.PlayerPlaytimeStore.SetPlayerDeltaPlaytime(ctx, playerUUID, 1.0); err != nil {
-			return fmt.Errorf("failed to set delta playtime for %s: %w", playerUUID, err)
+		if alreadyOnline && gs.rejectAlreadyOnline {
+			return true, fmt.Errorf("player %s: %w", playerUUID, ErrAlreadyOnline)
 		}
+		if alreadyOnline {
+			log.Printf("Service: Player %s is already online; re-initializing their session.", playerUUID)
+		}
+	}
+
+	// 2. Load player profile from Player Service (MongoDB), bounded by the concurrency limit
+	release, err := gs.acquireProfileFetchSlot(ctx)
+	if err != nil {
+		return alreadyOnline, err
+	}
+	playerProfile, err := gs.fetchPlayerProfile(ctx, playerUUID, release)
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		// Anything but "not found" may hide an existing profile; initializing defaults would clobber its playtime.
+		log.Printf("ERROR: Could not fetch player profile for %s from Player Service: %v. Refusing to bring player online.", playerUUID, err)
+		return alreadyOnline, fmt.Errorf("player %s: %w: %v", playerUUID, ErrProfileUnavailable, err)
+	}
+	// Delta playtime is always 1.0 on going online, according to previous logic
+	session := store.SessionInit{DeltaPlaytime: 1.0, Start: time.Now()}
+	if err != nil {
+		// If profile not found, initialize with default values: total playtime 0.0 and no team in Redis
+		log.Printf("Service: No player profile for %s in Player Service. Initializing with default values.", playerUUID)
+	} else {
+		// Profile found, set values from DB
+		session.Playtime = playerProfile.CurrentPlaytime.Seconds()
+		session.Multiplier = playerProfile.EffectivePlaytimeMultiplier()
 		// Set player's team in Redis for quick lookup for team playtime updates
 		if playerProfile.Team != "" {
-			playerTeamKey := fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID)
-			if err = gs.RedisClient.Set(ctx, playerTeamKey, playerProfile.Team, 0).Err(); err != nil { // No expiry, it's tied to player identity
-				log.Printf("Warning: Failed to set team ID for player %s in Redis: %v", playerUUID, err)
+			if err = gs.validateTeam(playerProfile.Team); err != nil {
+				log.Printf("Warning: Not assigning player %s to team in Redis: %v", playerUUID, err)
+			} else {
+				session.TeamID = playerProfile.Team
 			}
 		}
 	}
 
-	// 3. Mark player online in Redis (store session start time and set TTL)
-	err = gs.OnlinePlayersStore.SetPlayerOnline(ctx, playerUUID, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to set player %s online in Redis: %w", playerUUID, err)
+	// 3. Load the session and mark the player online (session start and TTL) in a single transaction
+	if err = store.StartSession(ctx, gs.OnlinePlayersStore, gs.PlayerPlaytimeStore, playerUUID, session); err != nil {
+		return alreadyOnline, fmt.Errorf("failed to set player %s online in Redis: %w", playerUUID, err)
 	}
 	log.Printf("Service: Player %s marked online and data loaded/initialized.", playerUUID)
 
-	return nil
+	var team string
+	if playerProfile != nil {
+		team = playerProfile.Team
+	}
+	gs.emitEvent(ctx, events.PlayerEvent{Type: events.TypePlayerOnline, UUID: playerUUID, Team: team, Timestamp: time.Now()})
+	return alreadyOnline, nil
+}
+
+// BatchResult is the outcome of one player in a batch online/offline operation.
+type BatchResult struct {
+	UUID string
+	Err  error // nil on success
+}
+
+// PlayerOnlineBatch runs PlayerOnline for every UUID with at most concurrency calls in flight.
+// Results are returned in the same order as playerUUIDs.
+func (gs *GameService) PlayerOnlineBatch(ctx context.Context, playerUUIDs []string, concurrency int) []BatchResult {
+	return runBatch(ctx, playerUUIDs, concurrency, func(ctx context.Context, playerUUID string) error {
+		_, err := gs.PlayerOnline(ctx, playerUUID)
+		return err
+	})
+}
+
+// PlayerOfflineBatch runs PlayerOffline for every UUID with at most concurrency calls in flight.
+// Results are returned in the same order as playerUUIDs.
+func (gs *GameService) PlayerOfflineBatch(ctx context.Context, playerUUIDs []string, concurrency int) []BatchResult {
+	return runBatch(ctx, playerUUIDs, concurrency, gs.PlayerOffline)
+}
+
+// runBatch applies op to each UUID using a bounded pool of goroutines. A UUID listed more than once is
+// processed once, and its result repeated for every occurrence. Once ctx is done, the UUIDs not started
+// yet fail with its error instead of waiting for a slot.
+func runBatch(ctx context.Context, playerUUIDs []string, concurrency int, op func(context.Context, string) error) []BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]BatchResult, len(playerUUIDs))
+	first := make(map[string]int, len(playerUUIDs)) // UUID -> index of its first occurrence
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, playerUUID := range playerUUIDs {
+		if _, seen := first[playerUUID]; seen {
+			continue
+		}
+		first[playerUUID] = i
+		if err := acquireBatchSlot(ctx, slots); err != nil {
+			results[i] = BatchResult{UUID: playerUUID, Err: err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, playerUUID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = BatchResult{UUID: playerUUID, Err: op(ctx, playerUUID)}
+		}(i, playerUUID)
+	}
+	wg.Wait()
+	for i, playerUUID := range playerUUIDs {
+		if j := first[playerUUID]; j != i {
+			results[i] = results[j]
+		}
+	}
+	return results
+}
+
+// acquireBatchSlot takes a slot, or returns ctx's error without one once ctx is done, even if a slot
+// freed up at the same time.
+func acquireBatchSlot(ctx context.Context, slots chan struct{}) error {
+	select {
+	case slots <- struct{}{}:
+		if err := ctx.Err(); err != nil {
+			<-slots
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchPlayerProfile loads a player's profile from the Player Service, retrying failures other
+// than api.ErrNotFound with exponential backoff. If release is set, it frees a profile-fetch slot
+// the caller holds: fetchPlayerProfile takes the slot over, gives it up while waiting between
+// attempts so other players can use it, and always frees it before returning.
+func (gs *GameService) fetchPlayerProfile(ctx context.Context, playerUUID string, release func()) (*models.Player, error) {
+	backoff := gs.profileFetchRetryBackoff
+	for attempt := 0; ; attempt++ {
+		profile, err := gs.PlayerServiceClient.GetPlayerProfile(ctx, playerUUID)
+		if release != nil {
+			release()
+		}
+		if err == nil || errors.Is(err, api.ErrNotFound) || attempt >= gs.profileFetchRetries {
+			return profile, err
+		}
+
+		log.Printf("Warning: Attempt %d to fetch profile for %s failed, retrying in %v: %v", attempt+1, playerUUID, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (retry aborted: %v)", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		if release != nil {
+			var slotErr error
+			if release, slotErr = gs.acquireProfileFetchSlot(ctx); slotErr != nil {
+				return nil, fmt.Errorf("%w (retry aborted: %v)", err, slotErr)
+			}
+		}
+	}
 }
 
-// PlayerOffline marks a player as offline, retrieves their final accumulated playtime from Redis,
-// persists it to the Player Service (MongoDB), and then cleans up all player-specific keys in Redis.
+// validateTeam checks teamID with the stores' team validator (see store.TeamValidator), e.g. to
+// reject an unknown team on reads or before starting a change the stores would refuse.
+func (gs *GameService) validateTeam(teamID string) error {
+	return gs.PlayerPlaytimeStore.ValidateTeam(teamID)
+}
+
+// emitEvent publishes a player event. Emission is best-effort: failures are logged and never fail the caller.
+func (gs *GameService) emitEvent(ctx context.Context, event events.PlayerEvent) {
+	if err := gs.EventEmitter.Emit(ctx, event); err != nil {
+		log.Printf("Warning: Failed to emit %s event for player %s: %v", event.Type, event.UUID, err)
+	}
+}
+
+// PlayerOffline marks a player as offline. If a grace period is configured, the player's online
+// status is removed right away (so playtime stops accruing) but the rest of the session is kept
+// for the grace period; a PlayerOnline within that window resumes it. Otherwise, or once the
+// grace period elapses, the session is ended via endSession.
 func (gs *GameService) PlayerOffline(ctx context.Context, playerUUID string) error {
 	log.Printf("Service: Handling player %s going offline.", playerUUID)
 
-	// 1. Retrieve the player's final total playtime from Redis.
+	if gs.offlineGracePeriod <= 0 {
+		return gs.endSession(ctx, playerUUID)
+	}
+
+	token := uuid.NewString()
+	if err := gs.OnlinePlayersStore.MarkPendingOffline(ctx, playerUUID, token, gs.offlineGracePeriod); err != nil {
+		return err
+	}
+	if err := gs.OnlinePlayersStore.RemovePlayerOnline(ctx, playerUUID); err != nil {
+		return fmt.Errorf("failed to mark player %s offline: %w", playerUUID, err)
+	}
+	gs.scheduleOfflineCleanup(playerUUID, token)
+	log.Printf("Service: Player %s offline; session cleanup scheduled in %v.", playerUUID, gs.offlineGracePeriod)
+	return nil
+}
+
+// pendingCleanup is a session cleanup this instance scheduled for the end of a grace period. The
+// player's pending-offline marker in Redis holds token for as long as the cleanup is still wanted.
+type pendingCleanup struct {
+	timer *time.Timer
+	token string
+}
+
+// FlushPendingOffline immediately ends every session still waiting out a grace period scheduled by
+// this instance, unless the player has reconnected meanwhile. It is called during shutdown so no
+// session is left behind in Redis.
+func (gs *GameService) FlushPendingOffline(ctx context.Context) {
+	gs.pendingOfflineMu.Lock()
+	pending := gs.pendingOffline
+	gs.pendingOffline = make(map[string]*pendingCleanup)
+	gs.pendingOfflineMu.Unlock()
+
+	for playerUUID, cleanup := range pending {
+		cleanup.timer.Stop()
+		if err := gs.endPendingSession(ctx, playerUUID, cleanup.token); err != nil {
+			log.Printf("ERROR: Failed to end pending session for player %s during shutdown: %v", playerUUID, err)
+		}
+	}
+}
+
+// scheduleOfflineCleanup ends the player's session once the grace period elapses, replacing any
+// cleanup already scheduled by this instance. token is the one MarkPendingOffline stored.
+func (gs *GameService) scheduleOfflineCleanup(playerUUID string, token string) {
+	gs.pendingOfflineMu.Lock()
+	defer gs.pendingOfflineMu.Unlock()
+
+	if existing, ok := gs.pendingOffline[playerUUID]; ok {
+		existing.timer.Stop()
+	}
+	cleanup := &pendingCleanup{token: token}
+	cleanup.timer = time.AfterFunc(gs.offlineGracePeriod, func() {
+		gs.pendingOfflineMu.Lock()
+		if gs.pendingOffline[playerUUID] != cleanup {
+			// Cancelled by a reconnect or replaced by a newer cleanup.
+			gs.pendingOfflineMu.Unlock()
+			return
+		}
+		delete(gs.pendingOffline, playerUUID)
+		gs.pendingOfflineMu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := gs.endPendingSession(ctx, playerUUID, token); err != nil {
+			log.Printf("ERROR: Failed to end session for player %s after grace period: %v", playerUUID, err)
+		}
+	})
+	gs.pendingOffline[playerUUID] = cleanup
+}
+
+// endPendingSession ends a session whose grace period is over, unless the player reconnected through
+// any instance, or went offline again under a newer marker, in the meantime. A reconnect elsewhere
+// clears the marker and sets the online key, so the claim fails and the live session is left alone.
+func (gs *GameService) endPendingSession(ctx context.Context, playerUUID string, token string) error {
+	claimed, err := gs.OnlinePlayersStore.ClaimPendingOffline(ctx, playerUUID, token)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		log.Printf("Service: Player %s reconnected or went offline again during the grace period; not ending the session.", playerUUID)
+		return nil
+	}
+	return gs.endSession(ctx, playerUUID)
+}
+
+// cancelPendingOffline cancels the player's grace-period cleanup, whichever instance scheduled it,
+// by clearing the marker in Redis and stopping this instance's timer, if any. It reports whether a
+// cleanup was pending.
+func (gs *GameService) cancelPendingOffline(ctx context.Context, playerUUID string) (bool, error) {
+	gs.pendingOfflineMu.Lock()
+	if cleanup, ok := gs.pendingOffline[playerUUID]; ok {
+		cleanup.timer.Stop()
+		delete(gs.pendingOffline, playerUUID)
+	}
+	gs.pendingOfflineMu.Unlock()
+
+	pending, err := gs.OnlinePlayersStore.ClearPendingOffline(ctx, playerUUID)
+	if err != nil {
+		return false, err
+	}
+	return pending, nil
+}
+
+// sessionSnapshot is the session state endSession reads from Redis before the keys are deleted.
+type sessionSnapshot struct {
+	totalPlaytime float64
+	hasPlaytime   bool      // False if the playtime key did not exist
+	teamID        string    // Empty if no team is assigned
+	sessionStart  time.Time // Zero if no session start is recorded
+}
+
+// readSessionSnapshot fetches a player's total playtime, team and session start in a single pipeline.
+// Only a failure to read the playtime is an error; the team and session start are best effort.
+func (gs *GameService) readSessionSnapshot(ctx context.Context, playerUUID string) (sessionSnapshot, error) {
+	var snapshot sessionSnapshot
+
+	pipe := gs.RedisClient.Pipeline()
+	playtimeCmd := pipe.Get(ctx, fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID))
+	teamCmd := pipe.Get(ctx, fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID))
+	sessionStartCmd := pipe.Get(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID))
+	// Exec returns the first command error, which is redis.Nil whenever a key is missing; check each command instead.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return snapshot, fmt.Errorf("failed to read session state for player %s from Redis: %w", playerUUID, err)
+	}
+
+	playtime, err := gs.PlayerPlaytimeStore.PlaytimeUnit().Seconds(playtimeCmd.Result())
+	switch {
+	case err == redis.Nil:
+	case err != nil:
+		return snapshot, fmt.Errorf("failed to retrieve total playtime for player %s from Redis: %w", playerUUID, err)
+	default:
+		snapshot.totalPlaytime = playtime
+		snapshot.hasPlaytime = true
+	}
+	if teamID, err := teamCmd.Result(); err == nil {
+		snapshot.teamID = teamID
+	}
+	if startTimestamp, err := sessionStartCmd.Int64(); err == nil {
+		snapshot.sessionStart = time.Unix(startTimestamp, 0)
+	}
+	return snapshot, nil
+}
+
+// sessionKeys returns the player-specific Redis keys that only live for the duration of a session.
+// They share the player's hash tag, so multi-key commands over them stay within one cluster slot.
+func sessionKeys(playerUUID string) []string {
+	return []string{
+		fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID),        // Marks player online status
+		fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID),      // Player's total accumulated playtime in Redis cache
+		fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID), // Player's current session delta playtime
+		fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID),    // Player's assigned team ID
+		fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID),  // Absolute session start used for the session cap
+		fmt.Sprintf(redisu.LastActivityKeyPrefix, playerUUID),  // Last activity used for idle detection
+		fmt.Sprintf(redisu.MultiplierKeyPrefix, playerUUID),    // Factor applied to the player's playtime ticks
+		// Offline grace period marker, if the session is ending after one
+		fmt.Sprintf(redisu.PendingOfflineKeyPrefix, playerUUID),
+		// Add any other player-specific keys that should be ephemeral per session
+	}
+}
+
+// endSession retrieves the player's final accumulated playtime from Redis, persists it to the
+// Player Service (MongoDB), and then cleans up all player-specific keys in Redis.
+// Redis is hit twice: one pipelined read of the session state and one multi-key delete.
+func (gs *GameService) endSession(ctx context.Context, playerUUID string) error {
+	// 1. Retrieve the player's final total playtime, along with what the player_offline event needs.
 	// This `totalPlaytime` should already be updated by the game's tick/increment logic.
-	finalTotalPlaytime, err := gs.PlayerPlaytimeStore.GetPlayerPlaytime(ctx, playerUUID)
+	snapshot, err := gs.readSessionSnapshot(ctx, playerUUID)
 	if err != nil {
-		if _, ok := err.(interface{ IsNil() bool }); ok && err.(interface{ IsNil() bool }).IsNil() { // Check for redis.Nil or custom ErrRedisKeyNotFound
-			log.Printf("INFO: Player %s had no recorded playtime in Redis (key non-existent or expired). Persisting 0.0 playtime.", playerUUID)
-			finalTotalPlaytime = 0.0 // Default to 0 if no playtime record found
-		} else {
-			// This is a more critical error (e.g., network issue, Redis corruption)
-			return fmt.Errorf("failed to retrieve final total playtime for player %s from Redis: %w", playerUUID, err)
-		}
+		// This is a more critical error (e.g., network issue, Redis corruption)
+		return fmt.Errorf("failed to retrieve final total playtime for player %s from Redis: %w", playerUUID, err)
+	}
+	finalTotalPlaytime := snapshot.totalPlaytime
+	if !snapshot.hasPlaytime {
+		log.Printf("INFO: Player %s had no recorded playtime in Redis (key non-existent or expired). Persisting 0.0 playtime.", playerUUID)
 	} else {
 		log.Printf("Service: Player %s final total playtime from Redis: %.2f seconds.", playerUUID, finalTotalPlaytime)
 	}
 
 	// 2. Persist the final accumulated total playtime to the Player Service (MongoDB).
 	// This is the authoritative save operation.
-	err = gs.PlayerServiceClient.UpdatePlayerPlaytime(ctx, playerUUID, finalTotalPlaytime)
+	err = gs.Persister.PersistPlaytime(ctx, playerUUID, finalTotalPlaytime)
 	if err != nil {
 		// Log the error but continue with Redis cleanup. Persistence should ideally
 		// have a robust retry/dead-letter queue mechanism for critical data.
@@ -128,22 +593,30 @@ func (gs *GameService) PlayerOffline(ctx context.Context, playerUUID string) err
 		// For now, we'll continue cleanup to free up Redis resources.
 	} else {
 		log.Printf("Service: Player %s total playtime (%.2f) successfully persisted to Player Service (MongoDB).", playerUUID, finalTotalPlaytime)
+		gs.PlayerPlaytimeStore.RecordPlaytimeAudit(ctx, playerUUID, store.PlaytimeAuditSourceOffline, 0, finalTotalPlaytime)
 	}
 
 	// 3. Clean up all player-specific keys in Redis.
 	// These keys will be re-set when the player comes online next.
-	keysToDelete := []string{
-		fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID),        // Marks player online status
-		fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID),      // Player's total accumulated playtime in Redis cache
-		fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID), // Player's current session delta playtime
-		fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID),    // Player's assigned team ID
-		// Add any other player-specific keys that should be ephemeral per session
+	keysToDelete := sessionKeys(playerUUID)
+
+	offlineEvent := events.PlayerEvent{Type: events.TypePlayerOffline, UUID: playerUUID, Team: snapshot.teamID}
+	if !snapshot.sessionStart.IsZero() {
+		offlineEvent.SessionDuration = time.Since(snapshot.sessionStart)
+	}
+
+	// The online key goes through the store first so the online counter is decremented; the DEL below
+	// then finds it already gone.
+	if err := gs.OnlinePlayersStore.RemovePlayerOnline(ctx, playerUUID); err != nil {
+		return err
 	}
 
 	// Use a pipeline for atomic deletion of multiple keys if they are in the same slot,
 	// or simply `Del` them if they might be in different slots (Redis Cluster handles this).
 	// In Redis Cluster, `DEL` can take multiple keys across slots.
 	deletedCount, err := gs.RedisClient.Del(ctx, keysToDelete...).Result()
+	gs.PlayerPlaytimeStore.InvalidatePlayerTeam(playerUUID) // Drop the cached team regardless of the outcome; the key may already be gone
+	gs.PlayerPlaytimeStore.RemoveFromPlayerLeaderboard(ctx, playerUUID)
 	if err != nil {
 		// This is a significant error during cleanup.
 		return fmt.Errorf("failed to delete all player %s related keys from Redis: %w", playerUUID, err)
@@ -151,9 +624,37 @@ func (gs *GameService) PlayerOffline(ctx context.Context, playerUUID string) err
 	log.Printf("Service: Cleaned up %d Redis keys for player %s.", deletedCount, playerUUID)
 
 	log.Printf("Service: Player %s is now fully offline and Redis keys cleaned.", playerUUID)
+
+	offlineEvent.Timestamp = time.Now()
+	gs.emitEvent(ctx, offlineEvent)
 	return nil
 }
 
+// FindGhostSessions returns online sessions that look like they will never end on their own
+// (see OnlinePlayersStore.FindGhostSessions). A maxAge of 0 uses the store's default.
+func (gs *GameService) FindGhostSessions(ctx context.Context, maxAge time.Duration) ([]store.GhostSession, error) {
+	ghosts, err := gs.OnlinePlayersStore.FindGhostSessions(ctx, maxAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ghost sessions: %w", err)
+	}
+	return ghosts, nil
+}
+
+// EndGhostSessions ends each given session as if the player had gone offline, persisting their
+// playtime. Failures are logged and skipped. Returns the number of sessions ended.
+func (gs *GameService) EndGhostSessions(ctx context.Context, ghosts []store.GhostSession) int {
+	ended := 0
+	for _, ghost := range ghosts {
+		if err := gs.endSession(ctx, ghost.UUID); err != nil {
+			log.Printf("ERROR: Failed to end ghost session for player %s: %v", ghost.UUID, err)
+			continue
+		}
+		ended++
+	}
+	log.Printf("Service: Ended %d of %d ghost sessions.", ended, len(ghosts))
+	return ended
+}
+
 // RefreshPlayerOnlineStatus updates the TTL for a player's online status.
 func (gs *GameService) RefreshPlayerOnlineStatus(ctx context.Context, playerUUID string) error {
 	// This simply calls the store to refresh the TTL. No complex logic needed here.
@@ -169,15 +670,63 @@ func (gs *GameService) RefreshPlayerOnlineStatus(ctx context.Context, playerUUID
 	return nil
 }
 
+// RefreshPlayerOnlineStatuses resets the online TTL of many players in one pipelined batch and reports
+// for each whether their session was still present (see store.OnlinePlayersStore.RefreshPlayerOnlineStatuses).
+func (gs *GameService) RefreshPlayerOnlineStatuses(ctx context.Context, playerUUIDs []string) ([]store.OnlineRefreshResult, error) {
+	results, err := gs.OnlinePlayersStore.RefreshPlayerOnlineStatuses(ctx, playerUUIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh online status of %d players: %w", len(playerUUIDs), err)
+	}
+	return results, nil
+}
+
+// RecordPlayerActivity records an activity heartbeat for an online player, keeping them from being
+// treated as idle. Unlike the presence heartbeat it does not extend the session.
+// Returns ErrNoActiveSession if the player is not online.
+func (gs *GameService) RecordPlayerActivity(ctx context.Context, playerUUID string) error {
+	isOnline, err := gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID)
+	if err != nil {
+		return fmt.Errorf("failed to check online status for player %s: %w", playerUUID, err)
+	}
+	if !isOnline {
+		return ErrNoActiveSession
+	}
+	return gs.OnlinePlayersStore.RecordActivity(ctx, playerUUID)
+}
+
 // GetPlayerTotalPlaytime retrieves a player's total accumulated playtime from Redis.
+// A corrupt stored value is reported as 0 (the store logs it) rather than failing the read.
 func (gs *GameService) GetPlayerTotalPlaytime(ctx context.Context, playerUUID string) (float64, error) {
 	playtime, err := gs.PlayerPlaytimeStore.GetPlayerPlaytime(ctx, playerUUID) // Calls Redis-only store
+	if errors.Is(err, store.ErrCorruptPlaytime) {
+		return 0, nil
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total playtime for player %s from Redis: %w", playerUUID, err)
 	}
 	return playtime, nil
 }
 
+// GetPlayerLivePlaytime returns a player's effective total playtime and whether it comes from a
+// session in Redis. During a session the Redis total is credited on every tick, so it already includes
+// the session so far (up to the last tick); adding the session duration on top would count it twice.
+// Without a session the Redis total is missing or stale, so the total persisted on the player's
+// profile is returned instead. Returns an error wrapping api.ErrNotFound if the player has neither.
+func (gs *GameService) GetPlayerLivePlaytime(ctx context.Context, playerUUID string) (float64, bool, error) {
+	snapshot, err := gs.readSessionSnapshot(ctx, playerUUID)
+	if err != nil {
+		return 0, false, err
+	}
+	if snapshot.hasPlaytime {
+		return snapshot.totalPlaytime, true, nil
+	}
+	profile, err := gs.fetchPlayerProfile(ctx, playerUUID, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load profile for player %s: %w", playerUUID, err)
+	}
+	return profile.CurrentPlaytime.Seconds(), false, nil
+}
+
 // GetPlayerDeltaPlaytime retrieves a player's last session's playtime (delta) from Redis.
 func (gs *GameService) GetPlayerDeltaPlaytime(ctx context.Context, playerUUID string) (float64, error) {
 	deltatime, err := gs.PlayerPlaytimeStore.GetPlayerDeltaPlaytime(ctx, playerUUID) // Calls Redis-only store
@@ -189,8 +738,21 @@ func (gs *GameService) GetPlayerDeltaPlaytime(ctx context.Context, playerUUID st
 	return deltatime, nil
 }
 
+// GetPlaytimeAudit returns the player's most recent playtime audit entries, newest first.
+// Returns ErrPlaytimeAuditDisabled if auditing is not enabled.
+func (gs *GameService) GetPlaytimeAudit(ctx context.Context, playerUUID string, limit int) ([]store.PlaytimeAuditEntry, error) {
+	if !gs.PlayerPlaytimeStore.AuditEnabled() {
+		return nil, ErrPlaytimeAuditDisabled
+	}
+	return gs.PlayerPlaytimeStore.GetPlaytimeAudit(ctx, playerUUID, limit)
+}
+
 // GetTeamTotalPlaytime retrieves the total playtime for a given team from Redis.
+// Returns an error wrapping ErrUnknownTeam if the team is not known.
 func (gs *GameService) GetTeamTotalPlaytime(ctx context.Context, teamID string) (float64, error) {
+	if err := gs.validateTeam(teamID); err != nil {
+		return 0, err
+	}
 	totalPlaytime, err := gs.TeamPlaytimeStore.GetTeamPlaytime(ctx, teamID) // Calls Redis-only store
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total playtime for team %s from Redis: %w", teamID, err)
@@ -198,6 +760,142 @@ func (gs *GameService) GetTeamTotalPlaytime(ctx context.Context, teamID string)
 	return totalPlaytime, nil
 }
 
+// GetTopTeams returns the n teams with the most playtime in Redis, highest first.
+func (gs *GameService) GetTopTeams(ctx context.Context, n int) ([]store.TeamPlaytime, error) {
+	teams, err := gs.TeamPlaytimeStore.GetTopTeams(ctx, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top %d teams: %w", n, err)
+	}
+	return teams, nil
+}
+
+// GetTopPlayers returns the n players with a session in Redis who have the most playtime, highest first.
+func (gs *GameService) GetTopPlayers(ctx context.Context, n int) ([]store.PlayerPlaytime, error) {
+	players, err := gs.PlayerPlaytimeStore.GetTopPlayers(ctx, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top %d players: %w", n, err)
+	}
+	return players, nil
+}
+
+// GetTeamTotalPlaytimes retrieves the total playtime of several teams from Redis in one round trip.
+// Every team must pass validation; teams with no recorded playtime are returned as 0.
+func (gs *GameService) GetTeamTotalPlaytimes(ctx context.Context, teamIDs []string) (map[string]float64, error) {
+	for _, teamID := range teamIDs {
+		if err := gs.validateTeam(teamID); err != nil {
+			return nil, err
+		}
+	}
+	playtimes, err := gs.TeamPlaytimeStore.GetTeamPlaytimes(ctx, teamIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total playtime for %d teams from Redis: %w", len(teamIDs), err)
+	}
+	return playtimes, nil
+}
+
+// ResetTeamPlaytime sets a team's total playtime in Redis back to zero, e.g., when it reached the
+// configured cap at the end of a season. The next team sync overwrites it with the Player Service's
+// total, so the persisted player playtimes must be reset there as well for the reset to stick.
+// Returns an error wrapping ErrUnknownTeam if the team is not known.
+func (gs *GameService) ResetTeamPlaytime(ctx context.Context, teamID string) error {
+	if err := gs.validateTeam(teamID); err != nil {
+		return err
+	}
+	return gs.TeamPlaytimeStore.ResetTeamPlaytime(ctx, teamID)
+}
+
+// TeamRenameResult reports the outcome of RenameTeam.
+type TeamRenameResult struct {
+	PlayersReassigned int     // Players whose team assignment in Redis was moved to the new team
+	MovedPlaytime     float64 // Total playtime moved from the old team's key to the new team's
+}
+
+// RenameTeam moves a renamed team's data in Redis to its new ID: the team assignments of players with
+// a session, the team total and its leaderboard entry. The Player Service renames the team in MongoDB
+// first and then calls this. Returns an error wrapping ErrUnknownTeam if the new team is not known.
+func (gs *GameService) RenameTeam(ctx context.Context, oldTeamID, newTeamID string) (*TeamRenameResult, error) {
+	if gs.TeamAllowList != nil {
+		// The new team was most likely created after the last periodic refresh.
+		if err := gs.TeamAllowList.Refresh(ctx); err != nil {
+			log.Printf("Warning: Failed to refresh known teams before renaming team %s: %v", oldTeamID, err)
+		}
+	}
+	if err := gs.validateTeam(newTeamID); err != nil {
+		return nil, err
+	}
+
+	// Players first, and every instance told to drop its cached assignments, so ticks stop crediting
+	// the old team before its total is moved.
+	reassigned, err := gs.PlayerPlaytimeStore.ReassignTeam(ctx, oldTeamID, newTeamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign players of team %s (%d moved): %w", oldTeamID, reassigned, err)
+	}
+	if err := gs.PlayerPlaytimeStore.PublishTeamRename(ctx, oldTeamID, newTeamID); err != nil {
+		return nil, err
+	}
+	moved, err := gs.TeamPlaytimeStore.RenameTeamPlaytime(ctx, oldTeamID, newTeamID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Service: Team %s renamed to %s in Redis (%d players reassigned, %.2f playtime moved).", oldTeamID, newTeamID, reassigned, moved)
+	return &TeamRenameResult{PlayersReassigned: reassigned, MovedPlaytime: moved}, nil
+}
+
+// PlaytimeResetResult reports the outcome of ResetPlayerPlaytime.
+type PlaytimeResetResult struct {
+	RemovedPlaytime float64 // Playtime the player had before the reset
+	Team            string  // Team the playtime was credited to; empty if the player has none
+	TeamAdjusted    bool    // The removed playtime was subtracted from the team's total in Redis
+	ProfileUpdated  bool    // Zero playtime persisted on the player's profile via the Player Service
+	ProfileError    string  // Non-empty if the Player Service update failed
+}
+
+// ResetPlayerPlaytime sets a player's total playtime to zero in Redis (if they have a session) and on
+// their profile, e.g., after an exploit. The per-tick delta is a rate rather than accrued time, so it is
+// left alone. Team totals in Redis keep the removed playtime unless adjustTeamTotal is set; either way the
+// next team sync recomputes them from the persisted playtimes, which no longer include it.
+// Returns an error wrapping api.ErrNotFound if the player has no profile.
+func (gs *GameService) ResetPlayerPlaytime(ctx context.Context, playerUUID string, adjustTeamTotal bool) (*PlaytimeResetResult, error) {
+	profile, err := gs.fetchPlayerProfile(ctx, playerUUID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile for player %s: %w", playerUUID, err)
+	}
+	result := &PlaytimeResetResult{RemovedPlaytime: profile.CurrentPlaytime.Seconds(), Team: profile.Team}
+
+	// During a session Redis holds the newer total and team.
+	snapshot, err := gs.readSessionSnapshot(ctx, playerUUID)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.hasPlaytime {
+		result.RemovedPlaytime = snapshot.totalPlaytime
+		if snapshot.teamID != "" {
+			result.Team = snapshot.teamID
+		}
+		if err := gs.PlayerPlaytimeStore.SetPlayerPlaytime(ctx, playerUUID, 0); err != nil {
+			return nil, fmt.Errorf("failed to reset playtime for player %s in Redis: %w", playerUUID, err)
+		}
+	}
+	gs.PlayerPlaytimeStore.RecordPlaytimeAudit(ctx, playerUUID, store.PlaytimeAuditSourceReset, -result.RemovedPlaytime, 0)
+	log.Printf("Service: Playtime of player %s reset (removed %.2f).", playerUUID, result.RemovedPlaytime)
+
+	if adjustTeamTotal && result.Team != "" && result.RemovedPlaytime > 0 {
+		if err := gs.TeamPlaytimeStore.IncrementTeamPlaytime(ctx, result.Team, -result.RemovedPlaytime); err != nil {
+			log.Printf("ERROR: Failed to subtract reset playtime of player %s from team %s: %v", playerUUID, result.Team, err)
+		} else {
+			result.TeamAdjusted = true
+		}
+	}
+
+	if err := gs.Persister.PersistPlaytime(ctx, playerUUID, 0); err != nil {
+		log.Printf("ERROR: Failed to persist reset playtime for player %s to Player Service: %v", playerUUID, err)
+		result.ProfileError = err.Error()
+	} else {
+		result.ProfileUpdated = true
+	}
+	return result, nil
+}
+
 // IsPlayerOnline checks if a player is currently marked as online in Redis.
 func (gs *GameService) IsPlayerOnline(ctx context.Context, playerUUID string) (bool, error) {
 	isOnline, err := gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID) // Calls Redis-only store
@@ -207,14 +905,106 @@ func (gs *GameService) IsPlayerOnline(ctx context.Context, playerUUID string) (b
 	return isOnline, nil
 }
 
+// PlayerDataExists reports whether any session or playtime data for the player exists in Redis,
+// using a single EXISTS over the session keys. Cheaper than loading the profile for presence checks.
+func (gs *GameService) PlayerDataExists(ctx context.Context, playerUUID string) (bool, error) {
+	count, err := gs.RedisClient.Exists(ctx, sessionKeys(playerUUID)...).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check Redis data for player %s: %w", playerUUID, err)
+	}
+	return count > 0, nil
+}
+
+// GetSessionStart returns the absolute start time of a player's current session.
+// Returns ErrNoActiveSession if the player is offline or no session start is recorded.
+func (gs *GameService) GetSessionStart(ctx context.Context, playerUUID string) (time.Time, error) {
+	isOnline, err := gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to check online status for player %s: %w", playerUUID, err)
+	}
+	if !isOnline {
+		return time.Time{}, ErrNoActiveSession
+	}
+	sessionStart, err := gs.OnlinePlayersStore.GetSessionStart(ctx, playerUUID)
+	if errors.Is(err, redisu.ErrRedisKeyNotFound) {
+		return time.Time{}, ErrNoActiveSession
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return sessionStart, nil
+}
+
+// GetOnlinePlayerCount returns the number of online players from the online counter, without scanning.
+func (gs *GameService) GetOnlinePlayerCount(ctx context.Context) (int, error) {
+	count, err := gs.OnlinePlayersStore.GetOnlinePlayerCount(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get online player count: %w", err)
+	}
+	return count, nil
+}
+
+// GetOnlineStats returns the number of online players and their per-team breakdown.
+// Online keys are joined with the players' team keys. Results are cached for onlineStatsCacheTTL.
+// The returned value is shared and must not be modified.
+func (gs *GameService) GetOnlineStats(ctx context.Context) (*OnlineStats, error) {
+	gs.onlineStatsMu.Lock()
+	defer gs.onlineStatsMu.Unlock()
+
+	if gs.cachedOnlineStats != nil && time.Since(gs.cachedOnlineStats.ComputedAt) < onlineStatsCacheTTL {
+		return gs.cachedOnlineStats, nil
+	}
+
+	onlinePlayers, err := gs.OnlinePlayersStore.GetAllOnlinePlayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online players for stats: %w", err)
+	}
+	playerUUIDs := make([]string, 0, len(onlinePlayers))
+	for playerUUID := range onlinePlayers {
+		playerUUIDs = append(playerUUIDs, playerUUID)
+	}
+
+	playerTeams, err := gs.PlayerPlaytimeStore.GetPlayerTeams(ctx, playerUUIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teams of online players for stats: %w", err)
+	}
+
+	stats := &OnlineStats{
+		TotalOnline: len(playerUUIDs),
+		Teams:       make(map[string]int),
+		ComputedAt:  time.Now(),
+	}
+	for _, playerUUID := range playerUUIDs {
+		if teamID, ok := playerTeams[playerUUID]; ok {
+			stats.Teams[teamID]++
+		} else {
+			stats.Unassigned++
+		}
+	}
+
+	gs.cachedOnlineStats = stats
+	return stats, nil
+}
+
 // BanPlayer bans a player for a specified duration or permanently.
+// An existing longer ban is never shortened unless force is set.
 // It also attempts to force the player offline if they are currently online.
-func (gs *GameService) BanPlayer(ctx context.Context, playerUUID string, expiresAt *time.Time, reason string) error {
-	err := gs.BanStore.BanPlayer(ctx, playerUUID, expiresAt, reason) // Assumed Redis-only BanStore
+// Returns the expiry of the ban in effect (nil for permanent).
+func (gs *GameService) BanPlayer(ctx context.Context, playerUUID string, expiresAt *time.Time, reason string, force bool) (*time.Time, error) {
+	effectiveExpiresAt, err := gs.BanStore.BanPlayer(ctx, playerUUID, expiresAt, reason, force) // Assumed Redis-only BanStore
 	if err != nil {
-		return fmt.Errorf("failed to ban player %s: %w", playerUUID, err)
+		return nil, fmt.Errorf("failed to ban player %s: %w", playerUUID, err)
+	}
+	log.Printf("Service: Player %s banned. Reason: %s, Expires: %v", playerUUID, reason, effectiveExpiresAt)
+
+	// A session waiting out its reconnect grace period is ended now; it must not be resumable.
+	if pending, err := gs.cancelPendingOffline(ctx, playerUUID); err != nil {
+		log.Printf("Warning: Failed to cancel grace period of banned player %s: %v", playerUUID, err)
+	} else if pending {
+		if err := gs.endSession(ctx, playerUUID); err != nil {
+			log.Printf("Warning: Failed to end pending session for banned player %s: %v", playerUUID, err)
+		}
 	}
-	log.Printf("Service: Player %s banned. Reason: %s, Expires: %v", playerUUID, reason, expiresAt)
 
 	// If the player is currently online, mark them offline immediately
 	isOnline, err := gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID)
@@ -222,12 +1012,112 @@ func (gs *GameService) BanPlayer(ctx context.Context, playerUUID string, expires
 		log.Printf("Warning: Could not check online status for %s after ban: %v", playerUUID, err)
 	} else if isOnline {
 		log.Printf("Player %s was online and is now forced offline due to ban.", playerUUID)
-		// Calling PlayerOffline to ensure playtime is saved to Redis and session cleared
-		if err := gs.PlayerOffline(ctx, playerUUID); err != nil {
+		// End the session right away (no grace period) so playtime is saved and the session cleared
+		if err := gs.endSession(ctx, playerUUID); err != nil {
 			log.Printf("Warning: Failed to force player %s offline after ban: %v", playerUUID, err)
 		}
 	}
-	return nil
+	return effectiveExpiresAt, nil
+}
+
+// BanAndKickResult reports the outcome of each step of BanAndKickPlayer so callers can
+// see exactly which parts of a coordinated ban succeeded.
+type BanAndKickResult struct {
+	ExpiresAt      *time.Time // Expiry of the ban in effect (nil for permanent); may be a longer existing ban
+	RedisBanned    bool       // Ban recorded in Redis (the game service's source of truth for logins)
+	ProfileUpdated bool       // Ban persisted on the player's profile via the Player Service
+	ProfileError   string     // Non-empty if the Player Service update failed
+	WasOnline      bool       // Player had an active session when the ban was applied
+	Kicked         bool       // Active session was ended and its playtime persisted
+	KickError      string     // Non-empty if the online check or forced logout failed
+}
+
+// Complete reports whether every applicable step of the ban succeeded.
+func (r *BanAndKickResult) Complete() bool {
+	return r.RedisBanned && r.ProfileUpdated && r.KickError == "" && (!r.WasOnline || r.Kicked)
+}
+
+// BanAndKickPlayer bans a player in Redis, persists the ban to the Player Service (MongoDB),
+// and forces the player offline if they are online. The Redis ban is applied first; if it
+// fails nothing else is attempted and an error is returned. Later failures do not roll back
+// the Redis ban and are reported in the returned result instead.
+// As with BanPlayer, an existing longer ban is never shortened unless force is set.
+func (gs *GameService) BanAndKickPlayer(ctx context.Context, playerUUID string, expiresAt *time.Time, reason string, force bool) (*BanAndKickResult, error) {
+	result := &BanAndKickResult{}
+
+	effectiveExpiresAt, err := gs.BanStore.BanPlayer(ctx, playerUUID, expiresAt, reason, force)
+	if err != nil {
+		return result, fmt.Errorf("failed to ban player %s: %w", playerUUID, err)
+	}
+	result.RedisBanned = true
+	result.ExpiresAt = effectiveExpiresAt
+	log.Printf("Service: Player %s banned. Reason: %s, Expires: %v", playerUUID, reason, effectiveExpiresAt)
+
+	if err := gs.Persister.PersistBan(ctx, playerUUID, true, effectiveExpiresAt); err != nil {
+		log.Printf("ERROR: Failed to persist ban for player %s to Player Service: %v", playerUUID, err)
+		result.ProfileError = err.Error()
+	} else {
+		result.ProfileUpdated = true
+	}
+
+	// A player inside the reconnect grace window is kicked as well: their session must not be resumable.
+	if pending, err := gs.cancelPendingOffline(ctx, playerUUID); err != nil {
+		log.Printf("Warning: Failed to cancel grace period of banned player %s: %v", playerUUID, err)
+		result.KickError = err.Error()
+	} else if pending {
+		if err := gs.endSession(ctx, playerUUID); err != nil {
+			log.Printf("Warning: Failed to end pending session for banned player %s: %v", playerUUID, err)
+			result.KickError = err.Error()
+		}
+	}
+
+	isOnline, err := gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID)
+	if err != nil {
+		log.Printf("Warning: Could not check online status for %s after ban: %v", playerUUID, err)
+		result.KickError = fmt.Sprintf("failed to check online status: %v", err)
+		return result, nil
+	}
+	result.WasOnline = isOnline
+	if isOnline {
+		if err := gs.endSession(ctx, playerUUID); err != nil {
+			log.Printf("Warning: Failed to force player %s offline after ban: %v", playerUUID, err)
+			result.KickError = err.Error()
+		} else {
+			result.Kicked = true
+			log.Printf("Player %s was online and is now forced offline due to ban.", playerUUID)
+		}
+	}
+	return result, nil
+}
+
+// ModifyBanResult reports the outcome of ModifyBan.
+type ModifyBanResult struct {
+	ExpiresAt      *time.Time // New expiry of the ban (nil for permanent)
+	ProfileUpdated bool       // New expiry persisted on the player's profile via the Player Service
+	ProfileError   string     // Non-empty if the Player Service update failed
+}
+
+// ModifyBan extends or reduces an active ban to expire at expiresAt (nil for permanent), updating
+// Redis in place and then the player's profile. Returns ErrNotBanned if the player is not banned.
+// A failed profile update does not roll back the Redis change and is reported in the result instead.
+func (gs *GameService) ModifyBan(ctx context.Context, playerUUID string, expiresAt *time.Time) (*ModifyBanResult, error) {
+	modified, err := gs.BanStore.ModifyBan(ctx, playerUUID, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to modify ban for player %s: %w", playerUUID, err)
+	}
+	if !modified {
+		return nil, ErrNotBanned
+	}
+	log.Printf("Service: Ban for player %s modified. Expires: %v", playerUUID, expiresAt)
+
+	result := &ModifyBanResult{ExpiresAt: expiresAt}
+	if err := gs.Persister.PersistBan(ctx, playerUUID, true, expiresAt); err != nil {
+		log.Printf("ERROR: Failed to persist modified ban for player %s to Player Service: %v", playerUUID, err)
+		result.ProfileError = err.Error()
+	} else {
+		result.ProfileUpdated = true
+	}
+	return result, nil
 }
 
 // UnbanPlayer removes a ban from a player.
@@ -239,3 +1129,58 @@ func (gs *GameService) UnbanPlayer(ctx context.Context, playerUUID string) error
 	log.Printf("Service: Player %s unbanned.", playerUUID)
 	return nil
 }
+
+// AssignPlayerTeam sets an online player's team in Redis mid-session, e.g., after a team transfer,
+// so playtime accrued afterwards is credited to the new team. Offline players are left alone since
+// their team is loaded from their profile when they next go online.
+// Returns false if the player is not online.
+func (gs *GameService) AssignPlayerTeam(ctx context.Context, playerUUID string, teamID string) (bool, error) {
+	if err := gs.validateTeam(teamID); err != nil {
+		return false, err
+	}
+	hasSession, err := gs.hasLiveSession(ctx, playerUUID)
+	if err != nil || !hasSession {
+		return false, err
+	}
+	if err := gs.PlayerPlaytimeStore.SetPlayerTeam(ctx, playerUUID, teamID); err != nil {
+		return false, fmt.Errorf("failed to assign team %s to player %s: %w", teamID, playerUUID, err)
+	}
+	return true, nil
+}
+
+// hasLiveSession reports whether a player has a session in Redis: they are online, or inside the
+// reconnect grace period, which keeps the session alive.
+func (gs *GameService) hasLiveSession(ctx context.Context, playerUUID string) (bool, error) {
+	isOnline, err := gs.OnlinePlayersStore.IsPlayerOnline(ctx, playerUUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check online status for player %s: %w", playerUUID, err)
+	}
+	if !isOnline {
+		return gs.OnlinePlayersStore.IsPendingOffline(ctx, playerUUID)
+	}
+	return isOnline, nil
+}
+
+// GetPlayerTeam returns the team a player is currently assigned to in Redis.
+// Returns ErrNoTeamAssigned if the player has none, e.g. because they are offline.
+func (gs *GameService) GetPlayerTeam(ctx context.Context, playerUUID string) (string, error) {
+	teamID, err := gs.PlayerPlaytimeStore.GetPlayerTeam(ctx, playerUUID)
+	if errors.Is(err, redisu.ErrRedisKeyNotFound) {
+		return "", ErrNoTeamAssigned
+	}
+	if err != nil {
+		return "", err
+	}
+	return teamID, nil
+}
+
+// UnassignPlayerTeam clears a player's team assignment in Redis mid-session.
+// Playtime accrued afterwards is only credited to the player, not to their former team.
+// Returns false if the player had no team assigned.
+func (gs *GameService) UnassignPlayerTeam(ctx context.Context, playerUUID string) (bool, error) {
+	removed, err := gs.PlayerPlaytimeStore.RemovePlayerTeam(ctx, playerUUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unassign team for player %s: %w", playerUUID, err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+	playerserviceclient "github.com/Ftotnem/GO-SERVICES/shared/service"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRunBatchProcessesDuplicatesOnce(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	op := func(ctx context.Context, playerUUID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[playerUUID]++
+		if playerUUID == "b" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	results := runBatch(context.Background(), []string{"a", "b", "a", "c", "b"}, 2, op)
+
+	for uuid, n := range calls {
+		if n != 1 {
+			t.Errorf("op ran %d times for %s, want once", n, uuid)
+		}
+	}
+	if len(calls) != 3 {
+		t.Errorf("op ran for %d UUIDs, want 3", len(calls))
+	}
+	want := []string{"a", "b", "a", "c", "b"}
+	for i, result := range results {
+		if result.UUID != want[i] {
+			t.Errorf("results[%d].UUID = %s, want %s", i, result.UUID, want[i])
+		}
+		if failed := result.Err != nil; failed != (want[i] == "b") {
+			t.Errorf("results[%d] (%s) error = %v", i, result.UUID, result.Err)
+		}
+	}
+}
+
+func TestRunBatchStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := 0
+	op := func(ctx context.Context, playerUUID string) error {
+		started++ // One slot, so calls never overlap
+		cancel()
+		return nil
+	}
+
+	results := runBatch(ctx, []string{"a", "b", "c"}, 1, op)
+
+	if started != 1 {
+		t.Errorf("op started %d times after the context was cancelled, want 1", started)
+	}
+	if results[0].Err != nil {
+		t.Errorf("results[0] error = %v, want nil", results[0].Err)
+	}
+	for _, result := range results[1:] {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result for %s error = %v, want context.Canceled", result.UUID, result.Err)
+		}
+	}
+}
+
+func TestFetchPlayerProfileFreesSlotWhileWaiting(t *testing.T) {
+	const playerUUID = "069a79f4-44e9-4726-a5be-fca90e38aaf5"
+	firstFailed := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			close(firstFailed)
+			return
+		}
+		json.NewEncoder(w).Encode(models.Player{UUID: playerUUID})
+	}))
+	defer server.Close()
+
+	gs := &GameService{
+		PlayerServiceClient:      playerserviceclient.NewPlayerClient(server.URL),
+		profileFetchSlots:        make(chan struct{}, 1),
+		profileFetchQueueSize:    1,
+		profileFetchRetries:      1,
+		profileFetchRetryBackoff: 200 * time.Millisecond,
+	}
+	ctx := context.Background()
+	release, err := gs.acquireProfileFetchSlot(ctx)
+	if err != nil {
+		t.Fatalf("acquireProfileFetchSlot: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := gs.fetchPlayerProfile(ctx, playerUUID, release)
+		done <- err
+	}()
+
+	<-firstFailed
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	other, err := gs.acquireProfileFetchSlot(waitCtx)
+	if err != nil {
+		t.Fatalf("slot still held during the retry backoff: %v", err)
+	}
+	other()
+
+	if err := <-done; err != nil {
+		t.Fatalf("fetchPlayerProfile: %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("profile requested %d times, want 2", requests.Load())
+	}
+	if len(gs.profileFetchSlots) != 0 {
+		t.Errorf("%d slots still held after the fetch", len(gs.profileFetchSlots))
+	}
+}
+
+func TestPlayerOnlineResumeRecordsActivity(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { client.Close() })
+	ops := store.NewOnlinePlayersStore(client, 15*time.Second, 0)
+	ops.SetIdleTimeout(time.Minute)
+	gs := &GameService{BanStore: store.NewBanStore(client), OnlinePlayersStore: ops}
+	ctx := context.Background()
+	if err := ops.MarkPendingOffline(ctx, "player-1", "token", time.Minute); err != nil {
+		t.Fatalf("MarkPendingOffline: %v", err)
+	}
+
+	if _, err := gs.PlayerOnline(ctx, "player-1"); err != nil {
+		t.Fatalf("PlayerOnline: %v", err)
+	}
+	active, err := ops.FilterActivePlayers(ctx, []string{"player-1"})
+	if err != nil {
+		t.Fatalf("FilterActivePlayers: %v", err)
+	}
+	if len(active) != 1 {
+		t.Error("player who resumed their session within the grace period counts as idle")
+	}
+}
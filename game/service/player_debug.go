@@ -0,0 +1,79 @@
+// game/service/player_debug.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// PlayerDebugState is everything the game service holds in Redis about one player, for incident response.
+// Pointer and zero values mean the corresponding key does not exist.
+type PlayerDebugState struct {
+	Online         bool
+	OnlineTTL      time.Duration // Remaining TTL of the online key; 0 if offline
+	PendingOffline bool          // The player is inside the reconnect grace period on this instance
+	SessionStart   time.Time
+	TotalPlaytime  *float64
+	DeltaPlaytime  *float64
+	Team           string
+	Ban            *store.BanInfo // Nil if the player has no ban record, including expired ones not yet cleaned up
+}
+
+// GetPlayerDebugState reads a player's session keys in a single pipeline, plus their ban record.
+// Keys that are missing are left unset; malformed values are reported as errors.
+func (gs *GameService) GetPlayerDebugState(ctx context.Context, playerUUID string) (*PlayerDebugState, error) {
+	pipe := gs.RedisClient.Pipeline()
+	onlineTTLCmd := pipe.PTTL(ctx, fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID))
+	sessionStartCmd := pipe.Get(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID))
+	playtimeCmd := pipe.Get(ctx, fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID))
+	deltaCmd := pipe.Get(ctx, fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID))
+	teamCmd := pipe.Get(ctx, fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID))
+	// Exec returns the first command error, which is redis.Nil whenever a key is missing; check each command instead.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read Redis state for player %s: %w", playerUUID, err)
+	}
+
+	state := &PlayerDebugState{}
+	// PTTL returns -2 for a missing key and -1 for a key without a TTL.
+	if ttl := onlineTTLCmd.Val(); ttl != -2 {
+		state.Online = true
+		state.OnlineTTL = max(ttl, 0)
+	}
+	if startTimestamp, err := sessionStartCmd.Int64(); err == nil {
+		state.SessionStart = time.Unix(startTimestamp, 0)
+	} else if err != redis.Nil {
+		return nil, fmt.Errorf("failed to parse session start for player %s: %w", playerUUID, err)
+	}
+	if playtime, err := gs.PlayerPlaytimeStore.PlaytimeUnit().Seconds(playtimeCmd.Result()); err == nil {
+		state.TotalPlaytime = &playtime
+	} else if err != redis.Nil {
+		return nil, fmt.Errorf("failed to parse total playtime for player %s: %w", playerUUID, err)
+	}
+	if delta, err := deltaCmd.Float64(); err == nil {
+		state.DeltaPlaytime = &delta
+	} else if err != redis.Nil {
+		return nil, fmt.Errorf("failed to parse delta playtime for player %s: %w", playerUUID, err)
+	}
+	if teamID, err := teamCmd.Result(); err == nil {
+		state.Team = teamID
+	}
+
+	pending, err := gs.OnlinePlayersStore.IsPendingOffline(ctx, playerUUID)
+	if err != nil {
+		return nil, err
+	}
+	state.PendingOffline = pending
+
+	// The ban keys are not hash-tagged with the player's UUID, so they are read separately.
+	banInfo, err := gs.BanStore.GetBanInfo(ctx, playerUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ban info for player %s: %w", playerUUID, err)
+	}
+	state.Ban = banInfo
+	return state, nil
+}
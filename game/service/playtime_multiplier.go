@@ -0,0 +1,43 @@
+// game/service/playtime_multiplier.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+)
+
+// ErrInvalidPlaytimeMultiplier is returned for a playtime multiplier that is not positive or exceeds
+// models.MaxPlaytimeMultiplier.
+var ErrInvalidPlaytimeMultiplier = errors.New("invalid playtime multiplier")
+
+// SetPlaytimeMultiplier stores a player's permanent playtime multiplier in their profile and, if they
+// have a live session, applies it to the ticks from now on. Offline players pick it up from their
+// profile when they next go online. Returns whether it was applied to a live session.
+func (gs *GameService) SetPlaytimeMultiplier(ctx context.Context, playerUUID string, multiplier float64) (bool, error) {
+	if math.IsNaN(multiplier) || multiplier <= 0 || multiplier > models.MaxPlaytimeMultiplier {
+		return false, fmt.Errorf("%w: %v (must be greater than 0 and at most %v)", ErrInvalidPlaytimeMultiplier, multiplier, models.MaxPlaytimeMultiplier)
+	}
+	if err := gs.Persister.PersistMultiplier(ctx, playerUUID, multiplier); err != nil {
+		return false, err
+	}
+	return gs.applyPlaytimeMultiplier(ctx, playerUUID, multiplier)
+}
+
+// applyPlaytimeMultiplier sets the multiplier of a player's live session in Redis. Returns false
+// without doing anything if the player has no live session.
+func (gs *GameService) applyPlaytimeMultiplier(ctx context.Context, playerUUID string, multiplier float64) (bool, error) {
+	hasSession, err := gs.hasLiveSession(ctx, playerUUID)
+	if err != nil || !hasSession {
+		return false, err
+	}
+	if err := gs.PlayerPlaytimeStore.SetPlaytimeMultiplier(ctx, playerUUID, multiplier); err != nil {
+		return false, err
+	}
+	log.Printf("Service: Playtime multiplier of online player %s set to %v.", playerUUID, multiplier)
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// multiplierPersister records persisted multipliers; the other persists are not used here.
+type multiplierPersister struct {
+	multipliers map[string]float64
+	err         error
+}
+
+func (p *multiplierPersister) PersistPlaytime(ctx context.Context, playerUUID string, totalPlaytime float64) error {
+	return nil
+}
+
+func (p *multiplierPersister) PersistBan(ctx context.Context, playerUUID string, banned bool, expiresAt *time.Time) error {
+	return nil
+}
+
+func (p *multiplierPersister) PersistMultiplier(ctx context.Context, playerUUID string, multiplier float64) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.multipliers[playerUUID] = multiplier
+	return nil
+}
+
+func newMultiplierTestService(t *testing.T, persister *multiplierPersister) *GameService {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { client.Close() })
+	return &GameService{
+		PlayerPlaytimeStore: store.NewPlayerPlaytimeStore(client),
+		OnlinePlayersStore:  store.NewOnlinePlayersStore(client, 15*time.Second, 0),
+		Persister:           persister,
+	}
+}
+
+func TestSetPlaytimeMultiplierPersistsThroughPersister(t *testing.T) {
+	persister := &multiplierPersister{multipliers: make(map[string]float64)}
+	gs := newMultiplierTestService(t, persister)
+
+	applied, err := gs.SetPlaytimeMultiplier(context.Background(), "player-1", 2)
+	if err != nil {
+		t.Fatalf("SetPlaytimeMultiplier: %v", err)
+	}
+	if applied {
+		t.Error("applied to a live session of an offline player")
+	}
+	if got := persister.multipliers["player-1"]; got != 2 {
+		t.Errorf("persisted multiplier = %v, want 2", got)
+	}
+}
+
+func TestSetPlaytimeMultiplierFailsWithPersister(t *testing.T) {
+	persister := &multiplierPersister{err: errors.New("player service down")}
+	gs := newMultiplierTestService(t, persister)
+
+	if _, err := gs.SetPlaytimeMultiplier(context.Background(), "player-1", 2); !errors.Is(err, persister.err) {
+		t.Errorf("SetPlaytimeMultiplier error = %v, want the persister's", err)
+	}
+}
@@ -0,0 +1,142 @@
+// game/service/profile_change_subscriber.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// ProfileChangeSubscriber listens on the Redis channel the Player Service publishes profile changes to
+// and applies each change to the GameService, so edits made outside the game service take effect
+// without waiting for caches to expire. Every instance receives every change; only the instance
+// responsible for the player writes to Redis.
+type ProfileChangeSubscriber struct {
+	gs            *GameService
+	client        *redis.ClusterClient
+	channel       string
+	isResponsible func(playerUUID string) (bool, error) // Whether this instance owns the player, e.g. per the assignment ring
+
+	ctx      context.Context // Cancelled by Stop, which closes the subscription
+	cancel   context.CancelFunc
+	doneChan chan struct{}
+}
+
+// NewProfileChangeSubscriber creates a ProfileChangeSubscriber for channel. isResponsible decides which
+// instance applies a change to Redis. Listening begins when Start is called.
+func NewProfileChangeSubscriber(gs *GameService, client *redis.ClusterClient, channel string, isResponsible func(playerUUID string) (bool, error)) *ProfileChangeSubscriber {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ProfileChangeSubscriber{
+		gs:            gs,
+		client:        client,
+		channel:       channel,
+		isResponsible: isResponsible,
+		ctx:           ctx,
+		cancel:        cancel,
+		doneChan:      make(chan struct{}),
+	}
+}
+
+// Start subscribes to the channel and applies changes until Stop is called. The Redis client
+// re-subscribes by itself after a connection loss; changes published meanwhile are missed and
+// only picked up once the affected caches expire. This should be run in a goroutine.
+func (pcs *ProfileChangeSubscriber) Start() {
+	defer close(pcs.doneChan)
+
+	pubsub := pcs.client.Subscribe(pcs.ctx, pcs.channel)
+	defer pubsub.Close()
+	log.Printf("ProfileChangeSubscriber: Listening for player profile changes on Redis channel '%s'.", pcs.channel)
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			pcs.handleMessage(msg.Payload)
+		case <-pcs.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop closes the subscription and waits for Start to return.
+func (pcs *ProfileChangeSubscriber) Stop() {
+	pcs.cancel()
+	<-pcs.doneChan
+}
+
+// handleMessage decodes and applies a single change, logging failures.
+func (pcs *ProfileChangeSubscriber) handleMessage(payload string) {
+	var change models.ProfileChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		log.Printf("WARNING: ProfileChangeSubscriber: Ignoring malformed profile change: %v", err)
+		return
+	}
+
+	responsible, err := pcs.isResponsible(change.UUID)
+	if err != nil {
+		// Better for several instances to apply the change than for none to.
+		log.Printf("WARNING: ProfileChangeSubscriber: Could not determine responsibility for player %s, applying the change anyway: %v", change.UUID, err)
+		responsible = true
+	}
+
+	ctx, cancel := context.WithTimeout(pcs.ctx, 5*time.Second)
+	defer cancel()
+	if err := pcs.gs.ApplyProfileChange(ctx, change, responsible); err != nil {
+		log.Printf("WARNING: ProfileChangeSubscriber: %v", err)
+	}
+}
+
+// ApplyProfileChange brings the game service's state in line with a changed player profile:
+//   - a changed team or deleted profile drops the player's cached team assignment;
+//   - a changed playtime multiplier is applied to the player's live session, if any;
+//   - a ban added or lifted on the profile is mirrored into Redis, the source of truth for logins.
+//
+// The cache is this instance's own, but the rest is shared state in Redis, so it is only applied if
+// responsible is set: every instance sees the change, and exactly one of them should act on it.
+// Bans the game service persisted itself are echoed back here and are left untouched, since Redis
+// already holds them (with their reason, which the profile doesn't store).
+func (gs *GameService) ApplyProfileChange(ctx context.Context, change models.ProfileChange, responsible bool) error {
+	if change.Touches("team") {
+		gs.PlayerPlaytimeStore.InvalidatePlayerTeam(change.UUID)
+	}
+	if !responsible {
+		return nil
+	}
+	if change.Profile != nil && change.Touches("playtime_multiplier") {
+		if _, err := gs.applyPlaytimeMultiplier(ctx, change.UUID, change.Profile.EffectivePlaytimeMultiplier()); err != nil {
+			return fmt.Errorf("failed to apply playtime multiplier from profile change: %w", err)
+		}
+	}
+
+	if change.Profile == nil || !change.Touches("banned", "ban_expires_at") {
+		return nil
+	}
+	banInfo, err := gs.BanStore.GetBanInfo(ctx, change.UUID)
+	if err != nil {
+		return fmt.Errorf("failed to check ban status of player %s after profile change: %w", change.UUID, err)
+	}
+
+	profile := change.Profile
+	switch {
+	case profile.Banned && banInfo == nil:
+		if profile.BanExpiresAt != nil && !profile.BanExpiresAt.After(time.Now()) {
+			return nil // Already expired; there is nothing to enforce
+		}
+		if _, err := gs.BanPlayer(ctx, change.UUID, profile.BanExpiresAt, "", false); err != nil {
+			return fmt.Errorf("failed to apply ban from profile change: %w", err)
+		}
+	case !profile.Banned && banInfo != nil:
+		if err := gs.UnbanPlayer(ctx, change.UUID); err != nil {
+			return fmt.Errorf("failed to lift ban from profile change: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// game/service/team_allowlist.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	playerserviceclient "github.com/Ftotnem/GO-SERVICES/shared/service"
+)
+
+// ErrUnknownTeam is returned when a team ID is not in the set of known teams.
+var ErrUnknownTeam = errors.New("unknown team")
+
+// TeamAllowList holds the set of teams the game service accepts, so a typo in a team ID cannot
+// create orphan team keys in Redis. It combines statically configured teams with the teams
+// known to the Player Service, which are refreshed periodically.
+type TeamAllowList struct {
+	client          *playerserviceclient.PlayerServiceClient
+	staticTeams     []string
+	refreshInterval time.Duration
+
+	mu    sync.RWMutex
+	teams map[string]struct{}
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewTeamAllowList creates a TeamAllowList seeded with staticTeams. Teams from the Player Service
+// are added by Refresh and by the loop started with Start.
+func NewTeamAllowList(client *playerserviceclient.PlayerServiceClient, staticTeams []string, refreshInterval time.Duration) *TeamAllowList {
+	tal := &TeamAllowList{
+		client:          client,
+		staticTeams:     staticTeams,
+		refreshInterval: refreshInterval,
+		teams:           make(map[string]struct{}),
+		stopChan:        make(chan struct{}),
+		doneChan:        make(chan struct{}),
+	}
+	for _, team := range staticTeams {
+		tal.teams[team] = struct{}{}
+	}
+	return tal
+}
+
+// Validate returns ErrUnknownTeam if teamID is not a known team.
+// While the set is still empty (no static teams and no successful refresh yet) every team is accepted,
+// so a Player Service outage at startup doesn't block all team operations.
+func (tal *TeamAllowList) Validate(teamID string) error {
+	tal.mu.RLock()
+	defer tal.mu.RUnlock()
+
+	if len(tal.teams) == 0 {
+		return nil
+	}
+	if _, ok := tal.teams[teamID]; !ok {
+		return fmt.Errorf("team '%s': %w", teamID, ErrUnknownTeam)
+	}
+	return nil
+}
+
+// Refresh replaces the known team set with the static teams plus the Player Service's current teams.
+// On failure the previous set is kept.
+func (tal *TeamAllowList) Refresh(ctx context.Context) error {
+	remoteTeams, err := tal.client.ListTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to refresh known teams: %w", err)
+	}
+
+	teams := make(map[string]struct{}, len(remoteTeams)+len(tal.staticTeams))
+	for _, team := range tal.staticTeams {
+		teams[team] = struct{}{}
+	}
+	for _, team := range remoteTeams {
+		teams[team] = struct{}{}
+	}
+
+	tal.mu.Lock()
+	tal.teams = teams
+	tal.mu.Unlock()
+	log.Printf("TeamAllowList: Refreshed known teams (%d teams).", len(teams))
+	return nil
+}
+
+// Start refreshes the known team set immediately and then every refresh interval until Stop is called.
+// This should be run in a goroutine.
+func (tal *TeamAllowList) Start() {
+	defer close(tal.doneChan)
+
+	ticker := time.NewTicker(tal.refreshInterval)
+	defer ticker.Stop()
+
+	tal.refreshOnce()
+	for {
+		select {
+		case <-ticker.C:
+			tal.refreshOnce()
+		case <-tal.stopChan:
+			return
+		}
+	}
+}
+
+// Stop signals the refresh loop to exit and waits for it to finish.
+func (tal *TeamAllowList) Stop() {
+	close(tal.stopChan)
+	<-tal.doneChan
+}
+
+// refreshOnce runs a single bounded refresh, logging failures.
+func (tal *TeamAllowList) refreshOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tal.Refresh(ctx); err != nil {
+		log.Printf("WARNING: TeamAllowList: %v", err)
+	}
+}
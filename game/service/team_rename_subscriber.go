@@ -0,0 +1,78 @@
+// game/service/team_rename_subscriber.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// TeamRenameSubscriber listens for the team renames game-service instances announce (see
+// PlayerPlaytimeStore.PublishTeamRename) and drops this instance's cached assignments to the old
+// team, so its ticks stop crediting a team key the rename is about to delete.
+type TeamRenameSubscriber struct {
+	gs     *GameService
+	client *redis.ClusterClient
+
+	ctx      context.Context // Cancelled by Stop, which closes the subscription
+	cancel   context.CancelFunc
+	doneChan chan struct{}
+}
+
+// NewTeamRenameSubscriber creates a TeamRenameSubscriber. Listening begins when Start is called.
+func NewTeamRenameSubscriber(gs *GameService, client *redis.ClusterClient) *TeamRenameSubscriber {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TeamRenameSubscriber{
+		gs:       gs,
+		client:   client,
+		ctx:      ctx,
+		cancel:   cancel,
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start subscribes to redisu.TeamRenamesChannel and applies renames until Stop is called. Renames
+// published while the connection is down are missed; the affected cache entries then expire on
+// their own. This should be run in a goroutine.
+func (trs *TeamRenameSubscriber) Start() {
+	defer close(trs.doneChan)
+
+	pubsub := trs.client.Subscribe(trs.ctx, redisu.TeamRenamesChannel)
+	defer pubsub.Close()
+	log.Printf("TeamRenameSubscriber: Listening for team renames on Redis channel '%s'.", redisu.TeamRenamesChannel)
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			trs.handleMessage(msg.Payload)
+		case <-trs.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop closes the subscription and waits for Start to return.
+func (trs *TeamRenameSubscriber) Stop() {
+	trs.cancel()
+	<-trs.doneChan
+}
+
+// handleMessage decodes a single rename and drops the cached assignments to the old team.
+func (trs *TeamRenameSubscriber) handleMessage(payload string) {
+	var rename store.TeamRename
+	if err := json.Unmarshal([]byte(payload), &rename); err != nil || rename.OldTeam == "" {
+		log.Printf("WARNING: TeamRenameSubscriber: Ignoring malformed team rename: %q", payload)
+		return
+	}
+	if dropped := trs.gs.PlayerPlaytimeStore.InvalidateTeam(rename.OldTeam); dropped > 0 {
+		log.Printf("TeamRenameSubscriber: Team %s renamed to %s; dropped %d cached assignments.", rename.OldTeam, rename.NewTeam, dropped)
+	}
+}
@@ -4,8 +4,9 @@ package store
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
+	"sync"
 	"time"
 
 	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
@@ -25,6 +26,7 @@ type BanInfo struct {
 // It manages ban status and reasons for individual players.
 type BanStore struct {
 	client *redis.ClusterClient
+	skew   time.Duration // Temporary bans count as active for this long past their expiry, see SetClockSkewTolerance
 }
 
 // NewBanStore creates a new BanStore instance.
@@ -35,9 +37,36 @@ func NewBanStore(client *redis.ClusterClient) *BanStore {
 	}
 }
 
+// SetClockSkewTolerance keeps temporary bans active for tolerance past their expiry when checked by
+// this instance. Redis removes a ban key at its expiry by its own clock, so with a tolerance covering
+// the clock skew between instances, every instance sees the ban end when Redis expires it instead of
+// a few seconds early on instances whose clock runs ahead.
+func (bs *BanStore) SetClockSkewTolerance(tolerance time.Duration) {
+	bs.skew = max(tolerance, 0)
+}
+
+// banExpired reports whether a temporary ban expiring at expiresAtUnix (0 for permanent) has expired
+// at now, allowing for the clock skew tolerance.
+func (bs *BanStore) banExpired(expiresAtUnix int64, now time.Time) bool {
+	return expiresAtUnix > 0 && !now.Before(time.Unix(expiresAtUnix, 0).Add(bs.skew))
+}
+
 // BanPlayer applies a ban to a player.
-// A ban can be temporary (with an expiration time) or permanent.
-func (bs *BanStore) BanPlayer(ctx context.Context, playerUUID string, expiresAt *time.Time, reason string) error {
+// A ban can be temporary (with an expiration time) or permanent. An active ban is only ever extended:
+// if it already lasts longer than the new one, it is kept unchanged (reason included) unless force is set.
+// Returns the expiry of the ban in effect afterwards (nil for permanent).
+func (bs *BanStore) BanPlayer(ctx context.Context, playerUUID string, expiresAt *time.Time, reason string, force bool) (*time.Time, error) {
+	if !force {
+		existing, err := bs.GetBanInfo(ctx, playerUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing ban for player %s: %w", playerUUID, err)
+		}
+		if existing != nil && !banExtends(existing, expiresAt) {
+			slog.Info("Player already banned for longer, keeping existing ban", "player_uuid", playerUUID, "existing_expires_at", existing.ExpiresAt, "requested_expires_at", expiresAt)
+			return existing.ExpiresAt, nil
+		}
+	}
+
 	// Construct the Redis key using the predefined constant for consistency.
 	banKey := fmt.Sprintf(redisu.BannedKeyPrefix, playerUUID)
 	reasonKey := fmt.Sprintf("ban_reason:%s", playerUUID) // Using a similar pattern for reason key
@@ -64,24 +93,75 @@ func (bs *BanStore) BanPlayer(ctx context.Context, playerUUID string, expiresAt
 	// Store the ban status: key -> playerUUID, value -> Unix timestamp of expiration (0 for permanent).
 	err := bs.client.Set(ctx, banKey, banExpiresAtUnix, duration).Err()
 	if err != nil {
-		return fmt.Errorf("failed to set ban status for player %s in Redis: %w", playerUUID, err)
+		return nil, fmt.Errorf("failed to set ban status for player %s in Redis: %w", playerUUID, err)
 	}
 
 	// Store the ban reason if provided. Its TTL will match the ban status.
 	if reason != "" {
 		reasonDuration := duration // Reason should expire with the ban itself
 		if err := bs.client.Set(ctx, reasonKey, reason, reasonDuration).Err(); err != nil {
-			log.Printf("Warning: Could not store ban reason for player %s: %v", playerUUID, err)
+			slog.Warn("Could not store ban reason", "player_uuid", playerUUID, "error", err)
 		}
 	}
 
 	if expiresAt != nil {
-		log.Printf("Player %s temporarily banned until %v. Reason: %s", playerUUID, *expiresAt, reason)
+		slog.Info("Player temporarily banned", "player_uuid", playerUUID, "expires_at", *expiresAt, "reason", reason)
 	} else {
-		log.Printf("Player %s permanently banned. Reason: %s", playerUUID, reason)
+		slog.Info("Player permanently banned", "player_uuid", playerUUID, "reason", reason)
 	}
 
-	return nil
+	return expiresAt, nil
+}
+
+// banExtends reports whether a new ban expiring at expiresAt (nil for permanent) lasts at least as long as an existing one.
+func banExtends(existing *BanInfo, expiresAt *time.Time) bool {
+	if expiresAt == nil {
+		return true
+	}
+	if existing.IsPermanent {
+		return false
+	}
+	return !expiresAt.Before(*existing.ExpiresAt)
+}
+
+// ModifyBan changes the expiry of an active ban in place (expiresAt nil makes it permanent), keeping its reason.
+// Unlike BanPlayer the new expiry always replaces the old one, so a ban can be shortened as well as extended.
+// Returns false if the player is not currently banned.
+func (bs *BanStore) ModifyBan(ctx context.Context, playerUUID string, expiresAt *time.Time) (bool, error) {
+	existing, err := bs.GetBanInfo(ctx, playerUUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing ban for player %s: %w", playerUUID, err)
+	}
+	if existing == nil {
+		return false, nil
+	}
+
+	banKey := fmt.Sprintf(redisu.BannedKeyPrefix, playerUUID)
+	reasonKey := fmt.Sprintf("ban_reason:%s", playerUUID)
+
+	var banExpiresAtUnix int64
+	var duration time.Duration
+	if expiresAt != nil {
+		banExpiresAtUnix = expiresAt.Unix()
+		duration = max(time.Until(*expiresAt), time.Millisecond) // Same handling of past expiries as BanPlayer
+	}
+
+	if err := bs.client.Set(ctx, banKey, banExpiresAtUnix, duration).Err(); err != nil {
+		return false, fmt.Errorf("failed to update ban for player %s in Redis: %w", playerUUID, err)
+	}
+
+	// The reason must expire together with the ban. A missing reason key is simply left alone.
+	if expiresAt != nil {
+		err = bs.client.Expire(ctx, reasonKey, duration).Err()
+	} else {
+		err = bs.client.Persist(ctx, reasonKey).Err()
+	}
+	if err != nil {
+		slog.Warn("Could not update ban reason expiry", "player_uuid", playerUUID, "error", err)
+	}
+
+	slog.Info("Player ban modified", "player_uuid", playerUUID, "previous_expires_at", existing.ExpiresAt, "expires_at", expiresAt)
+	return true, nil
 }
 
 // UnbanPlayer removes a ban from a player by deleting the relevant Redis keys.
@@ -96,9 +176,9 @@ func (bs *BanStore) UnbanPlayer(ctx context.Context, playerUUID string) error {
 	}
 
 	if deletedCount > 0 {
-		log.Printf("Player %s has been unbanned (%d ban-related keys removed).", playerUUID, deletedCount)
+		slog.Info("Player has been unbanned", "player_uuid", playerUUID, "keys_removed", deletedCount)
 	} else {
-		log.Printf("Player %s was not actively banned (no ban keys found to delete).", playerUUID)
+		slog.Info("Player was not actively banned (no ban keys found to delete)", "player_uuid", playerUUID)
 	}
 
 	return nil
@@ -121,20 +201,13 @@ func (bs *BanStore) IsPlayerBanned(ctx context.Context, playerUUID string) (bool
 	expiresAtUnix, parseErr := strconv.ParseInt(val, 10, 64)
 	if parseErr != nil {
 		// Log a warning if the stored value is malformed and treat as not banned.
-		log.Printf("Warning: Ban record for player %s contains an invalid expiration timestamp '%s'. Treating as not banned.", playerUUID, val)
+		slog.Warn("Ban record contains an invalid expiration timestamp, treating as not banned", "player_uuid", playerUUID, "value", val)
 		return false, nil
 	}
 
 	// If it's a temporary ban (expiresAtUnix > 0) and it has passed, the ban is expired.
-	if expiresAtUnix > 0 && time.Now().Unix() >= expiresAtUnix {
-		// The ban has expired. Asynchronously clean up the keys to prevent stale data.
-		go func() {
-			cleanupCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			defer cancel()
-			if err := bs.UnbanPlayer(cleanupCtx, playerUUID); err != nil {
-				log.Printf("Error cleaning up expired ban for player %s: %v", playerUUID, err)
-			}
-		}()
+	// Its keys are left for CleanupExpiredBans rather than deleted on every read.
+	if bs.banExpired(expiresAtUnix, time.Now()) {
 		return false, nil // Ban expired, so player is no longer considered banned.
 	}
 
@@ -177,7 +250,7 @@ func (bs *BanStore) GetBanInfo(ctx context.Context, playerUUID string) (*BanInfo
 	if reasonErr == redis.Nil {
 		reason = "No reason provided" // Default if reason key is missing
 	} else if reasonErr != nil {
-		log.Printf("Warning: Could not retrieve ban reason for player %s: %v", playerUUID, reasonErr)
+		slog.Warn("Could not retrieve ban reason", "player_uuid", playerUUID, "error", reasonErr)
 		reason = "Unknown reason" // Fallback for other errors
 	}
 
@@ -191,22 +264,15 @@ func (bs *BanStore) GetBanInfo(ctx context.Context, playerUUID string) (*BanInfo
 		// For temporary bans, set the actual expiration time and check if it's active.
 		expireTime := time.Unix(expiresAtUnix, 0)
 		banInfo.ExpiresAt = &expireTime
-		banInfo.IsActive = time.Now().Before(expireTime) // Ban is active if current time is before expiration
+		banInfo.IsActive = !bs.banExpired(expiresAtUnix, time.Now())
 	} else {
 		// Permanent bans are always active.
 		banInfo.IsActive = true
 	}
 
 	// If the ban is found but it's expired, return nil to signify no active ban.
-	// This also triggers an asynchronous cleanup, similar to IsPlayerBanned.
+	// As in IsPlayerBanned, the keys are left for CleanupExpiredBans.
 	if !banInfo.IsActive {
-		go func() {
-			cleanupCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			defer cancel()
-			if err := bs.UnbanPlayer(cleanupCtx, playerUUID); err != nil {
-				log.Printf("Error cleaning up expired ban for player %s after GetBanInfo: %v", playerUUID, err)
-			}
-		}()
 		return nil, nil // No active ban found
 	}
 
@@ -217,45 +283,131 @@ func (bs *BanStore) GetBanInfo(ctx context.Context, playerUUID string) (*BanInfo
 // It scans Redis for all ban keys and fetches their details.
 func (bs *BanStore) GetAllBannedPlayers(ctx context.Context) (map[string]*BanInfo, error) {
 	bannedPlayers := make(map[string]*BanInfo)
-
-	// Scan for all keys that match the banned player key pattern.
-	// We use '*' within the curly braces for cluster-friendly scanning (hash tag).
-	iter := bs.client.Scan(ctx, 0, fmt.Sprintf(redisu.BannedKeyPrefix, "*"), 0).Iterator()
-	for iter.Next(ctx) {
-		key := iter.Val()
-
-		// Extract the player UUID from the Redis key.
-		// Example key: banned:{uuid}:
-		// We need to parse "uuid" from it.
-		// The `redisu.BannedKeyPrefix` is "banned:{%s}:", so we extract what's between `{` and `}:`.
-		// A more robust way to extract UUID might involve regular expressions or careful string manipulation
-		// if the pattern gets more complex, but for this fixed pattern, slicing is efficient.
-		// Let's ensure the slice is safe.
-		const prefixLen = len("banned:{")
-		const suffixLen = len("}:")
-		if len(key) > prefixLen+suffixLen { // Ensure key is long enough to contain a UUID
-			uuid := key[prefixLen : len(key)-suffixLen]
+	var mu sync.Mutex // Protects bannedPlayers across cluster nodes
+
+	// Ban keys are spread over every master node by their hash tag, so each one is scanned.
+	err := redisu.ScanAllMasters(ctx, bs.client, fmt.Sprintf(redisu.BannedKeyPrefix, "*"), 0, func(ctx context.Context, _ *redis.Client, keys []string) error {
+		for _, key := range keys {
+			uuid, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipped invalid ban key format during scan", "key", key)
+				continue
+			}
 
 			// Get detailed ban information for the extracted UUID.
-			// GetBanInfo will automatically handle expired bans and clean them up.
+			// The ban reason has no hash tag, so it is read through the cluster client rather than this node.
 			banInfo, err := bs.GetBanInfo(ctx, uuid)
 			if err != nil {
-				log.Printf("Warning: Failed to retrieve ban info for player %s during full scan: %v", uuid, err)
+				slog.Warn("Failed to retrieve ban info during full scan", "player_uuid", uuid, "error", err)
 				continue
 			}
 
 			// Add to the map only if the ban is active.
 			if banInfo != nil && banInfo.IsActive {
+				mu.Lock()
 				bannedPlayers[uuid] = banInfo
+				mu.Unlock()
 			}
-		} else {
-			log.Printf("Warning: Skipped invalid ban key format during scan: %s", key)
 		}
-	}
+		return nil
+	})
 
-	if err := iter.Err(); err != nil {
+	if err != nil {
 		return nil, fmt.Errorf("failed to iterate through banned player keys in Redis: %w", err)
 	}
 
 	return bannedPlayers, nil
 }
+
+// CleanupExpiredBans deletes the keys of temporary bans whose expiry has passed but which are still
+// in Redis, roughly batchSize bans at a time (the SCAN count). Each master node is scanned separately and
+// its expired ban keys are removed with one pipelined DEL per batch instead of one round trip per ban.
+// Returns the number of expired bans removed; on error, bans removed before the failure are counted.
+func (bs *BanStore) CleanupExpiredBans(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("batch size must be positive (got %d)", batchSize)
+	}
+
+	var mu sync.Mutex
+	removed := 0
+	var reasonKeys []string // Reason keys have no hash tag, so they may live on any node
+
+	// Each SCAN page (about batchSize keys) is checked and deleted on its own node in one round trip.
+	err := redisu.ScanAllMasters(ctx, bs.client, fmt.Sprintf(redisu.BannedKeyPrefix, "*"), int64(batchSize), func(ctx context.Context, client *redis.Client, banKeys []string) error {
+		expiredKeys, expiredUUIDs, err := bs.expiredBanKeys(ctx, client, banKeys)
+		if err != nil || len(expiredKeys) == 0 {
+			return err
+		}
+		pipe := client.Pipeline()
+		for _, key := range expiredKeys {
+			pipe.Del(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete expired ban keys: %w", err)
+		}
+
+		mu.Lock()
+		removed += len(expiredKeys)
+		for _, playerUUID := range expiredUUIDs {
+			reasonKeys = append(reasonKeys, fmt.Sprintf("ban_reason:%s", playerUUID))
+		}
+		mu.Unlock()
+		return nil
+	})
+
+	// The reasons of bans that were removed are deleted even if another node failed.
+	for start := 0; start < len(reasonKeys); start += batchSize {
+		pipe := bs.client.Pipeline()
+		for _, key := range reasonKeys[start:min(start+batchSize, len(reasonKeys))] {
+			pipe.Del(ctx, key)
+		}
+		if _, reasonErr := pipe.Exec(ctx); reasonErr != nil {
+			slog.Warn("Could not delete reasons of expired bans", "error", reasonErr)
+			break
+		}
+	}
+
+	if removed > 0 {
+		slog.Info("Removed expired bans", "count", removed)
+	}
+	if err != nil {
+		return removed, fmt.Errorf("failed to clean up expired bans: %w", err)
+	}
+	return removed, nil
+}
+
+// expiredBanKeys reads the given ban keys from a single node and returns those whose temporary ban
+// has expired, along with the matching player UUIDs. Keys that vanished in the meantime are skipped.
+func (bs *BanStore) expiredBanKeys(ctx context.Context, client *redis.Client, banKeys []string) ([]string, []string, error) {
+	pipe := client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(banKeys))
+	for i, key := range banKeys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	// Exec returns redis.Nil whenever a key has expired since the scan; check each command instead.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, fmt.Errorf("failed to read ban keys: %w", err)
+	}
+
+	now := time.Now()
+	var expiredKeys, expiredUUIDs []string
+	for i, cmd := range cmds {
+		val, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		expiresAtUnix, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || !bs.banExpired(expiresAtUnix, now) {
+			continue // Malformed, permanent or still active
+		}
+		key := banKeys[i]
+		playerUUID, ok := redisu.HashTagValue(key)
+		if !ok {
+			slog.Warn("Skipped invalid ban key format during cleanup", "key", key)
+			continue
+		}
+		expiredKeys = append(expiredKeys, key)
+		expiredUUIDs = append(expiredUUIDs, playerUUID)
+	}
+	return expiredKeys, expiredUUIDs, nil
+}
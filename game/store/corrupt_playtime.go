@@ -0,0 +1,33 @@
+// game/store/corrupt_playtime.go
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCorruptPlaytime is returned (wrapped) when a stored playtime is not a number, e.g. because a bug
+// wrote something else to the key. Readers that only display the value can treat it as 0; anything
+// that persists the value must not, or it would overwrite the real total.
+var ErrCorruptPlaytime = errors.New("stored playtime is not a number")
+
+// isCorruptValue reports whether err is a failure to parse a stored value as a number, as opposed to
+// a missing key or a Redis error.
+func isCorruptValue(err error) bool {
+	var numErr *strconv.NumError
+	return errors.As(err, &numErr)
+}
+
+// logCorruptPlaytime logs the raw value of a playtime key that failed to parse.
+func logCorruptPlaytime(ctx context.Context, client *redis.ClusterClient, key string) {
+	raw, err := client.Get(ctx, key).Result()
+	if err != nil {
+		slog.Error("Playtime key holds a non-numeric value; could not re-read it", "key", key, "error", err)
+		return
+	}
+	slog.Error("Playtime key holds a non-numeric value", "key", key, "value", raw)
+}
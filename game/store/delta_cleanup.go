@@ -0,0 +1,105 @@
+// game/store/delta_cleanup.go
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// FindOrphanedDeltas scans every delta playtime key and returns the UUIDs of players whose delta was
+// set at least minAge ago and who have no online key, e.g. because the instance responsible for them
+// crashed before ending the session. A delta without a TTL counts as old. The age comes from the
+// key's remaining TTL, so it is the time since the delta was last written.
+func (pps *PlayerPlaytimeStore) FindOrphanedDeltas(ctx context.Context, minAge time.Duration) ([]string, error) {
+	var orphaned []string
+	var mu sync.Mutex // Protects orphaned across cluster nodes
+
+	err := redisu.ScanAllMasters(ctx, pps.redisClient, fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, "*"), 0, func(ctx context.Context, node *redis.Client, keys []string) error {
+		playerUUIDs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			playerUUID, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipping malformed delta key", "key", key)
+				continue
+			}
+			playerUUIDs = append(playerUUIDs, playerUUID)
+		}
+		if len(playerUUIDs) == 0 {
+			return nil
+		}
+
+		// A player's delta and online keys share a hash tag, so both live on this node.
+		pipe := node.Pipeline()
+		ttlCmds := make([]*redis.DurationCmd, len(playerUUIDs))
+		onlineCmds := make([]*redis.IntCmd, len(playerUUIDs))
+		for i, playerUUID := range playerUUIDs {
+			ttlCmds[i] = pipe.PTTL(ctx, fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID))
+			onlineCmds[i] = pipe.Exists(ctx, fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+
+		var found []string
+		for i, playerUUID := range playerUUIDs {
+			if onlineCmds[i].Val() > 0 {
+				continue
+			}
+			ttl := ttlCmds[i].Val()
+			if ttl == -2 { // Expired or consumed since the scan; -1 (no TTL) counts as old
+				continue
+			}
+			if ttl >= 0 && deltaPlaytimeKeyTTL-ttl < minAge {
+				continue
+			}
+			found = append(found, playerUUID)
+		}
+		mu.Lock()
+		orphaned = append(orphaned, found...)
+		mu.Unlock()
+		return nil
+	})
+	return orphaned, err
+}
+
+// ClearOrphanedDelta deletes a player's delta playtime key unless the player is online. The check and
+// the delete run in one optimistic transaction, so a session started in between keeps its fresh delta.
+// It reports whether the delta was deleted.
+func (pps *PlayerPlaytimeStore) ClearOrphanedDelta(ctx context.Context, playerUUID string) (bool, error) {
+	deltaKey := fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID)
+	onlineKey := fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID)
+
+	cleared := false
+	err := pps.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		online, err := tx.Exists(ctx, onlineKey).Result()
+		if err != nil {
+			return err
+		}
+		if online > 0 {
+			return nil
+		}
+		cmds, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, deltaKey)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		cleared = cmds[0].(*redis.IntCmd).Val() > 0
+		return nil
+	}, onlineKey, deltaKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil // The session changed while checking; it is no longer orphaned
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to clear orphaned delta for player %s: %w", playerUUID, err)
+	}
+	return cleared, nil
+}
@@ -0,0 +1,113 @@
+// game/store/ghost_sessions.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultGhostSessionAge is how old a session must be to count as a ghost when no maximum session
+// duration is configured.
+const DefaultGhostSessionAge = 24 * time.Hour
+
+// Reasons a session is reported as a ghost.
+const (
+	GhostReasonNoTTL       = "no_ttl"       // Online key never expires
+	GhostReasonTTLTooHigh  = "ttl_too_high" // Online key expires later than a heartbeat could have set
+	GhostReasonSessionAged = "session_aged" // Session started implausibly long ago
+)
+
+// GhostSession is an online session that looks like it will never end on its own, e.g., because
+// its client stopped sending heartbeats but never went offline.
+type GhostSession struct {
+	UUID         string   `json:"uuid"`
+	TTLMillis    int64    `json:"ttl_ms"`                  // PTTL of the online key; -1 if it has no expiry
+	SessionStart int64    `json:"session_start,omitempty"` // Unix timestamp; 0 if none is recorded
+	Reasons      []string `json:"reasons"`
+}
+
+// FindGhostSessions scans every online key and reports those whose TTL is higher than the online TTL
+// (or missing), or whose session started more than maxAge ago. A maxAge of 0 uses the maximum session
+// duration if one is configured, and DefaultGhostSessionAge otherwise.
+func (ops *OnlinePlayersStore) FindGhostSessions(ctx context.Context, maxAge time.Duration) ([]GhostSession, error) {
+	if maxAge <= 0 {
+		maxAge = DefaultGhostSessionAge
+		if ops.maxSessionDuration > 0 {
+			maxAge = ops.maxSessionDuration
+		}
+	}
+	cutoff := time.Now().Add(-maxAge)
+
+	var ghosts []GhostSession
+	var mu sync.Mutex // Protects ghosts across cluster nodes
+
+	err := redisu.ScanAllMasters(ctx, ops.client, fmt.Sprintf(redisu.OnlineKeyPrefix, "*"), 0, func(ctx context.Context, client *redis.Client, keys []string) error {
+		playerUUIDs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			playerUUID, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipping malformed online key", "key", key)
+				continue
+			}
+			playerUUIDs = append(playerUUIDs, playerUUID)
+		}
+		if len(playerUUIDs) == 0 {
+			return nil
+		}
+
+		// A player's online and session start keys share a hash tag, so both live on this node.
+		pipe := client.Pipeline()
+		ttlCmds := make([]*redis.DurationCmd, len(playerUUIDs))
+		startCmds := make([]*redis.StringCmd, len(playerUUIDs))
+		for i, playerUUID := range playerUUIDs {
+			ttlCmds[i] = pipe.PTTL(ctx, fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID))
+			startCmds[i] = pipe.Get(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID))
+		}
+		// Exec returns redis.Nil whenever a session start is missing; check each command instead.
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		for i, playerUUID := range playerUUIDs {
+			ttl, err := ttlCmds[i].Result()
+			if err != nil {
+				slog.Warn("Failed to get TTL of online key; skipping", "player_uuid", playerUUID, "error", err)
+				continue
+			}
+			if ttl == -2 {
+				continue // Went offline since the scan
+			}
+
+			ghost := GhostSession{UUID: playerUUID, TTLMillis: ttl.Milliseconds()}
+			if ttl == -1 {
+				ghost.TTLMillis = -1
+				ghost.Reasons = append(ghost.Reasons, GhostReasonNoTTL)
+			} else if ttl > ops.onlineTTL {
+				ghost.Reasons = append(ghost.Reasons, GhostReasonTTLTooHigh)
+			}
+			if startTimestamp, err := startCmds[i].Int64(); err == nil {
+				ghost.SessionStart = startTimestamp
+				if time.Unix(startTimestamp, 0).Before(cutoff) {
+					ghost.Reasons = append(ghost.Reasons, GhostReasonSessionAged)
+				}
+			}
+
+			if len(ghost.Reasons) > 0 {
+				mu.Lock()
+				ghosts = append(ghosts, ghost)
+				mu.Unlock()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error during scan for ghost sessions across Redis masters: %w", err)
+	}
+	return ghosts, nil
+}
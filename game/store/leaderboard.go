@@ -0,0 +1,114 @@
+// game/store/leaderboard.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// TeamPlaytime is a team's total playtime, as ranked by GetTopTeams.
+type TeamPlaytime struct {
+	TeamID   string  `json:"teamId"`
+	Playtime float64 `json:"playtime"`
+}
+
+// PlayerPlaytime is a player's total playtime, as ranked by GetTopPlayers.
+type PlayerPlaytime struct {
+	UUID     string  `json:"uuid"`
+	Playtime float64 `json:"playtime"`
+}
+
+// Leaderboard mirrors playtime totals into a single Redis sorted set, so the top entries can be
+// read with one ZREVRANGE instead of scanning the whole cluster. A nil *Leaderboard is valid and does nothing.
+//
+// Totals written around the stores (e.g., the Player Service pushing a recomputed team total straight
+// to Redis) are only picked up by the next increment or by Rebuild.
+type Leaderboard struct {
+	client *redis.ClusterClient
+	key    string
+}
+
+// NewTeamLeaderboard creates the leaderboard of team totals. It is shared by every store that writes them.
+func NewTeamLeaderboard(client *redis.ClusterClient) *Leaderboard {
+	return &Leaderboard{client: client, key: redisu.TeamLeaderboardKey}
+}
+
+// NewPlayerLeaderboard creates the leaderboard of the total playtimes of players with a session in Redis.
+func NewPlayerLeaderboard(client *redis.ClusterClient) *Leaderboard {
+	return &Leaderboard{client: client, key: redisu.PlayerLeaderboardKey}
+}
+
+// set records an entry's new total. Failures are logged; the per-entry key stays the source of truth.
+func (lb *Leaderboard) set(ctx context.Context, id string, total float64) {
+	if lb == nil {
+		return
+	}
+	if err := lb.client.ZAdd(ctx, lb.key, redis.Z{Score: total, Member: id}).Err(); err != nil {
+		slog.Warn("Failed to update leaderboard entry", "leaderboard", lb.key, "id", id, "error", err)
+	}
+}
+
+// incrBy queues an increment of an entry on pipe, so it is sent with the increment of the entry's own key.
+func (lb *Leaderboard) incrBy(ctx context.Context, pipe redis.Pipeliner, id string, delta float64) {
+	if lb == nil {
+		return
+	}
+	pipe.ZIncrBy(ctx, lb.key, delta, id)
+}
+
+// remove drops an entry from the leaderboard.
+func (lb *Leaderboard) remove(ctx context.Context, id string) {
+	if lb == nil {
+		return
+	}
+	if err := lb.client.ZRem(ctx, lb.key, id).Err(); err != nil {
+		slog.Warn("Failed to remove leaderboard entry", "leaderboard", lb.key, "id", id, "error", err)
+	}
+}
+
+// Rebuild replaces the leaderboard with the given totals, e.g., the result of a full scan.
+func (lb *Leaderboard) Rebuild(ctx context.Context, totals map[string]float64) error {
+	pipe := lb.client.TxPipeline() // The sorted set is a single key, so this is atomic
+	pipe.Del(ctx, lb.key)
+	if len(totals) > 0 {
+		members := make([]redis.Z, 0, len(totals))
+		for id, total := range totals {
+			members = append(members, redis.Z{Score: total, Member: id})
+		}
+		pipe.ZAdd(ctx, lb.key, members...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild %s: %w", lb.key, err)
+	}
+	return nil
+}
+
+// top returns the n entries with the highest totals, highest first.
+func (lb *Leaderboard) top(ctx context.Context, n int) ([]redis.Z, error) {
+	entries, err := lb.client.ZRevRangeWithScores(ctx, lb.key, 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read top %d entries from %s: %w", n, lb.key, err)
+	}
+	return entries, nil
+}
+
+// topFromTotals ranks a full set of totals, highest first, keeping at most n.
+// Ties are broken by ID in descending order, matching the sorted set's ordering.
+func topFromTotals(totals map[string]float64, n int) []redis.Z {
+	entries := make([]redis.Z, 0, len(totals))
+	for id, total := range totals {
+		entries = append(entries, redis.Z{Score: total, Member: id})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Member.(string) > entries[j].Member.(string)
+	})
+	return entries[:min(n, len(entries))]
+}
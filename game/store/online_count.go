@@ -0,0 +1,90 @@
+// game/store/online_count.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// setOnlineKey sets a player's online key to value with the online TTL and counts the player in the
+// online counter if the key did not exist before (a new session, or one whose key had expired).
+func (ops *OnlinePlayersStore) setOnlineKey(ctx context.Context, playerUUID string, value int64) error {
+	key := fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID)
+	// SET ... GET returns the previous value, or redis.Nil if there was none.
+	err := ops.client.SetArgs(ctx, key, value, redis.SetArgs{TTL: ops.onlineTTL, Get: true}).Err()
+	if err == redis.Nil {
+		ops.adjustOnlineCount(ctx, 1)
+		return nil
+	}
+	return err
+}
+
+// adjustOnlineCount moves the online counter by delta. The counter lives on a different node than
+// the online keys, so the two can't be updated atomically; failures are only logged and left for
+// ReconcileOnlineCount to correct.
+func (ops *OnlinePlayersStore) adjustOnlineCount(ctx context.Context, delta int64) {
+	if err := ops.client.IncrBy(ctx, redisu.OnlineCountKey, delta).Err(); err != nil {
+		slog.Warn("Failed to update online player counter", "delta", delta, "error", err)
+	}
+}
+
+// GetOnlinePlayerCount returns the number of players marked as online from the online counter,
+// without scanning. Online keys that expire instead of being removed are still counted until the
+// next ReconcileOnlineCount. If the counter does not exist yet it is rebuilt from a scan first.
+func (ops *OnlinePlayersStore) GetOnlinePlayerCount(ctx context.Context) (int, error) {
+	count, err := ops.client.Get(ctx, redisu.OnlineCountKey).Int()
+	if err == redis.Nil {
+		return ops.ReconcileOnlineCount(ctx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read online player counter from Redis: %w", err)
+	}
+	return max(count, 0), nil
+}
+
+// PeekOnlinePlayerCount reads the online counter like GetOnlinePlayerCount, but never rebuilds it:
+// a single GET, cheap enough for hot paths such as heartbeats. Returns an error wrapping
+// redisu.ErrRedisKeyNotFound if the counter does not exist yet.
+func (ops *OnlinePlayersStore) PeekOnlinePlayerCount(ctx context.Context) (int, error) {
+	count, err := ops.client.Get(ctx, redisu.OnlineCountKey).Int()
+	if err == redis.Nil {
+		return 0, fmt.Errorf("online player counter: %w", redisu.ErrRedisKeyNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read online player counter from Redis: %w", err)
+	}
+	return max(count, 0), nil
+}
+
+// ReconcileOnlineCount counts the online keys across the cluster and overwrites the online counter
+// with the result, correcting drift from expired keys and failed counter updates. Sessions starting
+// or ending during the scan may leave a small error, which the next reconciliation corrects.
+// Returns the number of online players found.
+func (ops *OnlinePlayersStore) ReconcileOnlineCount(ctx context.Context) (int, error) {
+	var count int64
+	var mu sync.Mutex // Protects count across cluster nodes
+	err := redisu.ScanAllMasters(ctx, ops.client, fmt.Sprintf(redisu.OnlineKeyPrefix, "*"), 0, func(ctx context.Context, _ *redis.Client, keys []string) error {
+		mu.Lock()
+		count += int64(len(keys))
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count online players for reconciliation: %w", err)
+	}
+
+	previous, err := ops.client.SetArgs(ctx, redisu.OnlineCountKey, count, redis.SetArgs{Get: true}).Result()
+	if err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("failed to store reconciled online player counter in Redis: %w", err)
+	}
+	if previous != strconv.FormatInt(count, 10) {
+		slog.Info("Reconciled online player counter", "counted", count, "previous", previous)
+	}
+	return int(count), nil
+}
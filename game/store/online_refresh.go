@@ -0,0 +1,113 @@
+// game/store/online_refresh.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// OnlineRefreshResult is the outcome of refreshing one player's online key in RefreshPlayerOnlineStatuses.
+type OnlineRefreshResult struct {
+	UUID    string
+	Present bool  // The online key existed and its TTL was reset
+	Err     error // E.g., ErrMaxSessionDurationExceeded if the session is over the cap; Present is false then
+}
+
+// RefreshPlayerOnlineStatuses resets the online TTL of many players at once with pipelined EXPIREs,
+// for servers that heartbeat all their players together. Unlike RefreshPlayerOnlineStatus it never
+// creates an online key: players whose key has already expired are reported as not present and must
+// go online again. If a maximum session duration is set, sessions over it (or whose start can't be
+// read) are not refreshed and get ErrMaxSessionDurationExceeded (or the read error).
+// Results are in the order of playerUUIDs.
+func (ops *OnlinePlayersStore) RefreshPlayerOnlineStatuses(ctx context.Context, playerUUIDs []string) ([]OnlineRefreshResult, error) {
+	results := make([]OnlineRefreshResult, len(playerUUIDs))
+	for i, playerUUID := range playerUUIDs {
+		results[i].UUID = playerUUID
+	}
+	if len(playerUUIDs) == 0 {
+		return results, nil
+	}
+
+	var missingStart []bool
+	if ops.maxSessionDuration > 0 {
+		var err error
+		missingStart, err = ops.checkSessionDurations(ctx, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pipe := ops.client.Pipeline()
+	expireCmds := make([]*redis.BoolCmd, len(results))
+	for i, result := range results {
+		if result.Err == nil {
+			expireCmds[i] = pipe.Expire(ctx, fmt.Sprintf(redisu.OnlineKeyPrefix, result.UUID), ops.onlineTTL)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to refresh online status of %d players in Redis: %w", len(playerUUIDs), err)
+	}
+
+	// Sessions without a recorded start start counting now, as in checkSessionDuration.
+	startPipe := ops.client.Pipeline()
+	now := time.Now().Unix()
+	for i, cmd := range expireCmds {
+		if cmd == nil {
+			continue
+		}
+		results[i].Present = cmd.Val()
+		if results[i].Present && missingStart != nil && missingStart[i] {
+			startPipe.SetNX(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, results[i].UUID), now, ops.maxSessionDuration+ops.onlineTTL)
+		}
+	}
+	if startPipe.Len() > 0 {
+		if _, err := startPipe.Exec(ctx); err != nil {
+			slog.Warn("Failed to record session start for refreshed players", "count", startPipe.Len(), "error", err)
+		}
+	}
+	return results, nil
+}
+
+// checkSessionDurations reads the session starts of every result's player in one pipeline, marks
+// sessions over the maximum duration with ErrMaxSessionDurationExceeded and, like
+// checkSessionDuration, keeps their session start alive. It reports which players have no session start.
+func (ops *OnlinePlayersStore) checkSessionDurations(ctx context.Context, results []OnlineRefreshResult) ([]bool, error) {
+	pipe := ops.client.Pipeline()
+	startCmds := make([]*redis.StringCmd, len(results))
+	for i, result := range results {
+		startCmds[i] = pipe.Get(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, result.UUID))
+	}
+	// Exec returns redis.Nil whenever a session start is missing; check each command instead.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to retrieve session starts of %d players from Redis: %w", len(results), err)
+	}
+
+	missingStart := make([]bool, len(results))
+	extendPipe := ops.client.Pipeline()
+	for i, cmd := range startCmds {
+		startTimestamp, err := cmd.Int64()
+		if err == redis.Nil {
+			missingStart[i] = true
+			continue
+		}
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to parse session start for player %s: %w", results[i].UUID, err)
+			continue
+		}
+		if time.Since(time.Unix(startTimestamp, 0)) > ops.maxSessionDuration {
+			results[i].Err = fmt.Errorf("player %s: %w", results[i].UUID, ErrMaxSessionDurationExceeded)
+			extendPipe.Expire(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, results[i].UUID), ops.onlineTTL)
+		}
+	}
+	if extendPipe.Len() > 0 {
+		if _, err := extendPipe.Exec(ctx); err != nil {
+			slog.Warn("Failed to extend session start TTLs", "count", extendPipe.Len(), "error", err)
+		}
+	}
+	return missingStart, nil
+}
@@ -3,10 +3,10 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
-	"strings" // Still needed for parsing UUID from scanned keys
 	"sync"
 	"time"
 
@@ -14,37 +14,49 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrMaxSessionDurationExceeded is returned when a heartbeat arrives for a session that has been
+// running longer than the configured maximum. The player has to go online again to start a new session.
+var ErrMaxSessionDurationExceeded = errors.New("session exceeded maximum duration")
+
 // OnlinePlayersStore manages the online status and session details of players in Redis.
 // It uses Redis's TTL (Time To Live) feature to automatically expire online status keys
 // after a defined duration, effectively acting as a heartbeat mechanism.
 type OnlinePlayersStore struct {
-	client    *redis.ClusterClient
-	onlineTTL time.Duration // The duration after which an online status key expires if not refreshed.
+	client             *redis.ClusterClient
+	onlineTTL          time.Duration // The duration after which an online status key expires if not refreshed.
+	maxSessionDuration time.Duration // Absolute cap on a session refreshed by heartbeats. 0 disables the cap.
+	idleTimeout        time.Duration // Players without activity for this long are idle, see SetIdleTimeout. 0 disables idle detection.
 }
 
 // NewOnlinePlayersStore creates and returns a new OnlinePlayersStore instance.
-// It requires a connected Redis Cluster client and a time-to-live duration for online status.
-func NewOnlinePlayersStore(client *redis.ClusterClient, onlineTTL time.Duration) *OnlinePlayersStore {
+// It requires a connected Redis Cluster client, a time-to-live duration for online status and
+// the maximum session duration (0 for no limit).
+func NewOnlinePlayersStore(client *redis.ClusterClient, onlineTTL time.Duration, maxSessionDuration time.Duration) *OnlinePlayersStore {
 	return &OnlinePlayersStore{
-		client:    client,
-		onlineTTL: onlineTTL,
+		client:             client,
+		onlineTTL:          onlineTTL,
+		maxSessionDuration: maxSessionDuration,
 	}
 }
 
 // SetPlayerOnline marks a player as online in Redis and stores their session start time.
 // The key will automatically expire after `ops.onlineTTL` unless refreshed.
 func (ops *OnlinePlayersStore) SetPlayerOnline(ctx context.Context, playerUUID string, sessionStartTime time.Time) error {
-	// Construct the Redis key using the predefined constant for consistency.
-	key := fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID)
-
 	// Store the session start timestamp (Unix seconds) as the value.
 	startTimestamp := sessionStartTime.Unix()
-	err := ops.client.Set(ctx, key, startTimestamp, ops.onlineTTL).Err()
-	if err != nil {
+	if err := ops.setOnlineKey(ctx, playerUUID, startTimestamp); err != nil {
 		return fmt.Errorf("failed to set player %s online status in Redis: %w", playerUUID, err)
 	}
 
-	log.Printf("Player %s marked online with session start time: %v (TTL: %s)", playerUUID, sessionStartTime, ops.onlineTTL)
+	if err := ops.setSessionStart(ctx, playerUUID, startTimestamp); err != nil {
+		return err
+	}
+	// Going online counts as activity, so a new session is not idle before its first activity heartbeat.
+	if err := ops.RecordActivity(ctx, playerUUID); err != nil {
+		return err
+	}
+
+	slog.Info("Player marked online", "player_uuid", playerUUID, "session_start", sessionStartTime, "ttl", ops.onlineTTL.String())
 	return nil
 }
 
@@ -92,9 +104,10 @@ func (ops *OnlinePlayersStore) RemovePlayerOnline(ctx context.Context, playerUUI
 	}
 
 	if deletedCount > 0 {
-		log.Printf("Player %s's online status removed from Redis.", playerUUID)
+		ops.adjustOnlineCount(ctx, -1)
+		slog.Info("Player online status removed from Redis", "player_uuid", playerUUID)
 	} else {
-		log.Printf("Attempted to remove online status for player %s, but they were not marked as online.", playerUUID)
+		slog.Info("Attempted to remove online status, but player was not marked as online", "player_uuid", playerUUID)
 	}
 
 	return nil
@@ -106,40 +119,26 @@ func (ops *OnlinePlayersStore) GetAllOnlinePlayers(ctx context.Context) (map[str
 	onlinePlayers := make(map[string]time.Time)
 	var mu sync.Mutex // Mutex to protect concurrent map writes from different cluster nodes
 
-	// ForEachMaster iterates over all master nodes in the Redis Cluster.
-	err := ops.client.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
-		if client == nil {
-			log.Printf("Warning: Redis Cluster ForEachMaster provided a nil client, skipping node.")
-			return nil // Skip this iteration if client is nil
-		}
-
-		// Use SCAN to iterate through keys on the current master node.
-		// The pattern "online:{*}:" ensures we only get keys matching our online status format.
-		iter := client.Scan(ctx, 0, fmt.Sprintf(redisu.OnlineKeyPrefix, "*"), 0).Iterator()
-		for iter.Next(ctx) {
-			key := iter.Val()
-
-			// Extract the player UUID from the key (e.g., "online:{uuid}:" -> "uuid").
-			// This string manipulation assumes the fixed format of `redisu.OnlineKeyPrefix`.
-			startIdx := strings.Index(key, "{")
-			endIdx := strings.Index(key, "}")
-			if startIdx == -1 || endIdx == -1 || endIdx <= startIdx {
-				log.Printf("Warning: Could not parse UUID from malformed online key: %s. Skipping.", key)
+	// The pattern "online:{*}:" ensures we only get keys matching our online status format.
+	err := redisu.ScanAllMasters(ctx, ops.client, fmt.Sprintf(redisu.OnlineKeyPrefix, "*"), 0, func(ctx context.Context, client *redis.Client, keys []string) error {
+		for _, key := range keys {
+			playerUUID, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipping malformed online key", "key", key)
 				continue
 			}
-			playerUUID := key[startIdx+1 : endIdx] // Extract the UUID without braces
 
 			// Retrieve the session start timestamp for the found key.
 			val, err := client.Get(ctx, key).Result()
 			if err != nil {
-				log.Printf("Warning: Failed to get session start time for player %s (key: %s): %v. Skipping.", playerUUID, key, err)
+				slog.Warn("Failed to get session start time; skipping", "player_uuid", playerUUID, "key", key, "error", err)
 				continue
 			}
 
 			// Parse the timestamp string to a time.Time object.
 			timestamp, parseErr := strconv.ParseInt(val, 10, 64)
 			if parseErr != nil {
-				log.Printf("Warning: Invalid timestamp '%s' for player %s (key: %s). Skipping.", val, playerUUID, key)
+				slog.Warn("Invalid session start timestamp; skipping", "player_uuid", playerUUID, "key", key, "value", val)
 				continue
 			}
 			sessionStart := time.Unix(timestamp, 0)
@@ -149,8 +148,7 @@ func (ops *OnlinePlayersStore) GetAllOnlinePlayers(ctx context.Context) (map[str
 			onlinePlayers[playerUUID] = sessionStart
 			mu.Unlock()
 		}
-
-		return iter.Err() // Return any error from the iterator
+		return nil
 	})
 
 	if err != nil {
@@ -160,17 +158,6 @@ func (ops *OnlinePlayersStore) GetAllOnlinePlayers(ctx context.Context) (map[str
 	return onlinePlayers, nil
 }
 
-// GetOnlinePlayerCount returns the total number of players currently marked as online.
-// This is less efficient than a direct Redis COUNT if available for key patterns,
-// but accurate as it counts active sessions.
-func (ops *OnlinePlayersStore) GetOnlinePlayerCount(ctx context.Context) (int, error) {
-	onlinePlayers, err := ops.GetAllOnlinePlayers(ctx)
-	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve all online players to count: %w", err)
-	}
-	return len(onlinePlayers), nil
-}
-
 // GetPlayerSessionDuration calculates the elapsed time since a player went online.
 // Returns a duration of 0 and an error if the player is not online.
 func (ops *OnlinePlayersStore) GetPlayerSessionDuration(ctx context.Context, playerUUID string) (time.Duration, error) {
@@ -187,8 +174,14 @@ func (ops *OnlinePlayersStore) GetPlayerSessionDuration(ctx context.Context, pla
 // RefreshPlayerOnlineStatus extends the TTL (Time To Live) for a player's online status key.
 // This acts as a "heartbeat" to keep a player marked as online.
 // It ensures the key exists or is refreshed, even if it expired.
+// If a maximum session duration is configured and the session has run longer than that,
+// the refresh is refused with ErrMaxSessionDurationExceeded.
 func (ops *OnlinePlayersStore) RefreshPlayerOnlineStatus(ctx context.Context, playerUUID string) error {
-	key := fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID)
+	if ops.maxSessionDuration > 0 {
+		if err := ops.checkSessionDuration(ctx, playerUUID); err != nil {
+			return err
+		}
+	}
 
 	// The value doesn't strictly matter for online status,
 	// it just needs to exist. You could use "online", "1", or even an empty string.
@@ -200,17 +193,11 @@ func (ops *OnlinePlayersStore) RefreshPlayerOnlineStatus(ctx context.Context, pl
 	// This will set the key 'key' to 'value' and set its TTL to 'ops.onlineTTL'.
 	// If the key already exists, it will be overwritten and its TTL reset.
 	// This is idempotent and handles both initial setting and refreshing.
-	status, err := ops.client.Set(ctx, key, startTimestamp, ops.onlineTTL).Result()
-	if err != nil {
+	if err := ops.setOnlineKey(ctx, playerUUID, startTimestamp); err != nil {
 		return fmt.Errorf("failed to set/refresh online status for player %s in Redis: %w", playerUUID, err)
 	}
 
-	// "OK" is returned if the SET operation was successful.
-	if status != "OK" {
-		return fmt.Errorf("unexpected status from Redis SET for player %s: %s", playerUUID, status)
-	}
-
-	log.Printf("Online status for player %s refreshed to %d (TTL: %s).", playerUUID, startTimestamp, ops.onlineTTL)
+	slog.Info("Player online status refreshed", "player_uuid", playerUUID, "refreshed_at", startTimestamp, "ttl", ops.onlineTTL.String())
 	return nil
 }
 
@@ -245,13 +232,69 @@ func (ops *OnlinePlayersStore) CleanupExpiredSessions(ctx context.Context) (int,
 		// This method's main utility might be for diagnostic purposes.
 		if sessionStart.Before(cutoffTime) { // If the session started before the cutoff
 			if err := ops.RemovePlayerOnline(ctx, uuid); err != nil {
-				log.Printf("Warning: Failed to cleanup logically expired session for player %s: %v", uuid, err)
+				slog.Warn("Failed to clean up logically expired session", "player_uuid", uuid, "error", err)
 			} else {
 				expiredCount++
-				log.Printf("Cleaned up logically expired session for player %s.", uuid)
+				slog.Info("Cleaned up logically expired session", "player_uuid", uuid)
 			}
 		}
 	}
 
 	return expiredCount, nil
 }
+
+// GetSessionStart returns the absolute start of a player's current session. Unlike the online key,
+// it is not moved forward by heartbeats. Returns an error wrapping redisu.ErrRedisKeyNotFound if none is recorded.
+func (ops *OnlinePlayersStore) GetSessionStart(ctx context.Context, playerUUID string) (time.Time, error) {
+	key := fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID)
+	startTimestamp, err := ops.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return time.Time{}, fmt.Errorf("no session start recorded for player %s: %w", playerUUID, redisu.ErrRedisKeyNotFound)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to retrieve session start for player %s from Redis: %w", playerUUID, err)
+	}
+	return time.Unix(startTimestamp, 0), nil
+}
+
+// setSessionStart records the absolute start of a player's session.
+// With a session cap, the key outlives the online key by the maximum session duration, so the cap
+// survives missed heartbeats. Without one it has no expiry and is removed when the session ends.
+func (ops *OnlinePlayersStore) setSessionStart(ctx context.Context, playerUUID string, startTimestamp int64) error {
+	key := fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID)
+	var ttl time.Duration
+	if ops.maxSessionDuration > 0 {
+		ttl = ops.maxSessionDuration + ops.onlineTTL
+	}
+	if err := ops.client.Set(ctx, key, startTimestamp, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set session start for player %s in Redis: %w", playerUUID, err)
+	}
+	return nil
+}
+
+// checkSessionDuration verifies a player's session is still within the maximum session duration.
+// Sessions without a recorded start (e.g., created by a heartbeat) start counting now.
+func (ops *OnlinePlayersStore) checkSessionDuration(ctx context.Context, playerUUID string) error {
+	key := fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID)
+
+	startTimestamp, err := ops.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return ops.setSessionStart(ctx, playerUUID, time.Now().Unix())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve session start for player %s from Redis: %w", playerUUID, err)
+	}
+
+	sessionStart := time.Unix(startTimestamp, 0)
+	if time.Since(sessionStart) <= ops.maxSessionDuration {
+		return nil
+	}
+
+	// Keep the session start around while the client keeps heartbeating, so the session
+	// cannot be revived until the player goes online again or stops sending heartbeats.
+	if err := ops.client.Expire(ctx, key, ops.onlineTTL).Err(); err != nil {
+		slog.Warn("Failed to extend session start TTL", "player_uuid", playerUUID, "error", err)
+	}
+	slog.Info("Refusing to refresh online status: session exceeds maximum duration", "player_uuid", playerUUID, "session_start", sessionStart, "max_session_duration", ops.maxSessionDuration.String())
+	return fmt.Errorf("player %s: %w", playerUUID, ErrMaxSessionDurationExceeded)
+}
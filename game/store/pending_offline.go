@@ -0,0 +1,92 @@
+// game/store/pending_offline.go
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// pendingOfflineTTLMargin keeps a pending-offline marker alive a little past the grace period, so it is
+// still there when the instance that scheduled the cleanup claims it.
+const pendingOfflineTTLMargin = time.Minute
+
+// MarkPendingOffline records that the player's session is in its offline grace period, to be ended
+// by whoever holds token once gracePeriod elapses. Any instance the player reconnects through clears
+// the marker with ClearPendingOffline, which cancels the cleanup.
+func (ops *OnlinePlayersStore) MarkPendingOffline(ctx context.Context, playerUUID string, token string, gracePeriod time.Duration) error {
+	key := fmt.Sprintf(redisu.PendingOfflineKeyPrefix, playerUUID)
+	if err := ops.client.Set(ctx, key, token, gracePeriod+pendingOfflineTTLMargin).Err(); err != nil {
+		return fmt.Errorf("failed to mark player %s pending offline in Redis: %w", playerUUID, err)
+	}
+	return nil
+}
+
+// ClearPendingOffline removes the player's pending-offline marker, reporting whether there was one,
+// i.e. whether the player is reconnecting within the grace period.
+func (ops *OnlinePlayersStore) ClearPendingOffline(ctx context.Context, playerUUID string) (bool, error) {
+	deleted, err := ops.client.Del(ctx, fmt.Sprintf(redisu.PendingOfflineKeyPrefix, playerUUID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to clear pending offline marker for player %s in Redis: %w", playerUUID, err)
+	}
+	return deleted > 0, nil
+}
+
+// IsPendingOffline reports whether the player's session is in its offline grace period.
+func (ops *OnlinePlayersStore) IsPendingOffline(ctx context.Context, playerUUID string) (bool, error) {
+	exists, err := ops.client.Exists(ctx, fmt.Sprintf(redisu.PendingOfflineKeyPrefix, playerUUID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending offline marker for player %s in Redis: %w", playerUUID, err)
+	}
+	return exists > 0, nil
+}
+
+// ClaimPendingOffline removes the player's pending-offline marker if it still holds token and the
+// player is not online, reporting whether it did. Only then may the caller end the session: otherwise
+// the player reconnected (possibly through another instance) or went offline again under a newer
+// marker. The check and the delete run in one optimistic transaction over keys sharing the player's
+// hash tag.
+func (ops *OnlinePlayersStore) ClaimPendingOffline(ctx context.Context, playerUUID string, token string) (bool, error) {
+	pendingKey := fmt.Sprintf(redisu.PendingOfflineKeyPrefix, playerUUID)
+	onlineKey := fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID)
+
+	claimed := false
+	err := ops.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, pendingKey).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if current != token {
+			return nil
+		}
+		online, err := tx.Exists(ctx, onlineKey).Result()
+		if err != nil {
+			return err
+		}
+		if online > 0 {
+			return nil
+		}
+		if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, pendingKey)
+			return nil
+		}); err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	}, pendingKey, onlineKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil // The session changed while checking, e.g. the player reconnected
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim pending offline cleanup for player %s: %w", playerUUID, err)
+	}
+	return claimed, nil
+}
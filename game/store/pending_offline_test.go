@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+)
+
+func TestClaimPendingOffline(t *testing.T) {
+	mr, client := newTestClient(t)
+	ops := NewOnlinePlayersStore(client, 15*time.Second, 0)
+	ctx := context.Background()
+	const playerUUID = "player-1"
+	onlineKey := fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID)
+
+	if err := ops.MarkPendingOffline(ctx, playerUUID, "token-a", 10*time.Second); err != nil {
+		t.Fatalf("MarkPendingOffline: %v", err)
+	}
+
+	// A newer offline marker belongs to another cleanup.
+	if claimed, err := ops.ClaimPendingOffline(ctx, playerUUID, "token-b"); err != nil || claimed {
+		t.Fatalf("ClaimPendingOffline with a stale token = %v, %v; want false, nil", claimed, err)
+	}
+
+	// The player came back online (e.g., through another instance) without the marker being cleared.
+	mr.Set(onlineKey, "1")
+	if claimed, err := ops.ClaimPendingOffline(ctx, playerUUID, "token-a"); err != nil || claimed {
+		t.Fatalf("ClaimPendingOffline while online = %v, %v; want false, nil", claimed, err)
+	}
+
+	mr.Del(onlineKey)
+	if claimed, err := ops.ClaimPendingOffline(ctx, playerUUID, "token-a"); err != nil || !claimed {
+		t.Fatalf("ClaimPendingOffline = %v, %v; want true, nil", claimed, err)
+	}
+	if pending, err := ops.IsPendingOffline(ctx, playerUUID); err != nil || pending {
+		t.Fatalf("IsPendingOffline after the claim = %v, %v; want false, nil", pending, err)
+	}
+}
+
+func TestClearPendingOfflineCancelsClaim(t *testing.T) {
+	_, client := newTestClient(t)
+	ops := NewOnlinePlayersStore(client, 15*time.Second, 0)
+	ctx := context.Background()
+	const playerUUID = "player-1"
+
+	if err := ops.MarkPendingOffline(ctx, playerUUID, "token-a", 10*time.Second); err != nil {
+		t.Fatalf("MarkPendingOffline: %v", err)
+	}
+	// A reconnect on any instance clears the marker.
+	if pending, err := ops.ClearPendingOffline(ctx, playerUUID); err != nil || !pending {
+		t.Fatalf("ClearPendingOffline = %v, %v; want true, nil", pending, err)
+	}
+	if claimed, err := ops.ClaimPendingOffline(ctx, playerUUID, "token-a"); err != nil || claimed {
+		t.Fatalf("ClaimPendingOffline after a reconnect = %v, %v; want false, nil", claimed, err)
+	}
+}
@@ -0,0 +1,57 @@
+// game/store/player_activity.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// SetIdleTimeout enables idle detection: players who have not reported activity (or gone online)
+// within timeout count as idle. 0, the default, disables it and every online player counts as active.
+func (ops *OnlinePlayersStore) SetIdleTimeout(timeout time.Duration) {
+	ops.idleTimeout = timeout
+}
+
+// RecordActivity marks a player as active now. The activity key expires after the idle timeout, so
+// its absence is what makes a player idle. It is a no-op when idle detection is disabled.
+// Sessions have no hash to hold it: each piece of session state is its own key, and a separate key
+// lets Redis expire idleness without the updater comparing timestamps.
+func (ops *OnlinePlayersStore) RecordActivity(ctx context.Context, playerUUID string) error {
+	if ops.idleTimeout <= 0 {
+		return nil
+	}
+	key := fmt.Sprintf(redisu.LastActivityKeyPrefix, playerUUID)
+	if err := ops.client.Set(ctx, key, time.Now().Unix(), ops.idleTimeout).Err(); err != nil {
+		return fmt.Errorf("failed to record activity for player %s in Redis: %w", playerUUID, err)
+	}
+	return nil
+}
+
+// FilterActivePlayers returns the players in playerUUIDs that are not idle, keeping their order.
+// The activity keys are checked in one pipeline. With idle detection disabled, playerUUIDs is returned as is.
+func (ops *OnlinePlayersStore) FilterActivePlayers(ctx context.Context, playerUUIDs []string) ([]string, error) {
+	if ops.idleTimeout <= 0 || len(playerUUIDs) == 0 {
+		return playerUUIDs, nil
+	}
+
+	pipe := ops.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(playerUUIDs))
+	for i, playerUUID := range playerUUIDs {
+		cmds[i] = pipe.Exists(ctx, fmt.Sprintf(redisu.LastActivityKeyPrefix, playerUUID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to check activity of %d players in Redis: %w", len(playerUUIDs), err)
+	}
+
+	active := make([]string, 0, len(playerUUIDs))
+	for i, cmd := range cmds {
+		if cmd.Val() == 1 {
+			active = append(active, playerUUIDs[i])
+		}
+	}
+	return active, nil
+}
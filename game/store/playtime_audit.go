@@ -0,0 +1,101 @@
+// game/store/playtime_audit.go
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sources recorded in the playtime audit log.
+const (
+	PlaytimeAuditSourceTick    = "tick"    // Periodic increment by the game updater
+	PlaytimeAuditSourceOffline = "offline" // Final total persisted when the session ended
+	PlaytimeAuditSourceReset   = "reset"   // Total reset to zero by an admin
+)
+
+// PlaytimeAuditEntry is a single change to a player's playtime.
+type PlaytimeAuditEntry struct {
+	Timestamp int64   `json:"timestamp"` // Unix milliseconds
+	Delta     float64 `json:"delta"`     // Amount added; 0 for entries that only record the total
+	Source    string  `json:"source"`    // PlaytimeAuditSourceTick or PlaytimeAuditSourceOffline
+	Total     float64 `json:"total"`     // Player's total playtime after the change
+}
+
+// EnableAudit turns on the per-player playtime audit log. Each player's log is a Redis list capped at
+// maxEntries (newest first) that expires retention after its last write, give or take a tenth of it.
+// Off by default due to storage cost.
+func (pps *PlayerPlaytimeStore) EnableAudit(maxEntries int, retention time.Duration) {
+	pps.auditMaxEntries = maxEntries
+	pps.auditRetention = retention
+}
+
+// AuditEnabled reports whether the playtime audit log is enabled.
+func (pps *PlayerPlaytimeStore) AuditEnabled() bool {
+	return pps.auditMaxEntries > 0
+}
+
+// RecordPlaytimeAudit appends an entry to the player's audit log. It is a no-op when auditing is disabled.
+// Failures are logged rather than returned so auditing never blocks playtime updates.
+func (pps *PlayerPlaytimeStore) RecordPlaytimeAudit(ctx context.Context, playerUUID string, source string, delta float64, total float64) {
+	if !pps.AuditEnabled() {
+		return
+	}
+
+	data, err := json.Marshal(PlaytimeAuditEntry{
+		Timestamp: time.Now().UnixMilli(),
+		Delta:     delta,
+		Source:    source,
+		Total:     total,
+	})
+	if err != nil {
+		slog.Warn("Failed to encode playtime audit entry", "player_uuid", playerUUID, "error", err)
+		return
+	}
+
+	key := fmt.Sprintf(redisu.PlaytimeAuditKeyPrefix, playerUUID)
+	retention := pps.auditRetention.Milliseconds()
+	err = recordAuditScript.Run(ctx, pps.redisClient, []string{key}, data, pps.auditMaxEntries-1, retention, retention/10).Err()
+	if err != nil {
+		slog.Warn("Failed to record playtime audit entry", "player_uuid", playerUUID, "error", err)
+	}
+}
+
+// recordAuditScript pushes ARGV[1] onto the audit log at KEYS[1] and trims it to indexes 0..ARGV[2], in
+// one round trip on every tick. If ARGV[3] is positive, the log expires ARGV[3] ms after its last write;
+// the expiry is only pushed back once more than ARGV[4] ms of it have passed (or the log is new), so most
+// writes leave it alone.
+var recordAuditScript = redis.NewScript(`
+redis.call('LPUSH', KEYS[1], ARGV[1])
+redis.call('LTRIM', KEYS[1], 0, ARGV[2])
+local retention = tonumber(ARGV[3])
+if retention > 0 and redis.call('PTTL', KEYS[1]) < retention - tonumber(ARGV[4]) then
+	redis.call('PEXPIRE', KEYS[1], retention)
+end
+return 1
+`)
+
+// GetPlaytimeAudit returns up to limit of the player's most recent audit entries, newest first.
+func (pps *PlayerPlaytimeStore) GetPlaytimeAudit(ctx context.Context, playerUUID string, limit int) ([]PlaytimeAuditEntry, error) {
+	key := fmt.Sprintf(redisu.PlaytimeAuditKeyPrefix, playerUUID)
+	raw, err := pps.redisClient.LRange(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playtime audit log for player %s from Redis: %w", playerUUID, err)
+	}
+
+	entries := make([]PlaytimeAuditEntry, 0, len(raw))
+	for _, item := range raw {
+		var entry PlaytimeAuditEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			slog.Warn("Skipping malformed playtime audit entry", "player_uuid", playerUUID, "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+)
+
+func TestRecordPlaytimeAuditTrimsAndExpires(t *testing.T) {
+	mr, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	pps.EnableAudit(2, time.Hour)
+	ctx := context.Background()
+	key := fmt.Sprintf(redisu.PlaytimeAuditKeyPrefix, "player-1")
+
+	for i := 1; i <= 3; i++ {
+		pps.RecordPlaytimeAudit(ctx, "player-1", PlaytimeAuditSourceTick, 1, float64(i))
+	}
+	entries, err := pps.GetPlaytimeAudit(ctx, "player-1", 10)
+	if err != nil {
+		t.Fatalf("GetPlaytimeAudit: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Total != 3 || entries[1].Total != 2 {
+		t.Errorf("entries = %+v, want the totals 3 and 2, newest first", entries)
+	}
+	if ttl := mr.TTL(key); ttl != time.Hour {
+		t.Errorf("TTL of a new log = %v, want 1h", ttl)
+	}
+
+	mr.FastForward(5 * time.Minute)
+	pps.RecordPlaytimeAudit(ctx, "player-1", PlaytimeAuditSourceTick, 1, 4)
+	if ttl := mr.TTL(key); ttl != 55*time.Minute {
+		t.Errorf("TTL after a write 5m in = %v, want it left at 55m", ttl)
+	}
+
+	mr.FastForward(5 * time.Minute)
+	pps.RecordPlaytimeAudit(ctx, "player-1", PlaytimeAuditSourceTick, 1, 5)
+	if ttl := mr.TTL(key); ttl != time.Hour {
+		t.Errorf("TTL after a write 10m in = %v, want it pushed back to 1h", ttl)
+	}
+}
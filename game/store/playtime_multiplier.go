@@ -0,0 +1,42 @@
+// game/store/playtime_multiplier.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetPlaytimeMultiplier stores the factor IncrementPlayerPlaytime applies to a player's ticks for the
+// rest of their session. A multiplier of 1.0 removes the key, since a missing key means 1.0.
+func (pps *PlayerPlaytimeStore) SetPlaytimeMultiplier(ctx context.Context, playerUUID string, multiplier float64) error {
+	key := fmt.Sprintf(redisu.MultiplierKeyPrefix, playerUUID)
+	var err error
+	if multiplier == 1.0 {
+		err = pps.redisClient.Del(ctx, key).Err()
+	} else {
+		err = pps.redisClient.Set(ctx, key, multiplier, deltaPlaytimeKeyTTL).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set playtime multiplier for player %s in Redis: %w", playerUUID, err)
+	}
+	return nil
+}
+
+// playtimeMultiplier interprets the result of reading a player's multiplier key. A missing key means
+// 1.0; an unreadable or non-positive value is logged and also treated as 1.0, so a bad multiplier never
+// stops a player from earning playtime.
+func playtimeMultiplier(playerUUID string, cmd *redis.StringCmd) float64 {
+	multiplier, err := cmd.Float64()
+	if err == redis.Nil {
+		return 1.0
+	}
+	if err != nil || multiplier <= 0 {
+		slog.Warn("Ignoring invalid playtime multiplier; using 1.0", "player_uuid", playerUUID, "value", cmd.Val(), "error", err)
+		return 1.0
+	}
+	return multiplier
+}
@@ -3,22 +3,63 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Correct alias for shared Redis constants
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
+// playerTeamCacheTTL bounds how long a cached UUID-to-team lookup is trusted before Redis is consulted again.
+// Team assignments rarely change, but other game-service instances may still update them.
+const playerTeamCacheTTL = 30 * time.Second
+
+const (
+	// playtimeKeyTTL expires total playtime keys left behind, e.g. by a crash before the session ended.
+	playtimeKeyTTL = 6 * time.Hour
+	// deltaPlaytimeKeyTTL cleans up old deltas that were never processed (e.g., service crash before processing).
+	deltaPlaytimeKeyTTL = 24 * time.Hour
+)
+
+// ErrPartialScan is returned alongside partial results when some Redis cluster nodes could not be scanned.
+var ErrPartialScan = errors.New("some Redis cluster nodes could not be scanned")
+
+// cachedPlayerTeam is an entry in the in-memory UUID-to-team cache.
+type cachedPlayerTeam struct {
+	teamID    string
+	expiresAt time.Time
+}
+
 // PlayerPlaytimeStore manages player playtime and delta playtime data exclusively in Redis.
 // It acts as a fast, in-memory cache for game session data before it's potentially
 // synchronized with a persistent Player microservice.
 type PlayerPlaytimeStore struct {
 	redisClient *redis.ClusterClient
+	teamCache   sync.Map // playerUUID -> cachedPlayerTeam, avoids a Redis GET per player per tick
+
+	teamCacheGen atomic.Uint64      // Bumped by every invalidation, see cachePlayerTeam
+	teamLookups  singleflight.Group // Shares a single Redis GET between concurrent misses for the same player
+
+	auditMaxEntries int           // 0 disables the playtime audit log, see EnableAudit
+	auditRetention  time.Duration // How long an idle player's audit log is kept
+
+	teamCap           *TeamPlaytimeCap // Optional; bounds team totals incremented on each tick
+	teamLeaderboard   *Leaderboard     // Optional; kept in step with team totals incremented on each tick
+	playerLeaderboard *Leaderboard     // Optional; kept in step with player totals, see GetTopPlayers
+
+	scanSlots chan struct{} // Optional; bounds concurrent per-node scans, see SetMaxConcurrentScans
+
+	teamPlaytimeDisabled bool // If true, ticks credit players only, see DisableTeamPlaytime
+
+	validateTeam TeamValidator // Optional; checks every team written, see SetTeamValidator
+
+	unit redisu.PlaytimeUnit // How player and team totals are stored, see SetPlaytimeUnit
 }
 
 // NewPlayerPlaytimeStore creates a new instance of PlayerPlaytimeStore.
@@ -29,34 +70,90 @@ func NewPlayerPlaytimeStore(redisClient *redis.ClusterClient) *PlayerPlaytimeSto
 	}
 }
 
+// SetTeamPlaytimeCap bounds the team totals incremented by IncrementPlayerPlaytime.
+func (pps *PlayerPlaytimeStore) SetTeamPlaytimeCap(teamCap *TeamPlaytimeCap) {
+	pps.teamCap = teamCap
+}
+
+// SetTeamValidator makes this store refuse to assign players to, or credit playtime to, a team v rejects.
+func (pps *PlayerPlaytimeStore) SetTeamValidator(v TeamValidator) {
+	pps.validateTeam = v
+}
+
+// ValidateTeam returns the error of the store's team validator for teamID, or nil if it has none.
+func (pps *PlayerPlaytimeStore) ValidateTeam(teamID string) error {
+	return pps.validateTeam.check(teamID)
+}
+
+// SetTeamLeaderboard makes IncrementPlayerPlaytime keep lb in step with the team totals it increments.
+func (pps *PlayerPlaytimeStore) SetTeamLeaderboard(lb *Leaderboard) {
+	pps.teamLeaderboard = lb
+}
+
+// SetPlayerLeaderboard makes this store keep lb in step with the player totals it writes and serve GetTopPlayers from it.
+func (pps *PlayerPlaytimeStore) SetPlayerLeaderboard(lb *Leaderboard) {
+	pps.playerLeaderboard = lb
+}
+
+// DisableTeamPlaytime makes IncrementPlayerPlaytime credit only the player, as for players without a
+// team, for modes that don't track team playtime. Team totals are left as they are.
+func (pps *PlayerPlaytimeStore) DisableTeamPlaytime() {
+	pps.teamPlaytimeDisabled = true
+}
+
+// SetPlaytimeUnit sets how this store reads and writes player and team totals in Redis. The default
+// is redisu.PlaytimeSeconds; it must match every other store and service using the cluster.
+func (pps *PlayerPlaytimeStore) SetPlaytimeUnit(unit redisu.PlaytimeUnit) {
+	pps.unit = unit
+}
+
+// PlaytimeUnit returns how this store keeps totals in Redis, for callers reading playtime keys directly.
+func (pps *PlayerPlaytimeStore) PlaytimeUnit() redisu.PlaytimeUnit {
+	return pps.unit
+}
+
+// SetMaxConcurrentScans limits how many master nodes GetAllPlayerPlaytimes scans at the same time.
+// ForEachMaster otherwise scans every node at once, which can put a large cluster under load during
+// the sync window. 1 scans the nodes one after another; 0 or less removes the limit.
+func (pps *PlayerPlaytimeStore) SetMaxConcurrentScans(n int) {
+	if n <= 0 {
+		pps.scanSlots = nil
+		return
+	}
+	pps.scanSlots = make(chan struct{}, n)
+}
+
 // SetPlayerPlaytime sets a player's total accumulated playtime in Redis.
 // This is typically used when loading a player's profile or after a major sync.
 func (pps *PlayerPlaytimeStore) SetPlayerPlaytime(ctx context.Context, playerUUID string, totalPlaytime float64) error {
-	// A TTL for total playtime keys can be useful for caching or eventual consistency cleanup.
-	// Adjust this duration based on how often you expect to synchronize with persistent storage.
-	playtimeTTL := 6 * time.Hour
-
 	// Construct the Redis key using the predefined constant.
 	key := fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID)
-	err := pps.redisClient.Set(ctx, key, totalPlaytime, playtimeTTL).Err()
+	err := pps.redisClient.Set(ctx, key, pps.unit.Value(totalPlaytime), playtimeKeyTTL).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set total playtime for player %s in Redis: %w", playerUUID, err)
 	}
+	pps.playerLeaderboard.set(ctx, playerUUID, totalPlaytime)
 
-	log.Printf("Successfully set total playtime for player %s to %.2f seconds (TTL: %s).", playerUUID, totalPlaytime, playtimeTTL)
+	slog.Info("Set player total playtime", "player_uuid", playerUUID, "playtime", totalPlaytime, "ttl", playtimeKeyTTL.String())
 	return nil
 }
 
 // GetPlayerPlaytime retrieves a player's current total playtime from Redis.
 // Returns 0.0 and nil if the key does not exist (player has no recorded playtime yet).
+// A non-numeric value is logged and returned as 0.0 with an error wrapping ErrCorruptPlaytime. The key
+// is left alone: overwriting it would get the bogus total persisted to the player's profile.
 func (pps *PlayerPlaytimeStore) GetPlayerPlaytime(ctx context.Context, playerUUID string) (float64, error) {
 	// Construct the Redis key using the predefined constant.
 	key := fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID)
 
-	val, err := pps.redisClient.Get(ctx, key).Float64()
+	val, err := pps.unit.Seconds(pps.redisClient.Get(ctx, key).Result())
 	if err == redis.Nil {
 		return 0.0, nil // Player has no recorded playtime yet, or key expired.
 	}
+	if isCorruptValue(err) {
+		logCorruptPlaytime(ctx, pps.redisClient, key)
+		return 0.0, fmt.Errorf("total playtime for player %s: %w", playerUUID, ErrCorruptPlaytime)
+	}
 	if err != nil {
 		return 0.0, fmt.Errorf("failed to retrieve total playtime for player %s from Redis: %w", playerUUID, err)
 	}
@@ -64,20 +161,48 @@ func (pps *PlayerPlaytimeStore) GetPlayerPlaytime(ctx context.Context, playerUUI
 	return val, nil
 }
 
+// LookupPlayerPlaytime is GetPlayerPlaytime for callers that persist the total: it reports false,
+// rather than a total of 0.0, if the key does not exist (e.g., the session ended or the key expired),
+// so a missing session is never written over the player's profile. A non-numeric total is returned as
+// an error wrapping ErrCorruptPlaytime.
+func (pps *PlayerPlaytimeStore) LookupPlayerPlaytime(ctx context.Context, playerUUID string) (float64, bool, error) {
+	key := fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID)
+	total, err := pps.unit.Seconds(pps.redisClient.Get(ctx, key).Result())
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	if isCorruptValue(err) {
+		logCorruptPlaytime(ctx, pps.redisClient, key)
+		return 0, false, fmt.Errorf("total playtime for player %s: %w", playerUUID, ErrCorruptPlaytime)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to retrieve total playtime for player %s from Redis: %w", playerUUID, err)
+	}
+	return total, true, nil
+}
+
 // IncrementPlayerPlaytime atomically increments a player's total playtime
 // and their associated team's total playtime in Redis.
-// It uses the `deltaPlaytime` stored under `DeltaPlaytimeKeyPrefix` and CONSUMES it (clears it after use).
+// It uses the `deltaPlaytime` stored under `DeltaPlaytimeKeyPrefix` and CONSUMES it (clears it after use),
+// scaled by the player's playtime multiplier, if any (see SetPlaytimeMultiplier).
 func (pps *PlayerPlaytimeStore) IncrementPlayerPlaytime(ctx context.Context, playerUUID string) error {
 	// Use the correct package alias for constants when constructing keys.
 	deltaKey := fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID)
 	totalPlaytimeKey := fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID)
 	playerTeamKey := fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID) // Key to get player's team ID
 
-	// 1. Fetch the delta playtime value.
-	deltaStr, err := pps.redisClient.Get(ctx, deltaKey).Result()
+	// 1. Fetch the delta playtime value and the player's multiplier, which share the player's hash tag.
+	readPipe := pps.redisClient.Pipeline()
+	deltaCmd := readPipe.Get(ctx, deltaKey)
+	multiplierCmd := readPipe.Get(ctx, fmt.Sprintf(redisu.MultiplierKeyPrefix, playerUUID))
+	// Exec returns redis.Nil whenever one of the keys is missing; check each command instead.
+	if _, err := readPipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to get delta playtime for player %s from Redis: %w", playerUUID, err)
+	}
+	deltaStr, err := deltaCmd.Result()
 	if err == redis.Nil {
 		// No delta playtime found for this player. This is a normal scenario if no recent activity.
-		log.Printf("INFO: No delta playtime found for player %s. Skipping playtime increment.", playerUUID)
+		slog.Info("No delta playtime found; skipping playtime increment", "player_uuid", playerUUID)
 		return nil
 	}
 	if err != nil {
@@ -94,38 +219,36 @@ func (pps *PlayerPlaytimeStore) IncrementPlayerPlaytime(ctx context.Context, pla
 	if deltaFloat <= 0 {
 		// If the delta is zero or negative, there's nothing to add.
 		// We still log this, but don't perform increments. We should still consume the delta.
-		log.Printf("INFO: Delta playtime for player %s is %.2f (non-positive). Consuming delta without increment.", playerUUID, deltaFloat)
+		slog.Info("Non-positive delta playtime; consuming it without increment", "player_uuid", playerUUID, "delta", deltaFloat)
 
 		// Clear the delta even if it's non-positive to prevent repeated processing
 		err = pps.redisClient.Del(ctx, deltaKey).Err()
 		if err != nil {
-			log.Printf("WARNING: Failed to clear non-positive delta for player %s: %v", playerUUID, err)
+			slog.Warn("Failed to clear non-positive delta", "player_uuid", playerUUID, "error", err)
 		}
 		return nil
 	}
+	deltaFloat = pps.unit.Round(deltaFloat * playtimeMultiplier(playerUUID, multiplierCmd))
+
+	if pps.teamPlaytimeDisabled {
+		// Team playtime isn't tracked, so skip the team lookup entirely.
+		return pps.incrementPlayerOnly(ctx, playerUUID, deltaFloat, "team playtime disabled")
+	}
 
 	// 3. Get the team ID for the player. This is needed to update team totals.
-	teamID, err := pps.redisClient.Get(ctx, playerTeamKey).Result()
+	teamID, err := pps.getPlayerTeam(ctx, playerUUID)
 	if err == redis.Nil {
 		// If no team ID is found, log a warning but proceed with player playtime increment.
-		log.Printf("WARNING: Team ID key %s not found for player %s. Player playtime will be incremented, but team playtime will not be updated.", playerTeamKey, playerUUID)
-
-		// Execute player playtime increment atomicall
-		pipe := pps.redisClient.Pipeline()
-		playerIncrCmd := pipe.IncrByFloat(ctx, totalPlaytimeKey, deltaFloat)
-
-		_, err := pipe.Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to execute player playtime increment for player %s (no team found): %w", playerUUID, err)
-		}
-		if playerIncrCmd.Err() != nil {
-			return fmt.Errorf("player total playtime increment failed for player %s (no team found): %w", playerUUID, playerIncrCmd.Err())
-		}
-		return nil
+		slog.Warn("Player has no team; incrementing player playtime only", "player_uuid", playerUUID, "key", playerTeamKey)
+		return pps.incrementPlayerOnly(ctx, playerUUID, deltaFloat, "no team found")
 	}
 	if err != nil {
 		return fmt.Errorf("failed to retrieve team ID for player %s from Redis: %w", playerUUID, err)
 	}
+	if err := pps.validateTeam.check(teamID); err != nil {
+		slog.Warn("Not crediting player playtime to their team", "player_uuid", playerUUID, "error", err)
+		return pps.incrementPlayerOnly(ctx, playerUUID, deltaFloat, "unknown team")
+	}
 
 	// Construct the Redis key for the team's total playtime.
 	teamTotalPlaytimeKey := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID)
@@ -134,26 +257,58 @@ func (pps *PlayerPlaytimeStore) IncrementPlayerPlaytime(ctx context.Context, pla
 	// This ensures that either all increments succeed, or none do.
 	// The delta key IS deleted here to consume it after use.
 	pipe := pps.redisClient.Pipeline()
-	playerIncrCmd := pipe.IncrByFloat(ctx, totalPlaytimeKey, deltaFloat)   // Increment player's total playtime
-	teamIncrCmd := pipe.IncrByFloat(ctx, teamTotalPlaytimeKey, deltaFloat) // Increment team's total playtime
-	_, err = pipe.Exec(ctx)                                                // Execute the pipeline
+	playerIncrCmd := pps.unit.IncrBy(ctx, pipe, totalPlaytimeKey, deltaFloat) // Increment player's total playtime
+	// Increment team's total playtime, clamped to the cap in the same step
+	teamIncrCmd := pps.teamCap.incrBy(ctx, pipe, pps.unit, teamTotalPlaytimeKey, deltaFloat)
+	pps.playerLeaderboard.incrBy(ctx, pipe, playerUUID, deltaFloat) // Keep the leaderboard entries in step, if enabled
+	pps.teamLeaderboard.incrBy(ctx, pipe, teamID, deltaFloat)
+	_, err = pipe.Exec(ctx) // Execute the pipeline
 	if err != nil {
 		return fmt.Errorf("failed to execute playtime increments pipeline for player %s (team %s): %w", playerUUID, teamID, err)
 	}
 
 	// Check individual command errors within the pipeline for more granular reporting.
-	if playerIncrCmd.Err() != nil {
-		return fmt.Errorf("player total playtime increment failed for player %s: %w", playerUUID, playerIncrCmd.Err())
+	playerTotal, err := pps.unit.IncrResult(playerIncrCmd)
+	if err != nil {
+		return fmt.Errorf("player total playtime increment failed for player %s: %w", playerUUID, err)
+	}
+	teamTotal, err := pps.unit.IncrResult(teamIncrCmd)
+	if err != nil {
+		return fmt.Errorf("team total playtime increment failed for team %s: %w", teamID, err)
 	}
-	if teamIncrCmd.Err() != nil {
-		return fmt.Errorf("team total playtime increment failed for team %s: %w", teamID, teamIncrCmd.Err())
+	if capped, exceeded := pps.teamCap.Check(teamID, teamTotal, deltaFloat); exceeded {
+		pps.teamLeaderboard.set(ctx, teamID, capped) // The increment already clamped the total itself
 	}
 
+	pps.RecordPlaytimeAudit(ctx, playerUUID, PlaytimeAuditSourceTick, deltaFloat, playerTotal)
+	return nil
+}
+
+// incrementPlayerOnly increments a player's total playtime without touching any team total.
+// reason explains why in error messages.
+func (pps *PlayerPlaytimeStore) incrementPlayerOnly(ctx context.Context, playerUUID string, deltaFloat float64, reason string) error {
+	totalPlaytimeKey := fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID)
+
+	pipe := pps.redisClient.Pipeline()
+	playerIncrCmd := pps.unit.IncrBy(ctx, pipe, totalPlaytimeKey, deltaFloat)
+	pps.playerLeaderboard.incrBy(ctx, pipe, playerUUID, deltaFloat)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to execute player playtime increment for player %s (%s): %w", playerUUID, reason, err)
+	}
+	playerTotal, err := pps.unit.IncrResult(playerIncrCmd)
+	if err != nil {
+		return fmt.Errorf("player total playtime increment failed for player %s (%s): %w", playerUUID, reason, err)
+	}
+	pps.RecordPlaytimeAudit(ctx, playerUUID, PlaytimeAuditSourceTick, deltaFloat, playerTotal)
 	return nil
 }
 
 // GetAllPlayerPlaytimes retrieves all current player total playtime data from Redis.
 // This operation can be resource-intensive in large clusters.
+// If only some nodes fail, the playtimes read from the others are still returned, together with an
+// error wrapping ErrPartialScan and each node's failure.
 func (pps *PlayerPlaytimeStore) GetAllPlayerPlaytimes(ctx context.Context) (map[string]float64, error) {
 	playtimes := make(map[string]float64)
 	var mu sync.Mutex // Protects map writes from concurrent goroutines across cluster nodes.
@@ -161,30 +316,18 @@ func (pps *PlayerPlaytimeStore) GetAllPlayerPlaytimes(ctx context.Context) (map[
 	// Construct the SCAN pattern using the constant, replacing the UUID placeholder with a wildcard.
 	scanPattern := fmt.Sprintf(redisu.PlaytimeKeyPrefix, "*")
 
-	// Iterate over all master nodes in the Redis Cluster to collect data.
-	err := pps.redisClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
-		if client == nil {
-			log.Printf("Warning: Redis Cluster ForEachMaster provided a nil client, skipping node.")
-			return nil
-		}
-
-		iter := client.Scan(ctx, 0, scanPattern, 0).Iterator()
-		for iter.Next(ctx) {
-			key := iter.Val()
-
-			// Extract the player UUID from the key (e.g., "playtime:{uuid}:" -> "uuid").
-			startIdx := strings.Index(key, "{")
-			endIdx := strings.Index(key, "}")
-			if startIdx == -1 || endIdx == -1 || endIdx <= startIdx {
-				log.Printf("Warning: Could not parse UUID from malformed playtime key: %s. Skipping.", key)
+	err := redisu.ScanAllMastersLimited(ctx, pps.redisClient, scanPattern, 0, pps.scanSlots, func(ctx context.Context, client *redis.Client, keys []string) error {
+		for _, key := range keys {
+			playerUUID, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipping malformed playtime key", "key", key)
 				continue
 			}
-			playerUUID := key[startIdx+1 : endIdx] // Extract the UUID without braces
 
 			// Retrieve the playtime value.
-			val, err := client.Get(ctx, key).Float64()
+			val, err := pps.unit.Seconds(client.Get(ctx, key).Result())
 			if err != nil {
-				log.Printf("Warning: Failed to get playtime for player %s (key: %s) from Redis: %v. Skipping.", playerUUID, key, err)
+				slog.Warn("Failed to get player playtime from Redis; skipping", "player_uuid", playerUUID, "key", key, "error", err)
 				continue
 			}
 
@@ -193,9 +336,14 @@ func (pps *PlayerPlaytimeStore) GetAllPlayerPlaytimes(ctx context.Context) (map[
 			playtimes[playerUUID] = val
 			mu.Unlock()
 		}
-		return iter.Err() // Return any error from the iterator.
+		return nil
 	})
 
+	// A failing node must not discard what the other nodes returned.
+	var nodeErr *redisu.NodeScanError
+	if errors.As(err, &nodeErr) {
+		return playtimes, fmt.Errorf("%w: %w", ErrPartialScan, err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan all player playtime data from Redis cluster: %w", err)
 	}
@@ -203,20 +351,61 @@ func (pps *PlayerPlaytimeStore) GetAllPlayerPlaytimes(ctx context.Context) (map[
 	return playtimes, nil
 }
 
+// GetTopPlayers returns the n players with a session in Redis who have the most playtime, highest first.
+// With a player leaderboard configured this is a single sorted-set read; otherwise every playtime key is
+// scanned and sorted.
+func (pps *PlayerPlaytimeStore) GetTopPlayers(ctx context.Context, n int) ([]PlayerPlaytime, error) {
+	if n <= 0 {
+		return []PlayerPlaytime{}, nil
+	}
+	var entries []redis.Z
+	if pps.playerLeaderboard != nil {
+		var err error
+		if entries, err = pps.playerLeaderboard.top(ctx, n); err != nil {
+			return nil, err
+		}
+	} else {
+		playtimes, err := pps.GetAllPlayerPlaytimes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = topFromTotals(playtimes, n)
+	}
+
+	players := make([]PlayerPlaytime, 0, len(entries))
+	for _, entry := range entries {
+		playerUUID, _ := entry.Member.(string)
+		players = append(players, PlayerPlaytime{UUID: playerUUID, Playtime: entry.Score})
+	}
+	return players, nil
+}
+
+// RebuildPlayerLeaderboard replaces the player leaderboard with playtimes, which must be the result of a
+// complete GetAllPlayerPlaytimes scan. It corrects any drift, e.g., from keys that expired instead of being
+// removed with their session. Does nothing if no player leaderboard is configured.
+func (pps *PlayerPlaytimeStore) RebuildPlayerLeaderboard(ctx context.Context, playtimes map[string]float64) error {
+	if pps.playerLeaderboard == nil {
+		return nil
+	}
+	return pps.playerLeaderboard.Rebuild(ctx, playtimes)
+}
+
+// RemoveFromPlayerLeaderboard drops a player from the player leaderboard, e.g., when their session ends.
+func (pps *PlayerPlaytimeStore) RemoveFromPlayerLeaderboard(ctx context.Context, playerUUID string) {
+	pps.playerLeaderboard.remove(ctx, playerUUID)
+}
+
 // SetPlayerDeltaPlaytime stores the latest calculated delta playtime for a player.
 // This delta represents the playtime accumulated in the current session since the last update.
 func (pps *PlayerPlaytimeStore) SetPlayerDeltaPlaytime(ctx context.Context, playerUUID string, deltaPlaytime float64) error {
 	key := fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID)
 
-	// Set a reasonable TTL for delta playtime. This ensures that old deltas are cleaned up
-	// if they are not processed for some reason (e.g., service crash before processing).
-	deltaTTL := 24 * time.Hour // Sufficiently long for pending processing.
-	err := pps.redisClient.Set(ctx, key, deltaPlaytime, deltaTTL).Err()
+	err := pps.redisClient.Set(ctx, key, deltaPlaytime, deltaPlaytimeKeyTTL).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set delta playtime for player %s in Redis: %w", playerUUID, err)
 	}
 
-	log.Printf("Delta playtime set for player %s: %.2f seconds (TTL: %s).", playerUUID, deltaPlaytime, deltaTTL)
+	slog.Info("Set player delta playtime", "player_uuid", playerUUID, "delta", deltaPlaytime, "ttl", deltaPlaytimeKeyTTL.String())
 	return nil
 }
 
@@ -237,15 +426,133 @@ func (pps *PlayerPlaytimeStore) GetPlayerDeltaPlaytime(ctx context.Context, play
 	return val, nil
 }
 
-// SetPlayerTeam assigns a player to a specific team in Redis.
+// SetPlayerTeam assigns a player to a specific team in Redis, if the team validator accepts it.
 // The team assignment typically doesn't expire unless the player is removed from the team.
 func (pps *PlayerPlaytimeStore) SetPlayerTeam(ctx context.Context, playerUUID string, teamID string) error {
+	if err := pps.validateTeam.check(teamID); err != nil {
+		return err
+	}
 	key := fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID)
 	// Set with no expiration (0 duration) as team assignment is usually persistent.
 	err := pps.redisClient.Set(ctx, key, teamID, 0).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set team ID for player %s in Redis: %w", playerUUID, err)
 	}
-	log.Printf("Player %s assigned to team %s.", playerUUID, teamID)
+	pps.InvalidatePlayerTeam(playerUUID)
+	slog.Info("Player assigned to team", "player_uuid", playerUUID, "team", teamID)
 	return nil
 }
+
+// RemovePlayerTeam deletes a player's team assignment from Redis, so subsequent playtime
+// is no longer credited to that team. Returns false if the player had no team assigned.
+// Other game-service instances may keep crediting the old team until their cached lookup expires.
+func (pps *PlayerPlaytimeStore) RemovePlayerTeam(ctx context.Context, playerUUID string) (bool, error) {
+	key := fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID)
+	deletedCount, err := pps.redisClient.Del(ctx, key).Result()
+	pps.InvalidatePlayerTeam(playerUUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove team ID for player %s from Redis: %w", playerUUID, err)
+	}
+	if deletedCount > 0 {
+		slog.Info("Player unassigned from their team", "player_uuid", playerUUID)
+	}
+	return deletedCount > 0, nil
+}
+
+// GetPlayerTeams returns the team ID of each given player that has one assigned; players without
+// a team are omitted. Cached assignments are reused and the rest are fetched in a single pipeline.
+func (pps *PlayerPlaytimeStore) GetPlayerTeams(ctx context.Context, playerUUIDs []string) (map[string]string, error) {
+	teams := make(map[string]string, len(playerUUIDs))
+	misses := make(map[string]*redis.StringCmd)
+	now := time.Now()
+	gen := pps.teamCacheGen.Load()
+
+	pipe := pps.redisClient.Pipeline()
+	for _, playerUUID := range playerUUIDs {
+		if entry, ok := pps.teamCache.Load(playerUUID); ok {
+			cached := entry.(cachedPlayerTeam)
+			if now.Before(cached.expiresAt) {
+				teams[playerUUID] = cached.teamID
+				continue
+			}
+		}
+		misses[playerUUID] = pipe.Get(ctx, fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID))
+	}
+	if len(misses) == 0 {
+		return teams, nil
+	}
+
+	// Exec returns the first command error, which is redis.Nil whenever a player has no team; check each command instead.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to fetch team IDs for %d players from Redis: %w", len(misses), err)
+	}
+	for playerUUID, cmd := range misses {
+		teamID, err := cmd.Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch team ID for player %s from Redis: %w", playerUUID, err)
+		}
+		teams[playerUUID] = teamID
+		pps.cachePlayerTeam(playerUUID, teamID, gen)
+	}
+	return teams, nil
+}
+
+// InvalidatePlayerTeam drops a player's cached team assignment so the next lookup reads from Redis.
+// It must be called whenever the player's team key is changed or deleted outside of SetPlayerTeam.
+func (pps *PlayerPlaytimeStore) InvalidatePlayerTeam(playerUUID string) {
+	pps.teamCacheGen.Add(1)
+	pps.teamCache.Delete(playerUUID)
+}
+
+// cachePlayerTeam caches a team assignment read from Redis when the cache generation was gen. If any
+// invalidation happened since, the read may predate the change it was for, so the entry is dropped
+// again rather than kept stale until it expires.
+func (pps *PlayerPlaytimeStore) cachePlayerTeam(playerUUID, teamID string, gen uint64) {
+	entry := cachedPlayerTeam{teamID: teamID, expiresAt: time.Now().Add(playerTeamCacheTTL)}
+	pps.teamCache.Store(playerUUID, entry)
+	if pps.teamCacheGen.Load() != gen {
+		pps.teamCache.CompareAndDelete(playerUUID, entry)
+	}
+}
+
+// GetPlayerTeam returns a player's current team ID from Redis (or the team cache).
+// Returns an error wrapping redisu.ErrRedisKeyNotFound if the player has no team assigned.
+func (pps *PlayerPlaytimeStore) GetPlayerTeam(ctx context.Context, playerUUID string) (string, error) {
+	teamID, err := pps.getPlayerTeam(ctx, playerUUID)
+	if err == redis.Nil {
+		return "", fmt.Errorf("no team assigned to player %s: %w", playerUUID, redisu.ErrRedisKeyNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get team for player %s from Redis: %w", playerUUID, err)
+	}
+	return teamID, nil
+}
+
+// getPlayerTeam returns a player's team ID, serving it from the in-memory cache when possible.
+// Returns redis.Nil if the player has no team assigned; misses are not cached.
+func (pps *PlayerPlaytimeStore) getPlayerTeam(ctx context.Context, playerUUID string) (string, error) {
+	if entry, ok := pps.teamCache.Load(playerUUID); ok {
+		cached := entry.(cachedPlayerTeam)
+		if time.Now().Before(cached.expiresAt) {
+			return cached.teamID, nil
+		}
+		pps.teamCache.Delete(playerUUID)
+	}
+
+	// Concurrent misses share one GET. It runs without the caller's context, so one caller giving up
+	// doesn't fail the others.
+	teamID, err, _ := pps.teamLookups.Do(playerUUID, func() (any, error) {
+		gen := pps.teamCacheGen.Load()
+		key := fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID)
+		teamID, err := pps.redisClient.Get(context.WithoutCancel(ctx), key).Result()
+		if err != nil {
+			return "", err
+		}
+		pps.cachePlayerTeam(playerUUID, teamID, gen)
+		return teamID, nil
+	})
+	return teamID.(string), err
+}
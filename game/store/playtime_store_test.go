@@ -0,0 +1,74 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestSetPlayerTeamLogsStructuredFields(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	_, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	if err := pps.SetPlayerTeam(context.Background(), "player-1", "RED"); err != nil {
+		t.Fatalf("SetPlayerTeam: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log output %q is not a JSON line: %v", logs.String(), err)
+	}
+	if entry["player_uuid"] != "player-1" || entry["team"] != "RED" {
+		t.Errorf("log entry = %v, want player_uuid player-1 and team RED", entry)
+	}
+}
+
+func TestCachePlayerTeamDropsFillRacingInvalidation(t *testing.T) {
+	_, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+
+	gen := pps.teamCacheGen.Load()              // A lookup reads RED from Redis...
+	pps.InvalidatePlayerTeam("player-1")        // ...while the player is moved to another team
+	pps.cachePlayerTeam("player-1", "RED", gen) // ...and then fills the cache
+
+	if entry, ok := pps.teamCache.Load("player-1"); ok {
+		t.Errorf("cached %+v from a lookup that raced an invalidation", entry)
+	}
+
+	pps.cachePlayerTeam("player-1", "BLUE", pps.teamCacheGen.Load())
+	if _, ok := pps.teamCache.Load("player-1"); !ok {
+		t.Error("lookup without a concurrent invalidation was not cached")
+	}
+}
+
+func TestGetPlayerTeamConcurrentMisses(t *testing.T) {
+	_, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	ctx := context.Background()
+	if err := pps.SetPlayerTeam(ctx, "player-1", "RED"); err != nil {
+		t.Fatalf("SetPlayerTeam: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if teamID, err := pps.GetPlayerTeam(ctx, "player-1"); err != nil || teamID != "RED" {
+				t.Errorf("GetPlayerTeam = %q, %v; want RED", teamID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := pps.GetPlayerTeam(ctx, "player-2"); err == nil {
+		t.Error("GetPlayerTeam found a team for a player without one")
+	}
+}
@@ -0,0 +1,71 @@
+// game/store/session.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionInit is the Redis state a new session starts with.
+type SessionInit struct {
+	Playtime      float64   // Total playtime carried over from the player's profile
+	DeltaPlaytime float64   // Playtime credited per tick
+	Multiplier    float64   // Factor applied to each tick's playtime; 0 means 1.0
+	TeamID        string    // Team the playtime is also credited to; empty leaves the team key untouched
+	Start         time.Time // Session start, stored as Unix seconds
+}
+
+// StartSession writes every key of a new session (total and delta playtime, multiplier, team, session
+// start, last activity and online status) in one MULTI/EXEC. All of them share the player's hash tag and
+// therefore a node, so either the whole session is set up or none of it is: a player is never online
+// without their playtime loaded. Follow-up bookkeeping outside the transaction (the online counter and
+// leaderboard) happens only once it has succeeded.
+func StartSession(ctx context.Context, ops *OnlinePlayersStore, pps *PlayerPlaytimeStore, playerUUID string, init SessionInit) error {
+	if init.TeamID != "" {
+		if err := pps.validateTeam.check(init.TeamID); err != nil {
+			return err
+		}
+	}
+	startTimestamp := init.Start.Unix()
+	var sessionStartTTL time.Duration
+	if ops.maxSessionDuration > 0 {
+		sessionStartTTL = ops.maxSessionDuration + ops.onlineTTL // See setSessionStart
+	}
+
+	pipe := ops.client.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(redisu.PlaytimeKeyPrefix, playerUUID), pps.unit.Value(init.Playtime), playtimeKeyTTL)
+	pipe.Set(ctx, fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, playerUUID), init.DeltaPlaytime, deltaPlaytimeKeyTTL)
+	if init.Multiplier > 0 && init.Multiplier != 1.0 {
+		pipe.Set(ctx, fmt.Sprintf(redisu.MultiplierKeyPrefix, playerUUID), init.Multiplier, deltaPlaytimeKeyTTL)
+	} else {
+		pipe.Del(ctx, fmt.Sprintf(redisu.MultiplierKeyPrefix, playerUUID)) // Don't inherit one from a previous session
+	}
+	if init.TeamID != "" {
+		pipe.Set(ctx, fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID), init.TeamID, 0)
+	}
+	pipe.Set(ctx, fmt.Sprintf(redisu.SessionStartKeyPrefix, playerUUID), startTimestamp, sessionStartTTL)
+	if ops.idleTimeout > 0 {
+		pipe.Set(ctx, fmt.Sprintf(redisu.LastActivityKeyPrefix, playerUUID), startTimestamp, ops.idleTimeout)
+	}
+	onlineCmd := pipe.SetArgs(ctx, fmt.Sprintf(redisu.OnlineKeyPrefix, playerUUID), startTimestamp, redis.SetArgs{TTL: ops.onlineTTL, Get: true})
+	// Exec reports redis.Nil when the online key did not exist before, which is the usual case.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to start session for player %s in Redis: %w", playerUUID, err)
+	}
+
+	if onlineCmd.Err() == redis.Nil {
+		ops.adjustOnlineCount(ctx, 1)
+	}
+	if init.TeamID != "" {
+		pps.InvalidatePlayerTeam(playerUUID)
+	}
+	pps.playerLeaderboard.set(ctx, playerUUID, init.Playtime)
+
+	slog.Info("Player session started", "player_uuid", playerUUID, "session_start", init.Start, "playtime", init.Playtime, "team", init.TeamID, "ttl", ops.onlineTTL.String())
+	return nil
+}
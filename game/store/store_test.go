@@ -0,0 +1,17 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient starts a miniredis server and returns it with a cluster client connected to it.
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.ClusterClient) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
@@ -0,0 +1,109 @@
+// game/store/team_playtime_cap.go
+package store
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis" // Alias for Redis constants
+	"github.com/redis/go-redis/v9"
+)
+
+// teamPlaytimeCapWarnRatio is the fraction of the cap at which a team is reported as approaching it.
+const teamPlaytimeCapWarnRatio = 0.9
+
+// TeamPlaytimeCap bounds team totals so long-running seasons never reach magnitudes where float64
+// increments lose precision. It is shared by every store that writes team totals.
+type TeamPlaytimeCap struct {
+	limit           float64
+	capWarned       sync.Map // teamID -> struct{}, teams already reported as approaching the cap
+	precisionWarned sync.Map // teamID -> struct{}, teams already reported as losing precision
+}
+
+// NewTeamPlaytimeCap creates a cap of limit ticks per team.
+func NewTeamPlaytimeCap(limit float64) *TeamPlaytimeCap {
+	return &TeamPlaytimeCap{limit: limit}
+}
+
+// Limit returns the configured cap.
+func (c *TeamPlaytimeCap) Limit() float64 {
+	return c.limit
+}
+
+// Check inspects a team total after delta was added to it. It warns (once per team until Reset) when
+// the total approaches the cap or when delta is too small to be represented at this magnitude, and
+// returns the value the total must be clamped to and whether clamping is needed.
+// A nil cap never clamps.
+func (c *TeamPlaytimeCap) Check(teamID string, total float64, delta float64) (float64, bool) {
+	if c == nil {
+		return total, false
+	}
+
+	if delta > 0 && precisionLost(total, delta) {
+		if _, alreadyWarned := c.precisionWarned.LoadOrStore(teamID, struct{}{}); !alreadyWarned {
+			slog.Warn("Team total playtime is too large to add increments precisely; they are being rounded", "team", teamID, "total", total, "delta", delta)
+		}
+	}
+
+	if total >= c.limit*teamPlaytimeCapWarnRatio {
+		if _, alreadyWarned := c.capWarned.LoadOrStore(teamID, struct{}{}); !alreadyWarned {
+			slog.Warn("Team total playtime is approaching the cap", "team", teamID, "total", total, "cap", c.limit)
+		}
+	}
+
+	if total > c.limit {
+		slog.Warn("Team total playtime exceeds the cap; clamping. Reset the team's playtime to continue counting", "team", teamID, "total", total, "cap", c.limit)
+		return c.limit, true
+	}
+	return total, false
+}
+
+// Reset forgets that a team was warned about, e.g., after its total was reset.
+func (c *TeamPlaytimeCap) Reset(teamID string) {
+	if c == nil {
+		return
+	}
+	c.capWarned.Delete(teamID)
+	c.precisionWarned.Delete(teamID)
+}
+
+// incrementCappedScript increments the total at KEYS[1] with the command in ARGV[1] (INCRBY or
+// INCRBYFLOAT) by ARGV[2] and, if the result exceeds the cap ARGV[3], sets the total to the cap. Both
+// happen in one step, so no other increment can land in between and be lost. It returns the total
+// before clamping, as the increment command does.
+var incrementCappedScript = redis.NewScript(`
+local total = redis.call(ARGV[1], KEYS[1], ARGV[2])
+if tonumber(total) > tonumber(ARGV[3]) then
+	redis.call('SET', KEYS[1], ARGV[3])
+end
+return total
+`)
+
+// cappedIncrementer runs increments and scripts; clients and pipelines both implement it.
+type cappedIncrementer interface {
+	redisu.Doer
+	redis.Scripter
+}
+
+// incrBy increments the team total at key by seconds with c, which may be a pipeline, clamping it to
+// the cap in the same step. Read the total before clamping with unit.IncrResult, and pass it to Check
+// to find the clamped value. A nil cap increments without clamping.
+func (c *TeamPlaytimeCap) incrBy(ctx context.Context, r cappedIncrementer, unit redisu.PlaytimeUnit, key string, seconds float64) *redis.Cmd {
+	if c == nil {
+		return unit.IncrBy(ctx, r, key, seconds)
+	}
+	// Eval rather than Run: in a pipeline a missing script is only reported once it is too late to retry.
+	if unit == redisu.PlaytimeMillis {
+		return incrementCappedScript.Eval(ctx, r, []string{key}, "incrby", redisu.ToMillis(seconds), unit.Value(c.limit))
+	}
+	return incrementCappedScript.Eval(ctx, r, []string{key}, "incrbyfloat", seconds, unit.Value(c.limit))
+}
+
+// precisionLost reports whether adding delta to a total of this magnitude is rounded by more than 1%,
+// i.e. the gap between adjacent float64 values at total exceeds a hundredth of delta.
+func precisionLost(total float64, delta float64) bool {
+	spacing := math.Nextafter(total, math.Inf(1)) - total
+	return spacing > delta/100
+}
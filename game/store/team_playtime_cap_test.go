@@ -0,0 +1,75 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+)
+
+func TestIncrementTeamPlaytimeClampsToCap(t *testing.T) {
+	for _, unit := range []redisu.PlaytimeUnit{redisu.PlaytimeSeconds, redisu.PlaytimeMillis} {
+		t.Run(fmt.Sprint(unit), func(t *testing.T) {
+			_, client := newTestClient(t)
+			tps := NewTeamPlaytimeStore(client)
+			tps.SetPlaytimeUnit(unit)
+			tps.SetTeamPlaytimeCap(NewTeamPlaytimeCap(100))
+			ctx := context.Background()
+
+			if err := tps.IncrementTeamPlaytime(ctx, "RED", 60); err != nil {
+				t.Fatalf("IncrementTeamPlaytime: %v", err)
+			}
+			if err := tps.IncrementTeamPlaytime(ctx, "RED", 60.5); err != nil {
+				t.Fatalf("IncrementTeamPlaytime: %v", err)
+			}
+			if got, err := tps.GetTeamPlaytime(ctx, "RED"); err != nil || got != 100 {
+				t.Errorf("total = %v (err %v), want the cap 100", got, err)
+			}
+		})
+	}
+}
+
+func TestIncrementPlayerPlaytimeClampsTeamToCap(t *testing.T) {
+	mr, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	pps.SetPlaytimeUnit(redisu.PlaytimeMillis)
+	pps.SetTeamPlaytimeCap(NewTeamPlaytimeCap(100))
+	ctx := context.Background()
+	if err := pps.SetPlayerTeam(ctx, "player-1", "RED"); err != nil {
+		t.Fatalf("SetPlayerTeam: %v", err)
+	}
+	mr.Set(fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, "RED"), "99500")
+	mr.Set(fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, "player-1"), "1")
+
+	if err := pps.IncrementPlayerPlaytime(ctx, "player-1"); err != nil {
+		t.Fatalf("IncrementPlayerPlaytime: %v", err)
+	}
+	if got, _ := mr.Get(fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, "RED")); got != "100000" {
+		t.Errorf("team total = %s ms, want the cap 100000", got)
+	}
+	if got, err := pps.GetPlayerPlaytime(ctx, "player-1"); err != nil || got != 1 {
+		t.Errorf("player total = %v (err %v), want 1", got, err)
+	}
+}
+
+func TestTeamPlaytimeCapWarnsSeparately(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	c := NewTeamPlaytimeCap(redisu.MaxExactTeamPlaytime)
+	c.Check("RED", 1<<40, 1e-9) // Loses precision, far from the cap
+	c.Check("RED", redisu.MaxExactTeamPlaytime*0.95, 1)
+
+	if !strings.Contains(logs.String(), "too large to add") {
+		t.Errorf("no precision warning in %q", logs.String())
+	}
+	if !strings.Contains(logs.String(), "approaching the cap") {
+		t.Errorf("precision warning suppressed the approaching-cap warning: %q", logs.String())
+	}
+}
@@ -4,8 +4,7 @@ package store
 import (
 	"context"
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -19,6 +18,14 @@ import (
 // with a persistent Team Stats microservice.
 type TeamPlaytimeStore struct {
 	redisClient *redis.ClusterClient
+	teamCap     *TeamPlaytimeCap // Optional; bounds the totals written by this store
+	leaderboard *Leaderboard     // Optional; sorted-set index of the totals, see GetTopTeams
+	allowNeg    bool             // If set, totals may go below zero instead of being floored at it
+	resetBad    bool             // If set, non-numeric totals are overwritten with 0 when read, see ResetCorruptTotals
+
+	validateTeam TeamValidator // Optional; checks every team written, see SetTeamValidator
+
+	unit redisu.PlaytimeUnit // How totals are stored, see SetPlaytimeUnit
 }
 
 // NewTeamPlaytimeStore creates a new TeamPlaytimeStore instance.
@@ -29,36 +36,105 @@ func NewTeamPlaytimeStore(redisClient *redis.ClusterClient) *TeamPlaytimeStore {
 	}
 }
 
+// SetTeamPlaytimeCap bounds the team totals written by this store.
+func (tps *TeamPlaytimeStore) SetTeamPlaytimeCap(teamCap *TeamPlaytimeCap) {
+	tps.teamCap = teamCap
+}
+
+// SetTeamValidator makes this store refuse to write the total of a team v rejects.
+func (tps *TeamPlaytimeStore) SetTeamValidator(v TeamValidator) {
+	tps.validateTeam = v
+}
+
+// SetTeamLeaderboard makes this store keep lb up to date and serve GetTopTeams from it.
+func (tps *TeamPlaytimeStore) SetTeamLeaderboard(lb *Leaderboard) {
+	tps.leaderboard = lb
+}
+
+// SetPlaytimeUnit sets how this store reads and writes team totals in Redis. The default is
+// redisu.PlaytimeSeconds; it must match the PlayerPlaytimeStore incrementing the same totals.
+func (tps *TeamPlaytimeStore) SetPlaytimeUnit(unit redisu.PlaytimeUnit) {
+	tps.unit = unit
+}
+
+// AllowNegativeTotals lets adjustments drive team totals below zero. By default this store floors
+// every total it writes at zero, so a large correction can't leave a negative total on the leaderboards.
+func (tps *TeamPlaytimeStore) AllowNegativeTotals() {
+	tps.allowNeg = true
+}
+
+// ResetCorruptTotals makes reads overwrite a non-numeric team total with 0, so increments on the key
+// work again. The next team sync recomputes the total from the player totals.
+func (tps *TeamPlaytimeStore) ResetCorruptTotals() {
+	tps.resetBad = true
+}
+
+// recoverCorruptTotal logs a team total that failed to parse and, if enabled, resets it to 0.
+func (tps *TeamPlaytimeStore) recoverCorruptTotal(ctx context.Context, teamID string) {
+	key := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID)
+	logCorruptPlaytime(ctx, tps.redisClient, key)
+	if !tps.resetBad {
+		return
+	}
+	if err := tps.redisClient.Set(ctx, key, 0, redis.KeepTTL).Err(); err != nil {
+		slog.Error("Failed to reset corrupt team total playtime", "team", teamID, "error", err)
+		return
+	}
+	tps.leaderboard.set(ctx, teamID, 0)
+	slog.Warn("Reset corrupt team total playtime to 0", "team", teamID)
+}
+
+// floorTotal returns the total to store for teamID: unchanged if negative totals are allowed or it is
+// not negative, 0 otherwise. It reports whether the total was clamped.
+func (tps *TeamPlaytimeStore) floorTotal(teamID string, total float64) (float64, bool) {
+	if tps.allowNeg || total >= 0 {
+		return total, false
+	}
+	slog.Warn("Team total playtime would be negative; clamping it to 0", "team", teamID, "total", total)
+	return 0, true
+}
+
 // SetTeamPlaytime sets a team's total accumulated playtime in Redis.
 // This is typically used to initialize a team's playtime or to overwrite it
 // (e.g., after loading from a persistent store or a manual adjustment).
 func (tps *TeamPlaytimeStore) SetTeamPlaytime(ctx context.Context, teamID string, totalPlaytime float64) error {
+	if err := tps.validateTeam.check(teamID); err != nil {
+		return err
+	}
 	// Construct the Redis key using the predefined constant.
 	key := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID)
+	totalPlaytime, _ = tps.teamCap.Check(teamID, totalPlaytime, 0)
+	totalPlaytime, _ = tps.floorTotal(teamID, totalPlaytime)
 
 	// Set the team's total playtime. A TTL of 0 means the key will not expire automatically.
 	// This implies that team playtime is considered persistent in Redis until explicitly deleted,
 	// or until a periodic sync mechanism updates it from a long-term store.
-	err := tps.redisClient.Set(ctx, key, totalPlaytime, 0).Err() // 0 duration for no expiration
+	err := tps.redisClient.Set(ctx, key, tps.unit.Value(totalPlaytime), 0).Err() // 0 duration for no expiration
 	if err != nil {
 		return fmt.Errorf("failed to set total playtime for team %s in Redis: %w", teamID, err)
 	}
+	tps.leaderboard.set(ctx, teamID, totalPlaytime)
 
-	log.Printf("Successfully set total playtime for team %s to %.2f seconds in Redis.", teamID, totalPlaytime)
+	slog.Info("Set team total playtime", "team", teamID, "playtime", totalPlaytime)
 	return nil
 }
 
 // GetTeamPlaytime retrieves a team's current total playtime from Redis.
-// Returns 0.0 and nil if the key does not exist (team has no recorded playtime yet).
+// Returns 0.0 and nil if the key does not exist (team has no recorded playtime yet), and also if it
+// holds a non-numeric value, which is logged (and reset, see ResetCorruptTotals).
 func (tps *TeamPlaytimeStore) GetTeamPlaytime(ctx context.Context, teamID string) (float64, error) {
 	// Construct the Redis key using the predefined constant.
 	key := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID)
 
-	val, err := tps.redisClient.Get(ctx, key).Float64()
+	val, err := tps.unit.Seconds(tps.redisClient.Get(ctx, key).Result())
 	if err == redis.Nil {
 		// If the key doesn't exist, it means the team has 0 playtime in the current session/cache.
 		return 0.0, nil
 	}
+	if isCorruptValue(err) {
+		tps.recoverCorruptTotal(ctx, teamID)
+		return 0.0, nil
+	}
 	if err != nil {
 		return 0.0, fmt.Errorf("failed to retrieve total playtime for team %s from Redis: %w", teamID, err)
 	}
@@ -66,10 +142,42 @@ func (tps *TeamPlaytimeStore) GetTeamPlaytime(ctx context.Context, teamID string
 	return val, nil
 }
 
+// GetTeamPlaytimes retrieves several teams' total playtimes from Redis in a single pipeline.
+// Teams with no recorded playtime or a non-numeric one are returned as 0.0, as in GetTeamPlaytime.
+func (tps *TeamPlaytimeStore) GetTeamPlaytimes(ctx context.Context, teamIDs []string) (map[string]float64, error) {
+	pipe := tps.redisClient.Pipeline()
+	cmds := make(map[string]*redis.StringCmd, len(teamIDs))
+	for _, teamID := range teamIDs {
+		cmds[teamID] = pipe.Get(ctx, fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID))
+	}
+	// Exec returns redis.Nil whenever a team has no playtime yet; check each command instead.
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to retrieve total playtime for %d teams from Redis: %w", len(cmds), err)
+	}
+
+	playtimes := make(map[string]float64, len(cmds))
+	for teamID, cmd := range cmds {
+		val, err := tps.unit.Seconds(cmd.Result())
+		if err == redis.Nil {
+			val = 0.0
+		} else if isCorruptValue(err) {
+			tps.recoverCorruptTotal(ctx, teamID)
+			val = 0.0
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to retrieve total playtime for team %s from Redis: %w", teamID, err)
+		}
+		playtimes[teamID] = val
+	}
+	return playtimes, nil
+}
+
 // IncrementTeamPlaytime atomically increments a team's total playtime in Redis.
 // This is the primary method for updating team playtime during gameplay,
 // typically called when a player from that team logs off and their session playtime is calculated.
 func (tps *TeamPlaytimeStore) IncrementTeamPlaytime(ctx context.Context, teamID string, additionalPlaytime float64) error {
+	if err := tps.validateTeam.check(teamID); err != nil {
+		return err
+	}
 	// Set a reasonable TTL for in-session playtime keys. This acts as a fallback
 	// if the team playtime is not regularly updated or persisted elsewhere.
 	// This TTL applies to the key itself, ensuring it doesn't stay forever if not touched.
@@ -78,23 +186,47 @@ func (tps *TeamPlaytimeStore) IncrementTeamPlaytime(ctx context.Context, teamID
 	// Construct the Redis key using the predefined constant.
 	key := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID)
 
-	// Use IncrByFloat to atomically increment the playtime.
+	// Use INCRBYFLOAT (or INCRBY for millisecond totals) to atomically increment the playtime.
 	// This command is safe for concurrent updates.
-	currentPlaytime, err := tps.redisClient.IncrByFloat(ctx, key, additionalPlaytime).Result()
+	// The total is clamped to the cap in the same step.
+	currentPlaytime, err := tps.unit.IncrResult(tps.teamCap.incrBy(ctx, tps.redisClient, tps.unit, key, additionalPlaytime))
 	if err != nil {
 		return fmt.Errorf("failed to increment playtime for team %s in Redis: %w", teamID, err)
 	}
+	currentPlaytime, _ = tps.teamCap.Check(teamID, currentPlaytime, additionalPlaytime)
+	if floored, clamped := tps.floorTotal(teamID, currentPlaytime); clamped {
+		if err := tps.redisClient.Set(ctx, key, floored, 0).Err(); err != nil {
+			return fmt.Errorf("failed to clamp playtime for team %s to zero: %w", teamID, err)
+		}
+		currentPlaytime = floored
+	}
+	tps.leaderboard.set(ctx, teamID, currentPlaytime)
 
 	// After incrementing, refresh the TTL for the key. This ensures that active teams'
 	// playtime keys don't expire prematurely if the session is long.
 	err = tps.redisClient.Expire(ctx, key, playtimeTTL).Err()
 	if err != nil {
-		log.Printf("Warning: Failed to refresh TTL for team %s playtime key in Redis: %v", teamID, err)
+		slog.Warn("Failed to refresh TTL of team playtime key", "team", teamID, "error", err)
 		// Do not return an error here, as the increment itself was successful.
 		// This warning indicates a potential caching issue, not a data integrity one.
 	}
 
-	log.Printf("Successfully incremented playtime for team %s by %.2f seconds. New total: %.2f.", teamID, additionalPlaytime, currentPlaytime)
+	slog.Info("Incremented team playtime", "team", teamID, "increment", additionalPlaytime, "total", currentPlaytime)
+	return nil
+}
+
+// ResetTeamPlaytime sets a team's total playtime back to zero in Redis and clears any cap warnings for it.
+func (tps *TeamPlaytimeStore) ResetTeamPlaytime(ctx context.Context, teamID string) error {
+	if err := tps.validateTeam.check(teamID); err != nil {
+		return err
+	}
+	key := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamID)
+	if err := tps.redisClient.Set(ctx, key, 0, 0).Err(); err != nil {
+		return fmt.Errorf("failed to reset playtime for team %s in Redis: %w", teamID, err)
+	}
+	tps.teamCap.Reset(teamID)
+	tps.leaderboard.set(ctx, teamID, 0)
+	slog.Info("Reset team total playtime", "team", teamID)
 	return nil
 }
 
@@ -108,11 +240,12 @@ func (tps *TeamPlaytimeStore) DeleteTeamPlaytime(ctx context.Context, teamID str
 	if err != nil {
 		return fmt.Errorf("failed to delete playtime record for team %s from Redis: %w", teamID, err)
 	}
+	tps.leaderboard.remove(ctx, teamID)
 
 	if deletedCount > 0 {
-		log.Printf("Successfully deleted playtime record for team %s from Redis.", teamID)
+		slog.Info("Deleted team playtime record", "team", teamID)
 	} else {
-		log.Printf("No playtime record found for team %s in Redis to delete.", teamID)
+		slog.Info("No team playtime record to delete", "team", teamID)
 	}
 	return nil
 }
@@ -125,37 +258,21 @@ func (tps *TeamPlaytimeStore) GetAllTeamPlaytimes(ctx context.Context) (map[stri
 	teamPlaytimes := make(map[string]float64)
 	var mu sync.Mutex // Protects the 'teamPlaytimes' map during concurrent writes from different cluster nodes.
 
-	// Construct the SCAN pattern using the constant, replacing the teamID placeholder with a wildcard.
+	// The pattern "team_total_playtime:{*}:" ensures we only get keys matching our format.
 	scanPattern := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, "*")
 
-	// Use ForEachMaster to iterate over all master nodes in the Redis Cluster.
-	// This ensures that you gather all data across all shards.
-	err := tps.redisClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
-		if client == nil {
-			log.Printf("Warning: Redis Cluster ForEachMaster provided a nil client; skipping node.")
-			return nil // Skip this iteration if the client is unexpectedly nil.
-		}
-
-		// Use SCAN to iterate through keys on the current master node.
-		// The pattern "team_total_playtime:{*}:" ensures we only get keys matching our format.
-		iter := client.Scan(ctx, 0, scanPattern, 0).Iterator()
-		for iter.Next(ctx) {
-			key := iter.Val()
-
-			// Extract the TeamID from the key (e.g., "team_total_playtime:{teamID}:" -> "teamID").
-			// This string manipulation assumes the fixed format of `redisu.TeamTotalPlaytimePrefix`.
-			startIdx := strings.Index(key, "{")
-			endIdx := strings.Index(key, "}")
-			if startIdx == -1 || endIdx == -1 || endIdx <= startIdx {
-				log.Printf("Warning: Could not parse TeamID from malformed team playtime key: %s. Skipping.", key)
+	err := redisu.ScanAllMasters(ctx, tps.redisClient, scanPattern, 0, func(ctx context.Context, client *redis.Client, keys []string) error {
+		for _, key := range keys {
+			teamID, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipping malformed team playtime key", "key", key)
 				continue
 			}
-			teamID := key[startIdx+1 : endIdx] // Extract the TeamID without braces
 
 			// Retrieve the playtime value for the found key.
-			val, err := client.Get(ctx, key).Float64()
+			val, err := tps.unit.Seconds(client.Get(ctx, key).Result())
 			if err != nil {
-				log.Printf("Warning: Failed to get playtime for team %s (key: %s) from Redis: %v. Skipping.", teamID, key, err)
+				slog.Warn("Failed to get team playtime from Redis; skipping", "team", teamID, "key", key, "error", err)
 				continue
 			}
 
@@ -164,7 +281,7 @@ func (tps *TeamPlaytimeStore) GetAllTeamPlaytimes(ctx context.Context) (map[stri
 			teamPlaytimes[teamID] = val
 			mu.Unlock()
 		}
-		return iter.Err() // Return any error encountered by the iterator.
+		return nil
 	})
 
 	if err != nil {
@@ -173,3 +290,31 @@ func (tps *TeamPlaytimeStore) GetAllTeamPlaytimes(ctx context.Context) (map[stri
 
 	return teamPlaytimes, nil
 }
+
+// GetTopTeams returns the n teams with the most playtime, highest first. With a leaderboard
+// configured this is a single sorted-set read; otherwise every team key is scanned and sorted.
+func (tps *TeamPlaytimeStore) GetTopTeams(ctx context.Context, n int) ([]TeamPlaytime, error) {
+	if n <= 0 {
+		return []TeamPlaytime{}, nil
+	}
+	var entries []redis.Z
+	if tps.leaderboard != nil {
+		var err error
+		if entries, err = tps.leaderboard.top(ctx, n); err != nil {
+			return nil, err
+		}
+	} else {
+		totals, err := tps.GetAllTeamPlaytimes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = topFromTotals(totals, n)
+	}
+
+	teams := make([]TeamPlaytime, 0, len(entries))
+	for _, entry := range entries {
+		teamID, _ := entry.Member.(string)
+		teams = append(teams, TeamPlaytime{TeamID: teamID, Playtime: entry.Score})
+	}
+	return teams, nil
+}
@@ -0,0 +1,189 @@
+// game/store/team_rename.go
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// ReassignTeam moves every player assigned to oldTeamID in Redis to newTeamID, e.g., after the team was
+// renamed, and returns how many were moved. Each assignment is changed in an optimistic transaction, so
+// a player reassigned in the meantime keeps their new team. If only some nodes could be scanned, the
+// players found are still moved and the scan error is returned with the count.
+// Other game-service instances keep crediting the old team until their cached lookups expire, unless
+// told about the rename with PublishTeamRename.
+func (pps *PlayerPlaytimeStore) ReassignTeam(ctx context.Context, oldTeamID, newTeamID string) (int, error) {
+	if err := pps.validateTeam.check(newTeamID); err != nil {
+		return 0, err
+	}
+	var matched []string
+	var mu sync.Mutex // Protects matched across cluster nodes
+
+	scanErr := redisu.ScanAllMasters(ctx, pps.redisClient, fmt.Sprintf(redisu.PlayerTeamKeyPrefix, "*"), 0, func(ctx context.Context, node *redis.Client, keys []string) error {
+		pipe := node.Pipeline()
+		teamCmds := make([]*redis.StringCmd, len(keys))
+		for i, key := range keys {
+			teamCmds[i] = pipe.Get(ctx, key)
+		}
+		// Exec returns redis.Nil if a key expired since the scan; check each command instead.
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		var found []string
+		for i, key := range keys {
+			if teamCmds[i].Val() != oldTeamID {
+				continue
+			}
+			playerUUID, ok := redisu.HashTagValue(key)
+			if !ok {
+				slog.Warn("Skipping malformed team key", "key", key)
+				continue
+			}
+			found = append(found, playerUUID)
+		}
+		mu.Lock()
+		matched = append(matched, found...)
+		mu.Unlock()
+		return nil
+	})
+
+	moved := 0
+	for _, playerUUID := range matched {
+		ok, err := pps.reassignPlayerTeam(ctx, playerUUID, oldTeamID, newTeamID)
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+	if scanErr != nil {
+		return moved, fmt.Errorf("failed to scan team assignments for team %s: %w", oldTeamID, scanErr)
+	}
+	return moved, nil
+}
+
+// reassignPlayerTeam sets a player's team to newTeamID if it still is oldTeamID, keeping the key's TTL.
+// It reports whether the team was changed.
+func (pps *PlayerPlaytimeStore) reassignPlayerTeam(ctx context.Context, playerUUID, oldTeamID, newTeamID string) (bool, error) {
+	key := fmt.Sprintf(redisu.PlayerTeamKeyPrefix, playerUUID)
+
+	changed := false
+	err := pps.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		teamID, err := tx.Get(ctx, key).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if teamID != oldTeamID {
+			return nil
+		}
+		if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, newTeamID, redis.KeepTTL)
+			return nil
+		}); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil // The assignment changed while checking; leave the newer one alone
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to reassign player %s from team %s to %s: %w", playerUUID, oldTeamID, newTeamID, err)
+	}
+	pps.InvalidatePlayerTeam(playerUUID)
+	return changed, nil
+}
+
+// TeamRename is the message PublishTeamRename sends on redisu.TeamRenamesChannel.
+type TeamRename struct {
+	OldTeam string `json:"oldTeam"`
+	NewTeam string `json:"newTeam"`
+}
+
+// PublishTeamRename announces on redisu.TeamRenamesChannel that oldTeamID was renamed, so every
+// game-service instance drops its cached assignments to it (see InvalidateTeam). This instance's
+// cache is invalidated right away, whether or not anyone is subscribed.
+func (pps *PlayerPlaytimeStore) PublishTeamRename(ctx context.Context, oldTeamID, newTeamID string) error {
+	pps.InvalidateTeam(oldTeamID)
+	payload, err := json.Marshal(TeamRename{OldTeam: oldTeamID, NewTeam: newTeamID})
+	if err != nil {
+		return fmt.Errorf("failed to encode rename of team %s: %w", oldTeamID, err)
+	}
+	if err := pps.redisClient.Publish(ctx, redisu.TeamRenamesChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish rename of team %s: %w", oldTeamID, err)
+	}
+	return nil
+}
+
+// InvalidateTeam drops every cached assignment to teamID, so the next lookups read the players'
+// current teams from Redis. It returns how many entries were dropped.
+func (pps *PlayerPlaytimeStore) InvalidateTeam(teamID string) int {
+	pps.teamCacheGen.Add(1) // Lookups in flight may still return the old team, see cachePlayerTeam
+	dropped := 0
+	pps.teamCache.Range(func(key, value any) bool {
+		if value.(cachedPlayerTeam).teamID == teamID {
+			pps.teamCache.Delete(key)
+			dropped++
+		}
+		return true
+	})
+	return dropped
+}
+
+// RenameTeamPlaytime moves a team's total playtime in Redis to a new team ID, adding it to any total
+// the new ID already has, and moves its leaderboard entry along. It returns the playtime moved.
+// The two keys live in different slots, so this is not atomic: the old key is only deleted once the
+// new one has been incremented, and anything credited to the old key in between is lost until the
+// next team sync recomputes the totals. Call it only once every instance stopped crediting the old
+// team, i.e. after ReassignTeam and PublishTeamRename.
+func (tps *TeamPlaytimeStore) RenameTeamPlaytime(ctx context.Context, oldTeamID, newTeamID string) (float64, error) {
+	if err := tps.validateTeam.check(newTeamID); err != nil {
+		return 0, err
+	}
+	oldKey := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, oldTeamID)
+	newKey := fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, newTeamID)
+
+	moved, err := tps.unit.Seconds(tps.redisClient.Get(ctx, oldKey).Result())
+	if err == redis.Nil {
+		slog.Info("No team playtime record to move", "team", oldTeamID, "new_team", newTeamID)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve total playtime for team %s from Redis: %w", oldTeamID, err)
+	}
+
+	newTotal, err := tps.unit.IncrResult(tps.teamCap.incrBy(ctx, tps.redisClient, tps.unit, newKey, moved)) // Clamped to the cap in the same step
+	if err != nil {
+		return 0, fmt.Errorf("failed to move playtime of team %s to team %s in Redis: %w", oldTeamID, newTeamID, err)
+	}
+	newTotal, _ = tps.teamCap.Check(newTeamID, newTotal, moved)
+	if floored, clamped := tps.floorTotal(newTeamID, newTotal); clamped {
+		if err := tps.redisClient.Set(ctx, newKey, floored, 0).Err(); err != nil {
+			slog.Error("Failed to clamp team total playtime to zero", "team", newTeamID, "error", err)
+		}
+		newTotal = floored
+	}
+	tps.leaderboard.set(ctx, newTeamID, newTotal)
+
+	if err := tps.redisClient.Del(ctx, oldKey).Err(); err != nil {
+		return moved, fmt.Errorf("failed to delete playtime record of renamed team %s from Redis: %w", oldTeamID, err)
+	}
+	tps.leaderboard.remove(ctx, oldTeamID)
+	tps.teamCap.Reset(oldTeamID)
+
+	slog.Info("Moved team total playtime", "team", oldTeamID, "new_team", newTeamID, "playtime", moved)
+	return moved, nil
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+)
+
+func TestPublishTeamRenameInvalidatesCachedAssignments(t *testing.T) {
+	_, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	ctx := context.Background()
+
+	pubsub := client.Subscribe(ctx, redisu.TeamRenamesChannel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil { // Wait for the subscription to be confirmed
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	for playerUUID, teamID := range map[string]string{"player-1": "RED", "player-2": "RED", "player-3": "BLUE"} {
+		if err := pps.SetPlayerTeam(ctx, playerUUID, teamID); err != nil {
+			t.Fatalf("SetPlayerTeam: %v", err)
+		}
+	}
+	if _, err := pps.GetPlayerTeams(ctx, []string{"player-1", "player-2", "player-3"}); err != nil { // Fills the cache
+		t.Fatalf("GetPlayerTeams: %v", err)
+	}
+
+	if err := pps.PublishTeamRename(ctx, "RED", "CRIMSON"); err != nil {
+		t.Fatalf("PublishTeamRename: %v", err)
+	}
+	if dropped := pps.InvalidateTeam("RED"); dropped != 0 {
+		t.Errorf("InvalidateTeam after publishing dropped %d entries, want 0", dropped)
+	}
+	if dropped := pps.InvalidateTeam("BLUE"); dropped != 1 {
+		t.Errorf("InvalidateTeam(BLUE) dropped %d entries, want 1", dropped)
+	}
+
+	msgCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	msg, err := pubsub.ReceiveMessage(msgCtx)
+	if err != nil {
+		t.Fatalf("ReceiveMessage: %v", err)
+	}
+	var rename TeamRename
+	if err := json.Unmarshal([]byte(msg.Payload), &rename); err != nil {
+		t.Fatalf("Unmarshal %q: %v", msg.Payload, err)
+	}
+	if rename != (TeamRename{OldTeam: "RED", NewTeam: "CRIMSON"}) {
+		t.Errorf("published %+v, want RED renamed to CRIMSON", rename)
+	}
+}
@@ -0,0 +1,14 @@
+// game/store/team_validator.go
+package store
+
+// TeamValidator returns an error if teamID is not a team the stores may write, e.g. one missing from
+// the game service's team allow-list. It keeps a typo in a team ID from creating orphan team keys.
+type TeamValidator func(teamID string) error
+
+// check runs the validator on teamID. A nil validator accepts every team.
+func (v TeamValidator) check(teamID string) error {
+	if v == nil {
+		return nil
+	}
+	return v(teamID)
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+)
+
+var errTestUnknownTeam = errors.New("unknown team")
+
+func onlyTeams(teams ...string) TeamValidator {
+	return func(teamID string) error {
+		for _, team := range teams {
+			if team == teamID {
+				return nil
+			}
+		}
+		return fmt.Errorf("team '%s': %w", teamID, errTestUnknownTeam)
+	}
+}
+
+func TestTeamValidatorGuardsTeamWrites(t *testing.T) {
+	mr, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	tps := NewTeamPlaytimeStore(client)
+	pps.SetTeamValidator(onlyTeams("RED"))
+	tps.SetTeamValidator(onlyTeams("RED"))
+	ctx := context.Background()
+
+	writes := map[string]func() error{
+		"SetPlayerTeam":         func() error { return pps.SetPlayerTeam(ctx, "player-1", "REDD") },
+		"SetTeamPlaytime":       func() error { return tps.SetTeamPlaytime(ctx, "REDD", 10) },
+		"IncrementTeamPlaytime": func() error { return tps.IncrementTeamPlaytime(ctx, "REDD", 10) },
+		"ResetTeamPlaytime":     func() error { return tps.ResetTeamPlaytime(ctx, "REDD") },
+		"ReassignTeam": func() error {
+			_, err := pps.ReassignTeam(ctx, "RED", "REDD")
+			return err
+		},
+		"RenameTeamPlaytime": func() error {
+			_, err := tps.RenameTeamPlaytime(ctx, "RED", "REDD")
+			return err
+		},
+		"StartSession": func() error {
+			ops := NewOnlinePlayersStore(client, 15*time.Second, 0)
+			return StartSession(ctx, ops, pps, "player-2", SessionInit{DeltaPlaytime: 1, TeamID: "REDD", Start: time.Now()})
+		},
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, errTestUnknownTeam) {
+			t.Errorf("%s with an unknown team: error = %v, want the validator's", name, err)
+		}
+	}
+	for _, key := range mr.Keys() {
+		t.Errorf("key %s written for an unknown team", key)
+	}
+
+	if err := pps.SetPlayerTeam(ctx, "player-1", "RED"); err != nil {
+		t.Errorf("SetPlayerTeam with a known team: %v", err)
+	}
+}
+
+func TestIncrementPlayerPlaytimeSkipsUnknownTeam(t *testing.T) {
+	mr, client := newTestClient(t)
+	pps := NewPlayerPlaytimeStore(client)
+	ctx := context.Background()
+	if err := pps.SetPlayerTeam(ctx, "player-1", "GONE"); err != nil { // Assigned before the team was removed
+		t.Fatalf("SetPlayerTeam: %v", err)
+	}
+	pps.SetTeamValidator(onlyTeams("RED"))
+	mr.Set(fmt.Sprintf(redisu.DeltaPlaytimeKeyPrefix, "player-1"), "5")
+
+	if err := pps.IncrementPlayerPlaytime(ctx, "player-1"); err != nil {
+		t.Fatalf("IncrementPlayerPlaytime: %v", err)
+	}
+	if got, err := pps.GetPlayerPlaytime(ctx, "player-1"); err != nil || got != 5 {
+		t.Errorf("player playtime = %v (err %v), want 5", got, err)
+	}
+	if mr.Exists(fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, "GONE")) {
+		t.Error("playtime credited to a team the validator rejects")
+	}
+}
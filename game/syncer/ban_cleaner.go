@@ -0,0 +1,84 @@
+// game/syncer/ban_cleaner.go
+package syncer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/cluster"
+)
+
+// banCleanupTaskKey is the assignment key deciding which instance removes expired bans.
+const banCleanupTaskKey = "expired_ban_cleanup_task"
+
+// BanCleaner periodically removes the Redis keys of expired temporary bans. Ban reads no longer
+// delete expired bans themselves, so a wave of expiring bans doesn't turn into a flood of DELs.
+// Only the instance responsible for banCleanupTaskKey does the work.
+type BanCleaner struct {
+	banStore          *store.BanStore
+	assignmentManager *cluster.ServiceAssignmentManager
+	interval          time.Duration
+	batchSize         int
+	ctx               context.Context
+	cancel            context.CancelFunc
+	doneChan          chan struct{} // Closed when the cleanup loop has exited
+}
+
+// NewBanCleaner creates a BanCleaner that cleans up every interval, deleting batchSize bans per batch.
+// assignmentManager must be running; it is shared with the component that started it.
+func NewBanCleaner(banStore *store.BanStore, assignmentManager *cluster.ServiceAssignmentManager, interval time.Duration, batchSize int) *BanCleaner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BanCleaner{
+		banStore:          banStore,
+		assignmentManager: assignmentManager,
+		interval:          interval,
+		batchSize:         batchSize,
+		ctx:               ctx,
+		cancel:            cancel,
+		doneChan:          make(chan struct{}),
+	}
+}
+
+// Start runs the cleanup loop until Stop is called. This should be run in a goroutine.
+func (bc *BanCleaner) Start() {
+	defer close(bc.doneChan)
+	log.Printf("Ban Cleaner starting with cleanup interval: %v", bc.interval)
+	ticker := time.NewTicker(bc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-bc.ctx.Done():
+			log.Println("Ban Cleaner shutting down.")
+			return
+		case <-ticker.C:
+			bc.cleanupOnce()
+		}
+	}
+}
+
+// Stop signals the cleanup loop to exit and waits for it to finish.
+func (bc *BanCleaner) Stop() {
+	bc.cancel()
+	<-bc.doneChan
+}
+
+// cleanupOnce removes expired bans if this instance is responsible for the task, logging failures.
+func (bc *BanCleaner) cleanupOnce() {
+	isLeader, err := bc.assignmentManager.IsResponsible(banCleanupTaskKey)
+	if err != nil {
+		log.Printf("ERROR: BanCleaner: Failed to check leadership for task '%s': %v", banCleanupTaskKey, err)
+		return
+	}
+	if !isLeader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(bc.ctx, bc.interval)
+	defer cancel()
+	if _, err := bc.banStore.CleanupExpiredBans(ctx, bc.batchSize); err != nil {
+		log.Printf("WARNING: BanCleaner: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// game/syncer/delta_cleaner.go
+package syncer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/persistence"
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/cluster"
+)
+
+// deltaCleanupTaskKey is the assignment key deciding which instance clears orphaned deltas.
+const deltaCleanupTaskKey = "orphaned_delta_cleanup_task"
+
+// DeltaCleaner periodically clears delta playtime keys left behind by sessions that were never ended,
+// e.g. because the responsible instance crashed, so another instance cannot pick them up later.
+// Only the instance responsible for deltaCleanupTaskKey does the work.
+//
+// Ticks credit the delta to the player's Redis total as they happen, so an orphaned session has no
+// playtime left to add; what it missed is the persist PlayerOffline would have done. Before clearing a
+// delta the cleaner therefore persists the total the session left in Redis, so the playtime earned on
+// the crashed instance reaches the player's profile even if the total expires before the next backup.
+//
+// A delta must be found orphaned (older than maxAge, no online key) in two consecutive passes
+// before it is cleared. A player in an offline grace period has no online key but keeps their delta
+// for a reconnect; with an interval longer than the grace period, such a session has either resumed
+// or ended by the next pass.
+type DeltaCleaner struct {
+	playerPlaytimeStore *store.PlayerPlaytimeStore
+	persister           persistence.PlaytimePersister // Nil only clears the deltas
+	assignmentManager   *cluster.ServiceAssignmentManager
+	interval            time.Duration
+	maxAge              time.Duration
+	suspects            map[string]struct{} // Orphaned deltas found by the previous pass; only used by the cleanup loop
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	doneChan            chan struct{} // Closed when the cleanup loop has exited
+}
+
+// NewDeltaCleaner creates a DeltaCleaner that checks every interval for deltas older than maxAge.
+// assignmentManager must be running; it is shared with the component that started it. persister
+// receives the totals of orphaned sessions; nil skips that and only clears the deltas.
+func NewDeltaCleaner(playerPlaytimeStore *store.PlayerPlaytimeStore, persister persistence.PlaytimePersister, assignmentManager *cluster.ServiceAssignmentManager, interval time.Duration, maxAge time.Duration) *DeltaCleaner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DeltaCleaner{
+		playerPlaytimeStore: playerPlaytimeStore,
+		persister:           persister,
+		assignmentManager:   assignmentManager,
+		interval:            interval,
+		maxAge:              maxAge,
+		suspects:            make(map[string]struct{}),
+		ctx:                 ctx,
+		cancel:              cancel,
+		doneChan:            make(chan struct{}),
+	}
+}
+
+// Start runs the cleanup loop until Stop is called. This should be run in a goroutine.
+func (dc *DeltaCleaner) Start() {
+	defer close(dc.doneChan)
+	log.Printf("Delta Cleaner starting with interval: %v, max age: %v", dc.interval, dc.maxAge)
+	ticker := time.NewTicker(dc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-dc.ctx.Done():
+			log.Println("Delta Cleaner shutting down.")
+			return
+		case <-ticker.C:
+			dc.cleanupOnce()
+		}
+	}
+}
+
+// Stop signals the cleanup loop to exit and waits for it to finish.
+func (dc *DeltaCleaner) Stop() {
+	dc.cancel()
+	<-dc.doneChan
+}
+
+// cleanupOnce clears the deltas found orphaned by both this and the previous pass, if this instance
+// is responsible for the task, logging failures.
+func (dc *DeltaCleaner) cleanupOnce() {
+	isLeader, err := dc.assignmentManager.IsResponsible(deltaCleanupTaskKey)
+	if err != nil {
+		log.Printf("ERROR: DeltaCleaner: Failed to check leadership for task '%s': %v", deltaCleanupTaskKey, err)
+		return
+	}
+	if !isLeader {
+		clear(dc.suspects) // A later leadership starts over, since the deltas may have changed meanwhile
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(dc.ctx, dc.interval)
+	defer cancel()
+	orphaned, err := dc.playerPlaytimeStore.FindOrphanedDeltas(ctx, dc.maxAge)
+	if err != nil {
+		// A partial scan would drop suspects on the failed nodes, so wait for a complete one.
+		log.Printf("WARNING: DeltaCleaner: Failed to find orphaned deltas: %v", err)
+		return
+	}
+
+	next := make(map[string]struct{}, len(orphaned))
+	cleared, persisted := 0, 0
+	for _, playerUUID := range orphaned {
+		if _, seen := dc.suspects[playerUUID]; !seen {
+			next[playerUUID] = struct{}{}
+			continue
+		}
+		ok, err := dc.persistOrphanedPlaytime(ctx, playerUUID)
+		if err != nil {
+			log.Printf("WARNING: DeltaCleaner: %v", err)
+			next[playerUUID] = struct{}{} // Keep the delta so the next pass tries again
+			continue
+		}
+		if ok {
+			persisted++
+		}
+		ok, err = dc.playerPlaytimeStore.ClearOrphanedDelta(ctx, playerUUID)
+		if err != nil {
+			log.Printf("WARNING: DeltaCleaner: %v", err)
+			next[playerUUID] = struct{}{} // Retry on the next pass
+			continue
+		}
+		if ok {
+			cleared++
+		}
+	}
+	dc.suspects = next
+
+	if cleared > 0 || persisted > 0 {
+		log.Printf("INFO: DeltaCleaner: Cleared %d orphaned delta playtime keys; persisted %d orphaned totals.", cleared, persisted)
+	}
+}
+
+// persistOrphanedPlaytime persists the total playtime an orphaned session left in Redis, reporting
+// whether there was one. A corrupt total is logged and skipped rather than persisted.
+func (dc *DeltaCleaner) persistOrphanedPlaytime(ctx context.Context, playerUUID string) (bool, error) {
+	if dc.persister == nil {
+		return false, nil
+	}
+	total, ok, err := dc.playerPlaytimeStore.LookupPlayerPlaytime(ctx, playerUUID) // False if the key already expired
+	if errors.Is(err, store.ErrCorruptPlaytime) {
+		log.Printf("WARNING: DeltaCleaner: Not persisting orphaned session of player %s: %v", playerUUID, err)
+		return false, nil
+	}
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := dc.persister.PersistPlaytime(ctx, playerUUID, total); err != nil {
+		return false, err
+	}
+	dc.playerPlaytimeStore.RecordPlaytimeAudit(ctx, playerUUID, store.PlaytimeAuditSourceOffline, 0, total)
+	return true, nil
+}
@@ -0,0 +1,82 @@
+// game/syncer/online_count_reconciler.go
+package syncer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/cluster"
+)
+
+// onlineCountReconcileTaskKey is the assignment key deciding which instance reconciles the online counter.
+const onlineCountReconcileTaskKey = "online_count_reconcile_task"
+
+// OnlineCountReconciler periodically recounts the online keys and corrects the online counter, which
+// drifts as online keys expire without the player going offline. Only the instance responsible for
+// onlineCountReconcileTaskKey does the work.
+type OnlineCountReconciler struct {
+	onlinePlayersStore *store.OnlinePlayersStore
+	assignmentManager  *cluster.ServiceAssignmentManager
+	interval           time.Duration
+	ctx                context.Context
+	cancel             context.CancelFunc
+	doneChan           chan struct{} // Closed when the reconcile loop has exited
+}
+
+// NewOnlineCountReconciler creates an OnlineCountReconciler that reconciles every interval.
+// assignmentManager must be running; it is shared with the component that started it.
+func NewOnlineCountReconciler(onlinePlayersStore *store.OnlinePlayersStore, assignmentManager *cluster.ServiceAssignmentManager, interval time.Duration) *OnlineCountReconciler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OnlineCountReconciler{
+		onlinePlayersStore: onlinePlayersStore,
+		assignmentManager:  assignmentManager,
+		interval:           interval,
+		ctx:                ctx,
+		cancel:             cancel,
+		doneChan:           make(chan struct{}),
+	}
+}
+
+// Start runs the reconcile loop until Stop is called. This should be run in a goroutine.
+func (ocr *OnlineCountReconciler) Start() {
+	defer close(ocr.doneChan)
+	log.Printf("Online Count Reconciler starting with interval: %v", ocr.interval)
+	ticker := time.NewTicker(ocr.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ocr.ctx.Done():
+			log.Println("Online Count Reconciler shutting down.")
+			return
+		case <-ticker.C:
+			ocr.reconcileOnce()
+		}
+	}
+}
+
+// Stop signals the reconcile loop to exit and waits for it to finish.
+func (ocr *OnlineCountReconciler) Stop() {
+	ocr.cancel()
+	<-ocr.doneChan
+}
+
+// reconcileOnce reconciles the online counter if this instance is responsible for the task, logging failures.
+func (ocr *OnlineCountReconciler) reconcileOnce() {
+	isLeader, err := ocr.assignmentManager.IsResponsible(onlineCountReconcileTaskKey)
+	if err != nil {
+		log.Printf("ERROR: OnlineCountReconciler: Failed to check leadership for task '%s': %v", onlineCountReconcileTaskKey, err)
+		return
+	}
+	if !isLeader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ocr.ctx, ocr.interval)
+	defer cancel()
+	if _, err := ocr.onlinePlayersStore.ReconcileOnlineCount(ctx); err != nil {
+		log.Printf("WARNING: OnlineCountReconciler: %v", err)
+	}
+}
@@ -3,16 +3,25 @@ package syncer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/Ftotnem/GO-SERVICES/game/persistence"
 	"github.com/Ftotnem/GO-SERVICES/game/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/api"
 	"github.com/Ftotnem/GO-SERVICES/shared/cluster"
 	"github.com/Ftotnem/GO-SERVICES/shared/config"
-	"github.com/Ftotnem/GO-SERVICES/shared/registry"
 	player_service_client "github.com/Ftotnem/GO-SERVICES/shared/service" // Your HTTP Player Service client
 )
 
+// GlobalSyncTaskKey is the assignment key of the global sync task. The instance responsible for it is
+// the sync leader, the only one that performs backups and team syncs.
+const GlobalSyncTaskKey = "global_playtime_sync_task"
+
 // PlaytimeSyncer handles the periodic backup of player playtimes to the Player Service
 // and synchronization of aggregated team totals from the Player Service back to Redis.
 // It uses ServiceAssignmentManager to ensure only one instance in the cluster performs these global tasks.
@@ -20,81 +29,115 @@ type PlaytimeSyncer struct {
 	config              *config.GameServiceConfig
 	playerPlaytimeStore *store.PlayerPlaytimeStore
 	teamPlaytimeStore   *store.TeamPlaytimeStore
-	playerServiceClient player_service_client.PlayerServiceClient // HTTP client to Player Service
-	assignmentManager   *cluster.ServiceAssignmentManager
-	serviceRegistrar    *registry.ServiceRegistrar // Used for ServiceAssignmentManager initialization
+	playerServiceClient player_service_client.PlayerServiceClient // HTTP client to Player Service, used for team sync
+	persister           persistence.PlaytimePersister             // Where player playtime backups are written
+	secondary           persistence.SnapshotBackup                // Optional; see SetSecondaryBackup
+	assignmentManager   *cluster.ServiceAssignmentManager         // Shared with the updater; started and stopped by its owner
+	syncing             atomic.Bool                               // Set while performGlobalSync runs, so cycles never overlap
 	ctx                 context.Context
 	cancel              context.CancelFunc
+	doneChan            chan struct{} // Closed when the sync loop has exited
 }
 
 // NewPlaytimeSyncer creates a new PlaytimeSyncer instance.
-// It relies on ServiceAssignmentManager to determine leadership for global sync tasks.
+// It relies on the given ServiceAssignmentManager to determine leadership for global sync tasks;
+// the syncer neither starts nor stops it, so it can be the same instance the updater uses.
 func NewPlaytimeSyncer(
 	cfg *config.GameServiceConfig,
 	playerPlaytimeStore *store.PlayerPlaytimeStore,
 	teamPlaytimeStore *store.TeamPlaytimeStore,
 	playerServiceClient player_service_client.PlayerServiceClient,
-	registryClient *registry.RegistryClient, // Needed for ServiceAssignmentManager
-	serviceRegistrar *registry.ServiceRegistrar,
+	persister persistence.PlaytimePersister,
+	assignmentManager *cluster.ServiceAssignmentManager,
 ) *PlaytimeSyncer {
 	log.Println("PlaytimeSyncer: Initializing.")
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// The assignment manager will be used to elect a leader for the global sync task.
-	assignmentManager := cluster.NewServiceAssignmentManager(
-		registryClient,
-		serviceRegistrar,
-		cfg.HeartbeatInterval, // Use heartbeat interval for consistent hash updates
-	)
-
 	return &PlaytimeSyncer{
 		config:              cfg,
 		playerPlaytimeStore: playerPlaytimeStore,
 		teamPlaytimeStore:   teamPlaytimeStore,
 		playerServiceClient: playerServiceClient,
+		persister:           persister,
 		assignmentManager:   assignmentManager,
-		serviceRegistrar:    serviceRegistrar,
 		ctx:                 ctx,
 		cancel:              cancel,
+		doneChan:            make(chan struct{}),
 	}
 }
 
+// SetSecondaryBackup makes every backup run also write the full playtime snapshot to backup, in
+// addition to the primary persister. Its failures are logged and never affect the primary backup.
+// Must be called before Start.
+func (ps *PlaytimeSyncer) SetSecondaryBackup(backup persistence.SnapshotBackup) {
+	ps.secondary = backup
+}
+
 // Start initiates the synchronization loop. This should be run in a goroutine.
 func (ps *PlaytimeSyncer) Start() {
+	defer close(ps.doneChan)
 	// Using ps.config.BackupInterval for the ticker frequency.
 	log.Printf("Playtime Syncer starting with sync interval: %v", ps.config.PersistenceInterval)
 	ticker := time.NewTicker(ps.config.PersistenceInterval)
 	defer ticker.Stop()
 
-	// Start the ServiceAssignmentManager's update loop in a goroutine.
-	go ps.assignmentManager.Start()
-
 	for {
 		select {
 		case <-ps.ctx.Done():
 			log.Println("Playtime Syncer shutting down.")
-			ps.assignmentManager.Stop() // Stop the assignment manager when Syncer stops
 			return
 		case <-ticker.C:
-			ps.performGlobalSync()
+			started := time.Now()
+			ps.performGlobalSync(ps.ctx)
+			if elapsed := time.Since(started); elapsed > ps.config.PersistenceInterval {
+				ps.handleOverrun(ticker, elapsed)
+			}
 		}
 	}
 }
 
-// Stop gracefully stops the synchronization loop.
+// handleOverrun logs a sync that took longer than the persistence interval. The ticker has queued a
+// tick meanwhile, which would start the next sync right away; with SkipOverrunSyncs it is dropped, so
+// the next sync waits for the following tick and a slow leader isn't kept syncing back to back.
+func (ps *PlaytimeSyncer) handleOverrun(ticker *time.Ticker, elapsed time.Duration) {
+	if !ps.config.SkipOverrunSyncs {
+		log.Printf("WARNING: Syncer: Sync took %v, longer than the %v interval; starting the next one right away.", elapsed, ps.config.PersistenceInterval)
+		return
+	}
+	select {
+	case <-ticker.C:
+		log.Printf("WARNING: Syncer: Sync took %v, longer than the %v interval; skipping the missed cycle.", elapsed, ps.config.PersistenceInterval)
+	default:
+		log.Printf("WARNING: Syncer: Sync took %v, longer than the %v interval.", elapsed, ps.config.PersistenceInterval)
+	}
+}
+
+// Stop gracefully stops the synchronization loop, waits for it to exit, and then runs one
+// final sync so playtime accrued since the last tick is flushed to the Player Service.
+// It must be called while Redis and the service registration are still available.
+// Start must have been called before Stop.
 func (ps *PlaytimeSyncer) Stop() {
 	ps.cancel()
+	<-ps.doneChan
+
+	log.Println("Playtime Syncer: Running final sync before shutdown.")
+	// ps.ctx is already canceled, so the final pass runs on a fresh context bounded by the configured timeouts.
+	ps.performGlobalSync(context.Background())
+	log.Println("Playtime Syncer: Final sync completed.")
 }
 
-// performGlobalSync executes the backup and team sync logic.
+// performGlobalSync executes the backup and team sync logic, deriving its timeouts from parent.
 // Only the cluster leader (determined by assignmentManager for a specific key) will perform this.
-func (ps *PlaytimeSyncer) performGlobalSync() {
-	// Use a unique, consistent key for the global sync task to ensure only one service instance picks it up.
-	const globalSyncTaskKey = "global_playtime_sync_task"
+func (ps *PlaytimeSyncer) performGlobalSync(parent context.Context) {
+	if !ps.syncing.CompareAndSwap(false, true) {
+		log.Println("WARNING: Syncer: Previous sync is still running; skipping this cycle.")
+		return
+	}
+	defer ps.syncing.Store(false)
 
-	isLeader, err := ps.assignmentManager.IsResponsible(globalSyncTaskKey)
+	isLeader, err := ps.assignmentManager.IsResponsible(GlobalSyncTaskKey)
 	if err != nil {
-		log.Printf("ERROR: PlaytimeSyncer: Failed to check leadership for task '%s': %v", globalSyncTaskKey, err)
+		log.Printf("ERROR: PlaytimeSyncer: Failed to check leadership for task '%s': %v", GlobalSyncTaskKey, err)
 		return
 	}
 
@@ -107,10 +150,25 @@ func (ps *PlaytimeSyncer) performGlobalSync() {
 	// --- 1. Backup all current player playtimes from Redis to Player Service (MongoDB) ---
 	// Create a context for the individual player updates during the backup.
 	// Using ps.config.BackupTimeout for the total duration of this backup phase.
-	backupCtx, backupCancel := context.WithTimeout(ps.ctx, ps.config.BackupTimeout)
+	backupCtx, backupCancel := context.WithTimeout(parent, ps.config.BackupTimeout)
 	defer backupCancel()
 
 	allPlayerPlaytimes, err := ps.playerPlaytimeStore.GetAllPlayerPlaytimes(backupCtx)
+	if err == nil {
+		// Only a complete scan may replace the player leaderboard; a partial one would drop players.
+		if rebuildErr := ps.playerPlaytimeStore.RebuildPlayerLeaderboard(backupCtx, allPlayerPlaytimes); rebuildErr != nil {
+			log.Printf("WARNING: Syncer: Failed to rebuild player leaderboard: %v", rebuildErr)
+		}
+	}
+	partial := errors.Is(err, store.ErrPartialScan)
+	if partial {
+		// Back up what could be read; the unreachable nodes' players are picked up on a later run.
+		log.Printf("WARNING: Syncer: Backing up %d player playtimes from the reachable Redis nodes only: %v", len(allPlayerPlaytimes), err)
+		err = nil
+	}
+	if err == nil && ps.secondary != nil {
+		ps.backupSnapshot(parent, allPlayerPlaytimes, partial)
+	}
 	if err != nil {
 		log.Printf("ERROR: Syncer: Failed to get all player playtimes from Redis for backup: %v", err)
 		// Continue to team sync even if player playtime backup fails.
@@ -127,8 +185,7 @@ func (ps *PlaytimeSyncer) performGlobalSync() {
 				// Continue
 			}
 
-			// Assuming your PlayerServiceClient has an UpdatePlayerPlaytime method that takes UUID and playtime
-			err := ps.playerServiceClient.UpdatePlayerPlaytime(backupCtx, uuid, totalPlaytime)
+			err := ps.persister.PersistPlaytime(backupCtx, uuid, totalPlaytime)
 			if err != nil {
 				log.Printf("ERROR: Syncer: Failed to update playtime for player %s in Player Service: %v", uuid, err)
 				// Log the error but continue to try other players.
@@ -143,11 +200,11 @@ endBackupLoop: // Label to jump to if backupCtx is done.
 
 	// --- 2. Trigger team total aggregation in Player Service and update Redis with results ---
 	// Using ps.config.SyncTimeout for the context of the team sync operation.
-	syncCtx, syncCancel := context.WithTimeout(ps.ctx, ps.config.SyncTimeout)
+	syncCtx, syncCancel := context.WithTimeout(parent, ps.config.SyncTimeout)
 	defer syncCancel()
 
-	// Use your existing playerServiceClient.SyncTeamTotals method
-	resp, err := ps.playerServiceClient.SyncTeamTotals(syncCtx)
+	// The sync only recomputes and overwrites the totals, so retrying it is safe.
+	resp, err := ps.syncTeamTotalsWithRetry(syncCtx)
 	if err != nil {
 		log.Printf("ERROR: Syncer: Failed to trigger player service team totals sync: %v", err)
 		return // Crucial error, cannot update Redis with stale data if sync failed
@@ -171,12 +228,77 @@ endBackupLoop: // Label to jump to if backupCtx is done.
 			// Continue
 		}
 
-		err := ps.teamPlaytimeStore.SetTeamPlaytime(syncCtx, teamID, totalPlaytime) // Overwrite existing Redis value
+		err := ps.setTeamPlaytimeWithRetry(syncCtx, teamID, totalPlaytime) // Overwrite existing Redis value
 		if err != nil {
-			log.Printf("ERROR: Syncer: Failed to update Redis for team %s total playtime: %v", teamID, err)
+			log.Printf("ERROR: Syncer: Failed to update Redis for team %s total playtime after %d attempts: %v", teamID, ps.config.TeamSyncRetries+1, err)
 		} else {
 			log.Printf("INFO: Syncer: Successfully updated Redis total playtime for team '%s' to %.2f ticks.", teamID, totalPlaytime)
 		}
 	}
 	log.Println("INFO: Syncer: Finished updating Redis with aggregated team totals.")
 }
+
+// backupSnapshot writes the playtimes read for this run to the secondary backup, with its own
+// BackupTimeout so a slow secondary can't eat into the primary backup. Failures are only logged.
+func (ps *PlaytimeSyncer) backupSnapshot(parent context.Context, playtimes map[string]float64, partial bool) {
+	ctx, cancel := context.WithTimeout(parent, ps.config.BackupTimeout)
+	defer cancel()
+
+	snapshot := persistence.PlaytimeSnapshot{TakenAt: time.Now(), Partial: partial, Playtimes: playtimes}
+	if err := ps.secondary.BackupSnapshot(ctx, snapshot); err != nil {
+		log.Printf("WARNING: Syncer: Failed to write playtime snapshot of %d players to the secondary backup: %v", len(playtimes), err)
+		return
+	}
+	log.Printf("INFO: Syncer: Wrote playtime snapshot of %d players to the secondary backup.", len(playtimes))
+}
+
+// syncTeamTotalsWithRetry triggers the player service's team totals sync, retrying up to
+// config.TeamSyncRetries times with exponential backoff if it fails for a reason that may be transient:
+// a network error, a 5xx or a 429. Other client errors won't go away on a retry and are returned at once.
+func (ps *PlaytimeSyncer) syncTeamTotalsWithRetry(ctx context.Context) (*player_service_client.SyncTeamTotalsResponse, error) {
+	backoff := ps.config.TeamSyncRetryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := ps.playerServiceClient.SyncTeamTotals(ctx)
+		if err == nil || !teamSyncRetryable(err) || attempt >= ps.config.TeamSyncRetries {
+			return resp, err
+		}
+
+		log.Printf("WARNING: Syncer: Attempt %d to sync team totals failed, retrying in %v: %v", attempt+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (retry aborted: %v)", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// teamSyncRetryable reports whether a failed team totals sync may succeed when tried again.
+func teamSyncRetryable(err error) bool {
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) {
+		return true // Network error or timeout
+	}
+	return httpErr.StatusCode >= http.StatusInternalServerError || httpErr.StatusCode == http.StatusTooManyRequests
+}
+
+// setTeamPlaytimeWithRetry writes a team's total to Redis, retrying up to config.TeamSyncRetries times
+// with exponential backoff so a transient failure doesn't leave the total stale until the next cycle.
+func (ps *PlaytimeSyncer) setTeamPlaytimeWithRetry(ctx context.Context, teamID string, totalPlaytime float64) error {
+	backoff := ps.config.TeamSyncRetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = ps.teamPlaytimeStore.SetTeamPlaytime(ctx, teamID, totalPlaytime)
+		if err == nil || attempt >= ps.config.TeamSyncRetries {
+			return err
+		}
+
+		log.Printf("WARNING: Syncer: Attempt %d to update Redis for team %s failed, retrying in %v: %v", attempt+1, teamID, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (retry aborted: %v)", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
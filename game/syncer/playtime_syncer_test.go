@@ -0,0 +1,119 @@
+package syncer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/shared/config"
+	player_service_client "github.com/Ftotnem/GO-SERVICES/shared/service"
+)
+
+// newTeamSyncServer serves POST /teams/sync-totals, answering with statuses in turn and then 200.
+func newTeamSyncServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"teamTotals":{"AQUA_CREEPERS":42},"message":"ok"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newRetryingSyncer(baseURL string, retries int) *PlaytimeSyncer {
+	return &PlaytimeSyncer{
+		config:              &config.GameServiceConfig{TeamSyncRetries: retries, TeamSyncRetryBackoff: time.Millisecond},
+		playerServiceClient: *player_service_client.NewPlayerClient(baseURL),
+	}
+}
+
+func TestSyncTeamTotalsWithRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		statuses  []int
+		retries   int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"succeeds first time", nil, 3, 1, false},
+		{"retries server errors", []int{http.StatusServiceUnavailable, http.StatusInternalServerError}, 3, 3, false},
+		{"retries rate limiting", []int{http.StatusTooManyRequests}, 3, 2, false},
+		{"gives up after the retries", []int{500, 500, 500, 500}, 3, 4, true},
+		{"no retries configured", []int{http.StatusBadGateway}, 0, 1, true},
+		{"client errors are not retried", []int{http.StatusBadRequest}, 3, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, calls := newTeamSyncServer(t, tc.statuses...)
+			ps := newRetryingSyncer(srv.URL, tc.retries)
+
+			resp, err := ps.syncTeamTotalsWithRetry(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && resp.TeamTotals["AQUA_CREEPERS"] != 42 {
+				t.Errorf("response = %+v, want AQUA_CREEPERS=42", resp)
+			}
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("player service called %d times, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSyncTeamTotalsWithRetryStopsAtDeadline(t *testing.T) {
+	srv, calls := newTeamSyncServer(t, 500, 500, 500, 500, 500)
+	ps := newRetryingSyncer(srv.URL, 5)
+	ps.config.TeamSyncRetryBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ps.syncTeamTotalsWithRetry(ctx); err == nil {
+		t.Fatal("expected an error once the sync timeout passed")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("player service called %d times, want 1 before the deadline", got)
+	}
+}
+
+func TestPerformGlobalSyncSkipsWhileRunning(t *testing.T) {
+	// Without an assignment manager, a sync that got past the guard would panic.
+	ps := &PlaytimeSyncer{config: &config.GameServiceConfig{}}
+	ps.syncing.Store(true)
+
+	ps.performGlobalSync(context.Background())
+
+	if !ps.syncing.Load() {
+		t.Error("a skipped cycle cleared the running sync's flag")
+	}
+}
+
+func TestHandleOverrun(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	for _, skip := range []bool{true, false} {
+		ps := &PlaytimeSyncer{config: &config.GameServiceConfig{PersistenceInterval: interval, SkipOverrunSyncs: skip}}
+		ticker := time.NewTicker(interval)
+		time.Sleep(interval + 10*time.Millisecond) // A tick is now due, as after a slow sync
+
+		ps.handleOverrun(ticker, interval+10*time.Millisecond)
+
+		var ticked bool
+		select {
+		case <-ticker.C:
+			ticked = true
+		default:
+		}
+		ticker.Stop()
+		if ticked == skip {
+			t.Errorf("SkipOverrunSyncs=%v: tick pending after the overrun = %v, want %v", skip, ticked, !skip)
+		}
+	}
+}
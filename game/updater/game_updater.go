@@ -2,56 +2,59 @@ package updater
 
 import (
 	"context"
+	"hash/fnv"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Ftotnem/GO-SERVICES/game/persistence"
 	"github.com/Ftotnem/GO-SERVICES/game/store"             // Your store package for PlayerPlaytimeStore and OnlinePlayersStore
 	cluster "github.com/Ftotnem/GO-SERVICES/shared/cluster" // Your cluster package (ServiceAssignmentManager)
 	"github.com/Ftotnem/GO-SERVICES/shared/config"          // Import for config.CommonConfig
-	"github.com/Ftotnem/GO-SERVICES/shared/registry"        // Your registry package (RegistryClient)
 )
 
 // GameUpdater handles the periodic updates for online players' playtime.
 type GameUpdater struct {
 	config              *config.GameServiceConfig         // Use CommonConfig directly
-	registryClient      *registry.RegistryClient          // New: Direct dependency on RegistryClient
-	assignmentManager   *cluster.ServiceAssignmentManager // Use ServiceAssignmentManager
+	assignmentManager   *cluster.ServiceAssignmentManager // Shared with the syncer; started and stopped by its owner
 	onlinePlayersStore  *store.OnlinePlayersStore         // Dependency for getting online UUIDs
 	playerPlaytimeStore *store.PlayerPlaytimeStore        // Dependency for incrementing playtime
-	serviceRegistrar    *registry.ServiceRegistrar        // Store my service type
+	persister           persistence.PlaytimePersister     // Used for the periodic in-session persists, see GAME_PERSIST_EVERY_TICKS
+	tickCount           uint64                            // Ticks performed so far; only touched by the update loop
+	persistInFlight     atomic.Bool                       // Set while a batch of in-session persists is running
+	tickLog             tickLogStats                      // Counters for the sampled tick log, see GAME_TICK_LOG_INTERVAL
+	assignedPlayers     atomic.Int64                      // Online players this instance was responsible for on the last tick
+	persistWG           sync.WaitGroup
 	ctx                 context.Context
 	cancel              context.CancelFunc
+	doneChan            chan struct{} // Closed when the update loop has exited
 }
 
 // NewGameUpdater creates a new GameUpdater instance.
-// It requires the CommonConfig, RegistryClient, OnlinePlayersStore, PlayerPlaytimeStore,
-// and the service ID and type that this *specific* instance will use for registration.
+// It requires the CommonConfig, the assignment manager deciding which players this instance
+// updates, the OnlinePlayersStore and the PlayerPlaytimeStore. The assignment manager is not
+// started or stopped by the updater, so the same instance can be shared with the syncer.
+// persister is only used when cfg.PersistEveryTicks is set.
 func NewGameUpdater(
 	cfg *config.GameServiceConfig,
-	registryClient *registry.RegistryClient,
+	assignmentManager *cluster.ServiceAssignmentManager,
 	onlinePlayersStore *store.OnlinePlayersStore,
 	playerPlaytimeStore *store.PlayerPlaytimeStore,
-	serviceRegistrar *registry.ServiceRegistrar,
+	persister persistence.PlaytimePersister,
 ) *GameUpdater {
 	log.Println("GameUpdater: Initialized.")
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Initialize the ServiceAssignmentManager
-	assignmentManager := cluster.NewServiceAssignmentManager(
-		registryClient,
-		serviceRegistrar,
-		cfg.HeartbeatInterval, // Using heartbeat interval for consistent hash updates
-	)
-
 	gu := &GameUpdater{
 		config:              cfg,
-		registryClient:      registryClient,
 		assignmentManager:   assignmentManager,
 		onlinePlayersStore:  onlinePlayersStore,
 		playerPlaytimeStore: playerPlaytimeStore,
-		serviceRegistrar:    serviceRegistrar,
+		persister:           persister,
 		ctx:                 ctx,
 		cancel:              cancel,
+		doneChan:            make(chan struct{}),
 	}
 	log.Printf("DEBUG: Configured TickInterval before updater start: %v", gu.config.TickInterval)
 	return gu
@@ -59,18 +62,15 @@ func NewGameUpdater(
 
 // Start initiates the game update loop. This should be run in a goroutine.
 func (gu *GameUpdater) Start() {
+	defer close(gu.doneChan)
 	log.Printf("Game Updater starting with tick interval: %v", gu.config.TickInterval)
 	ticker := time.NewTicker(gu.config.TickInterval)
 	defer ticker.Stop()
 
-	// Start the ServiceAssignmentManager's update loop in a goroutine
-	go gu.assignmentManager.Start()
-
 	for {
 		select {
 		case <-gu.ctx.Done():
 			log.Println("Game Updater shutting down.")
-			gu.assignmentManager.Stop() // Stop the assignment manager when GameUpdater stops
 			return
 		case <-ticker.C:
 			gu.performGameTick()
@@ -78,9 +78,18 @@ func (gu *GameUpdater) Start() {
 	}
 }
 
-// Stop gracefully stops the game update loop.
+// Stop gracefully stops the game update loop and waits for any in-progress tick and
+// in-session persist to finish. Start must have been called before Stop.
 func (gu *GameUpdater) Stop() {
 	gu.cancel()
+	<-gu.doneChan
+	gu.persistWG.Wait()
+}
+
+// AssignedPlayers returns how many online players, idle or not, this instance was responsible for on
+// the last tick, e.g. to report as the instance's load.
+func (gu *GameUpdater) AssignedPlayers() int64 {
+	return gu.assignedPlayers.Load()
 }
 
 // performGameTick executes the logic for a single game tick.
@@ -93,6 +102,8 @@ func (gu *GameUpdater) performGameTick() {
 	}
 
 	if len(onlinePlayersMap) == 0 {
+		gu.assignedPlayers.Store(0)
+		gu.recordTick(0, 0)
 		return
 	}
 
@@ -116,15 +127,89 @@ func (gu *GameUpdater) performGameTick() {
 		}
 	}
 
+	gu.assignedPlayers.Store(int64(len(playersToUpdate)))
+
+	// With idle detection enabled, idle players keep their session but stop accruing playtime.
+	// If the check fails the tick errs on the side of counting everyone.
+	if gu.config.IdleTimeout > 0 {
+		activePlayers, err := gu.onlinePlayersStore.FilterActivePlayers(gu.ctx, playersToUpdate)
+		if err != nil {
+			log.Printf("WARNING: GameUpdater: Failed to filter idle players: %v", err)
+		} else {
+			playersToUpdate = activePlayers
+		}
+	}
+
 	if len(playersToUpdate) == 0 {
+		gu.recordTick(0, 0)
 		return
 	}
 
-	//log.Printf("Performing game tick for %d players assigned to this instance.", len(playersToUpdate))
-	
+	failed := 0
 	for _, uuid := range playersToUpdate {
 		if err := gu.playerPlaytimeStore.IncrementPlayerPlaytime(gu.ctx, uuid); err != nil {
 			log.Printf("Error incrementing total playtime for %s: %v", uuid, err)
+			failed++
+		}
+	}
+	gu.recordTick(len(playersToUpdate), failed)
+
+	if gu.config.PersistEveryTicks > 0 {
+		gu.tickCount++
+		gu.persistDuePlayers(playersToUpdate)
+	}
+}
+
+// persistDuePlayers persists the playtime of the players due on this tick. Every player is due once
+// every PersistEveryTicks ticks, offset by a hash of their UUID so the writes are spread across ticks
+// rather than all landing on the same one. The persists run in the background so a slow Player Service
+// doesn't delay ticks; if the previous batch is still running, this tick's batch is skipped.
+func (gu *GameUpdater) persistDuePlayers(playerUUIDs []string) {
+	every := uint64(gu.config.PersistEveryTicks)
+	due := make([]string, 0, len(playerUUIDs)/int(every)+1)
+	for _, uuid := range playerUUIDs {
+		h := fnv.New32a()
+		h.Write([]byte(uuid))
+		if (uint64(h.Sum32())+gu.tickCount)%every == 0 {
+			due = append(due, uuid)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+	if !gu.persistInFlight.CompareAndSwap(false, true) {
+		log.Printf("WARNING: GameUpdater: Previous in-session persist still running, skipping %d players this tick.", len(due))
+		return
+	}
+
+	gu.persistWG.Add(1)
+	go func() {
+		defer gu.persistWG.Done()
+		defer gu.persistInFlight.Store(false)
+
+		// The batch must finish before the same players are due again. It is not tied to gu.ctx so
+		// a persist started just before shutdown still completes.
+		ctx, cancel := context.WithTimeout(context.Background(), gu.config.TickInterval*time.Duration(every))
+		defer cancel()
+		gu.persistPlayers(ctx, due)
+	}()
+}
+
+// persistPlayers persists the Redis total of each player in turn. Players whose total is gone by the
+// time it is read, e.g. because they went offline and their session was ended meanwhile, are skipped:
+// ending the session has persisted their total already, and writing 0 would overwrite it.
+func (gu *GameUpdater) persistPlayers(ctx context.Context, playerUUIDs []string) {
+	for _, uuid := range playerUUIDs {
+		totalPlaytime, ok, err := gu.playerPlaytimeStore.LookupPlayerPlaytime(ctx, uuid)
+		if err != nil {
+			log.Printf("Error reading playtime of %s for in-session persist: %v", uuid, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := gu.persister.PersistPlaytime(ctx, uuid, totalPlaytime); err != nil {
+			log.Printf("Error persisting in-session playtime for %s: %v", uuid, err)
 		}
 	}
 }
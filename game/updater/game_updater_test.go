@@ -0,0 +1,68 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/game/store"
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// recordingPersister records persisted totals and runs onPersist, if set, before each persist.
+type recordingPersister struct {
+	persisted map[string]float64
+	onPersist func(playerUUID string)
+}
+
+func (p *recordingPersister) PersistPlaytime(ctx context.Context, playerUUID string, totalPlaytime float64) error {
+	if p.onPersist != nil {
+		p.onPersist(playerUUID)
+	}
+	p.persisted[playerUUID] = totalPlaytime
+	return nil
+}
+
+func (p *recordingPersister) PersistBan(ctx context.Context, playerUUID string, banned bool, expiresAt *time.Time) error {
+	return nil
+}
+
+func (p *recordingPersister) PersistMultiplier(ctx context.Context, playerUUID string, multiplier float64) error {
+	return nil
+}
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.ClusterClient) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestPersistPlayersSkipsSessionEndedMidBatch(t *testing.T) {
+	mr, client := newTestClient(t)
+	playtimeStore := store.NewPlayerPlaytimeStore(client)
+	const first, second = "player-1", "player-2"
+	mr.Set(fmt.Sprintf(redisu.PlaytimeKeyPrefix, first), "120")
+	mr.Set(fmt.Sprintf(redisu.PlaytimeKeyPrefix, second), "300")
+
+	persister := &recordingPersister{persisted: make(map[string]float64)}
+	persister.onPersist = func(playerUUID string) {
+		if playerUUID == first {
+			mr.Del(fmt.Sprintf(redisu.PlaytimeKeyPrefix, second)) // The second player's session ends meanwhile
+		}
+	}
+	gu := &GameUpdater{playerPlaytimeStore: playtimeStore, persister: persister}
+
+	gu.persistPlayers(context.Background(), []string{first, second})
+
+	if got, ok := persister.persisted[first]; !ok || got != 120 {
+		t.Errorf("persisted total of %s = %v (present %v), want 120", first, got, ok)
+	}
+	if got, ok := persister.persisted[second]; ok {
+		t.Errorf("persisted total of %s = %v after its key vanished, want no persist", second, got)
+	}
+}
@@ -0,0 +1,80 @@
+package updater
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// PersistResponsiblePlayers persists the Redis total of every online player this instance is
+// responsible for, so playtime accrued since the last persist isn't lost when the instance shuts
+// down between syncs. Up to cfg.BatchConcurrency players are persisted at once; players not reached
+// before ctx is done are skipped, as are players whose total is gone by the time it is read (their
+// session ended or the key expired meanwhile), since persisting 0 would overwrite their profile.
+// It returns how many were persisted.
+// The delta is a per-tick rate rather than accrued time and the sessions go on under another
+// instance, so it is left alone. Call it after Stop, so no tick changes the totals meanwhile.
+func (gu *GameUpdater) PersistResponsiblePlayers(ctx context.Context) int {
+	onlinePlayersMap, err := gu.onlinePlayersStore.GetAllOnlinePlayers(ctx)
+	if err != nil {
+		log.Printf("ERROR: GameUpdater: Failed to list online players for the shutdown persist: %v", err)
+		return 0
+	}
+
+	responsible := make([]string, 0, len(onlinePlayersMap))
+	for uuid := range onlinePlayersMap {
+		isResponsible, err := gu.assignmentManager.IsResponsible(uuid)
+		if err != nil {
+			log.Printf("WARNING: GameUpdater: Failed to check responsibility for UUID %s: %v", uuid, err)
+			continue
+		}
+		if isResponsible {
+			responsible = append(responsible, uuid)
+		}
+	}
+	if len(responsible) == 0 {
+		return 0
+	}
+
+	var persisted, ended atomic.Int64
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(gu.config.BatchConcurrency, 1))
+	for _, uuid := range responsible {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(uuid string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			totalPlaytime, ok, err := gu.playerPlaytimeStore.LookupPlayerPlaytime(ctx, uuid)
+			if err != nil {
+				log.Printf("Error reading playtime of %s for shutdown persist: %v", uuid, err)
+				return
+			}
+			if !ok {
+				ended.Add(1)
+				return
+			}
+			if err := gu.persister.PersistPlaytime(ctx, uuid, totalPlaytime); err != nil {
+				log.Printf("Error persisting playtime for %s on shutdown: %v", uuid, err)
+				return
+			}
+			persisted.Add(1)
+		}(uuid)
+	}
+	wg.Wait()
+
+	n, skipped := int(persisted.Load()), int(ended.Load())
+	if n+skipped < len(responsible) {
+		log.Printf("WARNING: GameUpdater: Persisted playtime of only %d of %d players on shutdown.", n, len(responsible)-skipped)
+		return n
+	}
+	log.Printf("GameUpdater: Persisted playtime of %d players on shutdown (%d sessions had already ended).", n, skipped)
+	return n
+}
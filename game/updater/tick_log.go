@@ -0,0 +1,42 @@
+// game/updater/tick_log.go
+package updater
+
+import (
+	"log"
+	"time"
+)
+
+// tickLogStats accumulates what the ticks did since the last sampled tick log. Logging every tick
+// would flood the logs at the tick cadence, so the updater logs one summary per TickLogInterval.
+// Only touched by the update loop.
+type tickLogStats struct {
+	since   time.Time // Start of the current sample window; zero until the first tick
+	ticks   int
+	players int // Player playtime increments attempted
+	failed  int // Increments that returned an error
+}
+
+// recordTick adds one tick's results to the sample window and, once TickLogInterval has elapsed,
+// logs a summary and starts a new window. It does nothing if the tick log is disabled.
+func (gu *GameUpdater) recordTick(players, failed int) {
+	interval := gu.config.TickLogInterval
+	if interval <= 0 {
+		return
+	}
+	stats := &gu.tickLog
+	now := time.Now()
+	if stats.since.IsZero() {
+		stats.since = now
+	}
+	stats.ticks++
+	stats.players += players
+	stats.failed += failed
+
+	elapsed := now.Sub(stats.since)
+	if elapsed < interval {
+		return
+	}
+	log.Printf("GameUpdater: %d ticks in the last %v, %d player updates (%.1f per tick), %d failed.",
+		stats.ticks, elapsed.Round(time.Second), stats.players, float64(stats.players)/float64(stats.ticks), stats.failed)
+	*stats = tickLogStats{since: now}
+}
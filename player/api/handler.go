@@ -2,15 +2,16 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/player/service"
 	"github.com/Ftotnem/GO-SERVICES/shared/api"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
 	"github.com/gorilla/mux"
 )
 
@@ -18,6 +19,8 @@ import (
 type PlayerAPIHandlers struct {
 	PlayerService *service.PlayerService
 	TeamService   *service.TeamService
+
+	adminToken string // Required by admin-only routes, see SetAdminToken
 }
 
 // NewPlayerAPIHandlers is the constructor for your API handlers.
@@ -28,6 +31,12 @@ func NewPlayerAPIHandlers(ps *service.PlayerService, ts *service.TeamService) *P
 	}
 }
 
+// SetAdminToken sets the bearer token required by admin-only routes, such as team renames.
+// Without one those routes reject every request. Call it before RegisterRoutes.
+func (pah *PlayerAPIHandlers) SetAdminToken(token string) {
+	pah.adminToken = token
+}
+
 // --- Request/Response DTOs (Data Transfer Objects) ---
 // These are specific to the API and might differ slightly from your models if needed.
 type CreateProfileRequest struct {
@@ -42,16 +51,104 @@ type UpdateDeltaPlaytimeRequest struct {
 	TicksToSet float64 `json:"ticksToSet"`
 }
 
+type UpdatePlaytimeMultiplierRequest struct {
+	Multiplier float64 `json:"multiplier"`
+}
+
 type UpdateBanStatusRequest struct {
 	Banned       bool       `json:"banned"`
 	BanExpiresAt *time.Time `json:"banExpiresAt"`
 }
 
+type TransferTeamRequest struct {
+	Team            string `json:"team"`
+	MigratePlaytime bool   `json:"migratePlaytime"`
+}
+
+type TransferTeamResponse struct {
+	UUID             string  `json:"uuid"`
+	OldTeam          string  `json:"oldTeam"`
+	NewTeam          string  `json:"newTeam"`
+	TeamUsername     string  `json:"teamUsername"`
+	MigratedPlaytime float64 `json:"migratedPlaytime"`
+	RetainedPlaytime float64 `json:"retainedPlaytime"`
+}
+
 type SyncTeamTotalsResponse struct {
 	TeamTotals map[string]float64 `json:"teamTotals"`
 	Message    string             `json:"message"`
 }
 
+type ListTeamsResponse struct {
+	Teams []string `json:"teams"`
+}
+
+type RenameTeamRequest struct {
+	NewName string `json:"newName"`
+}
+
+type RenameTeamResponse struct {
+	OldName           string `json:"oldName"`
+	NewName           string `json:"newName"`
+	PlayersMoved      int64  `json:"playersMoved"`
+	PlayersReassigned int    `json:"playersReassigned"`          // Online players reassigned by the game service
+	GameServiceError  string `json:"gameServiceError,omitempty"` // Set if the game service could not be told about the rename
+	Message           string `json:"message"`
+}
+
+type TeamCountCorrection struct {
+	Team     string `json:"team"`
+	Previous int64  `json:"previous"`
+	Actual   int64  `json:"actual"`
+}
+
+type ReconcileTeamCountsResponse struct {
+	Corrections []TeamCountCorrection `json:"corrections"` // Only teams whose count changed
+	Message     string                `json:"message"`
+}
+
+type RecomputeTeamTotalResponse struct {
+	TeamName      string  `json:"teamName"`
+	TotalPlaytime float64 `json:"totalPlaytime"`
+	Message       string  `json:"message"`
+}
+
+type ListBannedProfilesResponse struct {
+	Players   []*models.Player `json:"players"`
+	NextAfter string           `json:"nextAfter,omitempty"` // Pass as ?after= to get the next page; empty on the last page
+}
+
+// maxCreateBatchSize caps the number of UUIDs accepted by the batch profile creation endpoint.
+const maxCreateBatchSize = 1000
+
+// Page sizes of the banned profiles listing.
+const (
+	defaultBannedPageSize = 100
+	maxBannedPageSize     = 1000
+)
+
+// Statuses of a CreateProfilesResult.
+const (
+	createStatusCreated       = "created"
+	createStatusAlreadyExists = "already_exists"
+	createStatusFailed        = "failed"
+)
+
+type CreateProfilesResult struct {
+	UUID         string `json:"uuid"`
+	Status       string `json:"status"` // "created", "already_exists" or "failed"
+	Team         string `json:"team,omitempty"`
+	TeamUsername string `json:"teamUsername,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type CreateProfilesResponse struct {
+	Created       int                    `json:"created"`
+	AlreadyExists int                    `json:"alreadyExists"`
+	Failed        int                    `json:"failed"`
+	Results       []CreateProfilesResult `json:"results"` // In request order
+}
+
 // --- Handler Methods ---
 
 // CreateProfileHandler handles requests to create a new player profile.
@@ -66,9 +163,14 @@ func (pah *PlayerAPIHandlers) CreateProfileHandler(w http.ResponseWriter, r *htt
 		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
+	normalizedUUID, err := api.NormalizeUUID(req.UUID)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+	req.UUID = normalizedUUID
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	createdProfile, err := pah.PlayerService.CreateProfile(ctx, req.UUID) // Call the service layer
 	if err != nil {
@@ -86,8 +188,89 @@ func (pah *PlayerAPIHandlers) CreateProfileHandler(w http.ResponseWriter, r *htt
 	log.Printf("Player profile %s created successfully.", createdProfile.UUID)
 }
 
+// CreateProfilesHandler handles requests to create many player profiles at once, e.g. for seeding or
+// a data migration. Invalid and repeated UUIDs are reported as failures without failing the whole batch.
+// POST /profiles/batch
+// Body: { "uuids": ["<player_uuid>", ...] }
+func (pah *PlayerAPIHandlers) CreateProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	var req api.BatchUUIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.UUIDs) == 0 {
+		api.WriteError(w, http.StatusBadRequest, "At least one UUID is required")
+		return
+	}
+	if len(req.UUIDs) > maxCreateBatchSize {
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Batch size %d exceeds the maximum of %d", len(req.UUIDs), maxCreateBatchSize))
+		return
+	}
+
+	results := make([]CreateProfilesResult, len(req.UUIDs))
+	validUUIDs := make([]string, 0, len(req.UUIDs))
+	validIdx := make([]int, 0, len(req.UUIDs))
+	seen := make(map[string]bool, len(req.UUIDs))
+	for i, raw := range req.UUIDs {
+		playerUUID, err := api.NormalizeUUID(raw)
+		if err != nil {
+			results[i] = CreateProfilesResult{UUID: raw, Status: createStatusFailed, Error: "Invalid UUID format"}
+			continue
+		}
+		if seen[playerUUID] {
+			results[i] = CreateProfilesResult{UUID: playerUUID, Status: createStatusFailed, Error: "Duplicate UUID in batch"}
+			continue
+		}
+		seen[playerUUID] = true
+		validUUIDs = append(validUUIDs, playerUUID)
+		validIdx = append(validIdx, i)
+	}
+
+	ctx := r.Context()
+
+	if len(validUUIDs) > 0 {
+		created, err := pah.PlayerService.CreateProfiles(ctx, validUUIDs)
+		if err != nil {
+			log.Printf("Error creating batch of %d player profiles: %v", len(validUUIDs), err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to create player profiles")
+			return
+		}
+		for j, result := range created {
+			item := CreateProfilesResult{UUID: result.UUID}
+			switch {
+			case result.Err == nil:
+				item.Status = createStatusCreated
+				item.Team = result.Profile.Team
+				item.TeamUsername = result.Profile.TeamUsername
+			case result.Err == service.ErrProfileAlreadyExists:
+				item.Status = createStatusAlreadyExists
+			default:
+				log.Printf("Error creating player profile %s in batch: %v", result.UUID, result.Err)
+				item.Status = createStatusFailed
+				item.Error = result.Err.Error()
+			}
+			results[validIdx[j]] = item
+		}
+	}
+
+	resp := CreateProfilesResponse{Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case createStatusCreated:
+			resp.Created++
+		case createStatusAlreadyExists:
+			resp.AlreadyExists++
+		default:
+			resp.Failed++
+		}
+	}
+	log.Printf("Batch profile creation processed: %d created, %d already existed, %d failed.", resp.Created, resp.AlreadyExists, resp.Failed)
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
 // GetProfileHandler handles requests to retrieve a player profile by UUID.
 // GET /profiles/{uuid}
+// Query: include_deleted=true to also return soft-deleted profiles (admin use).
 func (pah *PlayerAPIHandlers) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uuid := vars["uuid"]
@@ -95,11 +278,25 @@ func (pah *PlayerAPIHandlers) GetProfileHandler(w http.ResponseWriter, r *http.R
 		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	includeDeleted := false
+	if raw := r.URL.Query().Get("include_deleted"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "Invalid include_deleted value")
+			return
+		}
+		includeDeleted = parsed
+	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	profile, err := pah.PlayerService.GetProfile(ctx, uuid) // Call the service layer
+	profile, err := pah.PlayerService.GetProfile(ctx, uuid, includeDeleted) // Call the service layer
 	if err != nil {
 		switch err {
 		case service.ErrProfileNotFound:
@@ -124,6 +321,11 @@ func (pah *PlayerAPIHandlers) UpdateProfilePlaytimeHandler(w http.ResponseWriter
 		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
 
 	var req UpdatePlaytimeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -131,10 +333,9 @@ func (pah *PlayerAPIHandlers) UpdateProfilePlaytimeHandler(w http.ResponseWriter
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	err := pah.PlayerService.UpdateProfilePlaytime(ctx, uuid, req.TicksToSet) // Call the service layer
+	err = pah.PlayerService.UpdateProfilePlaytime(ctx, uuid, req.TicksToSet) // Call the service layer
 	if err != nil {
 		switch err {
 		case service.ErrProfileNotFound:
@@ -158,6 +359,11 @@ func (pah *PlayerAPIHandlers) UpdateProfileDeltaPlaytimeHandler(w http.ResponseW
 		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
 
 	var req UpdateDeltaPlaytimeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -165,10 +371,9 @@ func (pah *PlayerAPIHandlers) UpdateProfileDeltaPlaytimeHandler(w http.ResponseW
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	err := pah.PlayerService.UpdateProfileDeltaPlaytime(ctx, uuid, req.TicksToSet)
+	err = pah.PlayerService.UpdateProfileDeltaPlaytime(ctx, uuid, req.TicksToSet)
 	if err != nil {
 		switch err {
 		case service.ErrProfileNotFound:
@@ -192,6 +397,11 @@ func (pah *PlayerAPIHandlers) UpdateProfileBanStatusHandler(w http.ResponseWrite
 		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
 
 	var req UpdateBanStatusRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -199,10 +409,9 @@ func (pah *PlayerAPIHandlers) UpdateProfileBanStatusHandler(w http.ResponseWrite
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	err := pah.PlayerService.UpdateProfileBanStatus(ctx, uuid, req.Banned, req.BanExpiresAt)
+	err = pah.PlayerService.UpdateProfileBanStatus(ctx, uuid, req.Banned, req.BanExpiresAt)
 	if err != nil {
 		switch err {
 		case service.ErrProfileNotFound:
@@ -217,6 +426,46 @@ func (pah *PlayerAPIHandlers) UpdateProfileBanStatusHandler(w http.ResponseWrite
 	api.WriteJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Ban status updated for player profile %s", uuid)})
 }
 
+// UpdateProfilePlaytimeMultiplierHandler handles requests to update a player's permanent playtime multiplier.
+// PUT /profiles/{uuid}/playtime-multiplier
+func (pah *PlayerAPIHandlers) UpdateProfilePlaytimeMultiplierHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	var req UpdatePlaytimeMultiplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+
+	err = pah.PlayerService.UpdateProfilePlaytimeMultiplier(ctx, uuid, req.Multiplier)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidMultiplier:
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+		case service.ErrProfileNotFound:
+			api.WriteError(w, http.StatusNotFound, "Player profile not found")
+		default:
+			log.Printf("Error updating playtime multiplier for player profile %s: %v", uuid, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to update playtime multiplier")
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Playtime multiplier updated for player profile %s", uuid)})
+}
+
 // UpdateProfileLastLoginHandler handles requests to update only a player's last login timestamp.
 // PUT /profiles/{uuid}/lastlogin
 func (pah *PlayerAPIHandlers) UpdateProfileLastLoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -226,11 +475,15 @@ func (pah *PlayerAPIHandlers) UpdateProfileLastLoginHandler(w http.ResponseWrite
 		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
 		return
 	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	err := pah.PlayerService.UpdateProfileLastLogin(ctx, uuid)
+	err = pah.PlayerService.UpdateProfileLastLogin(ctx, uuid)
 	if err != nil {
 		switch err {
 		case service.ErrProfileNotFound:
@@ -245,11 +498,157 @@ func (pah *PlayerAPIHandlers) UpdateProfileLastLoginHandler(w http.ResponseWrite
 	api.WriteJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Last login updated for player profile %s", uuid)})
 }
 
+// DeleteProfileHandler handles requests to delete a player profile.
+// Whether the profile is soft-deleted or removed permanently depends on the service configuration.
+// DELETE /profiles/{uuid}
+func (pah *PlayerAPIHandlers) DeleteProfileHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	err = pah.PlayerService.DeleteProfile(ctx, uuid)
+	if err != nil {
+		switch err {
+		case service.ErrProfileNotFound:
+			api.WriteError(w, http.StatusNotFound, "Player profile not found")
+		default:
+			log.Printf("Error deleting player profile %s: %v", uuid, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to delete player profile")
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Player profile %s deleted", uuid)})
+	log.Printf("Player profile %s deleted successfully.", uuid)
+}
+
+// RestoreProfileHandler handles requests to undelete a soft-deleted player profile.
+// POST /profiles/{uuid}/restore
+func (pah *PlayerAPIHandlers) RestoreProfileHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	err = pah.PlayerService.RestoreProfile(ctx, uuid)
+	if err != nil {
+		switch err {
+		case service.ErrProfileNotFound:
+			api.WriteError(w, http.StatusNotFound, "Soft-deleted player profile not found")
+		default:
+			log.Printf("Error restoring player profile %s: %v", uuid, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to restore player profile")
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Player profile %s restored", uuid)})
+	log.Printf("Player profile %s restored successfully.", uuid)
+}
+
+// TransferTeamHandler moves a player to another team, optionally migrating their accumulated playtime.
+// POST /profiles/{uuid}/transfer-team
+func (pah *PlayerAPIHandlers) TransferTeamHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		api.WriteError(w, http.StatusBadRequest, "Player UUID is required")
+		return
+	}
+	uuid, err := api.NormalizeUUID(uuid)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	var req TransferTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Team == "" {
+		api.WriteError(w, http.StatusBadRequest, "Team is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	result, err := pah.PlayerService.TransferTeam(ctx, uuid, req.Team, req.MigratePlaytime)
+	if err != nil {
+		switch err {
+		case service.ErrProfileNotFound:
+			api.WriteError(w, http.StatusNotFound, "Player profile not found")
+		case service.ErrTeamNotFound:
+			api.WriteError(w, http.StatusNotFound, fmt.Sprintf("Team %s not found", req.Team))
+		case service.ErrAlreadyOnTeam:
+			api.WriteError(w, http.StatusConflict, fmt.Sprintf("Player is already on team %s", req.Team))
+		case service.ErrTeamTransferConflict:
+			api.WriteError(w, http.StatusConflict, "Player changed team during the transfer; retry")
+		default:
+			log.Printf("Error transferring player profile %s to team %s: %v", uuid, req.Team, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to transfer player team")
+		}
+		return
+	}
+
+	// Refresh both teams' totals so the transfer is visible without waiting for the next full sync.
+	for _, teamName := range []string{result.OldTeam, result.NewTeam} {
+		if teamName == "" {
+			continue
+		}
+		if _, err := pah.TeamService.RecomputeTeamTotal(ctx, teamName); err != nil {
+			log.Printf("WARN: Failed to recompute total for team %s after transfer: %v", teamName, err)
+		}
+	}
+
+	api.WriteJSON(w, http.StatusOK, TransferTeamResponse{
+		UUID:             uuid,
+		OldTeam:          result.OldTeam,
+		NewTeam:          result.NewTeam,
+		TeamUsername:     result.TeamUsername,
+		MigratedPlaytime: result.MigratedPlaytime,
+		RetainedPlaytime: result.RetainedPlaytime,
+	})
+}
+
+// ListTeamsHandler returns the names of all known teams.
+// GET /teams
+func (pah *PlayerAPIHandlers) ListTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	teams, err := pah.TeamService.ListTeamNames(ctx)
+	if err != nil {
+		log.Printf("Error listing teams: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to list teams")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, ListTeamsResponse{Teams: teams})
+}
+
 // SyncTeamTotalsHandler aggregates player playtimes from MongoDB and updates team totals.
 // POST /teams/sync-totals
 func (pah *PlayerAPIHandlers) SyncTeamTotalsHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second) // Longer timeout for aggregation
-	defer cancel()
+	ctx := r.Context()
 
 	teamTotals, err := pah.TeamService.SyncTeamTotals(ctx) // Call the service layer
 	if err != nil {
@@ -264,15 +663,172 @@ func (pah *PlayerAPIHandlers) SyncTeamTotalsHandler(w http.ResponseWriter, r *ht
 	})
 }
 
+// ReconcileTeamCountsHandler recounts the players on every team and corrects drifted player counts.
+// POST /teams/reconcile-counts
+func (pah *PlayerAPIHandlers) ReconcileTeamCountsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	corrections, err := pah.TeamService.ReconcileTeamCounts(ctx)
+	if err != nil {
+		log.Printf("Error reconciling team player counts: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to reconcile team player counts")
+		return
+	}
+
+	resp := ReconcileTeamCountsResponse{
+		Corrections: make([]TeamCountCorrection, 0, len(corrections)),
+		Message:     fmt.Sprintf("Corrected the player count of %d teams.", len(corrections)),
+	}
+	for _, c := range corrections {
+		resp.Corrections = append(resp.Corrections, TeamCountCorrection{Team: c.Team, Previous: c.Previous, Actual: c.Actual})
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
+// RecomputeTeamTotalHandler recomputes a single team's total playtime from MongoDB.
+// POST /teams/{name}/recompute
+func (pah *PlayerAPIHandlers) RecomputeTeamTotalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamName := vars["name"]
+	if teamName == "" {
+		api.WriteError(w, http.StatusBadRequest, "Team name is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	total, err := pah.TeamService.RecomputeTeamTotal(ctx, teamName)
+	if err != nil {
+		switch err {
+		case service.ErrTeamNotFound:
+			api.WriteError(w, http.StatusNotFound, fmt.Sprintf("Team %s not found", teamName))
+		default:
+			log.Printf("Error recomputing total playtime for team %s: %v", teamName, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to recompute team total")
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, RecomputeTeamTotalResponse{
+		TeamName:      teamName,
+		TotalPlaytime: total,
+		Message:       "Team total recomputed from MongoDB successfully.",
+	})
+}
+
+// RenameTeamHandler renames a team, moving its players and totals to the new name.
+// POST /teams/{name}/rename
+// Requires the admin token (see SetAdminToken), since it drives the game service's admin API.
+// Body: { "newName": "NEW_NAME" }
+// Responds 207 if MongoDB was updated but the game service could not be told about the rename.
+func (pah *PlayerAPIHandlers) RenameTeamHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamName := vars["name"]
+	if teamName == "" {
+		api.WriteError(w, http.StatusBadRequest, "Team name is required")
+		return
+	}
+
+	var req RenameTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.NewName == "" || req.NewName == teamName {
+		api.WriteError(w, http.StatusBadRequest, "A new team name different from the current one is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	result, err := pah.TeamService.RenameTeam(ctx, teamName, req.NewName)
+	if err != nil {
+		switch err {
+		case service.ErrTeamNotFound:
+			api.WriteError(w, http.StatusNotFound, fmt.Sprintf("Team %s not found", teamName))
+		case service.ErrTeamAlreadyExists:
+			api.WriteError(w, http.StatusConflict, fmt.Sprintf("Team %s already exists", req.NewName))
+		default:
+			log.Printf("Error renaming team %s to %s: %v", teamName, req.NewName, err)
+			api.WriteError(w, http.StatusInternalServerError, "Failed to rename team")
+		}
+		return
+	}
+
+	resp := RenameTeamResponse{
+		OldName:           teamName,
+		NewName:           req.NewName,
+		PlayersMoved:      result.PlayersMoved,
+		PlayersReassigned: result.PlayersReassigned,
+		GameServiceError:  result.GameServiceError,
+		Message:           "Team renamed successfully.",
+	}
+	status := http.StatusOK
+	if result.GameServiceError != "" {
+		status = http.StatusMultiStatus
+		resp.Message = "Team renamed in MongoDB, but the game service could not be updated."
+	}
+	api.WriteJSON(w, status, resp)
+}
+
+// ListBannedProfilesHandler lists the profiles marked as banned in MongoDB, a page at a time.
+// GET /profiles/banned?limit=<n>&after=<uuid>
+func (pah *PlayerAPIHandlers) ListBannedProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := defaultBannedPageSize
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxBannedPageSize {
+			api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxBannedPageSize))
+			return
+		}
+		limit = parsed
+	}
+	after := query.Get("after")
+	if after != "" {
+		normalized, err := api.NormalizeUUID(after)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "Invalid after UUID format")
+			return
+		}
+		after = normalized
+	}
+
+	ctx := r.Context()
+
+	players, err := pah.PlayerService.ListBannedProfiles(ctx, after, limit)
+	if err != nil {
+		log.Printf("Error listing banned profiles: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "Failed to list banned profiles")
+		return
+	}
+
+	resp := ListBannedProfilesResponse{Players: players}
+	if len(players) == limit {
+		resp.NextAfter = players[len(players)-1].UUID // There may be more; an empty next page ends the listing
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
 // RegisterRoutes registers all API endpoints for the Player Service.
 // This method is called from main.go to set up the HTTP routes.
 func (pah *PlayerAPIHandlers) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/profiles", pah.CreateProfileHandler).Methods("POST")
-	router.HandleFunc("/profiles/{uuid}", pah.GetProfileHandler).Methods("GET")
-	router.HandleFunc("/profiles/{uuid}/playtime", pah.UpdateProfilePlaytimeHandler).Methods("PUT")
-	router.HandleFunc("/profiles/{uuid}/deltaplaytime", pah.UpdateProfileDeltaPlaytimeHandler).Methods("PUT")
-	router.HandleFunc("/profiles/{uuid}/ban", pah.UpdateProfileBanStatusHandler).Methods("PUT")
-	router.HandleFunc("/profiles/{uuid}/lastlogin", pah.UpdateProfileLastLoginHandler).Methods("PUT")
-
-	router.HandleFunc("/teams/sync-totals", pah.SyncTeamTotalsHandler).Methods("POST")
+	router.HandleFunc("/profiles", api.WithTimeout(api.DefaultRequestTimeout, pah.CreateProfileHandler)).Methods("POST")
+	router.HandleFunc("/profiles/batch", api.WithTimeout(60*time.Second, pah.CreateProfilesHandler)).Methods("POST") // Large batches take a while
+	router.HandleFunc("/profiles/banned", api.WithTimeout(api.DefaultRequestTimeout, pah.ListBannedProfilesHandler)).Methods("GET")
+	router.HandleFunc("/profiles/{uuid}", api.WithTimeout(api.DefaultRequestTimeout, pah.GetProfileHandler)).Methods("GET")
+	router.HandleFunc("/profiles/{uuid}", api.WithTimeout(api.DefaultRequestTimeout, pah.DeleteProfileHandler)).Methods("DELETE")
+	router.HandleFunc("/profiles/{uuid}/restore", api.WithTimeout(api.DefaultRequestTimeout, pah.RestoreProfileHandler)).Methods("POST")
+	router.HandleFunc("/profiles/{uuid}/playtime", api.WithTimeout(api.DefaultRequestTimeout, pah.UpdateProfilePlaytimeHandler)).Methods("PUT")
+	router.HandleFunc("/profiles/{uuid}/deltaplaytime", api.WithTimeout(api.DefaultRequestTimeout, pah.UpdateProfileDeltaPlaytimeHandler)).Methods("PUT")
+	router.HandleFunc("/profiles/{uuid}/ban", api.WithTimeout(api.DefaultRequestTimeout, pah.UpdateProfileBanStatusHandler)).Methods("PUT")
+	router.HandleFunc("/profiles/{uuid}/playtime-multiplier", api.WithTimeout(api.DefaultRequestTimeout, pah.UpdateProfilePlaytimeMultiplierHandler)).Methods("PUT")
+	router.HandleFunc("/profiles/{uuid}/lastlogin", api.WithTimeout(api.DefaultRequestTimeout, pah.UpdateProfileLastLoginHandler)).Methods("PUT")
+	router.HandleFunc("/profiles/{uuid}/transfer-team", api.WithTimeout(30*time.Second, pah.TransferTeamHandler)).Methods("POST") // Includes recomputing both team totals
+
+	router.HandleFunc("/teams", api.WithTimeout(api.DefaultRequestTimeout, pah.ListTeamsHandler)).Methods("GET")
+	router.HandleFunc("/teams/sync-totals", api.WithTimeout(60*time.Second, pah.SyncTeamTotalsHandler)).Methods("POST")           // Longer timeout for aggregation
+	router.HandleFunc("/teams/reconcile-counts", api.WithTimeout(60*time.Second, pah.ReconcileTeamCountsHandler)).Methods("POST") // Counts every profile
+	router.HandleFunc("/teams/{name}/recompute", api.WithTimeout(30*time.Second, pah.RecomputeTeamTotalHandler)).Methods("POST")
+	router.HandleFunc("/teams/{name}/rename", api.WithTimeout(90*time.Second, api.RequireToken(pah.adminToken, pah.RenameTeamHandler))).Methods("POST") // Includes the game service's Redis migration
 }
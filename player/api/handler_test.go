@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListBannedProfilesHandlerRejectsBadPaging(t *testing.T) {
+	pah := &PlayerAPIHandlers{} // Bad paging is rejected before the service is used
+	for _, query := range []string{"limit=0", "limit=1001", "limit=abc", "after=not-a-uuid"} {
+		rec := httptest.NewRecorder()
+		pah.ListBannedProfilesHandler(rec, httptest.NewRequest(http.MethodGet, "/profiles/banned?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("?%s: status %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -15,10 +17,14 @@ import (
 	"github.com/Ftotnem/GO-SERVICES/player/service"
 	"github.com/Ftotnem/GO-SERVICES/player/store"
 	"github.com/Ftotnem/GO-SERVICES/shared/api"
+	"github.com/Ftotnem/GO-SERVICES/shared/cluster"
 	"github.com/Ftotnem/GO-SERVICES/shared/config"
+	"github.com/Ftotnem/GO-SERVICES/shared/logging"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
 	mongodbu "github.com/Ftotnem/GO-SERVICES/shared/mongodb"
 	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
 	"github.com/Ftotnem/GO-SERVICES/shared/registry"
+	sharedservice "github.com/Ftotnem/GO-SERVICES/shared/service"
 )
 
 func main() {
@@ -27,9 +33,13 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	if err := logging.Setup(cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to configure logging: %v", err)
+	}
 
 	// --- 2. Connect to MongoDB ---
-	mongoClient, err := mongodbu.NewClient(cfg.MongoDBConnStr, cfg.MongoDBDatabase) // Assuming NewClient is in shared/mongodb
+	// Retries with backoff so the service can come up before MongoDB does.
+	mongoClient, err := mongodbu.NewClient(cfg.MongoDBConnStr, cfg.MongoDBDatabase, cfg.MongoDBConnectAttempts, cfg.MongoDBConnectBackoff)
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
@@ -56,12 +66,26 @@ func main() {
 	teamsCollection := mongoClient.Collection(cfg.MongoDBTeamCollection)      // Use your actual collection name from config
 
 	playerStore := store.NewPlayerStore(playersCollection)
+	if cfg.MajorityPlaytimeWrites {
+		if err := playerStore.UseMajorityPlaytimeWrites(); err != nil {
+			log.Fatalf("Failed to configure player store: %v", err)
+		}
+		log.Println("Playtime persists use majority write concern.")
+	}
 	teamStore := store.NewTeamStore(teamsCollection)
 
 	// --- 5. Initialize External Services ---
 	mojangService := mojang.NewMojangService(mongoClient, cfg.MongoDBPlayersCollection, cfg.UsernameFillerInterval) // Adjusted constructor
-	go mojangService.StartFillerJob()                                                                               // Start background job
-	defer mojangService.StopFillerJob()
+	if cfg.MojangBreakerThreshold > 0 {
+		mojangService.SetCircuitBreaker(cfg.MojangBreakerThreshold, cfg.MojangBreakerCooldown)
+		log.Printf("MojangService: Calls pause for %v after %d consecutive Mojang API failures.", cfg.MojangBreakerCooldown, cfg.MojangBreakerThreshold)
+	}
+	if cfg.UsernameFillerEnabled {
+		go mojangService.StartFillerJob() // Start background job
+		defer mojangService.StopFillerJob()
+	} else {
+		log.Println("MojangService: Background username filler job disabled by configuration.")
+	}
 
 	// --- 6. Ensure Initial Data Exists (e.g., default teams) ---
 	if err := teamStore.EnsureTeamsExist(context.Background(), cfg.DefaultTeams); err != nil { // Assuming DefaultTeams is []string in config
@@ -69,21 +93,89 @@ func main() {
 	}
 
 	// --- 7. Initialize Business Logic Services (passing stores and external services) ---
-	playerService := service.NewPlayerService(playerStore, teamStore, mojangService)
-	teamService := service.NewTeamService(teamStore, playerStore) // TeamService needs both stores for aggregation
+	// The game service is told about team transfers and renames and, with online-aware balancing, asked how many players are online per team.
+	gameClient := sharedservice.NewGameClient(cfg.GameServiceURL)
+	gameClient.SetAdminToken(cfg.AdminToken) // Team renames use a /game/admin endpoint
+	if cfg.BalanceTeamsByOnline {
+		log.Printf("Team balancing considers online players from %s.", cfg.GameServiceURL)
+	}
+	playerService := service.NewPlayerService(playerStore, teamStore, mojangService, cfg, gameClient)
+	playtimeUnit := redisu.PlaytimeSeconds // Must match the game service, which increments these totals
+	if cfg.PlaytimeMillis {
+		playtimeUnit = redisu.PlaytimeMillis
+	}
+	// TeamService needs both stores for aggregation. Recomputed team totals are pushed straight into
+	// the shared Redis cluster so the game service picks them up without waiting for the next sync.
+	teamService := service.NewTeamService(teamStore, playerStore, func(ctx context.Context, teamName string, totalPlaytime float64) error {
+		return redisClient.Set(ctx, fmt.Sprintf(redisu.TeamTotalPlaytimePrefix, teamName), playtimeUnit.Value(totalPlaytime), 0).Err()
+	}, gameClient)
+
+	// Profile changes made anywhere (including directly in MongoDB) drop the game service's cached
+	// profile and are published so subscribers can react. Change streams require a replica set.
+	if cfg.ProfileChangesChannel != "" {
+		profileWatcher := service.NewProfileWatcher(playerStore, func(ctx context.Context, change models.ProfileChange) error {
+			if err := redisClient.Del(ctx, fmt.Sprintf(redisu.ProfileCacheKeyPrefix, change.UUID)).Err(); err != nil {
+				return fmt.Errorf("failed to invalidate cached profile: %w", err)
+			}
+			payload, err := json.Marshal(change)
+			if err != nil {
+				return fmt.Errorf("failed to encode profile change: %w", err)
+			}
+			return redisClient.Publish(ctx, cfg.ProfileChangesChannel, payload).Err()
+		})
+		go profileWatcher.Start()
+		defer profileWatcher.Stop()
+		log.Printf("Publishing player profile changes to Redis channel '%s'.", cfg.ProfileChangesChannel)
+	}
 
 	// --- 8. Initialize API Handlers (passing business logic services) ---
 	playerAPIHandlers := playerapi.NewPlayerAPIHandlers(playerService, teamService)
+	playerAPIHandlers.SetAdminToken(cfg.AdminToken) // Team renames require it
 
 	// --- 9. Initialize and Start Service Registrar ---
 	// No need for a separate 'serviceConfig' struct now, use common config directly
-	registrar := registry.NewServiceRegistrar(redisClient, "player-service", &cfg.CommonConfig) // <--- Pass common config directly
-	go registrar.Start()                                                                        // Start the heartbeating goroutine
-	defer registrar.Stop()                                                                      // Ensure registrar stops on shutdown
+	registrar := registry.NewServiceRegistrar(redisClient, "player-service", &cfg.CommonConfig, nil) // <--- Pass common config directly
+	// Start blocks until the first registration succeeds, then heartbeats in the background.
+	if err := registrar.Start(); err != nil {
+		log.Fatalf("Failed to register player-service: %v", err)
+	}
+	defer registrar.Stop() // Ensure registrar stops on shutdown
+
+	// The assignment ring elects the one instance that cleans stale player-service registry entries.
+	// Deferred after the registrar, so it is stopped first.
+	registryClient := registry.NewRegistryClient(redisClient, cfg.HeartbeatTTL)
+	assignmentManager := cluster.NewServiceAssignmentManager(registryClient, registrar, cfg.HeartbeatInterval)
+	go assignmentManager.Start()
+	defer assignmentManager.Stop()
+	registrar.SetCleanupLeader(func() (bool, error) {
+		return assignmentManager.IsResponsible(registry.CleanupTaskKey)
+	})
+
+	if cfg.TeamCountInterval > 0 {
+		teamCountReconciler := service.NewTeamCountReconciler(teamService, func() (bool, error) {
+			return assignmentManager.IsResponsible(service.TeamCountReconcileTaskKey)
+		}, cfg.TeamCountInterval)
+		go teamCountReconciler.Start()
+		defer teamCountReconciler.Stop()
+	}
 
 	// --- 10. Setup HTTP Server and Register Routes ---
-	baseServer := api.NewBaseServer(cfg.ListenAddr, log.Default()) // Assuming NewBaseServer takes address and sets up mux.Router
+	baseServer := api.NewBaseServer(cfg.ListenAddr, log.Default(), api.ServerOptions{
+		ReadTimeout:    cfg.HTTPReadTimeout,
+		WriteTimeout:   cfg.HTTPWriteTimeout,
+		IdleTimeout:    cfg.HTTPIdleTimeout,
+		MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
+	})
 	playerAPIHandlers.RegisterRoutes(baseServer.Router)
+	// Team recompute and sync aggregate every profile in MongoDB, so they get the stricter scan limit.
+	baseServer.UseRateLimits(cfg.AdminToken,
+		api.RateLimitRule{PathPrefix: "/teams/", Limiter: api.NewRateLimiter(cfg.ScanRateLimit.RPS, cfg.ScanRateLimit.Burst)},
+		api.RateLimitRule{PathPrefix: "/", Limiter: api.NewRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst)},
+	)
+	baseServer.UseCompression(cfg.CompressionMinSize)
+	if cfg.MessagePack {
+		baseServer.UseMessagePack()
+	}
 
 	// --- 11. Start HTTP Server ---
 	go func() {
@@ -0,0 +1,57 @@
+package mojang
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by GetUsernameByUUID without calling Mojang while the API is considered down.
+var ErrCircuitOpen = errors.New("mojang API circuit open after repeated failures")
+
+// circuitBreaker stops calls to the Mojang API after threshold consecutive failures, for cooldown.
+// Once the cooldown has passed a call is let through again; a failure reopens the circuit right
+// away and a success closes it. A nil *circuitBreaker always lets calls through.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int       // Consecutive failures; stays at or above threshold while open
+	openUntil time.Time // Calls are refused until then
+}
+
+// allow reports whether a call may be made and, if not, until when the circuit stays open.
+func (cb *circuitBreaker) allow() (bool, time.Time) {
+	if cb == nil {
+		return true, time.Time{}
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if time.Now().Before(cb.openUntil) {
+		return false, cb.openUntil
+	}
+	return true, time.Time{}
+}
+
+// record updates the breaker with a call's outcome.
+func (cb *circuitBreaker) record(failed bool) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if !failed {
+		if cb.failures >= cb.threshold {
+			log.Println("MojangService: INFO: Mojang API is responding again; circuit closed.")
+		}
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.openUntil = time.Now().Add(cb.cooldown)
+		log.Printf("MojangService: WARN: %d consecutive Mojang API failures; pausing calls for %v.", cb.failures, cb.cooldown)
+	}
+}
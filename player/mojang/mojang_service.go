@@ -3,6 +3,7 @@ package mojang // This file is part of your main executable
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -30,6 +31,7 @@ type MojangService struct {
 	// For Mojang API calls
 	httpClient    *http.Client
 	mojangBaseURL string
+	breaker       *circuitBreaker // Optional; see SetCircuitBreaker
 
 	// For the background filler job's MongoDB interactions
 	playerCollection *mongo.Collection // Directly use the collection for simplicity in this consolidated file
@@ -57,9 +59,36 @@ func NewMojangService(
 	}
 }
 
+// SetCircuitBreaker makes GetUsernameByUUID stop calling Mojang for cooldown after threshold
+// consecutive failures (errors other than an unknown UUID), so an outage isn't hammered by the filler
+// job and by every profile creation. A threshold of 0 or less disables the breaker.
+func (ms *MojangService) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	if threshold <= 0 {
+		ms.breaker = nil
+		return
+	}
+	ms.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// errProfileNotFound marks Mojang's answer for an unknown UUID, which doesn't count as an API failure.
+var errProfileNotFound = errors.New("mojang profile not found")
+
 // GetUsernameByUUID fetches a Minecraft username from Mojang's API using the player's UUID.
 // This is the direct Mojang API interaction part of the service.
+// Returns an error wrapping ErrCircuitOpen without calling Mojang while the circuit breaker is open.
 func (ms *MojangService) GetUsernameByUUID(ctx context.Context, uuid string) (string, error) {
+	if ok, openUntil := ms.breaker.allow(); !ok {
+		return "", fmt.Errorf("skipped Mojang API request for UUID %s until %s: %w", uuid, openUntil.Format(time.RFC3339), ErrCircuitOpen)
+	}
+	username, err := ms.fetchUsername(ctx, uuid)
+	if ctx.Err() == nil { // A caller giving up says nothing about Mojang
+		ms.breaker.record(err != nil && !errors.Is(err, errProfileNotFound))
+	}
+	return username, err
+}
+
+// fetchUsername makes the Mojang API request for GetUsernameByUUID.
+func (ms *MojangService) fetchUsername(ctx context.Context, uuid string) (string, error) {
 	url := fmt.Sprintf("%s/%s", ms.mojangBaseURL, uuid)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -74,7 +103,7 @@ func (ms *MojangService) GetUsernameByUUID(ctx context.Context, uuid string) (st
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
-			return "", fmt.Errorf("mojang profile not found for UUID %s (Status: %d)", uuid, resp.StatusCode)
+			return "", fmt.Errorf("%w for UUID %s (Status: %d)", errProfileNotFound, uuid, resp.StatusCode)
 		}
 		return "", fmt.Errorf("unexpected status from Mojang API for UUID %s: %d", uuid, resp.StatusCode)
 	}
@@ -174,6 +203,10 @@ func (ms *MojangService) performSingleFillerIteration() {
 
 		// Fetch username from Mojang
 		username, mojangErr := ms.GetUsernameByUUID(ctx, p.UUID) // Use MojangService's own method
+		if errors.Is(mojangErr, ErrCircuitOpen) {
+			log.Printf("MojangService: Filler job iteration paused, %v", mojangErr)
+			return // The remaining profiles are retried once the circuit lets calls through again
+		}
 		if mojangErr != nil {
 			log.Printf("MojangService: WARN: Filler job failed to fetch username for UUID %s: %v", p.UUID, mojangErr)
 			continue
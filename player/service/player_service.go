@@ -5,13 +5,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/player/mojang"
 	"github.com/Ftotnem/GO-SERVICES/player/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/config"
 	"github.com/Ftotnem/GO-SERVICES/shared/models"
+	gameclient "github.com/Ftotnem/GO-SERVICES/shared/service"
 	"go.mongodb.org/mongo-driver/mongo" // For checking specific MongoDB errors
 )
 
@@ -20,6 +23,10 @@ var (
 	ErrProfileAlreadyExists = fmt.Errorf("player profile already exists")
 	ErrProfileNotFound      = fmt.Errorf("player profile not found")
 	ErrTeamNotFound         = fmt.Errorf("team not found")
+	ErrAlreadyOnTeam        = fmt.Errorf("player is already on this team")
+	ErrTeamAlreadyExists    = fmt.Errorf("team already exists")
+	ErrTeamTransferConflict = fmt.Errorf("player changed team during transfer")
+	ErrInvalidMultiplier    = fmt.Errorf("playtime multiplier must be greater than 0 and at most %v", models.MaxPlaytimeMultiplier)
 )
 
 // PlayerService encapsulates the business logic for player profiles.
@@ -27,27 +34,100 @@ type PlayerService struct {
 	playerStore   *store.PlayerStore
 	teamStore     *store.TeamStore
 	mojangService *mojang.MojangService // Dependency on MojangService
+	config        *config.PlayerServiceConfig
+	gameClient    *gameclient.GameServiceClient // Optional; used for online-aware balancing and team transfers
+}
+
+// TeamTransferResult describes a completed team transfer.
+type TeamTransferResult struct {
+	OldTeam          string
+	NewTeam          string
+	TeamUsername     string
+	MigratedPlaytime float64 // Playtime moved from the old team to the new team
+	RetainedPlaytime float64 // Playtime left credited to the old team
 }
 
 // NewPlayerService creates a new PlayerService instance.
-func NewPlayerService(ps *store.PlayerStore, ts *store.TeamStore, ms *mojang.MojangService) *PlayerService {
+// gameClient may be nil, in which case teams are balanced by total player count only and
+// team transfers are not pushed to the game service.
+func NewPlayerService(ps *store.PlayerStore, ts *store.TeamStore, ms *mojang.MojangService, cfg *config.PlayerServiceConfig, gameClient *gameclient.GameServiceClient) *PlayerService {
 	return &PlayerService{
 		playerStore:   ps,
 		teamStore:     ts,
 		mojangService: ms,
+		config:        cfg,
+		gameClient:    gameClient,
+	}
+}
+
+// onlineTeamCounts returns the number of online players per team from the game service,
+// or nil if online balancing is disabled or the counts are unavailable.
+func (ps *PlayerService) onlineTeamCounts(ctx context.Context) map[string]int {
+	if ps.gameClient == nil || !ps.config.BalanceTeamsByOnline {
+		return nil
+	}
+	stats, err := ps.gameClient.GetOnlineStats(ctx)
+	if err != nil {
+		log.Printf("WARN: Could not retrieve online counts from game service: %v. Balancing by total player count only.", err)
+		return nil
+	}
+	return stats.Teams
+}
+
+// weightedTeamCandidates returns the teams furthest below their target share of players, given the
+// current player count per team (-1 marks a team whose count is unknown) and the configured weights.
+// Teams without a weight are never candidates.
+func weightedTeamCandidates(teamCounts map[string]int64, ratios map[string]float64) []string {
+	var totalPlayers int64
+	var totalWeight float64
+	for team, count := range teamCounts {
+		weight, ok := ratios[team]
+		if !ok || count == -1 {
+			continue
+		}
+		totalPlayers += count
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	var candidates []string
+	maxDeficit := 0.0
+	for team, count := range teamCounts {
+		weight, ok := ratios[team]
+		if !ok || count == -1 {
+			continue
+		}
+		share := 0.0
+		if totalPlayers > 0 {
+			share = float64(count) / float64(totalPlayers)
+		}
+		deficit := weight/totalWeight - share
+		if candidates == nil || deficit > maxDeficit {
+			maxDeficit = deficit
+			candidates = []string{team}
+		} else if deficit == maxDeficit {
+			candidates = append(candidates, team)
+		}
 	}
+	return candidates
 }
 
 // generateTeamUsername determines the next sequential team-based username for a given team.
-// It increments the team's player count and uses that as the suffix.
+// It increments the team's player count and uses the team's next username number as the suffix.
 func (ps *PlayerService) generateTeamUsername(ctx context.Context, teamName string) (string, error) {
 	// Increment the team's player count and get the new count.
 	// This should be an atomic operation in your TeamStore.
-	newCount, err := ps.teamStore.IncrementTeamPlayerCountAndGet(ctx, teamName)
+	number, err := ps.teamStore.IncrementTeamPlayerCountAndGet(ctx, teamName)
 	if err != nil {
 		return "", fmt.Errorf("failed to get and increment player count for team %s: %w", teamName, err)
 	}
+	return formatTeamUsername(teamName, number), nil
+}
 
+// formatTeamUsername builds the team username of a team's number-th player, e.g. "Creeper42".
+func formatTeamUsername(teamName string, number int64) string {
 	// Determine the base creature name (e.g., "CREEPER", "AXOLOTL")
 	// and then format it.
 	var baseName string
@@ -77,68 +157,112 @@ func (ps *PlayerService) generateTeamUsername(ctx context.Context, teamName stri
 		}
 	}
 
-	return fmt.Sprintf("%s%d", baseName, newCount), nil
+	return fmt.Sprintf("%s%d", baseName, number)
 }
 
-// CreateProfile handles the creation of a new player profile, including team assignment and username lookup.
-func (ps *PlayerService) CreateProfile(ctx context.Context, playerUUID string) (*models.Player, error) {
-	now := time.Now()
-
-	// 1. Check if profile already exists early to avoid unnecessary work
-	_, err := ps.playerStore.GetPlayerByUUID(ctx, playerUUID)
-	if err == nil { // Profile found
-		return nil, ErrProfileAlreadyExists
-	}
-	if err != mongo.ErrNoDocuments { // Other error during lookup
-		return nil, fmt.Errorf("service failed to check existing profile: %w", err)
-	}
-	// If mongo.ErrNoDocuments, proceed with creation
+// teamAssignment is the team state new players are balanced by, see chooseTeam.
+type teamAssignment struct {
+	teams  []models.Team
+	counts map[string]int64 // Total players per team; -1 if the count could not be read
+	online map[string]int   // Online players per team; nil if unknown or not used
+}
 
-	// --- Team Assignment Logic (from your original code) ---
+// loadTeamAssignment reads the teams and their player counts, falling back to the configured default
+// teams if the teams can't be read. Online counts are only fetched for online-aware balancing.
+func (ps *PlayerService) loadTeamAssignment(ctx context.Context) *teamAssignment {
 	allTeams, err := ps.teamStore.GetAllTeams(ctx) // Get all teams from store
 	if err != nil {
-		log.Printf("ERROR: Could not retrieve all teams for assignment: %v. Proceeding with random assignment fallback.", err)
-		allTeams = []models.Team{{Name: "AQUA_CREEPERS"}, {Name: "PURPLE_AXOLOTLS"}} // Fallback teams
+		log.Printf("ERROR: Could not retrieve all teams for assignment: %v. Proceeding with the configured default teams.", err)
+		allTeams = make([]models.Team, 0, len(ps.config.DefaultTeams))
+		for _, teamName := range ps.config.DefaultTeams {
+			allTeams = append(allTeams, models.Team{Name: teamName})
+		}
 	}
 
-	var assignedTeamName string
-	minPlayers := int64(-1)
+	ta := &teamAssignment{teams: allTeams, counts: make(map[string]int64)}
+	for _, team := range allTeams {
+		count, err := ps.teamStore.GetTeamPlayerCount(ctx, team.Name)
+		if err != nil {
+			log.Printf("WARN: Could not retrieve player count for team %s: %v. Skipping for least populated calculation.", team.Name, err)
+			ta.counts[team.Name] = -1 // Mark as error
+		} else {
+			ta.counts[team.Name] = count
+		}
+	}
+	if len(allTeams) > 0 && len(ps.config.TeamRatios) == 0 {
+		ta.online = ps.onlineTeamCounts(ctx)
+	}
+	return ta
+}
+
+// chooseTeam picks the team for a new player: the team furthest below its configured share with team
+// ratios, otherwise the team with the fewest online players, ties broken by total count and then at
+// random. If no team qualifies, a random default team is used.
+func (ps *PlayerService) chooseTeam(ta *teamAssignment, playerUUID string) (string, error) {
 	leastPopulatedTeams := []string{}
 
-	if len(allTeams) > 0 {
-		teamCounts := make(map[string]int64)
-		for _, team := range allTeams {
-			count, err := ps.teamStore.GetTeamPlayerCount(ctx, team.Name)
-			if err != nil {
-				log.Printf("WARN: Could not retrieve player count for team %s: %v. Skipping for least populated calculation.", team.Name, err)
-				teamCounts[team.Name] = -1 // Mark as error
-			} else {
-				teamCounts[team.Name] = count
-			}
-		}
+	if len(ta.teams) > 0 {
+		if len(ps.config.TeamRatios) > 0 {
+			// Weighted assignment: the team furthest below its configured share wins.
+			leastPopulatedTeams = weightedTeamCandidates(ta.counts, ps.config.TeamRatios)
+		} else {
+			// With online balancing, the team with the fewest online players wins and total counts only break ties.
+			minPlayers := int64(-1)
+			minOnline := -1
 
-		for _, team := range allTeams {
-			count := teamCounts[team.Name]
-			if count == -1 {
-				continue
-			} // Skip errored teams
-
-			if minPlayers == -1 || count < minPlayers {
-				minPlayers = count
-				leastPopulatedTeams = []string{team.Name}
-			} else if count == minPlayers {
-				leastPopulatedTeams = append(leastPopulatedTeams, team.Name)
+			for _, team := range ta.teams {
+				count := ta.counts[team.Name]
+				if count == -1 {
+					continue
+				} // Skip errored teams
+
+				online := 0
+				if ta.online != nil {
+					online = ta.online[team.Name]
+				}
+
+				if minPlayers == -1 || online < minOnline || (online == minOnline && count < minPlayers) {
+					minOnline = online
+					minPlayers = count
+					leastPopulatedTeams = []string{team.Name}
+				} else if online == minOnline && count == minPlayers {
+					leastPopulatedTeams = append(leastPopulatedTeams, team.Name)
+				}
 			}
 		}
 	}
 
 	if len(leastPopulatedTeams) > 0 {
-		assignedTeamName = leastPopulatedTeams[rand.Intn(len(leastPopulatedTeams))]
+		assignedTeamName := leastPopulatedTeams[rand.Intn(len(leastPopulatedTeams))]
 		log.Printf("INFO: Assigned player %s to team %s (least populated).", playerUUID, assignedTeamName)
-	} else {
-		log.Printf("WARN: No valid teams found or all failed to get count. Assigning player %s to random fallback team.", playerUUID)
-		fallbackTeams := []string{"AQUA_CREEPERS", "PURPLE_AXOLOTLS"} // Ensure these are also in your EnsureTeamsExist
-		assignedTeamName = fallbackTeams[rand.Intn(len(fallbackTeams))]
+		return assignedTeamName, nil
+	}
+	if len(ps.config.DefaultTeams) == 0 {
+		return "", fmt.Errorf("no team available to assign player %s to", playerUUID)
+	}
+	log.Printf("WARN: No valid teams found or all failed to get count. Assigning player %s to a random default team.", playerUUID)
+	return ps.config.DefaultTeams[rand.Intn(len(ps.config.DefaultTeams))], nil
+}
+
+// CreateProfile handles the creation of a new player profile, including team assignment and username lookup.
+func (ps *PlayerService) CreateProfile(ctx context.Context, playerUUID string) (*models.Player, error) {
+	now := time.Now()
+
+	// 1. Check if profile already exists early to avoid unnecessary work.
+	// Soft-deleted profiles still occupy the UUID and must be restored instead of re-created.
+	_, err := ps.playerStore.FindPlayerByUUID(ctx, playerUUID, true)
+	if err == nil { // Profile found
+		return nil, ErrProfileAlreadyExists
+	}
+	if err != mongo.ErrNoDocuments { // Other error during lookup
+		return nil, fmt.Errorf("service failed to check existing profile: %w", err)
+	}
+	// If mongo.ErrNoDocuments, proceed with creation
+
+	// --- Team Assignment Logic ---
+	assignedTeamName, err := ps.chooseTeam(ps.loadTeamAssignment(ctx), playerUUID)
+	if err != nil {
+		return nil, err
 	}
 	// --- End Team Assignment Logic ---
 
@@ -191,8 +315,9 @@ func (ps *PlayerService) CreateProfile(ctx context.Context, playerUUID string) (
 }
 
 // GetProfile retrieves a player's profile.
-func (ps *PlayerService) GetProfile(ctx context.Context, uuid string) (*models.Player, error) {
-	profile, err := ps.playerStore.GetPlayerByUUID(ctx, uuid)
+// Soft-deleted profiles are only returned when includeDeleted is true.
+func (ps *PlayerService) GetProfile(ctx context.Context, uuid string, includeDeleted bool) (*models.Player, error) {
+	profile, err := ps.playerStore.FindPlayerByUUID(ctx, uuid, includeDeleted)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, ErrProfileNotFound // Return custom error
@@ -248,6 +373,32 @@ func (ps *PlayerService) UpdateProfileBanStatus(ctx context.Context, uuid string
 	return nil
 }
 
+// ListBannedProfiles returns a page of up to limit banned profiles, ordered by UUID and starting after
+// afterUUID (empty for the first page). MongoDB is authoritative for bans, unlike the game service's
+// Redis copy, which only holds bans that are currently in effect.
+func (ps *PlayerService) ListBannedProfiles(ctx context.Context, afterUUID string, limit int) ([]*models.Player, error) {
+	players, err := ps.playerStore.GetBannedPlayers(ctx, afterUUID, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("service failed to list banned profiles: %w", err)
+	}
+	return players, nil
+}
+
+// UpdateProfilePlaytimeMultiplier updates a player's permanent playtime multiplier.
+func (ps *PlayerService) UpdateProfilePlaytimeMultiplier(ctx context.Context, uuid string, multiplier float64) error {
+	if math.IsNaN(multiplier) || multiplier <= 0 || multiplier > models.MaxPlaytimeMultiplier {
+		return ErrInvalidMultiplier
+	}
+	err := ps.playerStore.UpdatePlayerPlaytimeMultiplier(ctx, uuid, multiplier)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("player %s not found for playtime multiplier update", uuid) {
+			return ErrProfileNotFound
+		}
+		return fmt.Errorf("service failed to update player playtime multiplier: %w", err)
+	}
+	return nil
+}
+
 // UpdateProfileLastLogin updates a player's last login timestamp.
 func (ps *PlayerService) UpdateProfileLastLogin(ctx context.Context, uuid string) error {
 	err := ps.playerStore.UpdatePlayerLastLogin(ctx, uuid)
@@ -259,3 +410,129 @@ func (ps *PlayerService) UpdateProfileLastLogin(ctx context.Context, uuid string
 	}
 	return nil
 }
+
+// DeleteProfile removes a player's profile.
+// Depending on configuration, the profile is either tombstoned (soft-deleted) or removed permanently.
+func (ps *PlayerService) DeleteProfile(ctx context.Context, uuid string) error {
+	var err error
+	if ps.config.SoftDeleteProfiles {
+		err = ps.playerStore.SoftDeletePlayer(ctx, uuid)
+	} else {
+		err = ps.playerStore.DeletePlayer(ctx, uuid)
+	}
+	if err != nil {
+		if err.Error() == fmt.Sprintf("player %s not found for deletion", uuid) {
+			return ErrProfileNotFound
+		}
+		return fmt.Errorf("service failed to delete player profile: %w", err)
+	}
+	return nil
+}
+
+// RestoreProfile undeletes a soft-deleted player profile.
+func (ps *PlayerService) RestoreProfile(ctx context.Context, uuid string) error {
+	err := ps.playerStore.RestorePlayer(ctx, uuid)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("player %s not found for restore", uuid) {
+			return ErrProfileNotFound
+		}
+		return fmt.Errorf("service failed to restore player profile: %w", err)
+	}
+	return nil
+}
+
+// TransferTeam moves a player to another team. The new team's player count is incremented (which also
+// yields the player's new team username) and the old team's is decremented. If migratePlaytime is true,
+// the playtime credited to the old team moves with the player; otherwise the old team keeps it.
+// The game service is told about the new team so an online player's playtime is credited correctly.
+func (ps *PlayerService) TransferTeam(ctx context.Context, uuid, newTeam string, migratePlaytime bool) (*TeamTransferResult, error) {
+	profile, err := ps.playerStore.GetPlayerByUUID(ctx, uuid)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProfileNotFound
+		}
+		return nil, fmt.Errorf("service failed to get profile for team transfer: %w", err)
+	}
+	if _, err := ps.teamStore.GetTeam(ctx, newTeam); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("service failed to look up team %s: %w", newTeam, err)
+	}
+	if profile.Team == newTeam {
+		return nil, ErrAlreadyOnTeam
+	}
+
+	current := ps.livePlaytime(ctx, uuid, profile)
+
+	teamUsername, err := ps.generateTeamUsername(ctx, newTeam)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate team username: %w", err)
+	}
+
+	result := &TeamTransferResult{OldTeam: profile.Team, NewTeam: newTeam, TeamUsername: teamUsername}
+	credited := math.Max(current-profile.TeamPlaytimeBaseline.Seconds(), 0)
+	baseline := profile.TeamPlaytimeBaseline.Seconds()
+	if migratePlaytime {
+		result.MigratedPlaytime = credited
+	} else {
+		result.RetainedPlaytime = credited
+		baseline = current // Only playtime earned from now on counts for the new team
+	}
+
+	// MongoDB has no transactions without a replica set, so each step that fails undoes the ones
+	// before it. The new team's username sequence is never lowered, so its username is not reused.
+	if err := ps.playerStore.TransferPlayerTeam(ctx, uuid, profile.Team, newTeam, teamUsername, baseline); err != nil {
+		ps.undoTeamJoin(ctx, newTeam, uuid)
+		if err == store.ErrTeamTransferConflict {
+			return nil, ErrTeamTransferConflict
+		}
+		return nil, fmt.Errorf("service failed to transfer player team: %w", err)
+	}
+
+	if profile.Team != "" {
+		if err := ps.teamStore.RemoveTeamPlayer(ctx, profile.Team, result.RetainedPlaytime); err != nil {
+			undoCtx := context.WithoutCancel(ctx)
+			if undoErr := ps.playerStore.TransferPlayerTeam(undoCtx, uuid, newTeam, profile.Team, profile.TeamUsername, profile.TeamPlaytimeBaseline.Seconds()); undoErr != nil {
+				log.Printf("ERROR: Failed to move player %s back to team %s after a failed transfer: %v", uuid, profile.Team, undoErr)
+			} else {
+				ps.undoTeamJoin(ctx, newTeam, uuid)
+			}
+			return nil, fmt.Errorf("service failed to update team %s for player transfer: %w", profile.Team, err)
+		}
+	}
+
+	if ps.gameClient != nil {
+		if err := ps.gameClient.AssignPlayerTeam(ctx, uuid, newTeam); err != nil {
+			// The game service loads the team from the profile the next time the player goes online.
+			log.Printf("WARN: Failed to update team for player %s in game service: %v", uuid, err)
+		}
+	}
+
+	log.Printf("INFO: Transferred player %s from team %s to %s (migrated %.2f, retained %.2f).", uuid, profile.Team, newTeam, result.MigratedPlaytime, result.RetainedPlaytime)
+	return result, nil
+}
+
+// livePlaytime returns the player's total playtime in seconds, including the session still being
+// counted by the game service, which MongoDB only catches up with on the next persist. It falls back
+// to the profile's total if the game service is unavailable.
+func (ps *PlayerService) livePlaytime(ctx context.Context, uuid string, profile *models.Player) float64 {
+	stored := profile.CurrentPlaytime.Seconds()
+	if ps.gameClient == nil {
+		return stored
+	}
+	live, err := ps.gameClient.GetPlayerLivePlaytime(ctx, uuid)
+	if err != nil {
+		log.Printf("WARN: Could not get live playtime of player %s from game service: %v. Using the profile's total.", uuid, err)
+		return stored
+	}
+	return math.Max(live.Playtime, stored)
+}
+
+// undoTeamJoin gives back the player count taken for a player joining teamName whose transfer failed.
+// It runs even if ctx is cancelled, since the count would otherwise stay off until the next reconcile.
+func (ps *PlayerService) undoTeamJoin(ctx context.Context, teamName, uuid string) {
+	if err := ps.teamStore.DecrementTeamPlayerCount(context.WithoutCancel(ctx), teamName); err != nil {
+		log.Printf("ERROR: Failed to undo player count increment for team %s after failed transfer of player %s: %v", teamName, uuid, err)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ftotnem/GO-SERVICES/player/store"
+	gameclient "github.com/Ftotnem/GO-SERVICES/shared/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// newTransferService returns a PlayerService on mt's mock collection whose game service reports
+// livePlaytime for every player.
+func newTransferService(mt *mtest.T, livePlaytime float64) *PlayerService {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(gameclient.PlaytimeResponse{Playtime: livePlaytime, InSession: true})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	mt.Cleanup(server.Close)
+	return NewPlayerService(store.NewPlayerStore(mt.Coll), store.NewTeamStore(mt.Coll), nil, nil, gameclient.NewGameClient(server.URL))
+}
+
+// addTransferLookups queues the profile and new team lookups TransferTeam starts with, and the
+// new team's increment.
+func addTransferLookups(mt *mtest.T) {
+	ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+	mt.AddMockResponses(
+		mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "player-1"},
+			{Key: "team", Value: "AQUA_CREEPERS"},
+			{Key: "team_username", Value: "Creeper7"},
+			{Key: "current_playtime", Value: int64(100_000)},
+			{Key: "team_playtime_baseline", Value: int64(40_000)},
+		}),
+		mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: "PURPLE_AXOLOTLS"}}),
+		bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{{Key: "_id", Value: "PURPLE_AXOLOTLS"}, {Key: "username_seq", Value: int64(12)}}}},
+	)
+}
+
+func updateCommands(events []*event.CommandStartedEvent) []bson.Raw {
+	var updates []bson.Raw
+	for _, e := range events {
+		if e.CommandName == "update" {
+			updates = append(updates, e.Command.Lookup("updates").Array().Index(0).Value().Document())
+		}
+	}
+	return updates
+}
+
+func TestTransferTeamUsesLivePlaytime(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("retains live playtime", func(mt *mtest.T) {
+		ps := newTransferService(mt, 130)
+		addTransferLookups(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}), mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		result, err := ps.TransferTeam(context.Background(), "player-1", "PURPLE_AXOLOTLS", false)
+		if err != nil {
+			mt.Fatalf("TransferTeam: %v", err)
+		}
+		if result.TeamUsername != "Axolotl12" || result.RetainedPlaytime != 90 {
+			mt.Errorf("result = %+v, want username Axolotl12 and 90 retained", result)
+		}
+
+		updates := updateCommands(mt.GetAllStartedEvents())
+		if len(updates) != 2 {
+			mt.Fatalf("sent %d updates, want the transfer and the old team's", len(updates))
+		}
+		if team := updates[0].Lookup("q", "team").StringValue(); team != "AQUA_CREEPERS" {
+			mt.Errorf("transfer filter team = %q, want the old team", team)
+		}
+		if baseline := updates[0].Lookup("u", "$set", "team_playtime_baseline").AsInt64(); baseline != 130_000 {
+			mt.Errorf("new baseline = %d ms, want the live total 130000", baseline)
+		}
+		if retained := updates[1].Lookup("u", "$inc", "retained_playtime").Double(); retained != 90 {
+			mt.Errorf("retained playtime = %v, want 90", retained)
+		}
+	})
+}
+
+func TestTransferTeamUndoesFailedSteps(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("conflicting transfer", func(mt *mtest.T) {
+		ps := newTransferService(mt, 130)
+		addTransferLookups(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}), mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		if _, err := ps.TransferTeam(context.Background(), "player-1", "PURPLE_AXOLOTLS", true); err != ErrTeamTransferConflict {
+			mt.Fatalf("TransferTeam error = %v, want ErrTeamTransferConflict", err)
+		}
+		updates := updateCommands(mt.GetAllStartedEvents())
+		if len(updates) != 2 {
+			mt.Fatalf("sent %d updates, want the transfer and the undo", len(updates))
+		}
+		assertUndoesJoin(mt, updates[1])
+	})
+
+	mt.Run("old team update fails", func(mt *mtest.T) {
+		ps := newTransferService(mt, 130)
+		addTransferLookups(mt)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}), // Old team is gone
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		if _, err := ps.TransferTeam(context.Background(), "player-1", "PURPLE_AXOLOTLS", false); err == nil {
+			mt.Fatal("TransferTeam succeeded, want the old team's update error")
+		}
+		updates := updateCommands(mt.GetAllStartedEvents())
+		if len(updates) != 4 {
+			mt.Fatalf("sent %d updates, want transfer, old team, transfer back and undo", len(updates))
+		}
+		back := updates[2]
+		if team := back.Lookup("q", "team").StringValue(); team != "PURPLE_AXOLOTLS" {
+			mt.Errorf("transfer back filter team = %q, want the new team", team)
+		}
+		if team := back.Lookup("u", "$set", "team").StringValue(); team != "AQUA_CREEPERS" {
+			mt.Errorf("transfer back team = %q, want the old team", team)
+		}
+		if username := back.Lookup("u", "$set", "team_username").StringValue(); username != "Creeper7" {
+			mt.Errorf("transfer back username = %q, want the old username", username)
+		}
+		if baseline := back.Lookup("u", "$set", "team_playtime_baseline").AsInt64(); baseline != 40_000 {
+			mt.Errorf("transfer back baseline = %d ms, want the old baseline 40000", baseline)
+		}
+		assertUndoesJoin(mt, updates[3])
+	})
+}
+
+// assertUndoesJoin checks that update gives back the new team's player count and leaves its
+// username sequence alone.
+func assertUndoesJoin(mt *mtest.T, update bson.Raw) {
+	mt.Helper()
+	if team := update.Lookup("q", "_id").StringValue(); team != "PURPLE_AXOLOTLS" {
+		mt.Errorf("undo targets team %q, want PURPLE_AXOLOTLS", team)
+	}
+	if inc := update.Lookup("u", "$inc", "player_count").AsInt64(); inc != -1 {
+		mt.Errorf("undo increments player_count by %d, want -1", inc)
+	}
+	if _, err := update.LookupErr("u", "$inc", "username_seq"); err == nil {
+		mt.Errorf("undo %v lowers the username sequence", update)
+	}
+}
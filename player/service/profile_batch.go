@@ -0,0 +1,132 @@
+// player/service/profile_batch.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/player/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+)
+
+// ProfileCreateResult is the outcome of creating one profile in CreateProfiles.
+type ProfileCreateResult struct {
+	UUID    string
+	Profile *models.Player // Set if the profile was created
+	Err     error          // ErrProfileAlreadyExists if the UUID already has a profile
+}
+
+// CreateProfiles creates profiles for many players at once, e.g. to seed or migrate data. Teams are
+// assigned as in CreateProfile, with the counts updated in memory as the batch is assigned; each team's
+// player count is then incremented once for all its new players and the profiles are inserted with a
+// single bulk write; counts taken for profiles that fail to insert are given back. UUIDs that already
+// have a profile (soft-deleted included) get ErrProfileAlreadyExists.
+// Usernames are not looked up here; the Mojang filler job fills them in later.
+// Results are in the order of playerUUIDs, which must not contain duplicates.
+func (ps *PlayerService) CreateProfiles(ctx context.Context, playerUUIDs []string) ([]ProfileCreateResult, error) {
+	results := make([]ProfileCreateResult, len(playerUUIDs))
+	for i, playerUUID := range playerUUIDs {
+		results[i].UUID = playerUUID
+	}
+
+	existing, err := ps.playerStore.FindExistingUUIDs(ctx, playerUUIDs)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to check existing profiles: %w", err)
+	}
+
+	ta := ps.loadTeamAssignment(ctx)
+	teams := make([]string, len(playerUUIDs))
+	newPerTeam := make(map[string]int64)
+	for i, playerUUID := range playerUUIDs {
+		if existing[playerUUID] {
+			results[i].Err = ErrProfileAlreadyExists
+			continue
+		}
+		team, err := ps.chooseTeam(ta, playerUUID)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		teams[i] = team
+		newPerTeam[team]++
+		if count, ok := ta.counts[team]; ok && count >= 0 {
+			ta.counts[team] = count + 1 // Balance the rest of the batch against this assignment
+		}
+	}
+
+	// Each team's new players are numbered from its count before this batch.
+	nextNumber := make(map[string]int64, len(newPerTeam))
+	for team, n := range newPerTeam {
+		lastNumber, err := ps.teamStore.AddTeamPlayersAndGet(ctx, team, n)
+		if err != nil {
+			log.Printf("ERROR: Failed to add %d players to team %s: %v", n, team, err)
+			continue
+		}
+		nextNumber[team] = lastNumber - n + 1
+	}
+
+	now := time.Now()
+	profiles := make([]*models.Player, 0, len(playerUUIDs))
+	profileIdx := make([]int, 0, len(playerUUIDs))
+	for i, team := range teams {
+		if team == "" {
+			continue
+		}
+		number, ok := nextNumber[team]
+		if !ok {
+			results[i].Err = fmt.Errorf("failed to generate team username: player count of team %s could not be updated", team)
+			continue
+		}
+		nextNumber[team] = number + 1
+		profiles = append(profiles, &models.Player{
+			UUID:            results[i].UUID,
+			Team:            team,
+			TeamUsername:    formatTeamUsername(team, number),
+			CurrentPlaytime: 0.0,
+			DeltaPlaytime:   1.0,
+			CreatedAt:       &now,
+			LastLoginAt:     &now,
+		})
+		profileIdx = append(profileIdx, i)
+	}
+
+	insertErrs, err := ps.playerStore.CreatePlayers(ctx, profiles)
+	if err != nil {
+		ps.undoTeamAdds(ctx, profiles)
+		return nil, fmt.Errorf("service failed to create player profiles: %w", err)
+	}
+	var failed []*models.Player
+	for j, insertErr := range insertErrs {
+		i := profileIdx[j]
+		switch {
+		case insertErr == nil:
+			results[i].Profile = profiles[j]
+			continue
+		case errors.Is(insertErr, store.ErrDuplicatePlayer):
+			results[i].Err = ErrProfileAlreadyExists // Created concurrently since the lookup
+		default:
+			results[i].Err = insertErr
+		}
+		failed = append(failed, profiles[j])
+	}
+	ps.undoTeamAdds(ctx, failed)
+	return results, nil
+}
+
+// undoTeamAdds gives back the team player counts taken for profiles that were not inserted. Their
+// team usernames are not reused, since the username sequence is never lowered. It runs even if ctx
+// is cancelled, since the counts would otherwise stay off until the next reconcile.
+func (ps *PlayerService) undoTeamAdds(ctx context.Context, profiles []*models.Player) {
+	perTeam := make(map[string]int64)
+	for _, profile := range profiles {
+		perTeam[profile.Team]++
+	}
+	for team, n := range perTeam {
+		if err := ps.teamStore.DecrementTeamPlayerCountBy(context.WithoutCancel(ctx), team, n); err != nil {
+			log.Printf("ERROR: Failed to give back %d player count for team %s after failed profile inserts: %v", n, team, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Ftotnem/GO-SERVICES/player/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCreateProfilesGivesBackFailedInserts(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("duplicate insert", func(mt *mtest.T) {
+		ps := NewPlayerService(store.NewPlayerStore(mt.Coll), store.NewTeamStore(mt.Coll), nil, &config.PlayerServiceConfig{}, nil)
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch), // No existing profiles
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: "PURPLE_AXOLOTLS"}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: "PURPLE_AXOLOTLS"}, {Key: "player_count", Value: int64(9)}}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{{Key: "_id", Value: "PURPLE_AXOLOTLS"}, {Key: "username_seq", Value: int64(12)}}}},
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 1, Code: 11000, Message: "E11000 duplicate key error"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		results, err := ps.CreateProfiles(context.Background(), []string{"player-1", "player-2", "player-3"})
+		if err != nil {
+			mt.Fatalf("CreateProfiles: %v", err)
+		}
+		if !errors.Is(results[1].Err, ErrProfileAlreadyExists) {
+			mt.Errorf("results[1] error = %v, want ErrProfileAlreadyExists", results[1].Err)
+		}
+		for _, i := range []int{0, 2} {
+			if results[i].Err != nil || results[i].Profile == nil {
+				mt.Errorf("results[%d] = %+v, want a created profile", i, results[i])
+			}
+		}
+
+		updates := updateCommands(mt.GetAllStartedEvents())
+		if len(updates) != 1 {
+			mt.Fatalf("sent %d updates, want the give-back", len(updates))
+		}
+		assertUndoesJoin(mt, updates[0])
+	})
+}
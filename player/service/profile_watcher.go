@@ -0,0 +1,94 @@
+// player/service/profile_watcher.go
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/player/store"
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	profileWatchInitialBackoff = 1 * time.Second
+	profileWatchMaxBackoff     = 30 * time.Second
+)
+
+// ProfileChangeCallback is invoked for every change to a player profile observed by a ProfileWatcher,
+// so it can be propagated elsewhere (e.g., published on a Redis channel).
+type ProfileChangeCallback func(ctx context.Context, change models.ProfileChange) error
+
+// ProfileWatcher follows the player profile change stream in MongoDB and hands every change to a callback.
+// This lets other services react to profile edits made anywhere, including directly in the database.
+type ProfileWatcher struct {
+	playerStore *store.PlayerStore
+	onChange    ProfileChangeCallback
+
+	ctx      context.Context // Cancelled by Stop, which ends the change stream
+	cancel   context.CancelFunc
+	doneChan chan struct{}
+}
+
+// NewProfileWatcher creates a ProfileWatcher. Watching begins when Start is called.
+func NewProfileWatcher(ps *store.PlayerStore, onChange ProfileChangeCallback) *ProfileWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ProfileWatcher{
+		playerStore: ps,
+		onChange:    onChange,
+		ctx:         ctx,
+		cancel:      cancel,
+		doneChan:    make(chan struct{}),
+	}
+}
+
+// Start watches for profile changes until Stop is called. If the stream fails it is reopened with
+// exponential backoff, resuming after the last change handled so none are missed while the resume
+// token is still in the oplog. This should be run in a goroutine.
+func (pw *ProfileWatcher) Start() {
+	defer close(pw.doneChan)
+
+	log.Println("ProfileWatcher: Watching player profile changes.")
+	var resumeToken bson.Raw
+	backoff := profileWatchInitialBackoff
+	for {
+		var err error
+		openedAt := time.Now()
+		resumeToken, err = pw.playerStore.WatchProfileChanges(pw.ctx, resumeToken, pw.handleChange)
+		if pw.ctx.Err() != nil {
+			log.Println("ProfileWatcher: Stopped watching player profile changes.")
+			return
+		}
+		// A stream that stayed up for a while failed on its own; don't carry over the backoff from earlier failures.
+		if time.Since(openedAt) > profileWatchMaxBackoff {
+			backoff = profileWatchInitialBackoff
+		}
+		log.Printf("WARNING: ProfileWatcher: %v; reopening in %v", err, backoff)
+
+		select {
+		case <-time.After(backoff):
+			backoff = min(backoff*2, profileWatchMaxBackoff)
+		case <-pw.ctx.Done():
+			log.Println("ProfileWatcher: Stopped watching player profile changes.")
+			return
+		}
+	}
+}
+
+// Stop ends the change stream and waits for Start to return.
+func (pw *ProfileWatcher) Stop() {
+	pw.cancel()
+	<-pw.doneChan
+}
+
+// handleChange passes a change to the callback. Callback failures are logged rather than returned
+// so a single unpublishable change doesn't stall the stream.
+func (pw *ProfileWatcher) handleChange(change models.ProfileChange) error {
+	ctx, cancel := context.WithTimeout(pw.ctx, 5*time.Second)
+	defer cancel()
+	if err := pw.onChange(ctx, change); err != nil {
+		log.Printf("WARNING: ProfileWatcher: Failed to propagate %s of profile %s: %v", change.Operation, change.UUID, err)
+	}
+	return nil
+}
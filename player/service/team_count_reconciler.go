@@ -0,0 +1,83 @@
+// player/service/team_count_reconciler.go
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// TeamCountReconcileTaskKey is the assignment key deciding which instance reconciles team player counts.
+const TeamCountReconcileTaskKey = "team_count_reconcile_task"
+
+// TeamCountReconciler periodically runs TeamService.ReconcileTeamCounts on the one instance isLeader
+// reports true for, so instances don't all recount the same teams.
+type TeamCountReconciler struct {
+	teamService *TeamService
+	isLeader    func() (bool, error)
+	interval    time.Duration
+	ctx         context.Context
+	cancel      context.CancelFunc
+	doneChan    chan struct{} // Closed when the reconcile loop has exited
+}
+
+// NewTeamCountReconciler creates a TeamCountReconciler that reconciles every interval while isLeader
+// reports true, e.g. a check of the service's assignment ring for TeamCountReconcileTaskKey.
+func NewTeamCountReconciler(teamService *TeamService, isLeader func() (bool, error), interval time.Duration) *TeamCountReconciler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TeamCountReconciler{
+		teamService: teamService,
+		isLeader:    isLeader,
+		interval:    interval,
+		ctx:         ctx,
+		cancel:      cancel,
+		doneChan:    make(chan struct{}),
+	}
+}
+
+// Start runs the reconcile loop until Stop is called. This should be run in a goroutine.
+func (r *TeamCountReconciler) Start() {
+	defer close(r.doneChan)
+	log.Printf("TeamCountReconciler: Reconciling team player counts every %v.", r.interval)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			log.Println("TeamCountReconciler: Shutting down.")
+			return
+		case <-ticker.C:
+			r.reconcileOnce()
+		}
+	}
+}
+
+// Stop signals the reconcile loop to exit and waits for it to finish.
+func (r *TeamCountReconciler) Stop() {
+	r.cancel()
+	<-r.doneChan
+}
+
+// reconcileOnce reconciles the team counts if this instance is the leader, logging failures.
+func (r *TeamCountReconciler) reconcileOnce() {
+	isLeader, err := r.isLeader()
+	if err != nil {
+		log.Printf("ERROR: TeamCountReconciler: Failed to check leadership for task '%s': %v", TeamCountReconcileTaskKey, err)
+		return
+	}
+	if !isLeader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, r.interval)
+	defer cancel()
+	corrections, err := r.teamService.ReconcileTeamCounts(ctx)
+	if err != nil {
+		log.Printf("WARNING: TeamCountReconciler: %v", err)
+		return
+	}
+	if len(corrections) > 0 {
+		log.Printf("INFO: TeamCountReconciler: Corrected the player count of %d teams.", len(corrections))
+	}
+}
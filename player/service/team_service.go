@@ -5,26 +5,122 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
+	"sync"
+	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/player/store"
+	gameclient "github.com/Ftotnem/GO-SERVICES/shared/service"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// TeamTotalCallback is invoked after a team's total playtime has been recomputed in MongoDB,
+// so the new value can be propagated elsewhere (e.g., the game service's Redis cache).
+type TeamTotalCallback func(ctx context.Context, teamName string, totalPlaytime float64) error
+
 // TeamService encapsulates the business logic for teams.
 type TeamService struct {
-	teamStore   *store.TeamStore
-	playerStore *store.PlayerStore // Used for aggregation, still part of business logic
+	teamStore        *store.TeamStore
+	playerStore      *store.PlayerStore            // Used for aggregation, still part of business logic
+	onTeamRecomputed TeamTotalCallback             // Optional, may be nil
+	gameClient       *gameclient.GameServiceClient // Optional; told about renamed teams
+
+	syncs teamSyncGroup // Shares one team totals aggregation between concurrent SyncTeamTotals calls
+}
+
+// teamSyncGroup runs at most one team totals aggregation at a time; calls made while one is in
+// progress wait for it and share its result.
+type teamSyncGroup struct {
+	mu  sync.Mutex
+	run *teamSyncRun // The aggregation in progress, if any; protected by mu
+}
+
+// teamSyncRun is one run of the team totals aggregation, shared by every SyncTeamTotals call made
+// while it is in progress.
+type teamSyncRun struct {
+	done   chan struct{} // Closed when totals and err are set
+	totals map[string]float64
+	err    error
 }
 
+// teamSyncTimeout bounds a team totals aggregation, which no longer ends with the request that started it.
+const teamSyncTimeout = 60 * time.Second
+
 // NewTeamService creates a new TeamService instance.
-func NewTeamService(ts *store.TeamStore, ps *store.PlayerStore) *TeamService {
+// onTeamRecomputed is optional and is called after a single team's total has been recomputed.
+// gameClient may be nil, in which case renamed teams are only picked up by the game service
+// when players next go online.
+func NewTeamService(ts *store.TeamStore, ps *store.PlayerStore, onTeamRecomputed TeamTotalCallback, gameClient *gameclient.GameServiceClient) *TeamService {
 	return &TeamService{
-		teamStore:   ts,
-		playerStore: ps,
+		teamStore:        ts,
+		playerStore:      ps,
+		onTeamRecomputed: onTeamRecomputed,
+		gameClient:       gameClient,
 	}
 }
 
+// ListTeamNames returns the names of all known teams.
+func (ts *TeamService) ListTeamNames(ctx context.Context) ([]string, error) {
+	teams, err := ts.teamStore.GetAllTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	names := make([]string, 0, len(teams))
+	for _, team := range teams {
+		names = append(names, team.Name)
+	}
+	return names, nil
+}
+
 // SyncTeamTotals aggregates player playtimes and updates team totals in the database.
+// It only overwrites the totals with freshly computed ones, so it is safe to call repeatedly, e.g. when
+// the game service retries a sync whose response it never got. Calls made while a sync is in progress
+// join it instead of starting another aggregation. The aggregation outlives a canceled caller, so a
+// retry after a client timeout picks up the run that is already under way.
 func (ts *TeamService) SyncTeamTotals(ctx context.Context) (map[string]float64, error) {
+	return ts.syncs.do(ctx, ts.syncTeamTotals)
+}
+
+// do starts aggregate unless a run is already in progress, and waits for the run's result. The run is
+// detached from ctx and bounded by teamSyncTimeout instead; ctx only bounds the wait.
+func (g *teamSyncGroup) do(ctx context.Context, aggregate func(context.Context) (map[string]float64, error)) (map[string]float64, error) {
+	g.mu.Lock()
+	run := g.run
+	if run == nil {
+		run = &teamSyncRun{done: make(chan struct{})}
+		g.run = run
+		go g.start(context.WithoutCancel(ctx), run, aggregate)
+	} else {
+		log.Println("Team total playtime aggregation already in progress; waiting for it.")
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("service stopped waiting for team totals aggregation: %w", ctx.Err())
+	}
+	if run.err != nil {
+		return nil, run.err
+	}
+	return maps.Clone(run.totals), nil // Every caller gets its own copy
+}
+
+// start performs run, bounded by teamSyncTimeout, and clears it as the run in progress.
+func (g *teamSyncGroup) start(ctx context.Context, run *teamSyncRun, aggregate func(context.Context) (map[string]float64, error)) {
+	ctx, cancel := context.WithTimeout(ctx, teamSyncTimeout)
+	defer cancel()
+
+	run.totals, run.err = aggregate(ctx)
+
+	g.mu.Lock()
+	g.run = nil
+	g.mu.Unlock()
+	close(run.done)
+}
+
+// syncTeamTotals does the work of SyncTeamTotals.
+func (ts *TeamService) syncTeamTotals(ctx context.Context) (map[string]float64, error) {
 	log.Println("Starting team total playtime aggregation job (service layer)...")
 
 	// Call the store to perform the aggregation
@@ -33,6 +129,17 @@ func (ts *TeamService) SyncTeamTotals(ctx context.Context) (map[string]float64,
 		return nil, fmt.Errorf("service failed to aggregate team totals: %w", err)
 	}
 
+	// Add playtime retained from former members (team transfers without playtime migration).
+	teams, err := ts.teamStore.GetAllTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to load teams for retained playtime: %w", err)
+	}
+	for _, team := range teams {
+		if team.RetainedPlaytime != 0 {
+			teamTotalsMap[team.Name] += team.RetainedPlaytime
+		}
+	}
+
 	// Iterate through aggregation results and update MongoDB Team collection via the store
 	for teamName, calculatedTotal := range teamTotalsMap {
 		if err := ts.teamStore.UpdateTeamTotalPlaytime(ctx, teamName, calculatedTotal); err != nil {
@@ -46,3 +153,138 @@ func (ts *TeamService) SyncTeamTotals(ctx context.Context) (map[string]float64,
 	log.Println("Team total playtime aggregation job finished (service layer).")
 	return teamTotalsMap, nil
 }
+
+// TeamCountCorrection is a team whose stored player count was corrected by ReconcileTeamCounts.
+type TeamCountCorrection struct {
+	Team     string
+	Previous int64 // Stored player count before the correction
+	Actual   int64 // Number of (non-deleted) players on the team
+}
+
+// ReconcileTeamCounts recounts the players on every team and corrects the stored player counts that
+// have drifted, e.g. through failed decrements or manual edits, returning the corrections made.
+// Teams whose count could not be updated are logged and skipped, as are teams that gained or lost a
+// player while counting; the next run picks them up. New team usernames are numbered by a separate
+// sequence, which this leaves alone.
+func (ts *TeamService) ReconcileTeamCounts(ctx context.Context) ([]TeamCountCorrection, error) {
+	teams, err := ts.teamStore.GetAllTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to load teams: %w", err)
+	}
+	counts, err := ts.playerStore.CountPlayersByTeam(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to count players per team: %w", err)
+	}
+
+	var corrections []TeamCountCorrection
+	for _, team := range teams {
+		actual := counts[team.Name]
+		if team.PlayerCount == actual {
+			continue
+		}
+		replaced, err := ts.teamStore.ReplaceTeamPlayerCount(ctx, team.Name, team.PlayerCount, actual)
+		if err != nil {
+			log.Printf("ERROR: Failed to correct player count for team %s: %v", team.Name, err)
+			continue
+		}
+		if !replaced {
+			log.Printf("INFO: Player count of team '%s' changed while recounting; leaving it for the next run.", team.Name)
+			continue
+		}
+		log.Printf("INFO: Corrected player count for team '%s' from %d to %d.", team.Name, team.PlayerCount, actual)
+		corrections = append(corrections, TeamCountCorrection{Team: team.Name, Previous: team.PlayerCount, Actual: actual})
+	}
+	return corrections, nil
+}
+
+// RecomputeTeamTotal recalculates a single team's total playtime from MongoDB, stores it on the team
+// document and propagates it through the configured callback. This avoids a full SyncTeamTotals run
+// when only one team has drifted.
+func (ts *TeamService) RecomputeTeamTotal(ctx context.Context, teamName string) (float64, error) {
+	team, err := ts.teamStore.GetTeam(ctx, teamName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, ErrTeamNotFound
+		}
+		return 0, fmt.Errorf("service failed to look up team %s: %w", teamName, err)
+	}
+
+	total, err := ts.playerStore.AggregateTeamPlaytime(ctx, teamName)
+	if err != nil {
+		return 0, fmt.Errorf("service failed to aggregate total for team %s: %w", teamName, err)
+	}
+	total += team.RetainedPlaytime
+
+	if err := ts.teamStore.UpdateTeamTotalPlaytime(ctx, teamName, total); err != nil {
+		return 0, fmt.Errorf("service failed to update total playtime for team %s: %w", teamName, err)
+	}
+	log.Printf("INFO: Recomputed MongoDB total playtime for team '%s': %.2f ticks.", teamName, total)
+
+	if ts.onTeamRecomputed != nil {
+		if err := ts.onTeamRecomputed(ctx, teamName, total); err != nil {
+			// MongoDB is authoritative; the next full sync will correct the cache.
+			log.Printf("WARN: Failed to propagate recomputed total for team %s: %v", teamName, err)
+		}
+	}
+
+	return total, nil
+}
+
+// TeamRenameResult reports the outcome of RenameTeam.
+type TeamRenameResult struct {
+	PlayersMoved      int64  // Player profiles moved to the new team name in MongoDB
+	PlayersReassigned int    // Team assignments of players with a session moved in the game service's Redis
+	GameServiceError  string // Non-empty if the game service could not be told about the rename
+}
+
+// RenameTeam renames a team. The team document's _id is its name, so it is recreated under newName
+// with the same counts and totals; every player profile on the team is moved to it and the old
+// document is deleted. The game service is then told to move the team's Redis data (player
+// assignments, total and leaderboard entry) to the new name.
+// A team still listed in the default teams is recreated empty on the next start, so the configuration
+// must be updated too. Returns ErrTeamNotFound if oldName does not exist and ErrTeamAlreadyExists if
+// newName does.
+func (ts *TeamService) RenameTeam(ctx context.Context, oldName, newName string) (*TeamRenameResult, error) {
+	team, err := ts.teamStore.GetTeam(ctx, oldName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("service failed to look up team %s: %w", oldName, err)
+	}
+
+	now := time.Now()
+	renamed := *team
+	renamed.Name = newName
+	renamed.LastUpdated = &now
+	if err := ts.teamStore.CreateTeam(ctx, &renamed); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrTeamAlreadyExists
+		}
+		return nil, fmt.Errorf("service failed to create renamed team %s: %w", newName, err)
+	}
+
+	result := &TeamRenameResult{}
+	result.PlayersMoved, err = ts.playerStore.RenameTeam(ctx, oldName, newName)
+	if err != nil {
+		// Both documents exist now; moving the remaining players by hand and deleting the old team finishes the rename.
+		return nil, fmt.Errorf("service failed to move players of team %s to %s: %w", oldName, newName, err)
+	}
+	if err := ts.teamStore.DeleteTeam(ctx, oldName); err != nil {
+		log.Printf("ERROR: Failed to delete team %s after renaming it to %s: %v", oldName, newName, err)
+	}
+	log.Printf("INFO: Renamed team '%s' to '%s' in MongoDB (%d players moved).", oldName, newName, result.PlayersMoved)
+
+	if ts.gameClient != nil {
+		resp, err := ts.gameClient.RenameTeam(ctx, oldName, newName)
+		if err != nil {
+			// The game service loads the team from the profile the next time a player goes online,
+			// and the next team sync pushes the renamed team's total.
+			log.Printf("WARN: Failed to rename team %s in game service: %v", oldName, err)
+			result.GameServiceError = err.Error()
+		} else {
+			result.PlayersReassigned = resp.PlayersReassigned
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTeamSyncGroupSharesRunInProgress(t *testing.T) {
+	var g teamSyncGroup
+	var calls atomic.Int32
+	release := make(chan struct{})
+	aggregate := func(ctx context.Context) (map[string]float64, error) {
+		calls.Add(1)
+		<-release
+		return map[string]float64{"AQUA_CREEPERS": 10}, nil
+	}
+
+	const callers = 5
+	results := make([]map[string]float64, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			totals, err := g.do(context.Background(), aggregate)
+			if err != nil {
+				t.Errorf("caller %d: %v", i, err)
+			}
+			results[i] = totals
+		}()
+	}
+	waitFor(t, func() bool { return calls.Load() == 1 })
+	time.Sleep(20 * time.Millisecond) // Let the other callers join the run
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("aggregation ran %d times for %d concurrent callers, want 1", n, callers)
+	}
+	results[0]["AQUA_CREEPERS"] = 99 // Each caller has its own copy
+	for i, totals := range results[1:] {
+		if totals["AQUA_CREEPERS"] != 10 {
+			t.Errorf("caller %d got %v, want AQUA_CREEPERS=10", i+1, totals)
+		}
+	}
+
+	// Once finished, the next call starts a new run.
+	if _, err := g.do(context.Background(), func(ctx context.Context) (map[string]float64, error) {
+		calls.Add(1)
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("aggregation ran %d times after a finished run, want 2", n)
+	}
+}
+
+func TestTeamSyncGroupRunOutlivesCanceledCaller(t *testing.T) {
+	var g teamSyncGroup
+	release := make(chan struct{})
+	runCtxErr := make(chan error, 2)
+	var calls atomic.Int32
+	aggregate := func(ctx context.Context) (map[string]float64, error) {
+		calls.Add(1)
+		<-release
+		runCtxErr <- ctx.Err()
+		return nil, errors.New("aggregation failed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := g.do(ctx, aggregate)
+		firstErr <- err
+	}()
+	waitFor(t, func() bool { return calls.Load() == 1 })
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled caller got %v, want context.Canceled", err)
+	}
+
+	// A retry joins the run the canceled caller started rather than starting another.
+	retryErr := make(chan error, 1)
+	go func() {
+		_, err := g.do(context.Background(), aggregate)
+		retryErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if err := <-retryErr; err == nil || err.Error() != "aggregation failed" {
+		t.Errorf("retry got %v, want the run's error", err)
+	}
+	if err := <-runCtxErr; err != nil {
+		t.Errorf("run context was %v after the caller was canceled, want it still live", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("aggregation ran %d times, want 1", n)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
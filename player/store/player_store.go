@@ -3,6 +3,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -10,11 +11,15 @@ import (
 	"github.com/Ftotnem/GO-SERVICES/shared/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 // PlayerStore represents the MongoDB data store for player profiles.
 type PlayerStore struct {
 	collection *mongo.Collection
+	// playtimeCollection is used for playtime persists; it may carry a stricter write concern than collection.
+	playtimeCollection *mongo.Collection
 	// No direct MojangClient or TeamStore here! Stores should only do DB stuff.
 }
 
@@ -22,10 +27,23 @@ type PlayerStore struct {
 // The mongo.Client comes from your shared/mongodb package.
 func NewPlayerStore(collection *mongo.Collection) *PlayerStore {
 	return &PlayerStore{
-		collection: collection,
+		collection:         collection,
+		playtimeCollection: collection,
 	}
 }
 
+// UseMajorityPlaytimeWrites makes playtime persists wait for acknowledgment from a majority of the
+// replica set, so an acknowledged persist survives a primary crash. This trades latency for durability;
+// other writes keep the collection's default write concern.
+func (ps *PlayerStore) UseMajorityPlaytimeWrites() error {
+	playtimeCollection, err := ps.collection.Clone(options.Collection().SetWriteConcern(writeconcern.Majority()))
+	if err != nil {
+		return fmt.Errorf("failed to configure majority write concern for playtime persists: %w", err)
+	}
+	ps.playtimeCollection = playtimeCollection
+	return nil
+}
+
 // CreatePlayer inserts a new player document (profile) into the collection.
 func (ps *PlayerStore) CreatePlayer(ctx context.Context, player *models.Player) error {
 	_, err := ps.collection.InsertOne(ctx, player)
@@ -38,10 +56,79 @@ func (ps *PlayerStore) CreatePlayer(ctx context.Context, player *models.Player)
 	return nil
 }
 
+// ErrDuplicatePlayer is returned (wrapped) by CreatePlayers for a profile whose UUID is already taken.
+var ErrDuplicatePlayer = errors.New("player profile already exists")
+
+// CreatePlayers inserts many player documents with one unordered bulk write, so one failed insert
+// doesn't stop the others. It returns one error per player, in order: nil if inserted, wrapping
+// ErrDuplicatePlayer if the UUID is taken. The second return value is set if the whole write failed.
+func (ps *PlayerStore) CreatePlayers(ctx context.Context, players []*models.Player) ([]error, error) {
+	errs := make([]error, len(players))
+	if len(players) == 0 {
+		return errs, nil
+	}
+	writes := make([]mongo.WriteModel, len(players))
+	for i, player := range players {
+		writes[i] = mongo.NewInsertOneModel().SetDocument(player)
+	}
+
+	_, err := ps.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			uuid := players[writeErr.Index].UUID
+			if mongo.IsDuplicateKeyError(writeErr) {
+				errs[writeErr.Index] = fmt.Errorf("player profile %s: %w", uuid, ErrDuplicatePlayer)
+			} else {
+				errs[writeErr.Index] = fmt.Errorf("failed to create player profile %s: %w", uuid, writeErr)
+			}
+		}
+		return errs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %d player profiles: %w", len(players), err)
+	}
+	return errs, nil
+}
+
+// FindExistingUUIDs returns which of the given UUIDs already have a profile, soft-deleted ones included.
+func (ps *PlayerStore) FindExistingUUIDs(ctx context.Context, uuids []string) (map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := ps.collection.Find(ctx, bson.M{"_id": bson.M{"$in": uuids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %d player profiles: %w", len(uuids), err)
+	}
+	defer cursor.Close(ctx)
+
+	existing := make(map[string]bool)
+	for cursor.Next(ctx) {
+		var doc struct {
+			UUID string `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode player profile ID: %w", err)
+		}
+		existing[doc.UUID] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate player profiles: %w", err)
+	}
+	return existing, nil
+}
+
 // GetPlayerByUUID retrieves a player profile by their UUID.
+// Soft-deleted profiles are treated as not found.
 func (ps *PlayerStore) GetPlayerByUUID(ctx context.Context, uuid string) (*models.Player, error) {
+	return ps.FindPlayerByUUID(ctx, uuid, false)
+}
+
+// FindPlayerByUUID retrieves a player profile by their UUID, optionally including soft-deleted profiles.
+func (ps *PlayerStore) FindPlayerByUUID(ctx context.Context, uuid string, includeDeleted bool) (*models.Player, error) {
 	var profile models.Player
 	filter := bson.M{"_id": uuid}
+	if !includeDeleted {
+		filter["deleted_at"] = nil // Matches documents where deleted_at is missing or null
+	}
 	err := ps.collection.FindOne(ctx, filter).Decode(&profile)
 	if err != nil {
 		return nil, err // Return mongo.ErrNoDocuments if not found
@@ -49,6 +136,49 @@ func (ps *PlayerStore) GetPlayerByUUID(ctx context.Context, uuid string) (*model
 	return &profile, nil
 }
 
+// DeletePlayer permanently removes a player profile from the collection.
+func (ps *PlayerStore) DeletePlayer(ctx context.Context, uuid string) error {
+	filter := bson.M{"_id": uuid}
+	res, err := ps.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete player %s: %w", uuid, err)
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("player %s not found for deletion", uuid)
+	}
+	return nil
+}
+
+// SoftDeletePlayer tombstones a player profile by setting its deleted_at timestamp.
+// Profiles that are already soft-deleted are treated as not found.
+func (ps *PlayerStore) SoftDeletePlayer(ctx context.Context, uuid string) error {
+	filter := bson.M{"_id": uuid, "deleted_at": nil}
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deleted_at": &now}}
+	res, err := ps.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to soft-delete player %s: %w", uuid, err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("player %s not found for deletion", uuid)
+	}
+	return nil
+}
+
+// RestorePlayer clears the deleted_at timestamp of a soft-deleted player profile.
+func (ps *PlayerStore) RestorePlayer(ctx context.Context, uuid string) error {
+	filter := bson.M{"_id": uuid, "deleted_at": bson.M{"$ne": nil}}
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+	res, err := ps.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to restore player %s: %w", uuid, err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("player %s not found for restore", uuid)
+	}
+	return nil
+}
+
 // UpdatePlayerUsername updates only the Username field for a player profile.
 func (ps *PlayerStore) UpdatePlayerUsername(ctx context.Context, uuid, username string) error {
 	filter := bson.M{"_id": uuid}
@@ -66,8 +196,13 @@ func (ps *PlayerStore) UpdatePlayerUsername(ctx context.Context, uuid, username
 // UpdatePlayerPlaytime updates a player profile's total playtime.
 func (ps *PlayerStore) UpdatePlayerPlaytime(ctx context.Context, uuid string, newCurrentPlaytime float64) error {
 	filter := bson.M{"_id": uuid}
-	update := bson.M{"$set": bson.M{"current_playtime": newCurrentPlaytime}}
-	res, err := ps.collection.UpdateOne(ctx, filter, update)
+	millis := models.Playtime(newCurrentPlaytime).Millis()
+	// The team baseline is lowered along with the playtime (e.g., on a reset), so the team is never credited a negative amount.
+	update := mongo.Pipeline{bson.D{{Key: "$set", Value: bson.M{
+		"current_playtime":       millis,
+		"team_playtime_baseline": bson.M{"$min": bson.A{storedPlaytimeMillis("team_playtime_baseline"), millis}},
+	}}}}
+	res, err := ps.playtimeCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return fmt.Errorf("failed to set playtime for player %s: %w", uuid, err)
 	}
@@ -81,7 +216,7 @@ func (ps *PlayerStore) UpdatePlayerPlaytime(ctx context.Context, uuid string, ne
 func (ps *PlayerStore) UpdatePlayerDeltaPlaytime(ctx context.Context, uuid string, newDeltaPlaytime float64) error {
 	filter := bson.M{"_id": uuid}
 	update := bson.M{"$set": bson.M{"delta_playtime_ticks": newDeltaPlaytime}}
-	res, err := ps.collection.UpdateOne(ctx, filter, update)
+	res, err := ps.playtimeCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return fmt.Errorf("failed to set delta playtime for player %s: %w", uuid, err)
 	}
@@ -105,6 +240,42 @@ func (ps *PlayerStore) UpdatePlayerBanStatus(ctx context.Context, uuid string, b
 	return nil
 }
 
+// GetBannedPlayers returns up to limit non-deleted profiles marked as banned, ordered by UUID and
+// starting after afterUUID (empty for the first page). Temporary bans that have expired but were never
+// lifted are included; their BanExpiresAt tells them apart.
+func (ps *PlayerStore) GetBannedPlayers(ctx context.Context, afterUUID string, limit int64) ([]*models.Player, error) {
+	filter := bson.M{"banned": true, "deleted_at": nil}
+	if afterUUID != "" {
+		filter["_id"] = bson.M{"$gt": afterUUID}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := ps.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up banned players: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	players := []*models.Player{}
+	if err := cursor.All(ctx, &players); err != nil {
+		return nil, fmt.Errorf("failed to decode banned players: %w", err)
+	}
+	return players, nil
+}
+
+// UpdatePlayerPlaytimeMultiplier updates a player profile's playtime multiplier.
+func (ps *PlayerStore) UpdatePlayerPlaytimeMultiplier(ctx context.Context, uuid string, multiplier float64) error {
+	filter := bson.M{"_id": uuid}
+	update := bson.M{"$set": bson.M{"playtime_multiplier": multiplier}}
+	res, err := ps.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update playtime multiplier for player %s: %w", uuid, err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("player %s not found for playtime multiplier update", uuid)
+	}
+	return nil
+}
+
 // UpdatePlayerLastLogin updates only the LastLoginAt timestamp for a player profile.
 func (ps *PlayerStore) UpdatePlayerLastLogin(ctx context.Context, uuid string) error {
 	filter := bson.M{"_id": uuid}
@@ -120,12 +291,60 @@ func (ps *PlayerStore) UpdatePlayerLastLogin(ctx context.Context, uuid string) e
 	return nil
 }
 
+// ErrTeamTransferConflict is returned by TransferPlayerTeam when the player is no longer on the team
+// the transfer started from, e.g. because a concurrent transfer moved them first, or was deleted.
+var ErrTeamTransferConflict = errors.New("player changed team or was deleted during transfer")
+
+// TransferPlayerTeam moves a player from oldTeam to a new team with a new team username and team
+// playtime baseline. It returns ErrTeamTransferConflict if the player is no longer on oldTeam.
+func (ps *PlayerStore) TransferPlayerTeam(ctx context.Context, uuid, oldTeam, newTeam, teamUsername string, baseline float64) error {
+	filter := bson.M{"_id": uuid, "team": oldTeam, "deleted_at": nil}
+	update := bson.M{"$set": bson.M{"team": newTeam, "team_username": teamUsername, "team_playtime_baseline": models.Playtime(baseline)}}
+	res, err := ps.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to transfer player %s to team %s: %w", uuid, newTeam, err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrTeamTransferConflict
+	}
+	return nil
+}
+
+// RenameTeam moves every player on oldTeam, including soft-deleted ones, to newTeam and returns how
+// many were moved. Team usernames and playtime baselines are kept.
+func (ps *PlayerStore) RenameTeam(ctx context.Context, oldTeam, newTeam string) (int64, error) {
+	filter := bson.M{"team": oldTeam}
+	update := bson.M{"$set": bson.M{"team": newTeam}}
+	res, err := ps.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move players from team %s to %s: %w", oldTeam, newTeam, err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// storedPlaytimeMillis is an aggregation expression reading a playtime field (see models.Playtime) as
+// whole milliseconds. Profiles persisted before playtime was stored in milliseconds hold float seconds,
+// which are converted; a missing field counts as 0.
+func storedPlaytimeMillis(field string) bson.M {
+	ref := "$" + field
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": ref}, "double"}},
+		bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{ref, 1000}}, 0}}},
+		bson.M{"$ifNull": bson.A{ref, 0}},
+	}}
+}
+
+// teamCreditedPlaytime is the part of a player's playtime credited to their current team, in
+// milliseconds: current_playtime minus the baseline left with previous teams on a transfer.
+var teamCreditedPlaytime = bson.M{"$subtract": bson.A{storedPlaytimeMillis("current_playtime"), storedPlaytimeMillis("team_playtime_baseline")}}
+
 // AggregateTeamPlaytimes performs a MongoDB aggregation to calculate total playtime per team.
 func (ps *PlayerStore) AggregateTeamPlaytimes(ctx context.Context) (map[string]float64, error) {
 	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"deleted_at": nil}}}, // Soft-deleted profiles don't count towards team totals
 		bson.D{{Key: "$group", Value: bson.D{
 			{Key: "_id", Value: "$team"},
-			{Key: "calculatedTotal", Value: bson.D{{Key: "$sum", Value: "$current_playtime"}}},
+			{Key: "calculatedTotal", Value: bson.D{{Key: "$sum", Value: teamCreditedPlaytime}}},
 		}}},
 	}
 
@@ -138,17 +357,81 @@ func (ps *PlayerStore) AggregateTeamPlaytimes(ctx context.Context) (map[string]f
 	teamTotalsMap := make(map[string]float64)
 	for cursor.Next(ctx) {
 		var result struct {
-			TeamID          string  `bson:"_id"`
-			CalculatedTotal float64 `bson:"calculatedTotal"`
+			TeamID          string          `bson:"_id"`
+			CalculatedTotal models.Playtime `bson:"calculatedTotal"`
 		}
 		if err := cursor.Decode(&result); err != nil {
 			log.Printf("WARN: Error decoding aggregation result: %v", err) // Log and continue
 			continue
 		}
-		teamTotalsMap[result.TeamID] = result.CalculatedTotal
+		teamTotalsMap[result.TeamID] = result.CalculatedTotal.Seconds()
 	}
 	if err := cursor.Err(); err != nil {
 		return nil, fmt.Errorf("error during aggregation cursor iteration: %w", err)
 	}
 	return teamTotalsMap, nil
 }
+
+// CountPlayersByTeam counts the (non-deleted) players on each team.
+func (ps *PlayerStore) CountPlayersByTeam(ctx context.Context) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"deleted_at": nil}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$team"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := ps.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error running aggregation for team player counts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int64)
+	for cursor.Next(ctx) {
+		var result struct {
+			TeamID string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, fmt.Errorf("error decoding team player count: %w", err)
+		}
+		counts[result.TeamID] = result.Count
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error during aggregation cursor iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// AggregateTeamPlaytime performs a targeted MongoDB aggregation to calculate the total playtime of a single team.
+// Returns 0 if the team has no (non-deleted) players.
+func (ps *PlayerStore) AggregateTeamPlaytime(ctx context.Context, teamName string) (float64, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"team": teamName, "deleted_at": nil}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "calculatedTotal", Value: bson.D{{Key: "$sum", Value: teamCreditedPlaytime}}},
+		}}},
+	}
+
+	cursor, err := ps.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("error running aggregation for team %s total: %w", teamName, err)
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		CalculatedTotal models.Playtime `bson:"calculatedTotal"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, fmt.Errorf("error decoding aggregation result for team %s: %w", teamName, err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, fmt.Errorf("error during aggregation cursor iteration for team %s: %w", teamName, err)
+	}
+	return result.CalculatedTotal.Seconds(), nil
+}
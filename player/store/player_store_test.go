@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetBannedPlayers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("pages by UUID", func(mt *mtest.T) {
+		ps := NewPlayerStore(mt.Coll)
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "b"}, {Key: "banned", Value: true}},
+			bson.D{{Key: "_id", Value: "c"}, {Key: "banned", Value: true}},
+		))
+
+		players, err := ps.GetBannedPlayers(context.Background(), "a", 2)
+		if err != nil {
+			mt.Fatalf("GetBannedPlayers: %v", err)
+		}
+		if len(players) != 2 || players[0].UUID != "b" || players[1].UUID != "c" || !players[0].Banned {
+			mt.Fatalf("GetBannedPlayers = %+v, want banned players b and c", players)
+		}
+
+		cmd := mt.GetStartedEvent().Command
+		filter := cmd.Lookup("filter").Document()
+		if banned, ok := filter.Lookup("banned").BooleanOK(); !ok || !banned {
+			mt.Errorf("filter %v does not require banned=true", filter)
+		}
+		if filter.Lookup("deleted_at").Type != bson.TypeNull {
+			mt.Errorf("filter %v does not exclude deleted profiles", filter)
+		}
+		if after, ok := filter.Lookup("_id", "$gt").StringValueOK(); !ok || after != "a" {
+			mt.Errorf("filter %v does not start after UUID a", filter)
+		}
+		if limit, ok := cmd.Lookup("limit").AsInt64OK(); !ok || limit != 2 {
+			mt.Errorf("limit = %v, want 2", cmd.Lookup("limit"))
+		}
+		if sort := cmd.Lookup("sort").Document(); sort.Lookup("_id").AsInt64() != 1 {
+			mt.Errorf("sort = %v, want ascending _id", sort)
+		}
+	})
+
+	mt.Run("first page and no results", func(mt *mtest.T) {
+		ps := NewPlayerStore(mt.Coll)
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch))
+
+		players, err := ps.GetBannedPlayers(context.Background(), "", 100)
+		if err != nil {
+			mt.Fatalf("GetBannedPlayers: %v", err)
+		}
+		if players == nil || len(players) != 0 {
+			mt.Errorf("GetBannedPlayers = %#v, want an empty, non-nil slice", players)
+		}
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		if _, err := filter.LookupErr("_id"); err == nil {
+			mt.Errorf("first page filter %v restricts _id", filter)
+		}
+	})
+}
+
+func TestUpdatePlayerPlaytimeStoresMillis(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("stores int64 milliseconds", func(mt *mtest.T) {
+		ps := NewPlayerStore(mt.Coll)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		if err := ps.UpdatePlayerPlaytime(context.Background(), "u", 3600.0016); err != nil {
+			mt.Fatalf("UpdatePlayerPlaytime: %v", err)
+		}
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Array()
+		set := update.Index(0).Value().Document().Lookup("$set").Document()
+		stored := set.Lookup("current_playtime")
+		if stored.Type != bson.TypeInt64 || stored.Int64() != 3600002 {
+			mt.Errorf("current_playtime sent as %v %v, want int64 3600002", stored.Type, stored)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// player/store/profile_changes.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// profileChangeEvent is the subset of a change stream event WatchProfileChanges needs.
+type profileChangeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      *models.Player `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.Raw `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+// WatchProfileChanges opens a change stream on the player profiles and calls fn for every insert,
+// update, replace and delete until ctx is cancelled or the stream fails. Change streams require
+// MongoDB to run as a replica set.
+//
+// resumeAfter is the token of the last change already handled, or nil to start from now. The token
+// of the last change fn handled is returned alongside any error so the caller can resume after it.
+func (ps *PlayerStore) WatchProfileChanges(ctx context.Context, resumeAfter bson.Raw, fn func(models.ProfileChange) error) (bson.Raw, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{
+			models.ProfileChangeInsert, models.ProfileChangeUpdate, models.ProfileChangeReplace, models.ProfileChangeDelete,
+		}}}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeAfter != nil {
+		opts.SetResumeAfter(resumeAfter)
+	}
+
+	stream, err := ps.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return resumeAfter, fmt.Errorf("failed to open player profile change stream: %w", err)
+	}
+	defer stream.Close(context.Background())
+
+	lastToken := resumeAfter
+	for stream.Next(ctx) {
+		var event profileChangeEvent
+		if err := stream.Decode(&event); err != nil {
+			return lastToken, fmt.Errorf("failed to decode player profile change: %w", err)
+		}
+
+		change := models.ProfileChange{
+			UUID:      event.DocumentKey.ID,
+			Operation: event.OperationType,
+			Profile:   event.FullDocument,
+		}
+		if event.OperationType == models.ProfileChangeUpdate {
+			change.UpdatedFields = updatedTopLevelFields(event.UpdateDescription.UpdatedFields, event.UpdateDescription.RemovedFields)
+		}
+		if err := fn(change); err != nil {
+			return lastToken, err
+		}
+		lastToken = stream.ResumeToken()
+	}
+	if err := stream.Err(); err != nil {
+		return lastToken, fmt.Errorf("player profile change stream failed: %w", err)
+	}
+	return lastToken, ctx.Err()
+}
+
+// updatedTopLevelFields returns the distinct top-level field names set or removed by an update.
+// Dotted paths such as "boosters.0.value" are reduced to "boosters".
+func updatedTopLevelFields(updated bson.Raw, removed []string) []string {
+	var fields []string
+	seen := make(map[string]struct{})
+	add := func(path string) {
+		field, _, _ := strings.Cut(path, ".")
+		if _, ok := seen[field]; ok {
+			return
+		}
+		seen[field] = struct{}{}
+		fields = append(fields, field)
+	}
+
+	elements, _ := updated.Elements() // A malformed document just yields no fields
+	for _, element := range elements {
+		add(element.Key())
+	}
+	for _, path := range removed {
+		add(path)
+	}
+	return fields
+}
@@ -32,6 +32,7 @@ func (ts *TeamStore) EnsureTeamsExist(ctx context.Context, teams []string) error
 		update := bson.M{
 			"$setOnInsert": bson.M{
 				"player_count":   0,
+				"username_seq":   0,
 				"total_playtime": 0.0,
 				"created_at":     time.Now(),
 				"last_updated":   time.Now(),
@@ -50,6 +51,37 @@ func (ts *TeamStore) EnsureTeamsExist(ctx context.Context, teams []string) error
 	return nil
 }
 
+// GetTeam retrieves a single team document by name.
+// Returns mongo.ErrNoDocuments if the team does not exist.
+func (ts *TeamStore) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	var team models.Team
+	filter := bson.M{"_id": teamName}
+	if err := ts.collection.FindOne(ctx, filter).Decode(&team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam inserts a new team document. The error wraps a duplicate key error if the team already exists.
+func (ts *TeamStore) CreateTeam(ctx context.Context, team *models.Team) error {
+	if _, err := ts.collection.InsertOne(ctx, team); err != nil {
+		return fmt.Errorf("failed to create team %s: %w", team.Name, err)
+	}
+	return nil
+}
+
+// DeleteTeam removes a team document.
+func (ts *TeamStore) DeleteTeam(ctx context.Context, teamName string) error {
+	res, err := ts.collection.DeleteOne(ctx, bson.M{"_id": teamName})
+	if err != nil {
+		return fmt.Errorf("failed to delete team %s: %w", teamName, err)
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("team %s not found for deletion", teamName)
+	}
+	return nil
+}
+
 // GetTeamPlayerCount retrieves the current player count for a given team.
 func (ts *TeamStore) GetTeamPlayerCount(ctx context.Context, teamName string) (int64, error) {
 	var team models.Team
@@ -82,11 +114,30 @@ func (ts *TeamStore) IncrementTeamPlayerCount(ctx context.Context, teamName stri
 	return nil
 }
 
+// ReplaceTeamPlayerCount sets the player count of a team to count if it still is previous, e.g. to
+// correct drift without losing a registration made since previous was read. It reports whether the
+// count was replaced.
+func (ts *TeamStore) ReplaceTeamPlayerCount(ctx context.Context, teamName string, previous, count int64) (bool, error) {
+	filter := bson.M{"_id": teamName, "player_count": previous}
+	update := bson.M{"$set": bson.M{"player_count": count, "last_updated": time.Now()}}
+	res, err := ts.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to set player count for team %s: %w", teamName, err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
 // DecrementTeamPlayerCount atomically decrements the player count for a team.
 func (ts *TeamStore) DecrementTeamPlayerCount(ctx context.Context, teamName string) error {
+	return ts.DecrementTeamPlayerCountBy(ctx, teamName, 1)
+}
+
+// DecrementTeamPlayerCountBy atomically lowers the player count of a team by n, e.g. to give back counts
+// taken for players whose profiles could not be created. The username sequence is left alone.
+func (ts *TeamStore) DecrementTeamPlayerCountBy(ctx context.Context, teamName string, n int64) error {
 	filter := bson.M{"_id": teamName}
 	update := bson.M{
-		"$inc": bson.M{"player_count": -1},
+		"$inc": bson.M{"player_count": -n},
 		"$set": bson.M{"last_updated": time.Now()},
 	}
 	res, err := ts.collection.UpdateOne(ctx, filter, update)
@@ -99,6 +150,24 @@ func (ts *TeamStore) DecrementTeamPlayerCount(ctx context.Context, teamName stri
 	return nil
 }
 
+// RemoveTeamPlayer decrements the player count of a team a player left and adds the playtime that
+// stays credited to it, in one update.
+func (ts *TeamStore) RemoveTeamPlayer(ctx context.Context, teamName string, retainedPlaytime float64) error {
+	filter := bson.M{"_id": teamName}
+	update := bson.M{
+		"$inc": bson.M{"player_count": -1, "retained_playtime": retainedPlaytime},
+		"$set": bson.M{"last_updated": time.Now()},
+	}
+	res, err := ts.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to remove player from team %s: %w", teamName, err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("team %s not found for player removal", teamName)
+	}
+	return nil
+}
+
 // UpdateTeamTotalPlaytime updates the total playtime for a team.
 func (ts *TeamStore) UpdateTeamTotalPlaytime(ctx context.Context, teamName string, newTotalPlaytime float64) error {
 	filter := bson.M{"_id": teamName}
@@ -131,14 +200,25 @@ func (ts *TeamStore) GetAllTeams(ctx context.Context) ([]models.Team, error) {
 	return teams, nil
 }
 
-// IncrementTeamPlayerCountAndGet atomically increments the player_count for a team
-// and returns the *new* incremented count. This is crucial for assigning unique TeamUsernames.
+// IncrementTeamPlayerCountAndGet atomically increments the player_count for a team and returns the
+// number for the new player's TeamUsername (see AddTeamPlayersAndGet).
 func (ts *TeamStore) IncrementTeamPlayerCountAndGet(ctx context.Context, teamName string) (int64, error) {
+	return ts.AddTeamPlayersAndGet(ctx, teamName, 1)
+}
+
+// AddTeamPlayersAndGet atomically adds n to the player_count of a team and advances its username
+// sequence by n, returning the new sequence value, so the players added are numbered seq-n+1 to seq,
+// e.g. for a batch of new profiles. Unlike the player count, the sequence is never lowered, so team
+// usernames are not handed out twice. Teams created before the sequence existed start it from their
+// player count.
+func (ts *TeamStore) AddTeamPlayersAndGet(ctx context.Context, teamName string, n int64) (int64, error) {
 	filter := bson.M{"_id": teamName}
-	update := bson.M{
-		"$inc": bson.M{"player_count": 1},
-		"$set": bson.M{"last_updated": time.Now()}, // Also update last_updated timestamp
-	}
+	count := bson.M{"$ifNull": bson.A{"$player_count", 0}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"player_count": bson.M{"$add": bson.A{count, n}},
+		"username_seq": bson.M{"$add": bson.A{bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$username_seq", 0}}, count}}, n}},
+		"last_updated": time.Now(), // Also update last_updated timestamp
+	}}}}
 	// Configure options to return the document *after* the update.
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
 
@@ -154,5 +234,5 @@ func (ts *TeamStore) IncrementTeamPlayerCountAndGet(ctx context.Context, teamNam
 		}
 		return 0, fmt.Errorf("failed to atomically increment and get player count for team %s: %w", teamName, err)
 	}
-	return updatedTeam.PlayerCount, nil
+	return updatedTeam.UsernameSequence, nil
 }
@@ -0,0 +1,58 @@
+// shared/api/auth.go
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// RequireTokenMiddleware rejects requests under any of pathPrefixes with 401 unless they carry
+// "Authorization: Bearer <token>". An empty token rejects every request under them. Other paths are
+// passed through unchanged.
+func RequireTokenMiddleware(token string, pathPrefixes []string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range pathPrefixes {
+				if !strings.HasPrefix(r.URL.Path, prefix) {
+					continue
+				}
+				if !HasBearerToken(r, token) {
+					writeUnauthorized(w)
+					return
+				}
+				break
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireToken wraps a single handler, rejecting requests with 401 unless they carry
+// "Authorization: Bearer <token>". An empty token rejects every request.
+func RequireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !HasBearerToken(r, token) {
+			writeUnauthorized(w)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HasBearerToken reports whether r carries "Authorization: Bearer <token>". It is always false for an empty token.
+func HasBearerToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// writeUnauthorized responds 401 with a Bearer challenge.
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	WriteError(w, http.StatusUnauthorized, "Missing or invalid admin token")
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireTokenMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		header string
+		want   int
+	}{
+		{"valid token", "secret", "/game/admin/ban", "Bearer secret", http.StatusOK},
+		{"wrong token", "secret", "/game/admin/ban", "Bearer other", http.StatusUnauthorized},
+		{"missing token", "secret", "/game/admin/ban", "", http.StatusUnauthorized},
+		{"unprotected path", "secret", "/game/player/x/playtime", "", http.StatusOK},
+		{"no token configured", "", "/game/admin/ban", "", http.StatusUnauthorized},
+		{"no token configured, empty bearer", "", "/game/admin/ban", "Bearer ", http.StatusUnauthorized},
+		{"no token configured, unprotected path", "", "/game/player/x/playtime", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireTokenMiddleware(tt.token, []string{"/game/admin/"})(ok)
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// shared/api/batch.go
+package api
+
+// BatchUUIDRequest is the request body of batch endpoints that operate on a list of player UUIDs.
+type BatchUUIDRequest struct {
+	UUIDs []string `json:"uuids"`
+}
+
+// BatchItemResult is the outcome for a single item of a batch operation.
+type BatchItemResult struct {
+	UUID      string `json:"uuid"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"` // The failure may go away on a retry (e.g., a timeout); false for invalid input or a refusal
+}
+
+// BatchResponse is the response of a batch operation, with one result per item in request order.
+type BatchResponse struct {
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// NewBatchResponse builds a BatchResponse from per-item results, counting successes and failures.
+func NewBatchResponse(results []BatchItemResult) BatchResponse {
+	resp := BatchResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp
+}
+
+// RetryUUIDs returns the UUIDs of the failed items worth retrying, in request order, e.g., to send
+// them again as a new batch.
+func (r *BatchResponse) RetryUUIDs() []string {
+	var uuids []string
+	for _, result := range r.Results {
+		if !result.Success && result.Retryable {
+			uuids = append(uuids, result.UUID)
+		}
+	}
+	return uuids
+}
@@ -0,0 +1,77 @@
+// shared/api/bufferpool.go
+package api
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxPooledBufferSize is the largest buffer a Client returns to the pool by default.
+// Bigger buffers (e.g., from a large batch request) are left to the GC so the pool doesn't pin them.
+const DefaultMaxPooledBufferSize = 64 << 10
+
+// bufferPool holds the buffers used to encode request bodies and read response bodies.
+// The sync path sends thousands of small requests, so reusing them saves most per-request allocations.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to the pool unless it grew beyond maxSize.
+func putBuffer(buf *bytes.Buffer, maxSize int) {
+	if buf.Cap() > maxSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// pooledBody shares an encoded request body between the request and any copies the transport makes
+// through GetBody (e.g., to retry on a stale keep-alive connection). The transport may still be
+// reading a body after Do returns, so the buffer goes back to the pool only once Do has returned and
+// every reader has been closed.
+type pooledBody struct {
+	buf     *bytes.Buffer
+	maxSize int
+	refs    atomic.Int32
+}
+
+// newPooledBody takes ownership of buf. The caller holds one reference, dropped with release.
+func newPooledBody(buf *bytes.Buffer, maxSize int) *pooledBody {
+	pb := &pooledBody{buf: buf, maxSize: maxSize}
+	pb.refs.Store(1)
+	return pb
+}
+
+// reader returns a new reader over the body; closing it drops its reference.
+func (pb *pooledBody) reader() *pooledBodyReader {
+	pb.refs.Add(1)
+	return &pooledBodyReader{Reader: bytes.NewReader(pb.buf.Bytes()), body: pb}
+}
+
+// release drops a reference, returning the buffer to the pool when it was the last one.
+func (pb *pooledBody) release() {
+	if pb.refs.Add(-1) == 0 {
+		putBuffer(pb.buf, pb.maxSize)
+	}
+}
+
+// pooledBodyReader is a request body reading from a pooledBody.
+type pooledBodyReader struct {
+	*bytes.Reader
+	body   *pooledBody
+	closed atomic.Bool
+}
+
+func (r *pooledBodyReader) Close() error {
+	if r.closed.CompareAndSwap(false, true) {
+		r.body.release()
+	}
+	return nil
+}
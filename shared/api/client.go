@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"log" // For logging in client.go, consider using a structured logger later
+	"mime"
 	"net"
 	"net/http"
 	"time"
@@ -20,6 +21,9 @@ type HTTPError struct {
 	Message    string
 	URL        string
 	Method     string
+	ErrorCode  string   // The response's error_code, if any (see JSONErrorResponse)
+	Details    []string // The response's details, if any, e.g. every validation error of the request
+	Body       []byte   // Raw response body, kept when small enough for callers to decode endpoint-specific details
 	// Optional: add RequestID, Timestamp, etc. for tracing
 }
 
@@ -67,6 +71,9 @@ func NewDefaultHTTPClient() *http.Client {
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+	authToken  string // Sent as a bearer token if set
+	// Largest buffer returned to the shared pool after a request; 0 disables pooling (see SetMaxPooledBufferSize).
+	maxPooledBufferSize int
 }
 
 // NewClient creates a new API Client.
@@ -77,88 +84,165 @@ func NewClient(baseURL string, httpClient *http.Client) *Client {
 		httpClient = NewDefaultHTTPClient()
 	}
 	return &Client{
-		httpClient: httpClient,
-		baseURL:    baseURL,
+		httpClient:          httpClient,
+		baseURL:             baseURL,
+		maxPooledBufferSize: DefaultMaxPooledBufferSize,
 	}
 }
 
-// doRequest is a helper for common request logic
-func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+// SetMaxPooledBufferSize sets the largest request/response buffer kept in the shared pool for reuse.
+// 0 disables pooling, so every request allocates fresh buffers.
+func (c *Client) SetMaxPooledBufferSize(size int) {
+	c.maxPooledBufferSize = max(size, 0)
+}
+
+// SetAuthToken makes every subsequent request carry "Authorization: Bearer <token>". An empty token removes it.
+func (c *Client) SetAuthToken(token string) {
+	c.authToken = token
+}
+
+// acceptMessagePack is the Accept header of requests that prefer a MessagePack response. JSON stays
+// acceptable, so servers without MessagePack support answer as before.
+const acceptMessagePack = ContentTypeMessagePack + ", application/json;q=0.9"
+
+// RawResponse is a response body left undecoded, e.g. to pass it on to a client as is.
+type RawResponse struct {
+	Body        []byte
+	ContentType string
+}
+
+// doRequest is a helper for common request logic. accept, if set, is sent as the Accept header; the
+// response is decoded as MessagePack or JSON according to its Content-Type.
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}, accept string) error {
+	resp, url, err := c.send(ctx, method, path, body, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result != nil {
+		if resp.StatusCode == http.StatusNoContent { // Handle 204 No Content
+			return nil
+		}
+		unmarshal := json.Unmarshal
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == ContentTypeMessagePack {
+			unmarshal = unmarshalMsgpack
+		} else if c.maxPooledBufferSize <= 0 {
+			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+				return fmt.Errorf("failed to decode %s response from %s: %w", method, url, err)
+			}
+			return nil
+		}
+		// Reading into a pooled buffer avoids allocating a fresh decoder buffer per response.
+		buf := getBuffer()
+		defer putBuffer(buf, c.maxPooledBufferSize)
+		if _, err := buf.ReadFrom(resp.Body); err != nil {
+			return fmt.Errorf("failed to read %s response from %s: %w", method, url, err)
+		}
+		if err := unmarshal(buf.Bytes(), result); err != nil {
+			return fmt.Errorf("failed to decode %s response from %s: %w", method, url, err)
+		}
+	}
+	return nil
+}
+
+// send builds and sends a request, returning the response and the full URL. Responses with a status of
+// 400 or above are read and returned as an error instead (see createHTTPError).
+// On success the caller must close the response body.
+func (c *Client) send(ctx context.Context, method, path string, body interface{}, accept string) (*http.Response, string, error) {
 	url := fmt.Sprintf("%s%s", c.baseURL, path)
 
+	pooling := c.maxPooledBufferSize > 0
 	var reqBody io.Reader
-	if body != nil {
+	var pooled *pooledBody
+	if body != nil && pooling {
+		buf := getBuffer()
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			putBuffer(buf, c.maxPooledBufferSize)
+			return nil, url, fmt.Errorf("failed to marshal request body for %s %s: %w", method, url, err)
+		}
+		pooled = newPooledBody(buf, c.maxPooledBufferSize)
+		defer pooled.release()
+	} else if body != nil {
 		jsonData, err := json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body for %s %s: %w", method, url, err)
+			return nil, url, fmt.Errorf("failed to marshal request body for %s %s: %w", method, url, err)
 		}
 		reqBody = bytes.NewBuffer(jsonData)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create %s request for %s: %w", method, url, err)
+		return nil, url, fmt.Errorf("failed to create %s request for %s: %w", method, url, err)
+	}
+	if pooled != nil {
+		// Set up the way NewRequest does for a *bytes.Buffer, but with readers that release the pooled buffer.
+		req.Body = pooled.reader()
+		req.ContentLength = int64(pooled.buf.Len())
+		req.GetBody = func() (io.ReadCloser, error) { return pooled.reader(), nil }
 	}
 	req.Header.Set("Content-Type", "application/json")
-	// Add other common headers if needed (e.g., Authorization tokens)
-	// req.Header.Set("Authorization", "Bearer <token>")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		// Differentiate between context cancellation and other network errors
 		if errors.Is(ctx.Err(), context.Canceled) {
-			return fmt.Errorf("%s request to %s cancelled: %w", method, url, ctx.Err())
+			return nil, url, fmt.Errorf("%s request to %s cancelled: %w", method, url, ctx.Err())
 		}
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return fmt.Errorf("%s request to %s timed out: %w", method, url, ctx.Err())
+			return nil, url, fmt.Errorf("%s request to %s timed out: %w", method, url, ctx.Err())
 		}
-		return fmt.Errorf("failed to send %s request to %s: %w", method, url, err)
+		return nil, url, fmt.Errorf("failed to send %s request to %s: %w", method, url, err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
 		var errorResponse struct {
-			Message string `json:"message"`
+			Message   string   `json:"message"`
+			ErrorCode string   `json:"error_code"`
+			Details   []string `json:"details"`
 		}
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, URL: url, Method: method}
 		// Try to read error message from body
 		bodyBytes, readErr := io.ReadAll(resp.Body)
 		if readErr == nil && len(bodyBytes) > 0 {
-			if jsonErr := json.Unmarshal(bodyBytes, &errorResponse); jsonErr == nil && errorResponse.Message != "" {
-				return createHTTPError(resp.StatusCode, errorResponse.Message, url, method)
+			if len(bodyBytes) < 4096 {
+				httpErr.Body = bodyBytes
 			}
-			// Fallback: If JSON decoding fails or message is empty, just include the raw body if it's small
-			if len(bodyBytes) < 500 { // Limit size to avoid logging huge bodies
-				return createHTTPError(resp.StatusCode, string(bodyBytes), url, method)
+			if jsonErr := json.Unmarshal(bodyBytes, &errorResponse); jsonErr == nil && errorResponse.Message != "" {
+				httpErr.Message = errorResponse.Message
+				httpErr.ErrorCode = errorResponse.ErrorCode
+				httpErr.Details = errorResponse.Details
+			} else if len(bodyBytes) < 500 { // Limit size to avoid logging huge bodies
+				// Fallback: If JSON decoding fails or message is empty, just include the raw body if it's small
+				httpErr.Message = string(bodyBytes)
 			}
 		}
-		return createHTTPError(resp.StatusCode, "", url, method) // No readable message
-	}
-
-	if result != nil {
-		if resp.StatusCode == http.StatusNoContent { // Handle 204 No Content
-			return nil
-		}
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode %s response from %s: %w", method, url, err)
-		}
+		return nil, url, createHTTPError(httpErr)
 	}
-	return nil
+	return resp, url, nil
 }
 
 // createHTTPError maps common status codes to predefined errors.
-func createHTTPError(statusCode int, message, url, method string) error {
-	httpErr := &HTTPError{StatusCode: statusCode, Message: message, URL: url, Method: method}
-	switch statusCode {
+// The HTTPError stays in the chain, so errors.As can still reach its status code and body.
+func createHTTPError(httpErr *HTTPError) error {
+	switch httpErr.StatusCode {
 	case http.StatusNotFound:
-		return fmt.Errorf("%w: %s", ErrNotFound, httpErr.Error())
+		return fmt.Errorf("%w: %w", ErrNotFound, httpErr)
 	case http.StatusConflict:
-		return fmt.Errorf("%w: %s", ErrConflict, httpErr.Error())
+		return fmt.Errorf("%w: %w", ErrConflict, httpErr)
 	case http.StatusBadRequest:
-		return fmt.Errorf("%w: %s", ErrBadRequest, httpErr.Error())
+		return fmt.Errorf("%w: %w", ErrBadRequest, httpErr)
 	case http.StatusUnauthorized:
-		return fmt.Errorf("%w: %s", ErrUnauthorized, httpErr.Error())
+		return fmt.Errorf("%w: %w", ErrUnauthorized, httpErr)
 	case http.StatusForbidden:
-		return fmt.Errorf("%w: %s", ErrForbidden, httpErr.Error())
+		return fmt.Errorf("%w: %w", ErrForbidden, httpErr)
 	case http.StatusInternalServerError:
 		fallthrough // Fall through for 5xx errors
 	case http.StatusBadGateway:
@@ -166,27 +250,56 @@ func createHTTPError(statusCode int, message, url, method string) error {
 	case http.StatusServiceUnavailable:
 		fallthrough
 	case http.StatusGatewayTimeout:
-		return fmt.Errorf("%w: %s", ErrInternalError, httpErr.Error())
+		return fmt.Errorf("%w: %w", ErrInternalError, httpErr)
 	default:
 		return httpErr // Return the generic HTTPError for others
 	}
 }
 
 func (c *Client) Get(ctx context.Context, path string, result interface{}) error {
-	return c.doRequest(ctx, http.MethodGet, path, nil, result)
+	return c.doRequest(ctx, http.MethodGet, path, nil, result, "")
+}
+
+// GetMessagePack is Get asking for a MessagePack response, which is smaller and cheaper to decode.
+// Servers that do not support it answer with JSON, which is decoded as usual.
+func (c *Client) GetMessagePack(ctx context.Context, path string, result interface{}) error {
+	return c.doRequest(ctx, http.MethodGet, path, nil, result, acceptMessagePack)
+}
+
+// GetRaw performs a GET request and returns the response body without decoding it, along with its
+// Content-Type. Error responses are handled as for Get.
+func (c *Client) GetRaw(ctx context.Context, path string) (*RawResponse, error) {
+	resp, url, err := c.send(ctx, http.MethodGet, path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The body is handed to the caller, so it is read into its own slice rather than a pooled buffer.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GET response from %s: %w", url, err)
+	}
+	return &RawResponse{Body: body, ContentType: resp.Header.Get("Content-Type")}, nil
 }
 
 func (c *Client) Post(ctx context.Context, path string, body interface{}, result interface{}) error {
-	return c.doRequest(ctx, http.MethodPost, path, body, result)
+	return c.doRequest(ctx, http.MethodPost, path, body, result, "")
+}
+
+// PostMessagePack is Post asking for a MessagePack response (see GetMessagePack). The request body is
+// still sent as JSON.
+func (c *Client) PostMessagePack(ctx context.Context, path string, body interface{}, result interface{}) error {
+	return c.doRequest(ctx, http.MethodPost, path, body, result, acceptMessagePack)
 }
 
 func (c *Client) Put(ctx context.Context, path string, body interface{}, result interface{}) error {
-	return c.doRequest(ctx, http.MethodPut, path, body, result)
+	return c.doRequest(ctx, http.MethodPut, path, body, result, "")
 }
 
 // Delete performs a DELETE request.
 func (c *Client) Delete(ctx context.Context, path string) error {
-	return c.doRequest(ctx, http.MethodDelete, path, nil, nil) // No body, no result expected
+	return c.doRequest(ctx, http.MethodDelete, path, nil, nil, "") // No body, no result expected
 }
 
 // IsHTTPError checks if an error is an HTTPError and optionally matches status code.
@@ -0,0 +1,157 @@
+// shared/api/compress.go
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzipWriterPool reuses gzip writers, which are expensive to allocate, across responses.
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// CompressionMiddleware gzips response bodies of at least minSize bytes for clients that accept gzip.
+// The first minSize bytes are buffered to decide, so smaller responses go out unchanged and
+// uncompressed. Responses that already set a Content-Encoding are left alone.
+func CompressionMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gzw := &gzipResponseWriter{w: w, minSize: minSize}
+			defer gzw.close()
+			next.ServeHTTP(gzw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, i.e. lists "gzip" or "*"
+// without "q=0".
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter holds back the status and body until it has seen minSize bytes (compress) or the
+// handler returns with fewer (send as is).
+type gzipResponseWriter struct {
+	w          http.ResponseWriter
+	minSize    int
+	statusCode int
+	buf        []byte
+	gz         *gzip.Writer
+	decided    bool // The headers have been sent, either compressed (gz != nil) or not
+}
+
+func (gzw *gzipResponseWriter) Header() http.Header {
+	return gzw.w.Header()
+}
+
+func (gzw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gzw.w
+}
+
+func (gzw *gzipResponseWriter) WriteHeader(statusCode int) {
+	if gzw.statusCode == 0 {
+		gzw.statusCode = statusCode
+	}
+}
+
+func (gzw *gzipResponseWriter) Write(p []byte) (int, error) {
+	if gzw.decided {
+		if gzw.gz != nil {
+			return gzw.gz.Write(p)
+		}
+		return gzw.w.Write(p)
+	}
+
+	gzw.buf = append(gzw.buf, p...)
+	if len(gzw.buf) < gzw.minSize {
+		return len(p), nil
+	}
+	if err := gzw.flushBuffered(gzw.compressible()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// compressible reports whether the response may be gzipped once it is known to be large enough.
+func (gzw *gzipResponseWriter) compressible() bool {
+	if gzw.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	switch gzw.statusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// flushBuffered sends the headers and the buffered body, compressed or not.
+func (gzw *gzipResponseWriter) flushBuffered(compress bool) error {
+	gzw.decided = true
+	if gzw.statusCode == 0 {
+		gzw.statusCode = http.StatusOK
+	}
+	if compress {
+		header := gzw.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length") // Set by the handler for the uncompressed body, if at all
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(gzw.buf))
+		}
+		gzw.gz = gzipWriterPool.Get().(*gzip.Writer)
+		gzw.gz.Reset(gzw.w)
+	}
+	gzw.w.WriteHeader(gzw.statusCode)
+
+	buf := gzw.buf
+	gzw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if gzw.gz != nil {
+		_, err = gzw.gz.Write(buf)
+	} else {
+		_, err = gzw.w.Write(buf)
+	}
+	return err
+}
+
+// close sends a response still below minSize uncompressed, or finishes the gzip stream.
+func (gzw *gzipResponseWriter) close() {
+	if !gzw.decided {
+		if gzw.statusCode == 0 && len(gzw.buf) == 0 {
+			return // Nothing was written; let net/http send its implicit 200
+		}
+		gzw.flushBuffered(false)
+		return
+	}
+	if gzw.gz != nil {
+		gzw.gz.Close()
+		gzw.gz.Reset(nil)
+		gzipWriterPool.Put(gzw.gz)
+		gzw.gz = nil
+	}
+}
@@ -0,0 +1,79 @@
+// shared/api/health.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultReadinessTimeout bounds all readiness checks of one /readyz request together.
+const DefaultReadinessTimeout = 2 * time.Second
+
+// ReadinessCheck is one dependency /readyz reports on. A failing required check makes the service
+// unready; a failing advisory one is only reported.
+type ReadinessCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// ReadinessCheckResult is the outcome of one ReadinessCheck.
+type ReadinessCheckResult struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is the body of a /readyz response.
+type ReadinessResponse struct {
+	Ready  bool                   `json:"ready"`
+	Checks []ReadinessCheckResult `json:"checks"`
+}
+
+// HealthHandler reports that the process is up and serving HTTP. It checks no dependencies, so it
+// is cheap enough for other services to call from their own readiness checks.
+// GET /healthz
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadinessHandler runs every check concurrently within timeout and responds 200 if all required
+// checks pass, or 503 Service Unavailable otherwise. Every result is listed either way.
+// GET /readyz
+func ReadinessHandler(timeout time.Duration, checks ...ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		results := make([]ReadinessCheckResult, len(checks))
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := ReadinessCheckResult{Name: check.Name, Required: check.Required, OK: true}
+				if err := check.Check(ctx); err != nil {
+					result.OK = false
+					result.Error = err.Error()
+				}
+				results[i] = result
+			}()
+		}
+		wg.Wait()
+
+		resp := ReadinessResponse{Ready: true, Checks: results}
+		for _, result := range results {
+			if result.Required && !result.OK {
+				resp.Ready = false
+			}
+		}
+		status := http.StatusOK
+		if !resp.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		WriteJSON(w, status, resp)
+	}
+}
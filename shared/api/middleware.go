@@ -3,26 +3,37 @@ package api
 
 import (
 	// Or a custom logger interface
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 // LoggingMiddleware logs details of each HTTP request.
 // It's good practice to pass a logger into middleware rather than relying on global log.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		//start := time.Now()
-		// Wrap the ResponseWriter to capture status code
+		start := time.Now()
+		// Wrap the ResponseWriter to capture status code and response size
 		lrw := &loggingResponseWriter{w: w}
 		next.ServeHTTP(lrw, r)
 
 		//log.Printf("INFO: %s %s from %s - Status: %d, Duration: %v",	r.Method, r.URL.Path, r.RemoteAddr, lrw.statusCode, time.Since(start))
+		// Emitted at debug level so it stays quiet unless request-level metrics are wanted.
+		slog.Debug("HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"remote_addr", r.RemoteAddr,
+			"status", lrw.StatusCode(),
+			"response_size_bytes", lrw.BytesWritten(),
+			"duration_ms", time.Since(start).Milliseconds())
 	})
 }
 
-// loggingResponseWriter is a wrapper to capture the HTTP status code.
+// loggingResponseWriter is a wrapper to capture the HTTP status code and the number of body bytes written.
 type loggingResponseWriter struct {
-	w          http.ResponseWriter
-	statusCode int
+	w            http.ResponseWriter
+	statusCode   int
+	bytesWritten int64
 }
 
 func (lrw *loggingResponseWriter) Header() http.Header {
@@ -30,7 +41,9 @@ func (lrw *loggingResponseWriter) Header() http.Header {
 }
 
 func (lrw *loggingResponseWriter) Write(buf []byte) (int, error) {
-	return lrw.w.Write(buf)
+	n, err := lrw.w.Write(buf)
+	lrw.bytesWritten += int64(n) // Accumulate across multiple Write calls
+	return n, err
 }
 
 func (lrw *loggingResponseWriter) WriteHeader(statusCode int) {
@@ -38,6 +51,24 @@ func (lrw *loggingResponseWriter) WriteHeader(statusCode int) {
 	lrw.w.WriteHeader(statusCode)
 }
 
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.w
+}
+
+// StatusCode returns the status code sent to the client.
+// Handlers that write a body without calling WriteHeader implicitly send 200 OK.
+func (lrw *loggingResponseWriter) StatusCode() int {
+	if lrw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return lrw.statusCode
+}
+
+// BytesWritten returns the total number of response body bytes written so far.
+func (lrw *loggingResponseWriter) BytesWritten() int64 {
+	return lrw.bytesWritten
+}
+
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
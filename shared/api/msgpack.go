@@ -0,0 +1,98 @@
+// shared/api/msgpack.go
+package api
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// ContentTypeMessagePack is the media type of MessagePack encoded bodies.
+const ContentTypeMessagePack = "application/msgpack"
+
+// maxMsgpackDepth bounds how deeply arrays and maps may nest in a decoded body. The services' own
+// responses stay within a handful of levels.
+const maxMsgpackDepth = 32
+
+// The services encode MessagePack with github.com/vmihailenco/msgpack, reading the json struct tags
+// (names, omitempty, "-") so a type has the same field names in both encodings.
+
+// marshalMsgpack returns the MessagePack encoding of v.
+func marshalMsgpack(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalMsgpack decodes MessagePack data into the value v points to. Data left over after the
+// value is an error, as with encoding/json. The data is checked with checkMsgpackShape first.
+func unmarshalMsgpack(data []byte, v any) error {
+	if err := checkMsgpackShape(data); err != nil {
+		return err
+	}
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("msgpack: %d trailing bytes after value", r.Len())
+	}
+	return nil
+}
+
+// checkMsgpackShape walks the structure of data without decoding it, rejecting arrays and maps nested
+// deeper than maxMsgpackDepth and any that claim more elements than there are bytes left. The decoder
+// recurses per level and preallocates the claimed length when decoding into an interface, so either
+// could otherwise exhaust the stack or memory on a malformed body.
+func checkMsgpackShape(data []byte) error {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+
+	remaining := []int{1} // Values left to read at each open level
+	for len(remaining) > 0 {
+		top := len(remaining) - 1
+		if remaining[top] == 0 {
+			remaining = remaining[:top]
+			continue
+		}
+		remaining[top]--
+
+		c, err := dec.PeekCode()
+		if err != nil {
+			return fmt.Errorf("msgpack: %w", err)
+		}
+		var n int
+		switch {
+		case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+			n, err = dec.DecodeArrayLen()
+		case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+			n, err = dec.DecodeMapLen()
+			n *= 2 // Keys and values
+		default:
+			if err := dec.Skip(); err != nil {
+				return fmt.Errorf("msgpack: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("msgpack: %w", err)
+		}
+		if n > r.Len() { // Every element takes at least one byte
+			return fmt.Errorf("msgpack: container of %d elements exceeds the %d bytes left", n, r.Len())
+		}
+		if len(remaining) > maxMsgpackDepth {
+			return fmt.Errorf("msgpack: nesting exceeds %d levels", maxMsgpackDepth)
+		}
+		remaining = append(remaining, n)
+	}
+	return nil
+}
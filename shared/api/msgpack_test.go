@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type msgpackInner struct {
+	Level int `json:"level"`
+}
+
+type msgpackSample struct {
+	msgpackInner
+	UUID      string            `json:"uuid"`
+	Playtime  float64           `json:"playtime"`
+	Count     int64             `json:"count"`
+	Banned    bool              `json:"banned,omitempty"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	Teams     map[string]int    `json:"teams"`
+	Tags      []string          `json:"tags"`
+	Extra     map[string]string `json:"extra,omitempty"`
+	Internal  string            `json:"-"`
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := msgpackSample{
+		msgpackInner: msgpackInner{Level: 7},
+		UUID:         "069a79f4-44e9-4726-a5be-fca90e38aaf5",
+		Playtime:     1234.5,
+		Count:        -42,
+		Banned:       true,
+		ExpiresAt:    &expires,
+		Teams:        map[string]int{"AQUA_CREEPERS": 3},
+		Tags:         []string{"a", "b"},
+		Internal:     "not sent",
+	}
+
+	data, err := marshalMsgpack(in)
+	if err != nil {
+		t.Fatalf("marshalMsgpack: %v", err)
+	}
+	var out msgpackSample
+	if err := unmarshalMsgpack(data, &out); err != nil {
+		t.Fatalf("unmarshalMsgpack: %v", err)
+	}
+
+	want := in
+	want.Internal = ""
+	if !out.ExpiresAt.Equal(*want.ExpiresAt) {
+		t.Errorf("ExpiresAt = %v, want %v", out.ExpiresAt, want.ExpiresAt)
+	}
+	out.ExpiresAt, want.ExpiresAt = nil, nil
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("round trip = %+v, want %+v", out, want)
+	}
+}
+
+func TestMsgpackUsesJSONFieldNames(t *testing.T) {
+	data, err := marshalMsgpack(msgpackSample{UUID: "u", Internal: "x"})
+	if err != nil {
+		t.Fatalf("marshalMsgpack: %v", err)
+	}
+	var fields map[string]any
+	if err := unmarshalMsgpack(data, &fields); err != nil {
+		t.Fatalf("unmarshalMsgpack: %v", err)
+	}
+	for _, name := range []string{"uuid", "playtime", "level", "teams"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("field %q missing from %v", name, fields)
+		}
+	}
+	for _, name := range []string{"banned", "expiresAt", "extra", "Internal", "-"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("field %q should have been omitted from %v", name, fields)
+		}
+	}
+}
+
+func TestUnmarshalMsgpackRejectsTrailingData(t *testing.T) {
+	data, err := marshalMsgpack(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("marshalMsgpack: %v", err)
+	}
+	var out map[string]int
+	if err := unmarshalMsgpack(append(data, 0xc0), &out); err == nil {
+		t.Error("unmarshalMsgpack accepted trailing data")
+	}
+}
+
+func TestUnmarshalMsgpackRejectsMalformedShapes(t *testing.T) {
+	deep := bytes.Repeat([]byte{0x91}, maxMsgpackDepth+1) // Arrays of one element, nested
+	deep = append(deep, 0xc0)
+	for name, data := range map[string][]byte{
+		"oversized array": {0xdd, 0xff, 0xff, 0xff, 0xff},
+		"oversized map":   {0xdf, 0x7f, 0xff, 0xff, 0xff, 0xa1, 'k'},
+		"too deep":        deep,
+		"truncated":       {0x92, 0x01},
+	} {
+		var out any
+		if err := unmarshalMsgpack(data, &out); err == nil {
+			t.Errorf("%s: unmarshalMsgpack accepted %x", name, data)
+		}
+	}
+
+	var out any
+	if err := unmarshalMsgpack(deep[1:], &out); err != nil {
+		t.Errorf("nesting of %d levels rejected: %v", maxMsgpackDepth, err)
+	}
+}
+
+func FuzzUnmarshalMsgpack(f *testing.F) {
+	for _, v := range []any{
+		msgpackSample{UUID: "u", Teams: map[string]int{"t": 1}, Tags: []string{"x"}},
+		map[string]any{"nested": []any{1, "two", 3.5, nil, map[string]any{"deep": true}}},
+		[]byte("raw"),
+	} {
+		data, err := marshalMsgpack(v)
+		if err != nil {
+			f.Fatalf("marshalMsgpack: %v", err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}) // Array claiming 4G elements
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var sample msgpackSample
+		_ = unmarshalMsgpack(data, &sample)
+
+		var generic any
+		if err := unmarshalMsgpack(data, &generic); err != nil {
+			return
+		}
+		// Whatever decodes must encode again, and decode to the same value.
+		encoded, err := marshalMsgpack(generic)
+		if err != nil {
+			t.Fatalf("marshalMsgpack of decoded %#v: %v", generic, err)
+		}
+		var again any
+		if err := unmarshalMsgpack(encoded, &again); err != nil {
+			t.Fatalf("unmarshalMsgpack of re-encoded value: %v", err)
+		}
+		if a, b := mustJSON(t, generic), mustJSON(t, again); a != b {
+			t.Fatalf("re-decoded value %s, want %s", b, a)
+		}
+	})
+}
+
+// mustJSON renders v as JSON to compare decoded values, ignoring integer width differences.
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "unencodable" // e.g. maps with non-string keys; both sides fail alike
+	}
+	return string(b)
+}
@@ -0,0 +1,76 @@
+// shared/api/negotiate.go
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MessagePackMiddleware lets WriteJSON answer with MessagePack instead of JSON for requests whose
+// Accept header prefers application/msgpack (see prefersMessagePack). Everything else, including
+// error responses, stays JSON.
+func MessagePackMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if !prefersMessagePack(r.Header.Get("Accept")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&msgpackResponseWriter{ResponseWriter: w}, r)
+	})
+}
+
+// msgpackResponseWriter marks a response as negotiated to MessagePack; it writes through unchanged.
+type msgpackResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (mw *msgpackResponseWriter) Unwrap() http.ResponseWriter {
+	return mw.ResponseWriter
+}
+
+// wantsMessagePack reports whether w, or a writer it wraps, was marked by MessagePackMiddleware.
+// Middlewares added after it wrap its writer in turn, so the chain is followed through Unwrap.
+func wantsMessagePack(w http.ResponseWriter) bool {
+	for w != nil {
+		if _, ok := w.(*msgpackResponseWriter); ok {
+			return true
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = unwrapper.Unwrap()
+	}
+	return false
+}
+
+// prefersMessagePack reports whether an Accept header ranks application/msgpack (or the older
+// application/x-msgpack) above JSON. A JSON weight comes from application/json, application/* or
+// */*, so browsers and clients that do not mention MessagePack keep getting JSON.
+func prefersMessagePack(header string) bool {
+	if header == "" {
+		return false
+	}
+	var msgpackQ, jsonQ float64
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if weight, err := strconv.ParseFloat(value, 64); err == nil {
+					q = weight
+				}
+			}
+		}
+		switch mediaType {
+		case ContentTypeMessagePack, "application/x-msgpack":
+			msgpackQ = max(msgpackQ, q)
+		case "application/json", "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return msgpackQ > 0 && msgpackQ > jsonQ
+}
@@ -0,0 +1,137 @@
+// shared/api/ratelimit.go
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// rateLimitSweepInterval is how often idle clients' buckets are dropped.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter is a per-client token bucket limiter. Each client (see RateLimitMiddleware) may make
+// burst requests at once, refilled at rps requests per second.
+// A nil *RateLimiter allows everything.
+type RateLimiter struct {
+	rps   float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket tracks one client's remaining tokens as of lastRefill.
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second per client with bursts of up to burst.
+// Returns nil (no limit) if rps is not positive. A burst below 1 is raised to 1.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rps:       rps,
+		burst:     float64(max(burst, 1)),
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the client's bucket. If none is left it returns false and how long
+// until the next token is available.
+func (rl *RateLimiter) Allow(clientKey string) (bool, time.Duration) {
+	if rl == nil {
+		return true, 0
+	}
+	now := time.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if now.Sub(rl.lastSweep) >= rateLimitSweepInterval {
+		rl.sweep(now)
+	}
+
+	bucket, ok := rl.buckets[clientKey]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.burst, lastRefill: now}
+		rl.buckets[clientKey] = bucket
+	} else {
+		bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*rl.rps)
+		bucket.lastRefill = now
+	}
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rl.rps * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that would have refilled completely, since a new bucket starts full anyway.
+// Must be called with rl.mu held.
+func (rl *RateLimiter) sweep(now time.Time) {
+	refillTime := time.Duration(rl.burst / rl.rps * float64(time.Second))
+	for clientKey, bucket := range rl.buckets {
+		if now.Sub(bucket.lastRefill) >= refillTime {
+			delete(rl.buckets, clientKey)
+		}
+	}
+	rl.lastSweep = now
+}
+
+// RateLimitRule applies a limiter to every request whose path starts with PathPrefix.
+type RateLimitRule struct {
+	PathPrefix string
+	Limiter    *RateLimiter // nil means no limit for matching paths
+}
+
+// RateLimitMiddleware limits requests using the first rule whose PathPrefix matches the request path,
+// so more specific prefixes must come first. Requests over the limit get 429 with a Retry-After header.
+// Clients are told apart by IP, except that requests carrying trustedToken as their bearer token share
+// one bucket wherever they come from. An empty trustedToken keys every request by IP.
+// Install it before any authentication middleware, so failed attempts are limited too.
+func RateLimitMiddleware(rules []RateLimitRule, trustedToken string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, rule := range rules {
+				if !strings.HasPrefix(r.URL.Path, rule.PathPrefix) {
+					continue
+				}
+				if ok, wait := rule.Limiter.Allow(rateLimitClientKey(r, trustedToken)); !ok {
+					w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+					WriteError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+					return
+				}
+				break
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitClientKey identifies the client a request is counted against: the trusted token if the
+// request carries it, otherwise the remote IP. Any other Authorization header is ignored, so callers
+// can't dodge their limit by sending a different made-up token with each request.
+func rateLimitClientKey(r *http.Request, trustedToken string) string {
+	if HasBearerToken(r, trustedToken) {
+		return "token"
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -7,15 +7,35 @@ import (
 	"net/http"
 )
 
+// ErrorCodePlayerBanned identifies the 403 returned when a banned player tries to go online.
+const ErrorCodePlayerBanned = "player_banned"
+
+// ErrorCodePlayerAlreadyOnline identifies the 409 returned when a player who is already online tries
+// to go online and the Game Service is configured to refuse it.
+const ErrorCodePlayerAlreadyOnline = "player_already_online"
+
 // JSONErrorResponse defines a standard structure for API error responses.
 type JSONErrorResponse struct {
-	Message string `json:"message"`
-	Code    int    `json:"code,omitempty"`    // Optional: for custom application-specific error codes
-	Details string `json:"details,omitempty"` // Optional: for more detailed error info
+	Message   string   `json:"message"`
+	Code      int      `json:"code,omitempty"`       // Optional: for custom application-specific error codes
+	Details   []string `json:"details,omitempty"`    // Optional: every problem found, e.g. all validation errors of a request
+	ErrorCode string   `json:"error_code,omitempty"` // Optional: machine-readable reason, e.g. ErrorCodePlayerBanned
 }
 
-// WriteJSON writes a JSON response with the given status code.
+// WriteJSON writes a JSON response with the given status code. Successful responses are encoded as
+// MessagePack instead if the request negotiated it (see MessagePackMiddleware).
 func WriteJSON(w http.ResponseWriter, status int, data interface{}) error {
+	if status < 400 && wantsMessagePack(w) {
+		body, err := marshalMsgpack(data)
+		if err == nil {
+			w.Header().Set("Content-Type", ContentTypeMessagePack)
+			w.WriteHeader(status)
+			_, err = w.Write(body)
+			return err
+		}
+		log.Printf("WARNING: Failed to encode %T as MessagePack, sending JSON instead: %v", data, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	return json.NewEncoder(w).Encode(data)
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Ftotnem/GO-SERVICES/shared/version"
 	"github.com/gorilla/mux"
 )
 
@@ -17,7 +18,40 @@ type BaseServer struct {
 	Logger *log.Logger // Add a logger for server-specific messages
 }
 
-func NewBaseServer(addr string, logger *log.Logger) *BaseServer {
+// ServerOptions tunes the underlying http.Server. Zero fields keep the defaults in DefaultServerOptions.
+type ServerOptions struct {
+	ReadTimeout    time.Duration // Reading the whole request, including the body
+	WriteTimeout   time.Duration // Writing the response; must exceed the longest handler timeout (see WithTimeout)
+	IdleTimeout    time.Duration // Keep-alive connections are closed after this long without a request
+	MaxHeaderBytes int           // Largest accepted request header
+}
+
+// DefaultServerOptions are the options used for zero fields of the ServerOptions passed to NewBaseServer.
+var DefaultServerOptions = ServerOptions{
+	ReadTimeout:    10 * time.Second,
+	WriteTimeout:   120 * time.Second, // Longer than the slowest routes (team renames, aggregations)
+	IdleTimeout:    120 * time.Second,
+	MaxHeaderBytes: http.DefaultMaxHeaderBytes,
+}
+
+// withDefaults returns the options with zero fields replaced by DefaultServerOptions.
+func (o ServerOptions) withDefaults() ServerOptions {
+	if o.ReadTimeout <= 0 {
+		o.ReadTimeout = DefaultServerOptions.ReadTimeout
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = DefaultServerOptions.WriteTimeout
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = DefaultServerOptions.IdleTimeout
+	}
+	if o.MaxHeaderBytes <= 0 {
+		o.MaxHeaderBytes = DefaultServerOptions.MaxHeaderBytes
+	}
+	return o
+}
+
+func NewBaseServer(addr string, logger *log.Logger, opts ServerOptions) *BaseServer {
 	if logger == nil {
 		logger = log.Default() // Use default logger if none provided
 	}
@@ -28,12 +62,19 @@ func NewBaseServer(addr string, logger *log.Logger) *BaseServer {
 	router.Use(LoggingMiddleware) // LoggingMiddleware now uses `log`
 	router.Use(CORSMiddleware)
 
+	// Every service reports the build it is running.
+	router.HandleFunc("/version", VersionHandler).Methods("GET")
+	// Liveness only; dependencies are checked by /readyz, see UseReadinessChecks.
+	router.HandleFunc("/healthz", HealthHandler).Methods("GET")
+
+	opts = opts.withDefaults()
 	server := &http.Server{
-		Addr:         addr,
-		Handler:      router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Addr:           addr,
+		Handler:        router,
+		ReadTimeout:    opts.ReadTimeout,
+		WriteTimeout:   opts.WriteTimeout,
+		IdleTimeout:    opts.IdleTimeout,
+		MaxHeaderBytes: opts.MaxHeaderBytes,
 	}
 
 	return &BaseServer{
@@ -43,6 +84,48 @@ func NewBaseServer(addr string, logger *log.Logger) *BaseServer {
 	}
 }
 
+// VersionHandler returns the build version, git commit and build time injected via ldflags.
+// GET /version
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, version.Get())
+}
+
+// UseReadinessChecks serves GET /readyz, which is ready while every required check passes
+// (see ReadinessHandler).
+func (bs *BaseServer) UseReadinessChecks(checks ...ReadinessCheck) {
+	bs.Router.HandleFunc("/readyz", ReadinessHandler(DefaultReadinessTimeout, checks...)).Methods("GET")
+}
+
+// UseRateLimits limits requests per client according to rules, checked in order (see RateLimitMiddleware).
+// Requests carrying adminToken are counted together rather than per IP. Call it before RequireAdminToken.
+func (bs *BaseServer) UseRateLimits(adminToken string, rules ...RateLimitRule) {
+	bs.Router.Use(RateLimitMiddleware(rules, adminToken))
+}
+
+// UseCompression gzips responses of at least minSize bytes for clients that accept gzip
+// (see CompressionMiddleware). A minSize of 0 leaves responses uncompressed.
+func (bs *BaseServer) UseCompression(minSize int) {
+	if minSize <= 0 {
+		return
+	}
+	bs.Router.Use(CompressionMiddleware(minSize))
+}
+
+// UseMessagePack lets clients that prefer application/msgpack in their Accept header receive
+// successful responses as MessagePack (see MessagePackMiddleware).
+func (bs *BaseServer) UseMessagePack() {
+	bs.Router.Use(MessagePackMiddleware)
+}
+
+// RequireAdminToken requires "Authorization: Bearer <token>" on every path under pathPrefixes.
+// An empty token rejects every request to the paths, so a missing token never opens them up.
+func (bs *BaseServer) RequireAdminToken(token string, pathPrefixes ...string) {
+	if token == "" {
+		bs.Logger.Printf("WARNING: No admin token configured; %v endpoints are disabled.", pathPrefixes)
+	}
+	bs.Router.Use(RequireTokenMiddleware(token, pathPrefixes))
+}
+
 func (bs *BaseServer) Start() error {
 	bs.Logger.Printf("Starting HTTP server on %s...", bs.Server.Addr)
 	// ListenAndServe returns http.ErrServerClosed on graceful shutdown
@@ -0,0 +1,22 @@
+// shared/api/timeout.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultRequestTimeout bounds handlers that only talk to the local store.
+const DefaultRequestTimeout = 5 * time.Second
+
+// WithTimeout wraps a handler so its request context is cancelled after timeout, letting the handler
+// pass r.Context() straight to services. It is applied per route rather than with Router.Use because
+// a context's deadline can only be shortened: a router-wide default would cap every longer route.
+func WithTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,32 @@
+// shared/api/uuid.go
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidUUID is returned (wrapped) by NormalizeUUID for input that is not a UUID in either accepted form.
+var ErrInvalidUUID = errors.New("invalid UUID")
+
+// NormalizeUUID validates a player UUID and returns it in canonical form
+// (lowercase, hyphenated, e.g. "069a79f4-44e9-4726-a5be-fca90e38aaf5").
+// The hyphenated 36-character form and the undashed 32-character form Minecraft and Mojang
+// sometimes send are accepted, in any case and with surrounding whitespace trimmed. Braced and
+// urn:uuid: forms, which uuid.Parse would also take, are rejected so every caller accepts the same
+// inputs. All handlers must key Redis and MongoDB on the normalized value so the same player always
+// maps to the same keys.
+func NormalizeUUID(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if len(trimmed) != 36 && len(trimmed) != 32 {
+		return "", fmt.Errorf("%w '%s': expected 32 hex digits, optionally hyphenated as 8-4-4-4-12", ErrInvalidUUID, raw)
+	}
+	parsed, err := uuid.Parse(trimmed)
+	if err != nil {
+		return "", fmt.Errorf("%w '%s': %w", ErrInvalidUUID, raw, err)
+	}
+	return parsed.String(), nil
+}
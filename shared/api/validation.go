@@ -0,0 +1,33 @@
+// shared/api/validation.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ValidationErrors collects every problem found while validating a request, so they can be
+// reported together instead of one per round trip.
+type ValidationErrors []string
+
+// Add records a problem, formatted like fmt.Sprintf.
+func (v *ValidationErrors) Add(format string, args ...any) {
+	*v = append(*v, fmt.Sprintf(format, args...))
+}
+
+// WriteValidationErrors writes a 400 Bad Request listing every problem in Details. The message is the
+// problem itself if there is only one.
+func WriteValidationErrors(w http.ResponseWriter, problems ValidationErrors) {
+	message := fmt.Sprintf("Invalid request: %d problems", len(problems))
+	if len(problems) == 1 {
+		message = problems[0]
+	}
+	errResp := JSONErrorResponse{
+		Message: message,
+		Code:    http.StatusBadRequest,
+		Details: problems,
+	}
+	if err := WriteJSON(w, http.StatusBadRequest, errResp); err != nil {
+		http.Error(w, message, http.StatusBadRequest) // Fallback
+	}
+}
@@ -4,6 +4,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"slices"
 	"sync"
@@ -20,7 +21,10 @@ type ServiceAssignmentManager struct {
 	serviceRegistrar *registry.ServiceRegistrar // The type of service (e.g., "game-service", "chat-service")
 	updateInterval   time.Duration              // How often to update the consistent hash ring
 	consistentHash   *consistent.Consistent     // The consistent hash ring
-	chMux            sync.RWMutex               // Protects access to consistentHash
+	chMux            sync.RWMutex               // Protects access to consistentHash and the modulo settings
+	moduloEnabled    bool                       // If true, IsResponsible uses static modulo sharding instead of the ring
+	instanceID       int                        // This instance's static shard ID (modulo mode only)
+	totalInstances   int                        // Total number of static shards (modulo mode only)
 	ctx              context.Context            // Context for managing lifecycle
 	cancel           context.CancelFunc         // Cancel function for the context
 }
@@ -112,12 +116,63 @@ func (sam *ServiceAssignmentManager) updateConsistentHashRing() {
 	}
 }
 
+// RingMembers returns the instance IDs currently on the consistent hash ring, sorted.
+// In modulo mode the ring is not used for assignment, but its members are still returned.
+func (sam *ServiceAssignmentManager) RingMembers() []string {
+	sam.chMux.RLock()
+	defer sam.chMux.RUnlock()
+
+	members := sam.consistentHash.Members()
+	slices.Sort(members)
+	return members
+}
+
+// ModuloEnabled reports whether the manager uses static modulo sharding instead of the ring.
+func (sam *ServiceAssignmentManager) ModuloEnabled() bool {
+	sam.chMux.RLock()
+	defer sam.chMux.RUnlock()
+	return sam.moduloEnabled
+}
+
+// EnableModuloSharding switches the manager to static modulo sharding.
+// In this mode an instance is responsible for an entity when hash(entityID) % totalInstances == instanceID,
+// and the consistent hash ring is ignored. Call this before the manager is used.
+func (sam *ServiceAssignmentManager) EnableModuloSharding(instanceID, totalInstances int) error {
+	if totalInstances <= 0 {
+		return fmt.Errorf("total instances must be positive for modulo sharding (got %d)", totalInstances)
+	}
+	if instanceID < 0 || instanceID >= totalInstances {
+		return fmt.Errorf("instance ID %d out of range for %d instances", instanceID, totalInstances)
+	}
+
+	sam.chMux.Lock()
+	defer sam.chMux.Unlock()
+	sam.moduloEnabled = true
+	sam.instanceID = instanceID
+	sam.totalInstances = totalInstances
+
+	log.Printf("ServiceAssignmentManager: Modulo sharding enabled for '%s' (instance %d of %d).", sam.serviceRegistrar.GetServiceType(), instanceID, totalInstances)
+	return nil
+}
+
+// moduloShard returns the static shard an entity maps to in modulo mode.
+func moduloShard(entityID string, totalInstances int) int {
+	h := fnv.New32a()
+	h.Write([]byte(entityID))
+	return int(h.Sum32() % uint32(totalInstances))
+}
+
 // IsResponsible checks if the current service instance is responsible for the given entity ID.
-// It uses the consistent hash ring to determine which service instance is assigned to the entity.
+// It uses the consistent hash ring to determine which service instance is assigned to the entity,
+// unless modulo sharding has been enabled.
 func (sam *ServiceAssignmentManager) IsResponsible(entityID string) (bool, error) {
 	sam.chMux.RLock() // Use RLock for read access
 	defer sam.chMux.RUnlock()
 
+	if sam.moduloEnabled {
+		return moduloShard(entityID, sam.totalInstances) == sam.instanceID, nil
+	}
+
 	if len(sam.consistentHash.Members()) == 0 {
 		// This can happen briefly during startup or if no services are registered.
 		log.Printf("WARNING: ServiceAssignmentManager: Consistent hash ring for '%s' is empty. Cannot determine responsibility for entity '%s'.", sam.serviceRegistrar.GetServiceType(), entityID)
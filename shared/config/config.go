@@ -4,12 +4,33 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/shared/logging"
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+)
+
+// maxTeamPlaytimeCap is the largest allowed team playtime cap, beyond which float64 no longer
+// represents every whole tick exactly.
+const maxTeamPlaytimeCap = redisu.MaxExactTeamPlaytime
+
+// Sharding modes supported by the game-service for assigning players to instances.
+const (
+	ShardingModeConsistentHash = "consistent-hash" // Assignment follows the consistent hash ring built from the registry (default)
+	ShardingModeModulo         = "modulo"          // Assignment uses hash(uuid) % TotalGameServiceInstances == GameServiceInstanceID
 )
 
+// RateLimit is a per-client token bucket limit: RPS requests per second on average, with bursts of up to Burst.
+// An RPS of 0 disables the limit.
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
 // CommonConfig holds configuration fields that are shared across multiple services.
 type CommonConfig struct {
 	RedisAddrs              []string      // Redis server addresses (e.g., "redis-cluster:6379")
@@ -17,8 +38,27 @@ type CommonConfig struct {
 	HeartbeatInterval       time.Duration // How often to send a heartbeat to registry (e.g., 5s)
 	HeartbeatTTL            time.Duration // How long an instance is considered alive without a heartbeat (e.g., 15s)
 	RegistryCleanupInterval time.Duration // How often the registry actively cleans stale entries (e.g., 30s)
+	RegistryCleanupEnabled  bool          // If false, this instance never cleans stale registry entries and leaves it to the others
+	RegistryCleanupMisses   int           // Consecutive cleanup passes an entry must be stale for before it is removed (e.g., 3)
+	RegistrationTimeout     time.Duration // How long startup retries the initial registry registration before giving up (e.g., 30s)
 	ServiceIP               string        // The IP address this service advertises for registration (Kubernetes Pod IP)
 	ServicePort             int           // The port this service listens on, used for registration
+	LogFormat               string        // Log output format: "text" (default) or "json"
+	AdminToken              string        // Bearer token required by admin endpoints. Empty disables them.
+	RateLimit               RateLimit     // Default per-client limit for every endpoint (e.g., 50:100). Disabled by default.
+	AdminRateLimit          RateLimit     // Per-client limit for admin endpoints such as bans (e.g., 5:10)
+	ScanRateLimit           RateLimit     // Per-client limit for endpoints that scan Redis or aggregate MongoDB (e.g., 1:5)
+	CompressionMinSize      int           // Responses of at least this many bytes are gzipped for clients that accept it. 0 disables compression.
+	HTTPReadTimeout         time.Duration // Time allowed to read a whole request (e.g., 10s)
+	HTTPWriteTimeout        time.Duration // Time allowed to write a response; must exceed the longest handler timeout (e.g., 2m)
+	HTTPIdleTimeout         time.Duration // How long idle keep-alive connections are kept open (e.g., 2m)
+	HTTPMaxHeaderBytes      int           // Largest accepted request header in bytes (e.g., 1048576)
+	MessagePack             bool          // If true, responses are sent as MessagePack to clients that prefer it, and internal clients ask for it on hot endpoints
+	ProfileChangesChannel   string        // Redis pub/sub channel for player profile changes. Empty disables publishing and subscribing.
+	PlaytimeMillis          bool          // If true, Redis playtime totals are whole milliseconds incremented with INCRBY instead of float seconds. All services must agree.
+
+	// Extra key-value pairs published with the service registration (e.g., "region": "eu-west").
+	ServiceMetadata map[string]string
 }
 
 // GameServiceConfig holds configuration specific to the game-service.
@@ -26,13 +66,54 @@ type GameServiceConfig struct {
 	CommonConfig                            // Embed CommonConfig
 	ListenAddr                string        // Address for the HTTP server (e.g., ":8082")
 	RedisOnlineTTL            time.Duration // TTL for 'online:<uuid>' keys in Redis (e.g., 15s)
+	ClientHeartbeatInterval   time.Duration // How often game servers refresh their players' online status (e.g., 5s); RedisOnlineTTL must be at least twice this. 0 skips the check.
+	MaxSessionDuration        time.Duration // Absolute cap on a session kept alive by heartbeats (e.g., 12h). 0 disables the cap.
+	IdleTimeout               time.Duration // Players without an activity heartbeat for this long stop accruing playtime. 0 disables idle detection.
+	OfflineGracePeriod        time.Duration // How long a disconnected player's session is kept for a reconnect (e.g., 10s). 0 disables it.
 	TickInterval              time.Duration // Duration for the game tick (e.g., 50ms)
 	PersistenceInterval       time.Duration // Duration for periodic persistence (e.g., 1m)
+	SkipOverrunSyncs          bool          // If true, a sync that outlasts PersistenceInterval skips the tick missed meanwhile instead of starting the next sync right away
+	MaxConcurrentScans        int           // Max Redis master nodes scanned at once when reading all playtimes during sync (e.g., 2). 0 disables the limit.
+	PersistEveryTicks         int           // Persist each responsible online player's playtime every N ticks, staggered per player (e.g., 1200). 0 disables it.
+	TickLogInterval           time.Duration // How often the updater logs a summary of the ticks since the last one (e.g., 1m). 0 disables it.
 	PlayerServiceURL          string        // The URL to the used player-service (e.g., "http://player-service:8081")
+	RequirePlayerServiceReady bool          // If true, /readyz fails while the player service is unreachable; otherwise it is only reported
 	GameServiceInstanceID     int           // Unique identifier for this game service instance (e.g., 0, 1, 2 for sharding)
 	TotalGameServiceInstances int           // Total number of active game service instances (e.g., 1, 3 for sharding)
+	ShardingMode              string        // How players are assigned to instances: "consistent-hash" or "modulo"
+	MaxConcurrentOnline       int           // Max PlayerOnline profile fetches in flight at once (e.g., 64). 0 disables the limit.
+	OnlineQueueSize           int           // Max PlayerOnline calls waiting for a fetch slot before rejecting with 503 (e.g., 1024)
+	RejectAlreadyOnline       bool          // If true, going online while already online is refused with 409 instead of re-initializing the session
+	PlaytimeAuditEnabled      bool          // If true, every playtime change is appended to a per-player audit log in Redis
+	PlaytimeAuditMaxEntries   int           // Entries kept per player in the audit log (e.g., 1000)
+	PlaytimeAuditRetention    time.Duration // How long an idle player's audit log is kept (e.g., 168h)
+	BatchConcurrency          int           // Max players processed in parallel by the batch online/offline endpoints (e.g., 16)
+	PlaytimeDecimals          int           // Decimal places playtime values in API responses are rounded to (e.g., 2). -1 disables rounding.
+	ProfileCacheTTL           time.Duration // How long fetched player profiles are cached in Redis (e.g., 30s). 0 disables the cache.
+	ProfileFetchRetries       int           // Extra attempts when the player service fails a profile fetch with a non-404 error (e.g., 2)
+	ProfileFetchRetryBackoff  time.Duration // Delay before the first profile fetch retry; doubled on each subsequent retry (e.g., 200ms)
 	BackupTimeout             time.Duration // NEW: Timeout for the full player playtime backup operation (e.g., 60 seconds)
 	SyncTimeout               time.Duration // NEW: Timeout for the team total sync operation (e.g., 30 seconds)
+	TeamSyncRetries           int           // Extra attempts for a failed team totals sync call or per-team Redis update during sync (e.g., 3). 0 disables retries.
+	TeamSyncRetryBackoff      time.Duration // Delay before the first retry; doubled on each subsequent retry (e.g., 100ms)
+	TeamPlaytimeCap           float64       // Maximum total playtime per team in Redis (at most 2^53). 0 disables the cap.
+	TeamPlaytimeDisabled      bool          // If true, playtime ticks credit players only and never add to team totals
+	AllowNegativeTeamPlaytime bool          // If true, corrections may drive team totals below zero; otherwise they are floored at zero
+	ResetCorruptTeamPlaytime  bool          // If true, a non-numeric team total found on read is reset to 0 so increments work again
+	TeamLeaderboardIndex      bool          // If true, team totals are mirrored into a Redis sorted set so top teams are read without a scan
+	PlayerLeaderboardIndex    bool          // If true, player totals are mirrored into a Redis sorted set (rebuilt on every sync) so top players are read without a scan
+	MaxBanDuration            time.Duration // Longest temporary ban accepted by the ban endpoints (e.g., 8760h). 0 disables the limit.
+	BanCleanupInterval        time.Duration // How often the leader removes expired ban keys from Redis (e.g., 1m). 0 disables the cleanup.
+	BanCleanupBatchSize       int           // Expired bans deleted per pipelined batch during cleanup (e.g., 500)
+	BanClockSkewTolerance     time.Duration // How long past its expiry a temporary ban still counts as active, to absorb clock skew between instances (e.g., 2s)
+	OnlineRecountInterval     time.Duration // How often the leader recounts online keys to correct the online counter (e.g., 1m). 0 disables reconciliation.
+	SnapshotBackupDir         string        // Directory every sync also writes a JSON playtime snapshot to, as a secondary backup. Empty disables it.
+	SnapshotBackupRetain      int           // Number of snapshot files kept in SnapshotBackupDir (e.g., 48). 0 keeps all of them.
+	DeltaCleanupInterval      time.Duration // How often the leader clears deltas of players without an online session (e.g., 10m). 0 disables the cleanup.
+	DeltaMaxAge               time.Duration // How old a delta without an online session must be before it is cleared (e.g., 1h)
+	AllowedTeams              []string      // Teams always accepted, in addition to those loaded from the player service (e.g., "AQUA_CREEPERS")
+	TeamRefreshInterval       time.Duration // How often the known team set is refreshed from the player service (e.g., 5m)
+	EventsStream              string        // Redis stream that player_online/player_offline events are published to. Empty disables events.
 }
 
 // PlayerServiceConfig holds configuration specific to the player-service.
@@ -43,8 +124,22 @@ type PlayerServiceConfig struct {
 	MongoDBDatabase          string        // MongoDB database name (e.g., "minecraft_players")
 	MongoDBPlayersCollection string        // MongoDB collection for players (e.g., "players")
 	MongoDBTeamCollection    string        // MongoDB collection for team related info
+	MongoDBConnectAttempts   int           // Connection attempts at startup before giving up (e.g., 10), so MongoDB may start after the service
+	MongoDBConnectBackoff    time.Duration // Delay before the first connection retry; doubled on each subsequent retry, up to 30s (e.g., 1s)
 	UsernameFillerInterval   time.Duration // An interval for where to perform Background tasks (e.g., Username Filler Jobs)
-	DefaultTeams             []string
+	UsernameFillerEnabled    bool          // If false, the Mojang username filler job is not started (e.g., air-gapped clusters)
+	MojangBreakerThreshold   int           // Consecutive Mojang API failures after which calls are paused (e.g., 5). 0 disables the circuit breaker.
+	MojangBreakerCooldown    time.Duration // How long Mojang API calls are paused once the breaker opens (e.g., 5m)
+	DefaultTeams             []string      // Teams created at startup and used for assignment if teams cannot be loaded (e.g., "AQUA_CREEPERS")
+	SoftDeleteProfiles       bool          // If true, deleting a profile marks it with deleted_at instead of removing the document
+	MajorityPlaytimeWrites   bool          // If true, playtime persists use a majority write concern (durable across primary failover)
+	BalanceTeamsByOnline     bool          // If true, new players join the team with the fewest online players (ties broken by total players)
+	TeamCountInterval        time.Duration // How often the leader corrects drifted team player counts from the players in MongoDB (e.g., 1h). 0 disables it.
+	GameServiceURL           string        // The URL of the game-service, used for online counts and team transfers (e.g., "http://game-service:8082")
+
+	// Target share of players per team (e.g., {"AQUA_CREEPERS": 60, "PURPLE_AXOLOTLS": 40}). If set, new players join
+	// the team furthest below its target share, taking precedence over BalanceTeamsByOnline. Unlisted teams get no new players.
+	TeamRatios map[string]float64
 }
 
 // LoadCommonConfig loads common configuration from environment variables.
@@ -77,6 +172,90 @@ func LoadCommonConfig() (CommonConfig, error) {
 	if err != nil {
 		return cfg, err
 	}
+	cfg.RegistryCleanupEnabled, err = getBool("SERVICE_REGISTRY_CLEANUP_ENABLED", true)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.RegistryCleanupMisses, err = getInt("SERVICE_REGISTRY_CLEANUP_MISSES", 3)
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.RegistryCleanupMisses < 1 {
+		return cfg, fmt.Errorf("SERVICE_REGISTRY_CLEANUP_MISSES must be at least 1 (got %d)", cfg.RegistryCleanupMisses)
+	}
+	cfg.RegistrationTimeout, err = getDuration("SERVICE_REGISTRATION_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.ServiceMetadata, err = parseKeyValues("SERVICE_METADATA")
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.RateLimit, err = getRateLimit("RATE_LIMIT", RateLimit{})
+	if err != nil {
+		return cfg, err
+	}
+	cfg.AdminRateLimit, err = getRateLimit("RATE_LIMIT_ADMIN", RateLimit{RPS: 5, Burst: 10})
+	if err != nil {
+		return cfg, err
+	}
+	cfg.ScanRateLimit, err = getRateLimit("RATE_LIMIT_SCAN", RateLimit{RPS: 1, Burst: 5})
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.CompressionMinSize, err = getInt("HTTP_COMPRESSION_MIN_SIZE", 0)
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.CompressionMinSize < 0 {
+		return cfg, fmt.Errorf("HTTP_COMPRESSION_MIN_SIZE must not be negative (got %d)", cfg.CompressionMinSize)
+	}
+
+	cfg.MessagePack, err = getBool("HTTP_MESSAGEPACK", false)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.HTTPReadTimeout, err = getDuration("HTTP_READ_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.HTTPWriteTimeout, err = getDuration("HTTP_WRITE_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.HTTPIdleTimeout, err = getDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.HTTPReadTimeout <= 0 || cfg.HTTPWriteTimeout <= 0 || cfg.HTTPIdleTimeout <= 0 {
+		return cfg, fmt.Errorf("HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must be positive")
+	}
+	cfg.HTTPMaxHeaderBytes, err = getInt("HTTP_MAX_HEADER_BYTES", 1<<20)
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.HTTPMaxHeaderBytes <= 0 {
+		return cfg, fmt.Errorf("HTTP_MAX_HEADER_BYTES must be positive (got %d)", cfg.HTTPMaxHeaderBytes)
+	}
+
+	cfg.PlaytimeMillis, err = getBool("PLAYTIME_MILLISECONDS", false)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
+	cfg.ProfileChangesChannel = os.Getenv("PROFILE_CHANGES_CHANNEL")
+
+	cfg.LogFormat = os.Getenv("LOG_FORMAT")
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = logging.FormatText
+	}
+	if cfg.LogFormat != logging.FormatText && cfg.LogFormat != logging.FormatJSON {
+		return cfg, fmt.Errorf("LOG_FORMAT must be '%s' or '%s' (got '%s')", logging.FormatText, logging.FormatJSON, cfg.LogFormat)
+	}
 
 	// Service IP (for registration, from Kubernetes Pod IP)
 	cfg.ServiceIP = os.Getenv("POD_IP") // Injected by Kubernetes
@@ -115,6 +294,142 @@ func getInt(envKey string, defaultVal int) (int, error) {
 	return i, nil
 }
 
+// Helper function to parse float from environment variable
+func getFloat(envKey string, defaultVal float64) (float64, error) {
+	valStr := os.Getenv(envKey)
+	if valStr == "" {
+		return defaultVal, nil
+	}
+	f, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float format for %s: %w", envKey, err)
+	}
+	return f, nil
+}
+
+// Helper function to parse bool from environment variable
+func getBool(envKey string, defaultVal bool) (bool, error) {
+	valStr := os.Getenv(envKey)
+	if valStr == "" {
+		return defaultVal, nil
+	}
+	b, err := strconv.ParseBool(valStr)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean format for %s: %w", envKey, err)
+	}
+	return b, nil
+}
+
+// getRateLimit parses a rate limit given as "rps:burst" (e.g., "5:10"); "0" disables the limit.
+func getRateLimit(envKey string, defaultVal RateLimit) (RateLimit, error) {
+	valStr := strings.TrimSpace(os.Getenv(envKey))
+	if valStr == "" {
+		return defaultVal, nil
+	}
+	if valStr == "0" {
+		return RateLimit{}, nil
+	}
+	rpsStr, burstStr, ok := strings.Cut(valStr, ":")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit '%s' for %s: expected rps:burst", valStr, envKey)
+	}
+	rps, err := strconv.ParseFloat(strings.TrimSpace(rpsStr), 64)
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("invalid rate for %s: %w", envKey, err)
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(burstStr))
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("invalid burst for %s: %w", envKey, err)
+	}
+	if rps < 0 || burst < 1 {
+		return RateLimit{}, fmt.Errorf("%s must have a non-negative rate and a burst of at least 1 (got '%s')", envKey, valStr)
+	}
+	return RateLimit{RPS: rps, Burst: burst}, nil
+}
+
+// parseTeamRatios parses comma-separated TEAM:weight pairs (e.g., "AQUA_CREEPERS:60,PURPLE_AXOLOTLS:40").
+// Returns nil if the variable is unset.
+func parseTeamRatios(envKey string) (map[string]float64, error) {
+	valStr := os.Getenv(envKey)
+	if valStr == "" {
+		return nil, nil
+	}
+	ratios := make(map[string]float64)
+	for _, pair := range strings.Split(valStr, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		team, weightStr, ok := strings.Cut(pair, ":")
+		team = strings.TrimSpace(team)
+		if !ok || team == "" {
+			return nil, fmt.Errorf("invalid team ratio '%s' for %s: expected TEAM:weight", pair, envKey)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for team %s in %s: %w", team, envKey, err)
+		}
+		if weight <= 0 {
+			return nil, fmt.Errorf("weight for team %s in %s must be positive (got %v)", team, envKey, weight)
+		}
+		ratios[team] = weight
+	}
+	return ratios, nil
+}
+
+// parseKeyValues parses comma-separated key=value pairs (e.g., "region=eu-west,zone=b").
+// Returns nil if the variable is unset.
+func parseKeyValues(envKey string) (map[string]string, error) {
+	valStr := os.Getenv(envKey)
+	if valStr == "" {
+		return nil, nil
+	}
+	values := make(map[string]string)
+	for _, pair := range strings.Split(valStr, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid entry '%s' for %s: expected key=value", pair, envKey)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// validateServiceURL ensures a URL pointing at another service is usable (http/https scheme and a host).
+func validateServiceURL(envKey, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL for %s ('%s'): %w", envKey, rawURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid URL for %s ('%s'): scheme must be http or https", envKey, rawURL)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("invalid URL for %s ('%s'): host is required", envKey, rawURL)
+	}
+	return nil
+}
+
+// validateCollectionName ensures an explicitly configured MongoDB collection name is usable:
+// not blank, no '$' or NUL characters and not in the reserved "system." namespace.
+func validateCollectionName(envKey, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%s must not be empty", envKey)
+	}
+	if strings.ContainsAny(name, "$\x00") {
+		return fmt.Errorf("invalid collection name for %s ('%s'): must not contain '$' or NUL characters", envKey, name)
+	}
+	if strings.HasPrefix(name, "system.") {
+		return fmt.Errorf("invalid collection name for %s ('%s'): the 'system.' prefix is reserved", envKey, name)
+	}
+	return nil
+}
+
 // extractPort extracts the numeric port from a listen address (e.g., ":8082" -> 8082, "0.0.0.0:8082" -> 8082)
 func extractPort(listenAddr string) (int, error) {
 	_, portStr, err := net.SplitHostPort(listenAddr)
@@ -153,6 +468,9 @@ func LoadGameServiceConfig() (*GameServiceConfig, error) {
 	if cfg.PlayerServiceURL == "" {
 		cfg.PlayerServiceURL = "http://localhost:8081" //"http://player-service:8081" // Default for K8s internal DNS
 	}
+	if err := validateServiceURL("PLAYERS_SERVICE_URL", cfg.PlayerServiceURL); err != nil {
+		return nil, err
+	}
 
 	// Extract ServicePort from ListenAddr
 	cfg.ServicePort, err = extractPort(cfg.ListenAddr)
@@ -164,6 +482,31 @@ func LoadGameServiceConfig() (*GameServiceConfig, error) {
 	if err != nil {
 		return cfg, err
 	}
+	cfg.ClientHeartbeatInterval, err = getDuration("GAME_CLIENT_HEARTBEAT_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	// With less than two heartbeats per TTL, one late or lost heartbeat lets the online key expire
+	// and the player flaps offline.
+	if cfg.ClientHeartbeatInterval > 0 && cfg.RedisOnlineTTL < 2*cfg.ClientHeartbeatInterval {
+		return nil, fmt.Errorf("REDIS_ONLINE_TTL (%v) must be at least twice GAME_CLIENT_HEARTBEAT_INTERVAL (%v)", cfg.RedisOnlineTTL, cfg.ClientHeartbeatInterval)
+	}
+	cfg.MaxSessionDuration, err = getDuration("GAME_MAX_SESSION_DURATION", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RequirePlayerServiceReady, err = getBool("GAME_READINESS_REQUIRES_PLAYER_SERVICE", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.IdleTimeout, err = getDuration("GAME_IDLE_TIMEOUT", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OfflineGracePeriod, err = getDuration("GAME_OFFLINE_GRACE_PERIOD", 0)
+	if err != nil {
+		return nil, err
+	}
 	cfg.TickInterval, err = getDuration("GAME_SERVICE_TICK_INTERVAL", 50*time.Millisecond)
 	if err != nil {
 		return nil, err
@@ -172,6 +515,10 @@ func LoadGameServiceConfig() (*GameServiceConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.SkipOverrunSyncs, err = getBool("GAME_SKIP_OVERRUN_SYNCS", true)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg.GameServiceInstanceID, err = getInt("GAME_SERVICE_INSTANCE_ID", 0)
 	if err != nil {
@@ -190,6 +537,78 @@ func LoadGameServiceConfig() (*GameServiceConfig, error) {
 		return nil, fmt.Errorf("GAME_SERVICE_INSTANCE_ID (%d) must be non-negative and less than TOTAL_GAME_SERVICE_INSTANCES (%d)", cfg.GameServiceInstanceID, cfg.TotalGameServiceInstances)
 	}
 
+	cfg.ShardingMode = os.Getenv("GAME_SHARDING_MODE")
+	if cfg.ShardingMode == "" {
+		cfg.ShardingMode = ShardingModeConsistentHash
+	}
+	if cfg.ShardingMode != ShardingModeConsistentHash && cfg.ShardingMode != ShardingModeModulo {
+		return nil, fmt.Errorf("GAME_SHARDING_MODE must be '%s' or '%s' (got '%s')", ShardingModeConsistentHash, ShardingModeModulo, cfg.ShardingMode)
+	}
+
+	cfg.MaxConcurrentOnline, err = getInt("GAME_MAX_CONCURRENT_ONLINE", 64)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConcurrentOnline < 0 {
+		return nil, fmt.Errorf("GAME_MAX_CONCURRENT_ONLINE must be non-negative (got %d)", cfg.MaxConcurrentOnline)
+	}
+	cfg.OnlineQueueSize, err = getInt("GAME_ONLINE_QUEUE_SIZE", 1024)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OnlineQueueSize < 0 {
+		return nil, fmt.Errorf("GAME_ONLINE_QUEUE_SIZE must be non-negative (got %d)", cfg.OnlineQueueSize)
+	}
+	cfg.RejectAlreadyOnline, err = getBool("GAME_REJECT_ALREADY_ONLINE", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.PlaytimeAuditEnabled, err = getBool("GAME_PLAYTIME_AUDIT_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.PlaytimeAuditMaxEntries, err = getInt("GAME_PLAYTIME_AUDIT_MAX_ENTRIES", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PlaytimeAuditMaxEntries <= 0 {
+		return nil, fmt.Errorf("GAME_PLAYTIME_AUDIT_MAX_ENTRIES must be a positive integer (got %d)", cfg.PlaytimeAuditMaxEntries)
+	}
+	cfg.PlaytimeAuditRetention, err = getDuration("GAME_PLAYTIME_AUDIT_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BatchConcurrency, err = getInt("GAME_BATCH_CONCURRENCY", 16)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BatchConcurrency <= 0 {
+		return nil, fmt.Errorf("GAME_BATCH_CONCURRENCY must be a positive integer (got %d)", cfg.BatchConcurrency)
+	}
+	cfg.PlaytimeDecimals, err = getInt("GAME_PLAYTIME_DECIMALS", 2)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PlaytimeDecimals < -1 || cfg.PlaytimeDecimals > 15 {
+		return nil, fmt.Errorf("GAME_PLAYTIME_DECIMALS must be between -1 and 15 (got %d)", cfg.PlaytimeDecimals)
+	}
+	cfg.ProfileCacheTTL, err = getDuration("GAME_PROFILE_CACHE_TTL", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ProfileFetchRetries, err = getInt("GAME_PROFILE_FETCH_RETRIES", 2)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ProfileFetchRetries < 0 {
+		return nil, fmt.Errorf("GAME_PROFILE_FETCH_RETRIES must be non-negative (got %d)", cfg.ProfileFetchRetries)
+	}
+	cfg.ProfileFetchRetryBackoff, err = getDuration("GAME_PROFILE_FETCH_RETRY_BACKOFF", 200*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
 	backupTimeoutStr := os.Getenv("GAME_BACKUP_TIMEOUT")
 	cfg.BackupTimeout, err = time.ParseDuration(backupTimeoutStr)
 	if err != nil {
@@ -202,6 +621,152 @@ func LoadGameServiceConfig() (*GameServiceConfig, error) {
 		cfg.SyncTimeout = 30 * time.Second // Default timeout for the team total sync operation
 	}
 
+	cfg.EventsStream = os.Getenv("GAME_EVENTS_STREAM")
+
+	if allowedTeamsStr := os.Getenv("GAME_ALLOWED_TEAMS"); allowedTeamsStr != "" {
+		for _, team := range strings.Split(allowedTeamsStr, ",") {
+			if team = strings.TrimSpace(team); team != "" {
+				cfg.AllowedTeams = append(cfg.AllowedTeams, team)
+			}
+		}
+	}
+	cfg.TeamRefreshInterval, err = getDuration("GAME_TEAM_REFRESH_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.TeamSyncRetries, err = getInt("GAME_TEAM_SYNC_RETRIES", 3)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TeamSyncRetries < 0 {
+		return nil, fmt.Errorf("GAME_TEAM_SYNC_RETRIES must be non-negative (got %d)", cfg.TeamSyncRetries)
+	}
+	cfg.TeamSyncRetryBackoff, err = getDuration("GAME_TEAM_SYNC_RETRY_BACKOFF", 100*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MaxBanDuration, err = getDuration("GAME_MAX_BAN_DURATION", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxBanDuration < 0 {
+		return nil, fmt.Errorf("GAME_MAX_BAN_DURATION must be non-negative (got %v)", cfg.MaxBanDuration)
+	}
+
+	cfg.MaxConcurrentScans, err = getInt("GAME_MAX_CONCURRENT_SCANS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConcurrentScans < 0 {
+		return nil, fmt.Errorf("GAME_MAX_CONCURRENT_SCANS must be non-negative (got %d)", cfg.MaxConcurrentScans)
+	}
+
+	cfg.PersistEveryTicks, err = getInt("GAME_PERSIST_EVERY_TICKS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PersistEveryTicks < 0 {
+		return nil, fmt.Errorf("GAME_PERSIST_EVERY_TICKS must be non-negative (got %d)", cfg.PersistEveryTicks)
+	}
+
+	cfg.TickLogInterval, err = getDuration("GAME_TICK_LOG_INTERVAL", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TickLogInterval < 0 {
+		return nil, fmt.Errorf("GAME_TICK_LOG_INTERVAL must be non-negative (got %v)", cfg.TickLogInterval)
+	}
+
+	cfg.BanCleanupInterval, err = getDuration("GAME_BAN_CLEANUP_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BanCleanupInterval < 0 {
+		return nil, fmt.Errorf("GAME_BAN_CLEANUP_INTERVAL must be non-negative (got %v)", cfg.BanCleanupInterval)
+	}
+	cfg.OnlineRecountInterval, err = getDuration("GAME_ONLINE_COUNT_RECONCILE_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OnlineRecountInterval < 0 {
+		return nil, fmt.Errorf("GAME_ONLINE_COUNT_RECONCILE_INTERVAL must be non-negative (got %v)", cfg.OnlineRecountInterval)
+	}
+	cfg.SnapshotBackupDir = os.Getenv("GAME_SNAPSHOT_BACKUP_DIR")
+	cfg.SnapshotBackupRetain, err = getInt("GAME_SNAPSHOT_BACKUP_RETAIN", 48)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SnapshotBackupRetain < 0 {
+		return nil, fmt.Errorf("GAME_SNAPSHOT_BACKUP_RETAIN must be non-negative (got %d)", cfg.SnapshotBackupRetain)
+	}
+	cfg.DeltaCleanupInterval, err = getDuration("GAME_DELTA_CLEANUP_INTERVAL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DeltaCleanupInterval < 0 {
+		return nil, fmt.Errorf("GAME_DELTA_CLEANUP_INTERVAL must be non-negative (got %v)", cfg.DeltaCleanupInterval)
+	}
+	if cfg.DeltaCleanupInterval > 0 && cfg.DeltaCleanupInterval <= cfg.OfflineGracePeriod {
+		// A delta must stay orphaned for a whole interval, which only rules out reconnects if that outlasts the grace period.
+		return nil, fmt.Errorf("GAME_DELTA_CLEANUP_INTERVAL (%v) must be longer than GAME_OFFLINE_GRACE_PERIOD (%v)", cfg.DeltaCleanupInterval, cfg.OfflineGracePeriod)
+	}
+	cfg.DeltaMaxAge, err = getDuration("GAME_DELTA_MAX_AGE", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DeltaMaxAge < 0 {
+		return nil, fmt.Errorf("GAME_DELTA_MAX_AGE must be non-negative (got %v)", cfg.DeltaMaxAge)
+	}
+	cfg.BanCleanupBatchSize, err = getInt("GAME_BAN_CLEANUP_BATCH_SIZE", 500)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BanCleanupBatchSize <= 0 {
+		return nil, fmt.Errorf("GAME_BAN_CLEANUP_BATCH_SIZE must be positive (got %d)", cfg.BanCleanupBatchSize)
+	}
+	cfg.BanClockSkewTolerance, err = getDuration("GAME_BAN_CLOCK_SKEW_TOLERANCE", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BanClockSkewTolerance < 0 || cfg.BanClockSkewTolerance > time.Minute {
+		return nil, fmt.Errorf("GAME_BAN_CLOCK_SKEW_TOLERANCE must be between 0 and 1m (got %v)", cfg.BanClockSkewTolerance)
+	}
+
+	cfg.TeamLeaderboardIndex, err = getBool("GAME_TEAM_LEADERBOARD_INDEX", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.PlayerLeaderboardIndex, err = getBool("GAME_PLAYER_LEADERBOARD_INDEX", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.TeamPlaytimeCap, err = getFloat("GAME_TEAM_PLAYTIME_CAP", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TeamPlaytimeCap < 0 || cfg.TeamPlaytimeCap > maxTeamPlaytimeCap {
+		return nil, fmt.Errorf("GAME_TEAM_PLAYTIME_CAP must be between 0 and %d (got %v)", int64(maxTeamPlaytimeCap), cfg.TeamPlaytimeCap)
+	}
+
+	cfg.TeamPlaytimeDisabled, err = getBool("GAME_TEAM_PLAYTIME_DISABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.AllowNegativeTeamPlaytime, err = getBool("GAME_TEAM_PLAYTIME_ALLOW_NEGATIVE", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ResetCorruptTeamPlaytime, err = getBool("GAME_RESET_CORRUPT_TEAM_PLAYTIME", false)
+	if err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -232,14 +797,100 @@ func LoadPlayerServiceConfig() (*PlayerServiceConfig, error) {
 	if cfg.MongoDBDatabase == "" {
 		cfg.MongoDBDatabase = "test"
 	}
-	if cfg.MongoDBPlayersCollection == "" {
+	if _, set := os.LookupEnv("MONGODB_PLAYERS_COLLECTION"); !set {
 		cfg.MongoDBPlayersCollection = "players"
 	}
-	if cfg.MongoDBTeamCollection == "" {
+	if _, set := os.LookupEnv("MONGODB_TEAM_COLLECTION"); !set {
 		cfg.MongoDBTeamCollection = "teams"
 	}
+	if err := validateCollectionName("MONGODB_PLAYERS_COLLECTION", cfg.MongoDBPlayersCollection); err != nil {
+		return nil, err
+	}
+	if err := validateCollectionName("MONGODB_TEAM_COLLECTION", cfg.MongoDBTeamCollection); err != nil {
+		return nil, err
+	}
+	if cfg.MongoDBPlayersCollection == cfg.MongoDBTeamCollection {
+		return nil, fmt.Errorf("MONGODB_PLAYERS_COLLECTION and MONGODB_TEAM_COLLECTION must be different (both are '%s')", cfg.MongoDBPlayersCollection)
+	}
+
+	if defaultTeamsStr := os.Getenv("PLAYER_DEFAULT_TEAMS"); defaultTeamsStr != "" {
+		cfg.DefaultTeams = nil
+		for _, team := range strings.Split(defaultTeamsStr, ",") {
+			if team = strings.TrimSpace(team); team != "" {
+				cfg.DefaultTeams = append(cfg.DefaultTeams, team)
+			}
+		}
+		if len(cfg.DefaultTeams) == 0 {
+			return nil, fmt.Errorf("PLAYER_DEFAULT_TEAMS must list at least one team")
+		}
+	}
+
+	cfg.MongoDBConnectAttempts, err = getInt("MONGODB_CONNECT_ATTEMPTS", 10)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MongoDBConnectAttempts < 1 {
+		return nil, fmt.Errorf("MONGODB_CONNECT_ATTEMPTS must be at least 1 (got %d)", cfg.MongoDBConnectAttempts)
+	}
+	cfg.MongoDBConnectBackoff, err = getDuration("MONGODB_CONNECT_BACKOFF", time.Second)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg.UsernameFillerInterval = 30 * time.Second
+	cfg.UsernameFillerEnabled, err = getBool("MOJANG_FILLER_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MojangBreakerThreshold, err = getInt("MOJANG_BREAKER_THRESHOLD", 5)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MojangBreakerThreshold < 0 {
+		return nil, fmt.Errorf("MOJANG_BREAKER_THRESHOLD must not be negative (got %d)", cfg.MojangBreakerThreshold)
+	}
+	cfg.MojangBreakerCooldown, err = getDuration("MOJANG_BREAKER_COOLDOWN", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MojangBreakerThreshold > 0 && cfg.MojangBreakerCooldown <= 0 {
+		return nil, fmt.Errorf("MOJANG_BREAKER_COOLDOWN must be positive (got %v)", cfg.MojangBreakerCooldown)
+	}
+
+	cfg.SoftDeleteProfiles, err = getBool("PLAYER_SOFT_DELETE", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MajorityPlaytimeWrites, err = getBool("PLAYER_PLAYTIME_WRITE_MAJORITY", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.BalanceTeamsByOnline, err = getBool("PLAYER_BALANCE_BY_ONLINE", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TeamRatios, err = parseTeamRatios("PLAYER_TEAM_RATIOS")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.TeamCountInterval, err = getDuration("PLAYER_TEAM_COUNT_RECONCILE_INTERVAL", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TeamCountInterval < 0 {
+		return nil, fmt.Errorf("PLAYER_TEAM_COUNT_RECONCILE_INTERVAL must be non-negative (got %v)", cfg.TeamCountInterval)
+	}
+	cfg.GameServiceURL = os.Getenv("GAME_SERVICE_URL")
+	if cfg.GameServiceURL == "" {
+		cfg.GameServiceURL = "http://localhost:8082"
+	}
+	if err := validateServiceURL("GAME_SERVICE_URL", cfg.GameServiceURL); err != nil {
+		return nil, err
+	}
 
 	// Extract ServicePort from ListenAddr
 	cfg.ServicePort, err = extractPort(cfg.ListenAddr)
@@ -0,0 +1,29 @@
+// shared/logging/logging.go
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+const (
+	FormatText = "text" // Plain log lines (default)
+	FormatJSON = "json" // One JSON object per line, for log aggregation (ELK, Loki, ...)
+)
+
+// Setup configures the process-wide logger for the given format.
+// With FormatJSON, both slog calls and the standard library `log` package emit JSON lines,
+// so structured fields (e.g., service_id, player_uuid) can be indexed by the log aggregator.
+// FormatText leaves the default logger untouched.
+func Setup(format string) error {
+	switch format {
+	case FormatText, "":
+		return nil
+	case FormatJSON:
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format '%s' (expected '%s' or '%s')", format, FormatText, FormatJSON)
+	}
+}
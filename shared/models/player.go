@@ -20,10 +20,27 @@ type Player struct {
 	Username        string     `bson:"username" json:"username"`           // Real Minecraft username from Mojang
 	TeamUsername    string     `bson:"team_username" json:"team_username"` // Renamed field: e.g., "AQUA_CREEPER1", "PURPLE_AXOLOTL69"
 	Team            string     `bson:"team" json:"team"`                   // Assigned team (e.g., "AQUA_CREEPERS", "PURPLE_AXOLOTLS")
-	CurrentPlaytime float64    `bson:"current_playtime" json:"current_playtime"`
+	CurrentPlaytime Playtime   `bson:"current_playtime" json:"current_playtime"`
 	DeltaPlaytime   float64    `bson:"delta_playtime" json:"delta_playtime"`
 	Banned          bool       `bson:"banned" json:"banned"`
 	BanExpiresAt    *time.Time `bson:"ban_expires_at,omitempty" json:"ban_expires_at,omitempty"`
 	CreatedAt       *time.Time `bson:"created_at" json:"created_at"`
 	LastLoginAt     *time.Time `bson:"last_login_at" json:"last_login_at"`
+	DeletedAt       *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Set when the profile is soft-deleted (tombstoned)
+	// Part of CurrentPlaytime credited to previous teams; only playtime above it counts towards the current team.
+	TeamPlaytimeBaseline Playtime `bson:"team_playtime_baseline,omitempty" json:"team_playtime_baseline,omitempty"`
+	// Permanent factor applied to every playtime tick (e.g., VIP or founder perks). 0 (unset) means 1.0.
+	PlaytimeMultiplier float64 `bson:"playtime_multiplier,omitempty" json:"playtime_multiplier,omitempty"`
+}
+
+// MaxPlaytimeMultiplier is the largest PlaytimeMultiplier a profile may be given.
+const MaxPlaytimeMultiplier = 100.0
+
+// EffectivePlaytimeMultiplier returns the player's playtime multiplier, treating an unset (or
+// non-positive) value as 1.0.
+func (p *Player) EffectivePlaytimeMultiplier() float64 {
+	if p.PlaytimeMultiplier <= 0 {
+		return 1.0
+	}
+	return p.PlaytimeMultiplier
 }
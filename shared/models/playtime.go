@@ -0,0 +1,54 @@
+// shared/models/playtime.go
+package models
+
+import (
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Playtime is an amount of playtime in seconds. MongoDB stores it as whole milliseconds (int64), so
+// totals persisted over and over don't pick up float rounding error; JSON carries it as seconds.
+// Profiles persisted before the switch hold float seconds, which are still read as such.
+type Playtime float64
+
+// PlaytimeFromMillis returns the playtime of ms milliseconds.
+func PlaytimeFromMillis(ms int64) Playtime {
+	return Playtime(float64(ms) / 1000)
+}
+
+// Seconds returns the playtime in seconds.
+func (p Playtime) Seconds() float64 {
+	return float64(p)
+}
+
+// Millis returns the playtime in whole milliseconds, rounded to the nearest one.
+func (p Playtime) Millis() int64 {
+	return int64(math.Round(float64(p) * 1000))
+}
+
+// MarshalBSONValue stores the playtime as int64 milliseconds.
+func (p Playtime) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.TypeInt64, bsoncore.AppendInt64(nil, p.Millis()), nil
+}
+
+// UnmarshalBSONValue reads integer milliseconds, or float seconds written before playtime was stored
+// in milliseconds. Null reads as zero.
+func (p *Playtime) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	val := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeInt64, bson.TypeInt32:
+		ms, _ := val.AsInt64OK()
+		*p = PlaytimeFromMillis(ms)
+	case bson.TypeDouble:
+		*p = Playtime(val.Double())
+	case bson.TypeNull:
+		*p = 0
+	default:
+		return fmt.Errorf("cannot decode BSON %s as playtime", t)
+	}
+	return nil
+}
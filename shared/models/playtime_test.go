@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPlaytimeBSON(t *testing.T) {
+	in := Player{UUID: "u", CurrentPlaytime: 1234.5678, TeamPlaytimeBaseline: 0.0004}
+	data, err := bson.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	raw := bson.Raw(data)
+	stored := raw.Lookup("current_playtime")
+	if stored.Type != bson.TypeInt64 || stored.Int64() != 1234568 {
+		t.Errorf("current_playtime stored as %v %v, want int64 1234568", stored.Type, stored)
+	}
+	if _, err := raw.LookupErr("team_playtime_baseline"); err != nil {
+		t.Errorf("team_playtime_baseline missing: %v", err) // 0.0004 is not zero, even if it rounds to 0ms
+	}
+
+	var out Player
+	if err := bson.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.CurrentPlaytime != 1234.568 {
+		t.Errorf("CurrentPlaytime read back as %v, want 1234.568", out.CurrentPlaytime)
+	}
+}
+
+func TestPlaytimeReadsLegacySeconds(t *testing.T) {
+	data, err := bson.Marshal(bson.M{"_id": "u", "current_playtime": 12.25, "team_playtime_baseline": int32(500)})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var out Player
+	if err := bson.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.CurrentPlaytime != 12.25 {
+		t.Errorf("legacy float seconds read as %v, want 12.25", out.CurrentPlaytime)
+	}
+	if out.TeamPlaytimeBaseline != 0.5 {
+		t.Errorf("int32 milliseconds read as %v, want 0.5", out.TeamPlaytimeBaseline)
+	}
+
+	bad, _ := bson.Marshal(bson.M{"current_playtime": "12"})
+	if err := bson.Unmarshal(bad, &out); err == nil {
+		t.Error("a string playtime was accepted")
+	}
+}
+
+func TestPlaytimeMillisNoDrift(t *testing.T) {
+	// Persisting a total that grew by 50ms ticks stays exact in milliseconds.
+	var millis int64
+	var seconds float64
+	for range 100000 {
+		millis += Playtime(0.05).Millis()
+		seconds += 0.05
+	}
+	if millis != 5000000 {
+		t.Errorf("sum of 100000 ticks of 50ms = %dms, want 5000000", millis)
+	}
+	if got := PlaytimeFromMillis(millis).Seconds(); got != 5000 {
+		t.Errorf("PlaytimeFromMillis(%d) = %v s, want 5000", millis, got)
+	}
+	t.Logf("the same sum in float seconds is %.12f", seconds)
+}
@@ -0,0 +1,36 @@
+package models
+
+// Profile change operations, mirroring the MongoDB change stream operation types.
+const (
+	ProfileChangeInsert  = "insert"
+	ProfileChangeUpdate  = "update"
+	ProfileChangeReplace = "replace"
+	ProfileChangeDelete  = "delete"
+)
+
+// ProfileChange describes a change to a player profile, as published by the Player Service
+// on the profile changes channel.
+type ProfileChange struct {
+	UUID      string `json:"uuid"`
+	Operation string `json:"operation"`
+	// Top-level profile fields set or removed by an update; empty for other operations.
+	UpdatedFields []string `json:"updated_fields,omitempty"`
+	// Profile as it was after the change; nil for deletes or if it was already gone when looked up.
+	Profile *Player `json:"profile,omitempty"`
+}
+
+// Touches reports whether the change may have modified any of the given profile fields.
+// Inserts, replaces and deletes touch every field.
+func (pc ProfileChange) Touches(fields ...string) bool {
+	if pc.Operation != ProfileChangeUpdate {
+		return true
+	}
+	for _, updated := range pc.UpdatedFields {
+		for _, field := range fields {
+			if updated == field {
+				return true
+			}
+		}
+	}
+	return false
+}
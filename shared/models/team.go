@@ -6,7 +6,9 @@ import "time"
 type Team struct {
 	Name               string     `bson:"_id"` // Team name as _id (e.g., "AQUA_CREEPERS")
 	PlayerCount        int64      `bson:"player_count"`
-	TotalPlaytimeTicks float64    `bson:"total_playtime"` // Aggregate playtime for the team
+	UsernameSequence   int64      `bson:"username_seq"`      // Last number handed out in a team username; never decreases
+	TotalPlaytimeTicks float64    `bson:"total_playtime"`    // Aggregate playtime for the team
+	RetainedPlaytime   float64    `bson:"retained_playtime"` // Playtime earned by former members that stays with the team
 	CreatedAt          *time.Time `bson:"created_at"`
 	LastUpdated        *time.Time `bson:"last_updated"`
 }
@@ -18,8 +18,34 @@ type Client struct {
 	database    string
 }
 
+// maxConnectBackoff caps the delay between connection attempts in NewClient.
+const maxConnectBackoff = 30 * time.Second
+
 // NewClient establishes a connection to the MongoDB server and returns a new Client instance.
-func NewClient(connStr, databaseName string) (*Client, error) {
+// Connect and ping are attempted up to attempts times (at least once), waiting backoff before the
+// first retry and doubling it on each subsequent one, so the service can start before MongoDB is ready.
+func NewClient(connStr, databaseName string, attempts int, backoff time.Duration) (*Client, error) {
+	attempts = max(attempts, 1)
+	for attempt := 1; ; attempt++ {
+		client, err := connect(connStr)
+		if err == nil {
+			log.Println("Successfully connected to MongoDB!")
+			return &Client{
+				mongoClient: client,
+				database:    databaseName,
+			}, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		log.Printf("Warning: MongoDB connection attempt %d/%d failed: %v. Retrying in %v.", attempt, attempts, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// connect makes a single attempt to connect to MongoDB and ping the primary.
+func connect(connStr string) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -37,12 +63,7 @@ func NewClient(connStr, databaseName string) (*Client, error) {
 		}
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
-
-	log.Println("Successfully connected to MongoDB!")
-	return &Client{
-		mongoClient: client,
-		database:    databaseName,
-	}, nil
+	return client, nil
 }
 
 // Collection returns a mongo.Collection for the specified collection name.
@@ -11,6 +11,16 @@ const (
 	BannedKeyPrefix         = "banned:{%s}:"              // Key for player ban status: banned:{uuid}
 	PlayerTeamKeyPrefix     = "team:{%s}:"                // Key for player's assigned team: team:{uuid}
 	TeamTotalPlaytimePrefix = "team_total_playtime:{%s}:" // Key for total playtime of a team: team_total_playtime:{teamID}
+	SessionStartKeyPrefix   = "session_start:{%s}:"       // Key for the absolute start of a player's session: session_start:{uuid}
+	LastActivityKeyPrefix   = "last_activity:{%s}:"       // Key for a player's last activity, expiring after the idle timeout: last_activity:{uuid}
+	ProfileCacheKeyPrefix   = "profile_cache:{%s}:"       // Key for a cached player profile (JSON): profile_cache:{uuid}
+	PlaytimeAuditKeyPrefix  = "playtime_audit:{%s}:"      // Capped list of playtime changes, newest first: playtime_audit:{uuid}
+	MultiplierKeyPrefix     = "playtime_multiplier:{%s}:" // Factor applied to a player's playtime ticks, absent for 1.0: playtime_multiplier:{uuid}
+	PendingOfflineKeyPrefix = "pending_offline:{%s}:"     // Marks a session in its offline grace period; holds the scheduling instance's token: pending_offline:{uuid}
+	TeamLeaderboardKey      = "team_leaderboard"          // Sorted set of team total playtimes, kept when the leaderboard index is enabled
+	PlayerLeaderboardKey    = "player_leaderboard"        // Sorted set of total playtimes of players with a session, kept when the leaderboard index is enabled
+	OnlineCountKey          = "online_count"              // Counter of online keys, kept by the online store and periodically reconciled with a scan
+	TeamRenamesChannel      = "team_renames"              // Pub/sub channel game-service instances announce team renames on, so all of them drop cached assignments
 )
 
 // Define a custom error for when a Redis key is not found (can also be a constant)
@@ -0,0 +1,89 @@
+// shared/redis/playtime_unit.go
+package redis
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PlaytimeUnit is how player and team playtime totals are stored in Redis. Callers always deal in
+// seconds; the unit only decides what the keys hold. Every service sharing the cluster must use the
+// same unit, and switching it requires the totals to be rewritten (e.g., reloaded from MongoDB).
+type PlaytimeUnit int
+
+const (
+	// PlaytimeSeconds stores totals as float seconds, incremented with INCRBYFLOAT. Summing many
+	// fractional ticks slowly accumulates rounding error.
+	PlaytimeSeconds PlaytimeUnit = iota
+	// PlaytimeMillis stores totals as whole milliseconds, incremented with INCRBY, so sums are exact.
+	// Increments are rounded to the nearest millisecond.
+	PlaytimeMillis
+)
+
+// MaxExactTeamPlaytime is the largest team total (2^53) up to which float64 still represents every
+// whole tick exactly. Team playtime caps may not exceed it.
+const MaxExactTeamPlaytime = 1 << 53
+
+// ToMillis converts seconds to whole milliseconds, rounding to the nearest one.
+func ToMillis(seconds float64) int64 {
+	return int64(math.Round(seconds * 1000))
+}
+
+// Value returns what a playtime key holds for a total of seconds, e.g. to pass to SET.
+func (u PlaytimeUnit) Value(seconds float64) any {
+	if u == PlaytimeMillis {
+		return ToMillis(seconds)
+	}
+	return seconds
+}
+
+// Round returns seconds as this unit stores them, e.g. to keep leaderboards and logs in step with
+// what an increment actually added.
+func (u PlaytimeUnit) Round(seconds float64) float64 {
+	if u == PlaytimeMillis {
+		return float64(ToMillis(seconds)) / 1000
+	}
+	return seconds
+}
+
+// Seconds converts what a playtime key holds to seconds. It takes the result of a GET as is, so an
+// error such as redis.Nil is passed through; a non-numeric value returns a *strconv.NumError.
+func (u PlaytimeUnit) Seconds(val string, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if u == PlaytimeMillis {
+		millis, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return float64(millis) / 1000, nil
+	}
+	return strconv.ParseFloat(val, 64)
+}
+
+// Doer runs an arbitrary command; clients and pipelines both implement it.
+type Doer interface {
+	Do(ctx context.Context, args ...any) *redis.Cmd
+}
+
+// IncrBy increments the playtime total at key by seconds with c, which may be a pipeline. Read the
+// new total with IncrResult.
+func (u PlaytimeUnit) IncrBy(ctx context.Context, c Doer, key string, seconds float64) *redis.Cmd {
+	if u == PlaytimeMillis {
+		return c.Do(ctx, "incrby", key, ToMillis(seconds))
+	}
+	return c.Do(ctx, "incrbyfloat", key, seconds)
+}
+
+// IncrResult returns the new total, in seconds, of an increment made with IncrBy.
+func (u PlaytimeUnit) IncrResult(cmd *redis.Cmd) (float64, error) {
+	if u == PlaytimeMillis {
+		millis, err := cmd.Int64()
+		return float64(millis) / 1000, err
+	}
+	return cmd.Float64()
+}
@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestToMillis(t *testing.T) {
+	for _, tc := range []struct {
+		seconds float64
+		want    int64
+	}{
+		{0, 0},
+		{1, 1000},
+		{0.05, 50},
+		{0.0004, 0},
+		{0.0005, 1}, // Rounds half away from zero
+		{1.2345, 1235},
+		{-0.05, -50},
+		{86400.001, 86400001},
+	} {
+		if got := ToMillis(tc.seconds); got != tc.want {
+			t.Errorf("ToMillis(%v) = %d, want %d", tc.seconds, got, tc.want)
+		}
+	}
+}
+
+func TestPlaytimeUnitValueAndRound(t *testing.T) {
+	if got := PlaytimeSeconds.Value(1.2345); got != 1.2345 {
+		t.Errorf("PlaytimeSeconds.Value(1.2345) = %v, want 1.2345", got)
+	}
+	if got := PlaytimeMillis.Value(1.2345); got != int64(1235) {
+		t.Errorf("PlaytimeMillis.Value(1.2345) = %v (%T), want int64 1235", got, got)
+	}
+	if got := PlaytimeSeconds.Round(1.2345); got != 1.2345 {
+		t.Errorf("PlaytimeSeconds.Round(1.2345) = %v, want 1.2345", got)
+	}
+	if got := PlaytimeMillis.Round(1.2345); got != 1.235 {
+		t.Errorf("PlaytimeMillis.Round(1.2345) = %v, want 1.235", got)
+	}
+}
+
+func TestPlaytimeUnitSeconds(t *testing.T) {
+	if got, err := PlaytimeSeconds.Seconds("12.5", nil); err != nil || got != 12.5 {
+		t.Errorf("PlaytimeSeconds.Seconds(12.5) = %v, %v; want 12.5", got, err)
+	}
+	if got, err := PlaytimeMillis.Seconds("12500", nil); err != nil || got != 12.5 {
+		t.Errorf("PlaytimeMillis.Seconds(12500) = %v, %v; want 12.5", got, err)
+	}
+	if _, err := PlaytimeMillis.Seconds("12.5", nil); err == nil {
+		t.Error("PlaytimeMillis.Seconds accepted a float total")
+	}
+	var numErr *strconv.NumError
+	if _, err := PlaytimeSeconds.Seconds("abc", nil); !errors.As(err, &numErr) {
+		t.Errorf("PlaytimeSeconds.Seconds(abc) error = %v, want a *strconv.NumError", err)
+	}
+	if _, err := PlaytimeMillis.Seconds("", redis.Nil); err != redis.Nil {
+		t.Errorf("Seconds passed through %v, want redis.Nil", err)
+	}
+}
+
+func TestPlaytimeUnitIncrBy(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		unit   PlaytimeUnit
+		stored string
+	}{
+		{PlaytimeSeconds, "0.3"},
+		{PlaytimeMillis, "300"},
+	} {
+		key := "playtime:" + strconv.Itoa(int(tc.unit))
+		var total float64
+		for range 3 {
+			var err error
+			total, err = tc.unit.IncrResult(tc.unit.IncrBy(ctx, client, key, 0.1))
+			if err != nil {
+				t.Fatalf("unit %d: IncrBy: %v", tc.unit, err)
+			}
+		}
+		if total < 0.2999 || total > 0.3001 {
+			t.Errorf("unit %d: total after three increments = %v, want 0.3", tc.unit, total)
+		}
+		stored, _ := mr.Get(key)
+		if got, _ := strconv.ParseFloat(stored, 64); got != mustParse(t, tc.stored) {
+			t.Errorf("unit %d: key holds %q, want %q", tc.unit, stored, tc.stored)
+		}
+		if seconds, err := tc.unit.Seconds(client.Get(ctx, key).Result()); err != nil || seconds != total {
+			t.Errorf("unit %d: Seconds of the stored total = %v, %v; want %v", tc.unit, seconds, err, total)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) float64 {
+	t.Helper()
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestPlaytimeMillisIncrementsStayExact(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	const ticks = 10000
+	pipe := client.Pipeline()
+	for range ticks {
+		PlaytimeMillis.IncrBy(ctx, pipe, "millis", 0.1)
+		PlaytimeSeconds.IncrBy(ctx, pipe, "seconds", 0.1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	millis, err := PlaytimeMillis.Seconds(client.Get(ctx, "millis").Result())
+	if err != nil || millis != 1000 {
+		t.Errorf("%d increments of 0.1s in milliseconds = %v, %v; want exactly 1000", ticks, millis, err)
+	}
+	seconds, _ := PlaytimeSeconds.Seconds(client.Get(ctx, "seconds").Result())
+	t.Logf("the same increments in float seconds sum to %.12f", seconds)
+}
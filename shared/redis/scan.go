@@ -0,0 +1,102 @@
+// shared/redis/scan.go
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NodeScanError is a failure to scan, or to process the keys of, one master node. ScanAllMasters
+// joins one per failed node, so errors.As finding one means the other nodes were scanned completely.
+type NodeScanError struct {
+	Addr string
+	Err  error
+}
+
+func (e *NodeScanError) Error() string {
+	return fmt.Sprintf("node %s: %v", e.Addr, e.Err)
+}
+
+func (e *NodeScanError) Unwrap() error {
+	return e.Err
+}
+
+// ScanAllMasters runs SCAN with pattern on every master node of the cluster, count keys per call
+// (0 for the Redis default), and passes each page of keys to fn together with the node's client,
+// so follow-up reads can be pipelined to that node. Nodes are scanned concurrently, so fn must be
+// safe for concurrent use. A node whose SCAN or fn fails stops there while the others carry on;
+// the returned error joins a *NodeScanError per failed node.
+func ScanAllMasters(ctx context.Context, client *redis.ClusterClient, pattern string, count int64, fn func(ctx context.Context, node *redis.Client, keys []string) error) error {
+	return ScanAllMastersLimited(ctx, client, pattern, count, nil, fn)
+}
+
+// ScanAllMastersLimited is ScanAllMasters with at most cap(slots) nodes scanned at a time. Each node
+// holds a slot for its whole scan; a nil slots channel means no limit.
+func ScanAllMastersLimited(ctx context.Context, client *redis.ClusterClient, pattern string, count int64, slots chan struct{}, fn func(ctx context.Context, node *redis.Client, keys []string) error) error {
+	var mu sync.Mutex // Protects nodeErrs across nodes
+	var nodeErrs []error
+
+	err := client.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		if node == nil {
+			return nil
+		}
+		if err := scanNode(ctx, node, pattern, count, slots, fn); err != nil {
+			mu.Lock()
+			nodeErrs = append(nodeErrs, &NodeScanError{Addr: node.Options().Addr, Err: err})
+			mu.Unlock()
+		}
+		return nil // A failing node must not cancel the scans of the others
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan Redis masters for '%s': %w", pattern, err)
+	}
+	return errors.Join(nodeErrs...)
+}
+
+// scanNode scans a single node page by page, holding a slot for the duration if slots is set.
+func scanNode(ctx context.Context, node *redis.Client, pattern string, count int64, slots chan struct{}, fn func(ctx context.Context, node *redis.Client, keys []string) error) error {
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for a scan slot: %w", ctx.Err())
+		}
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := node.Scan(ctx, cursor, pattern, count).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(ctx, node, keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// HashTagValue returns what is between the braces of a key's hash tag, e.g. the UUID of
+// "playtime:{uuid}:". ok is false if the key has no non-empty hash tag.
+func HashTagValue(key string) (value string, ok bool) {
+	start := strings.Index(key, "{")
+	if start == -1 {
+		return "", false
+	}
+	end := strings.Index(key[start+1:], "}")
+	if end <= 0 {
+		return "", false
+	}
+	return key[start+1 : start+1+end], true
+}
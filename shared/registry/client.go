@@ -3,6 +3,7 @@ package registry
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -12,6 +13,9 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrNoLoadedInstance is returned by GetLeastLoadedInstance when no active instance reports its load.
+var ErrNoLoadedInstance = errors.New("no active instance reports its load")
+
 // GetActiveServices is now part of a separate client to read the registry,
 // making the ServiceRegistrar purely for self-registration.
 // This allows other services (like Gate-Proxy) to query the registry.
@@ -55,3 +59,30 @@ func (rc *RegistryClient) GetActiveServices(ctx context.Context, serviceType str
 	}
 	return activeServices, nil
 }
+
+// GetLeastLoadedInstance returns the active instance of serviceType with the lowest load reported in
+// its heartbeat (see ServiceRegistrar.SetLoadReporter). Instances that report no load are skipped; ties
+// go to the lowest ServiceID so callers agree on the choice. Returns ErrNoLoadedInstance if no active
+// instance reports a load.
+func (rc *RegistryClient) GetLeastLoadedInstance(ctx context.Context, serviceType string) (ServiceInfo, error) {
+	services, err := rc.GetActiveServices(ctx, serviceType)
+	if err != nil {
+		return ServiceInfo{}, err
+	}
+
+	var best ServiceInfo
+	bestLoad, found := 0.0, false
+	for _, info := range services {
+		load, ok := info.Load()
+		if !ok {
+			continue
+		}
+		if !found || load < bestLoad || (load == bestLoad && info.ServiceID < best.ServiceID) {
+			best, bestLoad, found = info, load, true
+		}
+	}
+	if !found {
+		return ServiceInfo{}, fmt.Errorf("%w: %s", ErrNoLoadedInstance, serviceType)
+	}
+	return best, nil
+}
@@ -1,6 +1,8 @@
 // shared/registry/constants.go
 package registry
 
+import "time"
+
 const (
 	// RedisRegistryHashPrefix is the prefix used for Redis hash keys that store
 	// service registration data. The full key format will be:
@@ -10,5 +12,18 @@ const (
 	ServiceTypeProxy        = "proxy:"
 	ServiceTypeMinestom     = "minestom:"
 
+	// CleanupTaskKey is the assignment key a service can use with SetCleanupLeader to decide which of
+	// its instances cleans stale registry entries.
+	CleanupTaskKey = "registry_cleanup_task"
+
 	// Add any other common registry-related constants here
 )
+
+// heartbeatReportTimeout bounds each heartbeat metadata reporter, see SetHeartbeatMetadata.
+const heartbeatReportTimeout = 250 * time.Millisecond
+
+// Backoff bounds for retrying the initial registration at startup.
+const (
+	initialRegistrationBackoff = 500 * time.Millisecond
+	maxRegistrationBackoff     = 5 * time.Second
+)
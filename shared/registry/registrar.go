@@ -4,10 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"runtime/debug"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/shared/config"
+	"github.com/Ftotnem/GO-SERVICES/shared/version"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
@@ -18,51 +23,95 @@ type ServiceRegistrar struct {
 	serviceType string               // <--- Now passed explicitly
 	cfg         *config.CommonConfig // <--- Use CommonConfig directly
 	serviceID   string
+	metadata    map[string]string // Published with every registration
 	stopChan    chan struct{}
 	doneChan    chan struct{}
+
+	// staleCounts tracks consecutive cleanup passes each instance has been seen stale for, and
+	// cleanupLeading whether the previous pass ran. Only accessed from the cleanup goroutine.
+	staleCounts    map[string]int
+	cleanupLeading bool
+
+	cleanupMu     sync.Mutex
+	cleanupLeader func() (bool, error) // Optional; cleanup passes only run while it reports true, see SetCleanupLeader
+
+	reportersMu sync.Mutex
+	reporters   map[string]func(context.Context) (string, error) // Metadata key -> value read on each heartbeat, see SetHeartbeatMetadata
 }
 
 // NewServiceRegistrar creates a new ServiceRegistrar.
 // It now takes the serviceType and a reference to the common config.
-func NewServiceRegistrar(redisClient *redis.ClusterClient, serviceType string, config *config.CommonConfig) *ServiceRegistrar {
+// Registered metadata starts with the build version, overlaid by config.ServiceMetadata and then
+// by the metadata argument (e.g., a shard index); metadata may be nil.
+func NewServiceRegistrar(redisClient *redis.ClusterClient, serviceType string, config *config.CommonConfig, metadata map[string]string) *ServiceRegistrar {
 	// Generate a unique ServiceID if not provided
 	serviceID := fmt.Sprintf("%s-%s", serviceType, uuid.New().String())
 
+	merged := map[string]string{"version": buildVersion()}
+	maps.Copy(merged, config.ServiceMetadata)
+	maps.Copy(merged, metadata)
+
 	return &ServiceRegistrar{
 		redisClient: redisClient,
 		serviceType: serviceType,
 		cfg:         config, // Store the pointer to common config
 		serviceID:   serviceID,
+		metadata:    merged,
 		stopChan:    make(chan struct{}),
 		doneChan:    make(chan struct{}),
+		staleCounts: make(map[string]int),
 	}
 }
 
-// Start begins the service registration and heartbeating process in a goroutine.
-func (sr *ServiceRegistrar) Start() {
-	log.Printf("Starting service registrar for %s (ID: %s) at %s:%d",
-		sr.serviceType, sr.serviceID, sr.cfg.ServiceIP, sr.cfg.ServicePort) // Use commonConfig
+// Start registers the service, retrying with backoff until the first registration succeeds or
+// RegistrationTimeout elapses, then continues heartbeating in a goroutine. It returns an error if
+// the service could not be registered, so an instance never runs invisible to the cluster.
+func (sr *ServiceRegistrar) Start() error {
+	sr.logger().Info("Starting service registrar",
+		"ip", sr.cfg.ServiceIP, "port", sr.cfg.ServicePort) // Use commonConfig
+
+	if err := sr.registerWithRetry(sr.cfg.RegistrationTimeout); err != nil {
+		return err
+	}
 
 	go sr.run()
+	return nil
+}
+
+// registerWithRetry attempts the initial registration until it succeeds or the timeout elapses,
+// doubling the delay between attempts up to maxRegistrationBackoff.
+func (sr *ServiceRegistrar) registerWithRetry(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := initialRegistrationBackoff
+	for attempt := 1; ; attempt++ {
+		err := sr.registerService()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("failed to register service after %d attempts: %w", attempt, err)
+		}
+		sr.logger().Warn("Initial service registration failed, retrying", "attempt", attempt, "backoff", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxRegistrationBackoff)
+	}
 }
 
 // Stop signals the registrar to stop its operations and waits for it to finish.
 func (sr *ServiceRegistrar) Stop() {
-	log.Printf("Signaling service registrar for %s (ID: %s) to stop...", sr.serviceType, sr.serviceID)
+	sr.logger().Info("Signaling service registrar to stop")
 	close(sr.stopChan)
 	<-sr.doneChan
-	log.Printf("Service registrar for %s (ID: %s) stopped successfully.", sr.serviceType, sr.serviceID)
+	sr.logger().Info("Service registrar stopped successfully")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	hashKey := fmt.Sprintf("%s%s", RedisRegistryHashPrefix, sr.serviceType)
 	if _, err := sr.redisClient.HDel(ctx, hashKey, sr.serviceID).Result(); err != nil {
-		log.Printf("ERROR: Failed to remove service %s (ID: %s) from Redis registry on shutdown: %v",
-			sr.serviceType, sr.serviceID, err)
+		sr.logger().Error("Failed to remove service from Redis registry on shutdown", "error", err)
 	} else {
-		log.Printf("INFO: Service %s (ID: %s) removed from Redis registry on shutdown.",
-			sr.serviceType, sr.serviceID)
+		sr.logger().Info("Service removed from Redis registry on shutdown")
 	}
 }
 
@@ -73,16 +122,18 @@ func (sr *ServiceRegistrar) run() {
 	ticker := time.NewTicker(sr.cfg.HeartbeatInterval) // <--- Use commonConfig
 	defer ticker.Stop()
 
-	sr.registerService()
-
-	if sr.cfg.RegistryCleanupInterval > 0 { // <--- Use commonConfig
+	if sr.cfg.RegistryCleanupEnabled && sr.cfg.RegistryCleanupInterval > 0 { // <--- Use commonConfig
 		sr.startCleanupLoop()
+	} else {
+		sr.logger().Info("Registry cleanup disabled on this instance")
 	}
 
 	for {
 		select {
 		case <-ticker.C:
-			sr.registerService()
+			if err := sr.registerService(); err != nil {
+				sr.logger().Error("Failed to register/heartbeat service to Redis", "error", err)
+			}
 		case <-sr.stopChan:
 			return
 		}
@@ -90,7 +141,7 @@ func (sr *ServiceRegistrar) run() {
 }
 
 // registerService performs the actual registration/heartbeat in Redis.
-func (sr *ServiceRegistrar) registerService() {
+func (sr *ServiceRegistrar) registerService() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -100,23 +151,94 @@ func (sr *ServiceRegistrar) registerService() {
 		IP:          sr.cfg.ServiceIP,   // <--- Use commonConfig
 		Port:        sr.cfg.ServicePort, // <--- Use commonConfig
 		LastSeen:    time.Now().UnixMilli(),
-		Metadata:    map[string]string{"version": "1.0"}, // Still add metadata if desired
+		Metadata:    sr.heartbeatMetadata(ctx),
 	}
 
 	infoJSON, err := json.Marshal(serviceInfo)
 	if err != nil {
-		log.Printf("ERROR: Failed to marshal ServiceInfo for %s (ID: %s): %v",
-			sr.serviceType, sr.serviceID, err)
-		return
+		return fmt.Errorf("failed to marshal ServiceInfo: %w", err)
 	}
 
 	hashKey := fmt.Sprintf("%s%s", RedisRegistryHashPrefix, sr.serviceType)
 	if _, err := sr.redisClient.HSet(ctx, hashKey, sr.serviceID, infoJSON).Result(); err != nil {
-		log.Printf("ERROR: Failed to register/heartbeat service %s (ID: %s) to Redis: %v",
-			sr.serviceType, sr.serviceID, err)
-	} else {
-		log.Printf("INFO: Service %s (ID: %s) heartbeated successfully.", sr.serviceType, sr.serviceID)
+		return fmt.Errorf("failed to write service %s to registry: %w", sr.serviceID, err)
+	}
+	sr.logger().Info("Service heartbeated successfully")
+	return nil
+}
+
+// SetHeartbeatMetadata makes every heartbeat publish the value report returns under key, overriding
+// any registered metadata of that key. report gets heartbeatReportTimeout so a slow metric can't hold
+// up the heartbeat; if it fails, that heartbeat goes out without the key. It may be called after Start.
+func (sr *ServiceRegistrar) SetHeartbeatMetadata(key string, report func(ctx context.Context) (string, error)) {
+	sr.reportersMu.Lock()
+	defer sr.reportersMu.Unlock()
+	if sr.reporters == nil {
+		sr.reporters = make(map[string]func(context.Context) (string, error))
+	}
+	sr.reporters[key] = report
+}
+
+// SetLoadReporter makes every heartbeat publish the value report returns as the instance's load
+// under LoadMetadataKey, so RegistryClient.GetLeastLoadedInstance can pick the least busy instance.
+func (sr *ServiceRegistrar) SetLoadReporter(report func() (float64, error)) {
+	sr.SetHeartbeatMetadata(LoadMetadataKey, func(context.Context) (string, error) {
+		load, err := report()
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(load, 'f', -1, 64), nil
+	})
+}
+
+// heartbeatMetadata returns the metadata to publish with the next heartbeat: the registered metadata
+// plus the current value of every heartbeat metadata reporter.
+func (sr *ServiceRegistrar) heartbeatMetadata(ctx context.Context) map[string]string {
+	sr.reportersMu.Lock()
+	reporters := maps.Clone(sr.reporters)
+	sr.reportersMu.Unlock()
+	if len(reporters) == 0 {
+		return sr.metadata
+	}
+
+	metadata := maps.Clone(sr.metadata)
+	for key, report := range reporters {
+		reportCtx, cancel := context.WithTimeout(ctx, heartbeatReportTimeout)
+		value, err := report(reportCtx)
+		cancel()
+		if err != nil {
+			sr.logger().Warn("Failed to read heartbeat metadata, publishing without it", "key", key, "error", err)
+			delete(metadata, key)
+			continue
+		}
+		metadata[key] = value
 	}
+	return metadata
+}
+
+// SetCleanupLeader restricts the registry cleanup to passes where isLeader reports true, e.g. a check
+// of the service's assignment ring, so instances don't all scan and delete the same stale entries.
+// It may be called after Start, since the ring usually depends on the registrar.
+func (sr *ServiceRegistrar) SetCleanupLeader(isLeader func() (bool, error)) {
+	sr.cleanupMu.Lock()
+	defer sr.cleanupMu.Unlock()
+	sr.cleanupLeader = isLeader
+}
+
+// isCleanupLeader reports whether this instance should run the current cleanup pass.
+func (sr *ServiceRegistrar) isCleanupLeader() bool {
+	sr.cleanupMu.Lock()
+	isLeader := sr.cleanupLeader
+	sr.cleanupMu.Unlock()
+	if isLeader == nil {
+		return true
+	}
+	ok, err := isLeader()
+	if err != nil {
+		sr.logger().Warn("Cleanup: Failed to check leadership, skipping this pass", "error", err)
+		return false
+	}
+	return ok
 }
 
 // startCleanupLoop starts a background goroutine to periodically clean up stale service entries.
@@ -124,14 +246,14 @@ func (sr *ServiceRegistrar) startCleanupLoop() {
 	go func() {
 		cleanupTicker := time.NewTicker(sr.cfg.RegistryCleanupInterval) // <--- Use commonConfig
 		defer cleanupTicker.Stop()
-		log.Printf("Starting registry cleanup loop for %s, checking every %v", sr.serviceType, sr.cfg.RegistryCleanupInterval)
+		sr.logger().Info("Starting registry cleanup loop", "interval", sr.cfg.RegistryCleanupInterval.String())
 
 		for {
 			select {
 			case <-cleanupTicker.C:
 				sr.performCleanup()
 			case <-sr.stopChan:
-				log.Println("Registry cleanup loop stopping.")
+				sr.logger().Info("Registry cleanup loop stopping")
 				return
 			}
 		}
@@ -139,40 +261,89 @@ func (sr *ServiceRegistrar) startCleanupLoop() {
 }
 
 // performCleanup iterates through registered services and removes stale ones.
+// An instance is only removed once it has been stale for RegistryCleanupMisses consecutive passes,
+// so a single late heartbeat (e.g., a GC pause) doesn't remove a healthy instance and churn the ring.
 func (sr *ServiceRegistrar) performCleanup() {
+	leading := sr.isCleanupLeader()
+	if leading != sr.cleanupLeading {
+		sr.cleanupLeading = leading
+		if leading {
+			sr.logger().Info("Cleanup: This instance now cleans the registry")
+		} else {
+			sr.logger().Info("Cleanup: Registry cleanup handed over to another instance")
+		}
+	}
+	if !leading {
+		clear(sr.staleCounts) // A later leadership starts counting misses over
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	hashKey := fmt.Sprintf("%s%s", RedisRegistryHashPrefix, sr.serviceType)
 	results, err := sr.redisClient.HGetAll(ctx, hashKey).Result()
 	if err != nil {
-		log.Printf("ERROR: Cleanup failed to get all services for type %s: %v", sr.serviceType, err)
+		sr.logger().Error("Cleanup failed to get all services", "error", err)
 		return
 	}
 
+	// Forget instances that are no longer registered (deregistered or removed by another instance).
+	for instanceID := range sr.staleCounts {
+		if _, ok := results[instanceID]; !ok {
+			delete(sr.staleCounts, instanceID)
+		}
+	}
+
 	currentTime := time.Now()
 	for instanceID, infoJSON := range results {
 		var info ServiceInfo
 		if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
-			log.Printf("WARNING: Cleanup: Failed to unmarshal ServiceInfo for ID %s (type %s): %v. Deleting.", instanceID, sr.serviceType, err)
+			sr.logger().Warn("Cleanup: Failed to unmarshal ServiceInfo, deleting", "instance_id", instanceID, "error", err)
 			if _, delErr := sr.redisClient.HDel(ctx, hashKey, instanceID).Result(); delErr != nil {
-				log.Printf("ERROR: Cleanup: Failed to delete corrupt entry %s for type %s: %v", instanceID, sr.serviceType, delErr)
+				sr.logger().Error("Cleanup: Failed to delete corrupt entry", "instance_id", instanceID, "error", delErr)
 			}
 			continue
 		}
 		lastSeenTime := time.UnixMilli(info.LastSeen)
 
-		if currentTime.Sub(lastSeenTime) > sr.cfg.HeartbeatTTL { // <--- Use commonConfig
-			if _, delErr := sr.redisClient.HDel(ctx, hashKey, instanceID).Result(); delErr != nil {
-				log.Printf("ERROR: Cleanup: Failed to delete stale service %s (ID: %s) for type %s: %v",
-					info.ServiceType, instanceID, sr.serviceType, delErr)
-			} else {
-				log.Printf("INFO: Cleanup: Removed stale service %s (ID: %s) from registry.", info.ServiceType, instanceID)
-			}
+		if currentTime.Sub(lastSeenTime) <= sr.cfg.HeartbeatTTL { // <--- Use commonConfig
+			delete(sr.staleCounts, instanceID)
+			continue
+		}
+
+		sr.staleCounts[instanceID]++
+		if misses := sr.staleCounts[instanceID]; misses < sr.cfg.RegistryCleanupMisses {
+			sr.logger().Warn("Cleanup: Service missed heartbeat, keeping it for now", "instance_id", instanceID, "last_seen", lastSeenTime, "misses", misses)
+			continue
+		}
+
+		if _, delErr := sr.redisClient.HDel(ctx, hashKey, instanceID).Result(); delErr != nil {
+			sr.logger().Error("Cleanup: Failed to delete stale service", "instance_id", instanceID, "error", delErr)
+		} else {
+			delete(sr.staleCounts, instanceID)
+			sr.logger().Info("Cleanup: Removed stale service from registry", "instance_id", instanceID, "last_seen", lastSeenTime)
 		}
 	}
 }
 
+// buildVersion returns the version injected via ldflags, else the main module version from the build
+// info, or "1.0" if neither is available (e.g., binaries built from a working tree report "(devel)").
+func buildVersion() string {
+	if version.Version != "dev" {
+		return version.Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "1.0"
+}
+
+// logger returns a logger carrying this instance's service_type and service_id fields.
+func (sr *ServiceRegistrar) logger() *slog.Logger {
+	return slog.With("service_type", sr.serviceType, "service_id", sr.serviceID)
+}
+
 // GetServiceID returns the unique ID assigned to this service instance.
 func (sr *ServiceRegistrar) GetServiceID() string {
 	return sr.serviceID
@@ -1,6 +1,16 @@
 // shared/registry/types.go
 package registry
 
+import "strconv"
+
+// LoadMetadataKey is the metadata key an instance reports its current load under, see
+// ServiceRegistrar.SetLoadReporter. What the load counts is up to the service (e.g., online players).
+const LoadMetadataKey = "load"
+
+// OnlinePlayersMetadataKey is the metadata key game-service instances report the cluster-wide number
+// of online players under, as read from the online counter.
+const OnlinePlayersMetadataKey = "online_players"
+
 // ServiceInfo represents the details of a registered service instance.
 // This information is stored in Redis and used for service discovery.
 type ServiceInfo struct {
@@ -11,3 +21,17 @@ type ServiceInfo struct {
 	LastSeen    int64             `json:"last_seen"`
 	Metadata    map[string]string `json:"metadata,omitempty"` // Optional: additional key-value pairs (e.g., "version", "region")
 }
+
+// Load returns the load the instance reported in its last heartbeat, and false if it reported none
+// or the value can't be parsed.
+func (si ServiceInfo) Load() (float64, bool) {
+	raw, ok := si.Metadata[LoadMetadataKey]
+	if !ok {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false
+	}
+	return load, true
+}
@@ -3,7 +3,11 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/shared/api"
 )
@@ -11,7 +15,8 @@ import (
 // GameServiceClient is a client for the Game Service.
 // It interacts with the Game Service's HTTP API.
 type GameServiceClient struct {
-	apiClient *api.Client
+	apiClient   *api.Client
+	messagePack bool // See SetMessagePack
 }
 
 // NewGameClient creates a new Game Service client.
@@ -22,6 +27,53 @@ func NewGameClient(baseURL string) *GameServiceClient {
 	}
 }
 
+// SetAdminToken sets the token sent with every request, required by the /game/admin endpoints
+// when the Game Service has an admin token configured.
+func (c *GameServiceClient) SetAdminToken(token string) {
+	c.apiClient.SetAuthToken(token)
+}
+
+// SetMessagePack makes the hot read endpoints (playtimes and online status) ask for MessagePack
+// responses, which are smaller and cheaper to decode than JSON. Game Services without MessagePack
+// support keep answering with JSON, so this is safe to enable before every instance is upgraded.
+func (c *GameServiceClient) SetMessagePack(enabled bool) {
+	c.messagePack = enabled
+}
+
+// getHot performs a GET for a hot endpoint, asking for MessagePack if enabled.
+func (c *GameServiceClient) getHot(ctx context.Context, path string, result interface{}) error {
+	if c.messagePack {
+		return c.apiClient.GetMessagePack(ctx, path, result)
+	}
+	return c.apiClient.Get(ctx, path, result)
+}
+
+// ErrPlayerBanned is returned (as a *PlayerBannedError) by PlayerOnline when the player is banned.
+var ErrPlayerBanned = errors.New("player is banned")
+
+// ErrPlayerAlreadyOnline is returned by PlayerOnline when the player is already online and the
+// Game Service refuses to re-initialize their session.
+var ErrPlayerAlreadyOnline = errors.New("player is already online")
+
+// PlayerBannedError describes the ban that stopped a player from going online, so callers such as
+// the proxy can show the player the reason and expiry.
+type PlayerBannedError struct {
+	UUID      string
+	Reason    string
+	ExpiresAt *time.Time // nil for a permanent ban
+}
+
+func (e *PlayerBannedError) Error() string {
+	if e.ExpiresAt == nil {
+		return fmt.Sprintf("player %s is permanently banned: %s", e.UUID, e.Reason)
+	}
+	return fmt.Sprintf("player %s is banned until %s: %s", e.UUID, e.ExpiresAt.Format(time.RFC3339), e.Reason)
+}
+
+func (e *PlayerBannedError) Unwrap() error {
+	return ErrPlayerBanned
+}
+
 // --- Request/Response DTOs for Game Service Communication ---
 // These mirror the DTOs defined in your game/api/handlers.go for consistency.
 
@@ -30,16 +82,50 @@ type PlayerUUIDRequest struct {
 	UUID string `json:"uuid"`
 }
 
+// PlayerOnlineResponse is the response to a successful POST /game/player/online.
+type PlayerOnlineResponse struct {
+	Message       string `json:"message"`
+	UUID          string `json:"uuid"`
+	AlreadyOnline bool   `json:"alreadyOnline"`
+}
+
+// RefreshOnlineBatchItem is the outcome for a single player in a batch heartbeat.
+type RefreshOnlineBatchItem struct {
+	UUID    string `json:"uuid"`
+	Present bool   `json:"present"` // False if the session had expired; the player must go online again
+	Error   string `json:"error,omitempty"`
+}
+
+// RefreshOnlineBatchResponse is the structure for the response of a batch heartbeat.
+type RefreshOnlineBatchResponse struct {
+	Refreshed int                      `json:"refreshed"`
+	Missing   int                      `json:"missing"`
+	Results   []RefreshOnlineBatchItem `json:"results"`
+}
+
+// AssignTeamRequest is the structure for the request body for assigning a player's team.
+type AssignTeamRequest struct {
+	Team string `json:"team"`
+}
+
 // BanRequest is the structure for the request body for banning.
 type BanRequest struct {
 	UUID        string `json:"uuid"`
 	DurationSec int64  `json:"duration_seconds"` // Duration in seconds. 0 for permanent.
 	Reason      string `json:"reason,omitempty"`
+	Force       bool   `json:"force,omitempty"` // If true, replaces an existing ban even if it lasts longer
+}
+
+// ModifyBanRequest is the structure for the request body for modifying an active ban.
+type ModifyBanRequest struct {
+	UUID        string `json:"uuid"`
+	DurationSec int64  `json:"duration_seconds"` // New duration in seconds, counted from now. 0 for permanent.
 }
 
 // PlaytimeResponse is the structure for the JSON response for playtime requests.
 type PlaytimeResponse struct {
-	Playtime float64 `json:"playtime"`
+	Playtime  float64 `json:"playtime"`
+	InSession bool    `json:"inSession,omitempty"` // Only set by GetPlayerLivePlaytime
 }
 
 // DeltaPlaytimeResponse is the structure for the JSON response for delta playtime requests.
@@ -53,12 +139,58 @@ type TeamTotalPlaytimeResponse struct {
 	TotalPlaytime float64 `json:"totalPlaytime"`
 }
 
+// TeamPlaytimeBatchRequest is the structure for the request body for fetching several teams' playtimes.
+type TeamPlaytimeBatchRequest struct {
+	TeamIDs []string `json:"teamIds"`
+}
+
+// TeamPlaytimeBatchResponse defines the structure for the JSON response for several teams' total playtimes.
+type TeamPlaytimeBatchResponse struct {
+	Teams map[string]float64 `json:"teams"` // Team ID -> total playtime; 0 for teams with no recorded playtime
+}
+
 // PlayerOnlineStatusResponse defines the structure for the JSON response for player online status.
 type PlayerOnlineStatusResponse struct {
 	UUID     string `json:"uuid"`
 	IsOnline bool   `json:"isOnline"`
 }
 
+// PlayerExistsResponse defines the structure for the JSON response for a player data presence check.
+type PlayerExistsResponse struct {
+	UUID   string `json:"uuid"`
+	Exists bool   `json:"exists"` // True if any session or playtime data for the player is in Redis
+}
+
+// PlayerTeamResponse defines the structure for the JSON response for a player's current team.
+type PlayerTeamResponse struct {
+	UUID string `json:"uuid"`
+	Team string `json:"team"`
+}
+
+// SessionStartResponse defines the structure for the JSON response for a player's session start.
+type SessionStartResponse struct {
+	UUID         string `json:"uuid"`
+	SessionStart int64  `json:"sessionStart"` // Unix timestamp of when the current session began
+}
+
+// OnlineStatsResponse defines the structure for the JSON response for aggregate online stats.
+type OnlineStatsResponse struct {
+	TotalOnline int            `json:"totalOnline"`
+	Teams       map[string]int `json:"teams"`      // Team ID -> online player count
+	Unassigned  int            `json:"unassigned"` // Online players without a team
+	ComputedAt  int64          `json:"computedAt"` // Unix timestamp of the (possibly cached) snapshot
+}
+
+// PlayerBannedResponse is the body of the 403 returned when a banned player tries to go online.
+type PlayerBannedResponse struct {
+	Message     string `json:"message"`
+	ErrorCode   string `json:"error_code"`
+	UUID        string `json:"uuid"`
+	Reason      string `json:"reason,omitempty"`
+	ExpiresAt   int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 for permanent
+	IsPermanent bool   `json:"is_permanent"`
+}
+
 // BanResponse is the structure for the JSON response after a ban operation.
 type BanResponse struct {
 	Message     string `json:"message"`
@@ -67,16 +199,104 @@ type BanResponse struct {
 	IsPermanent bool   `json:"is_permanent"`
 }
 
+// ModifyBanResponse is the structure for the JSON response after modifying a ban.
+type ModifyBanResponse struct {
+	Message        string `json:"message"`
+	UUID           string `json:"uuid"`
+	ExpiresAt      int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 for permanent
+	IsPermanent    bool   `json:"is_permanent"`
+	ProfileUpdated bool   `json:"profile_updated"`
+	ProfileError   string `json:"profile_error,omitempty"`
+}
+
+// ResetPlaytimeRequest is the structure for the request body for resetting a player's playtime.
+type ResetPlaytimeRequest struct {
+	AdjustTeamTotal bool `json:"adjust_team_total,omitempty"` // If true, the removed playtime is subtracted from the team total right away
+}
+
+// ResetPlaytimeResponse is the structure for the JSON response after resetting a player's playtime.
+type ResetPlaytimeResponse struct {
+	Message         string  `json:"message"`
+	UUID            string  `json:"uuid"`
+	RemovedPlaytime float64 `json:"removed_playtime"`
+	Team            string  `json:"team,omitempty"`
+	TeamAdjusted    bool    `json:"team_adjusted"`
+	ProfileUpdated  bool    `json:"profile_updated"`
+	ProfileError    string  `json:"profile_error,omitempty"`
+}
+
+// RenameTeamRequest is the structure for the request body for renaming a team.
+type RenameTeamRequest struct {
+	NewTeam string `json:"new_team"`
+}
+
+// RenameTeamResponse is the structure for the JSON response after renaming a team.
+type RenameTeamResponse struct {
+	Message           string  `json:"message"`
+	OldTeam           string  `json:"old_team"`
+	NewTeam           string  `json:"new_team"`
+	PlayersReassigned int     `json:"players_reassigned"`
+	MovedPlaytime     float64 `json:"moved_playtime"`
+}
+
+// BanAndKickResponse is the structure for the JSON response after a coordinated ban-and-kick.
+type BanAndKickResponse struct {
+	Message        string `json:"message"`
+	UUID           string `json:"uuid"`
+	ExpiresAt      int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 for permanent
+	IsPermanent    bool   `json:"is_permanent"`
+	RedisBanned    bool   `json:"redis_banned"`
+	ProfileUpdated bool   `json:"profile_updated"`
+	ProfileError   string `json:"profile_error,omitempty"`
+	WasOnline      bool   `json:"was_online"`
+	Kicked         bool   `json:"kicked"`
+	KickError      string `json:"kick_error,omitempty"`
+}
+
 // --- Client Methods for Game Service API Endpoints ---
 
 // PlayerOnline sends a POST request to mark a player as online and load their data.
 // Corresponds to POST /game/player/online.
-func (c *GameServiceClient) PlayerOnline(ctx context.Context, playerUUID string) error {
+// It reports whether the player was already online. If the player is banned, the error is a
+// *PlayerBannedError (errors.Is(err, ErrPlayerBanned) holds); if the Game Service refuses players who
+// are already online, the error wraps ErrPlayerAlreadyOnline.
+func (c *GameServiceClient) PlayerOnline(ctx context.Context, playerUUID string) (bool, error) {
 	reqData := PlayerUUIDRequest{
 		UUID: playerUUID,
 	}
-	// The Game Service responds with a simple message, so we expect nil for the response target.
-	return c.apiClient.Post(ctx, "/game/player/online", reqData, nil)
+	var resp PlayerOnlineResponse
+	err := c.apiClient.Post(ctx, "/game/player/online", reqData, &resp)
+	if bannedErr := playerBannedError(err, playerUUID); bannedErr != nil {
+		return false, bannedErr
+	}
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusConflict && httpErr.ErrorCode == api.ErrorCodePlayerAlreadyOnline {
+		return true, fmt.Errorf("player %s: %w", playerUUID, ErrPlayerAlreadyOnline)
+	}
+	if err != nil {
+		return false, err
+	}
+	return resp.AlreadyOnline, nil
+}
+
+// playerBannedError converts the Game Service's 403 "player_banned" response into a *PlayerBannedError.
+// Returns nil if err is not such a response.
+func playerBannedError(err error, playerUUID string) *PlayerBannedError {
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden || httpErr.ErrorCode != api.ErrorCodePlayerBanned {
+		return nil
+	}
+
+	bannedErr := &PlayerBannedError{UUID: playerUUID}
+	var resp PlayerBannedResponse
+	if json.Unmarshal(httpErr.Body, &resp) == nil {
+		bannedErr.Reason = resp.Reason
+		if !resp.IsPermanent && resp.ExpiresAt > 0 {
+			expiresAt := time.Unix(resp.ExpiresAt, 0)
+			bannedErr.ExpiresAt = &expiresAt
+		}
+	}
+	return bannedErr
 }
 
 // PlayerOffline sends a POST request to mark a player as offline and persist playtime.
@@ -89,6 +309,28 @@ func (c *GameServiceClient) PlayerOffline(ctx context.Context, playerUUID string
 	return c.apiClient.Post(ctx, "/game/player/offline", reqData, nil)
 }
 
+// PlayerOnlineBatch sends a POST request to mark several players online at once.
+// Corresponds to POST /game/player/online/batch.
+func (c *GameServiceClient) PlayerOnlineBatch(ctx context.Context, playerUUIDs []string) (*api.BatchResponse, error) {
+	resp := &api.BatchResponse{}
+	err := c.apiClient.Post(ctx, "/game/player/online/batch", api.BatchUUIDRequest{UUIDs: playerUUIDs}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark %d players online: %w", len(playerUUIDs), err)
+	}
+	return resp, nil
+}
+
+// PlayerOfflineBatch sends a POST request to mark several players offline at once.
+// Corresponds to POST /game/player/offline/batch.
+func (c *GameServiceClient) PlayerOfflineBatch(ctx context.Context, playerUUIDs []string) (*api.BatchResponse, error) {
+	resp := &api.BatchResponse{}
+	err := c.apiClient.Post(ctx, "/game/player/offline/batch", api.BatchUUIDRequest{UUIDs: playerUUIDs}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark %d players offline: %w", len(playerUUIDs), err)
+	}
+	return resp, nil
+}
+
 // RefreshPlayerOnlineStatus sends a POST request to refresh a player's online status (heartbeat).
 // Corresponds to POST /game/player/refresh-online.
 func (c *GameServiceClient) RefreshPlayerOnlineStatus(ctx context.Context, playerUUID string) error {
@@ -99,22 +341,52 @@ func (c *GameServiceClient) RefreshPlayerOnlineStatus(ctx context.Context, playe
 	return c.apiClient.Post(ctx, "/game/player/refresh-online", reqData, nil)
 }
 
+// RefreshPlayerOnlineStatuses sends a single heartbeat for several players. Players whose session had
+// already expired are reported as not present and must go online again.
+// Corresponds to POST /game/player/refresh-online/batch.
+func (c *GameServiceClient) RefreshPlayerOnlineStatuses(ctx context.Context, playerUUIDs []string) (*RefreshOnlineBatchResponse, error) {
+	resp := &RefreshOnlineBatchResponse{}
+	err := c.apiClient.Post(ctx, "/game/player/refresh-online/batch", api.BatchUUIDRequest{UUIDs: playerUUIDs}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh online status of %d players: %w", len(playerUUIDs), err)
+	}
+	return resp, nil
+}
+
+// RecordPlayerActivity sends a POST request recording an activity heartbeat, which keeps an online
+// player from being treated as idle. Corresponds to POST /game/player/{uuid}/activity.
+func (c *GameServiceClient) RecordPlayerActivity(ctx context.Context, playerUUID string) error {
+	return c.apiClient.Post(ctx, fmt.Sprintf("/game/player/%s/activity", playerUUID), nil, nil)
+}
+
 // GetPlayerTotalPlaytime sends a GET request to retrieve a player's total playtime.
 // Corresponds to GET /game/player/{uuid}/playtime.
 func (c *GameServiceClient) GetPlayerTotalPlaytime(ctx context.Context, playerUUID string) (*PlaytimeResponse, error) {
 	resp := &PlaytimeResponse{}
-	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/player/%s/playtime", playerUUID), resp)
+	err := c.getHot(ctx, fmt.Sprintf("/game/player/%s/playtime", playerUUID), resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total playtime for player %s: %w", playerUUID, err)
 	}
 	return resp, nil
 }
 
+// GetPlayerLivePlaytime sends a GET request for a player's effective total playtime: the session
+// total while the player has a session, and the profile's total otherwise.
+// Corresponds to GET /game/player/{uuid}/playtime?includeLive=true.
+func (c *GameServiceClient) GetPlayerLivePlaytime(ctx context.Context, playerUUID string) (*PlaytimeResponse, error) {
+	resp := &PlaytimeResponse{}
+	err := c.getHot(ctx, fmt.Sprintf("/game/player/%s/playtime?includeLive=true", playerUUID), resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live playtime for player %s: %w", playerUUID, err)
+	}
+	return resp, nil
+}
+
 // GetPlayerDeltaPlaytime sends a GET request to retrieve a player's delta playtime.
 // Corresponds to GET /game/player/{uuid}/deltatime.
 func (c *GameServiceClient) GetPlayerDeltaPlaytime(ctx context.Context, playerUUID string) (*DeltaPlaytimeResponse, error) {
 	resp := &DeltaPlaytimeResponse{}
-	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/player/%s/deltatime", playerUUID), resp)
+	err := c.getHot(ctx, fmt.Sprintf("/game/player/%s/deltatime", playerUUID), resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get delta playtime for player %s: %w", playerUUID, err)
 	}
@@ -125,31 +397,115 @@ func (c *GameServiceClient) GetPlayerDeltaPlaytime(ctx context.Context, playerUU
 // Corresponds to GET /game/team/{teamId}/playtime.
 func (c *GameServiceClient) GetTeamTotalPlaytime(ctx context.Context, teamID string) (*TeamTotalPlaytimeResponse, error) {
 	resp := &TeamTotalPlaytimeResponse{}
-	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/team/%s/playtime", teamID), resp)
+	err := c.getHot(ctx, fmt.Sprintf("/game/team/%s/playtime", teamID), resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total playtime for team %s: %w", teamID, err)
 	}
 	return resp, nil
 }
 
+// GetTeamTotalPlaytimes sends a POST request to retrieve the total playtime of several teams at once.
+// Corresponds to POST /game/team/playtime/batch.
+func (c *GameServiceClient) GetTeamTotalPlaytimes(ctx context.Context, teamIDs []string) (map[string]float64, error) {
+	resp := &TeamPlaytimeBatchResponse{}
+	var err error
+	if c.messagePack {
+		err = c.apiClient.PostMessagePack(ctx, "/game/team/playtime/batch", TeamPlaytimeBatchRequest{TeamIDs: teamIDs}, resp)
+	} else {
+		err = c.apiClient.Post(ctx, "/game/team/playtime/batch", TeamPlaytimeBatchRequest{TeamIDs: teamIDs}, resp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total playtime for %d teams: %w", len(teamIDs), err)
+	}
+	return resp.Teams, nil
+}
+
+// GetPlayerExists sends a GET request to check whether any session or playtime data exists for a player.
+// Corresponds to GET /game/player/{uuid}/exists.
+func (c *GameServiceClient) GetPlayerExists(ctx context.Context, playerUUID string) (*PlayerExistsResponse, error) {
+	resp := &PlayerExistsResponse{}
+	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/player/%s/exists", playerUUID), resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check data for player %s: %w", playerUUID, err)
+	}
+	return resp, nil
+}
+
 // GetPlayerOnlineStatus sends a GET request to check a player's online status.
 // Corresponds to GET /game/player/{uuid}/is-online.
 func (c *GameServiceClient) GetPlayerOnlineStatus(ctx context.Context, playerUUID string) (*PlayerOnlineStatusResponse, error) {
 	resp := &PlayerOnlineStatusResponse{}
-	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/player/%s/is-online", playerUUID), resp)
+	err := c.getHot(ctx, fmt.Sprintf("/game/player/%s/is-online", playerUUID), resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get online status for player %s: %w", playerUUID, err)
 	}
 	return resp, nil
 }
 
-// BanPlayer sends a POST request to ban a player.
+// GetPlayerSessionStart sends a GET request for the start time of a player's current session.
+// Returns an error wrapping api.ErrNotFound if the player is not online.
+// Corresponds to GET /game/player/{uuid}/session-start.
+func (c *GameServiceClient) GetPlayerSessionStart(ctx context.Context, playerUUID string) (*SessionStartResponse, error) {
+	resp := &SessionStartResponse{}
+	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/player/%s/session-start", playerUUID), resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session start for player %s: %w", playerUUID, err)
+	}
+	return resp, nil
+}
+
+// GetOnlineStats sends a GET request to retrieve the online player count and per-team breakdown.
+// Corresponds to GET /game/stats/online.
+func (c *GameServiceClient) GetOnlineStats(ctx context.Context) (*OnlineStatsResponse, error) {
+	resp := &OnlineStatsResponse{}
+	err := c.apiClient.Get(ctx, "/game/stats/online", resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online stats: %w", err)
+	}
+	return resp, nil
+}
+
+// GetPlayerTeam sends a GET request for the team a player is currently assigned to.
+// Returns an error wrapping api.ErrNotFound if the player has no team assigned.
+// Corresponds to GET /game/player/{uuid}/team.
+func (c *GameServiceClient) GetPlayerTeam(ctx context.Context, playerUUID string) (string, error) {
+	resp := &PlayerTeamResponse{}
+	err := c.apiClient.Get(ctx, fmt.Sprintf("/game/player/%s/team", playerUUID), resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to get team for player %s: %w", playerUUID, err)
+	}
+	return resp.Team, nil
+}
+
+// AssignPlayerTeam sends a PUT request to change an online player's team.
+// Corresponds to PUT /game/player/{uuid}/team.
+func (c *GameServiceClient) AssignPlayerTeam(ctx context.Context, playerUUID string, teamID string) error {
+	err := c.apiClient.Put(ctx, fmt.Sprintf("/game/player/%s/team", playerUUID), AssignTeamRequest{Team: teamID}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to assign team %s to player %s: %w", teamID, playerUUID, err)
+	}
+	return nil
+}
+
+// UnassignPlayerTeam sends a DELETE request to clear a player's team assignment.
+// Corresponds to DELETE /game/player/{uuid}/team.
+func (c *GameServiceClient) UnassignPlayerTeam(ctx context.Context, playerUUID string) error {
+	err := c.apiClient.Delete(ctx, fmt.Sprintf("/game/player/%s/team", playerUUID))
+	if err != nil {
+		return fmt.Errorf("failed to unassign team for player %s: %w", playerUUID, err)
+	}
+	return nil
+}
+
+// BanPlayer sends a POST request to ban a player. An existing longer ban is kept unless force is set;
+// the response reports the ban in effect.
 // Corresponds to POST /game/admin/ban.
-func (c *GameServiceClient) BanPlayer(ctx context.Context, playerUUID string, durationSec int64, reason string) (*BanResponse, error) {
+func (c *GameServiceClient) BanPlayer(ctx context.Context, playerUUID string, durationSec int64, reason string, force bool) (*BanResponse, error) {
 	reqData := BanRequest{
 		UUID:        playerUUID,
 		DurationSec: durationSec,
 		Reason:      reason,
+		Force:       force,
 	}
 	resp := &BanResponse{}
 	err := c.apiClient.Post(ctx, "/game/admin/ban", reqData, resp)
@@ -159,6 +515,70 @@ func (c *GameServiceClient) BanPlayer(ctx context.Context, playerUUID string, du
 	return resp, nil
 }
 
+// BanAndKickPlayer sends a POST request to ban a player in Redis and on their profile, and kick them if online.
+// Corresponds to POST /game/admin/ban-and-kick.
+func (c *GameServiceClient) BanAndKickPlayer(ctx context.Context, playerUUID string, durationSec int64, reason string, force bool) (*BanAndKickResponse, error) {
+	reqData := BanRequest{
+		UUID:        playerUUID,
+		DurationSec: durationSec,
+		Reason:      reason,
+		Force:       force,
+	}
+	resp := &BanAndKickResponse{}
+	err := c.apiClient.Post(ctx, "/game/admin/ban-and-kick", reqData, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ban and kick player %s: %w", playerUUID, err)
+	}
+	return resp, nil
+}
+
+// ModifyBan sends a POST request to change an active ban to last durationSec from now (0 for permanent).
+// Corresponds to POST /game/admin/ban/modify.
+func (c *GameServiceClient) ModifyBan(ctx context.Context, playerUUID string, durationSec int64) (*ModifyBanResponse, error) {
+	reqData := ModifyBanRequest{
+		UUID:        playerUUID,
+		DurationSec: durationSec,
+	}
+	resp := &ModifyBanResponse{}
+	err := c.apiClient.Post(ctx, "/game/admin/ban/modify", reqData, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to modify ban for player %s: %w", playerUUID, err)
+	}
+	return resp, nil
+}
+
+// ResetTeamPlaytime sends a POST request to reset a team's total playtime in Redis.
+// Corresponds to POST /game/admin/team/{teamId}/reset-playtime.
+func (c *GameServiceClient) ResetTeamPlaytime(ctx context.Context, teamID string) error {
+	err := c.apiClient.Post(ctx, fmt.Sprintf("/game/admin/team/%s/reset-playtime", teamID), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reset playtime for team %s: %w", teamID, err)
+	}
+	return nil
+}
+
+// RenameTeam sends a POST request to move a renamed team's data in Redis to its new ID.
+// Corresponds to POST /game/admin/team/{teamId}/rename.
+func (c *GameServiceClient) RenameTeam(ctx context.Context, oldTeamID, newTeamID string) (*RenameTeamResponse, error) {
+	resp := &RenameTeamResponse{}
+	err := c.apiClient.Post(ctx, fmt.Sprintf("/game/admin/team/%s/rename", oldTeamID), RenameTeamRequest{NewTeam: newTeamID}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename team %s to %s: %w", oldTeamID, newTeamID, err)
+	}
+	return resp, nil
+}
+
+// ResetPlayerPlaytime sends a POST request to reset a player's total playtime to zero.
+// Corresponds to POST /game/admin/player/{uuid}/reset-playtime.
+func (c *GameServiceClient) ResetPlayerPlaytime(ctx context.Context, playerUUID string, adjustTeamTotal bool) (*ResetPlaytimeResponse, error) {
+	resp := &ResetPlaytimeResponse{}
+	err := c.apiClient.Post(ctx, fmt.Sprintf("/game/admin/player/%s/reset-playtime", playerUUID), ResetPlaytimeRequest{AdjustTeamTotal: adjustTeamTotal}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset playtime for player %s: %w", playerUUID, err)
+	}
+	return resp, nil
+}
+
 // UnbanPlayer sends a POST request to unban a player.
 // Corresponds to POST /game/admin/unban.
 func (c *GameServiceClient) UnbanPlayer(ctx context.Context, playerUUID string) error {
@@ -3,19 +3,21 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/Ftotnem/GO-SERVICES/shared/api"
 	"github.com/Ftotnem/GO-SERVICES/shared/models" // This should contain your Player model
-	"github.com/google/uuid"                       // Use standard google/uuid
 )
 
 // PlayerServiceClient is a client for the Player Data Service.
 // It uses an internal apiClient to make HTTP requests to the Player Service.
 type PlayerServiceClient struct {
-	apiClient *api.Client
+	apiClient   *api.Client
+	cache       *profileCache // Optional, see EnableProfileCache
+	messagePack bool          // See SetMessagePack
 }
 
 // NewPlayerClient creates a new Player Data Service client.
@@ -27,6 +29,12 @@ func NewPlayerClient(baseURL string) *PlayerServiceClient {
 	}
 }
 
+// SetMessagePack makes GetPlayerProfile ask for a MessagePack response, which is smaller and cheaper
+// to decode than JSON. A Player Service without MessagePack support keeps answering with JSON.
+func (c *PlayerServiceClient) SetMessagePack(enabled bool) {
+	c.messagePack = enabled
+}
+
 // --- Request/Response DTOs for Player Service Communication ---
 // These mirror the DTOs defined in your player/api/handlers.go for consistency.
 
@@ -46,6 +54,11 @@ type UpdateDeltaPlaytimeRequest struct {
 	TicksToSet float64 `json:"ticksToSet"`
 }
 
+// UpdatePlaytimeMultiplierRequest is the structure for updating a player's playtime multiplier.
+type UpdatePlaytimeMultiplierRequest struct {
+	Multiplier float64 `json:"multiplier"`
+}
+
 // CreateProfileRequest is the structure for creating a new player profile.
 type CreateProfileRequest struct {
 	UUID string `json:"uuid"`
@@ -57,6 +70,11 @@ type SyncTeamTotalsResponse struct {
 	Message    string             `json:"message"`
 }
 
+// ListTeamsResponse defines the expected response structure from the player service's team list endpoint.
+type ListTeamsResponse struct {
+	Teams []string `json:"teams"` // Names of all known teams
+}
+
 // --- Client Methods for Player Service API Endpoints ---
 
 // GetPlayerProfile fetches a player's profile by UUID.
@@ -64,13 +82,24 @@ type SyncTeamTotalsResponse struct {
 // Returns *models.Player if found, nil and error if not found or other issue.
 // Specifically returns api.ErrNotFound if the profile does not exist (HTTP 404).
 func (c *PlayerServiceClient) GetPlayerProfile(ctx context.Context, playerUUID string) (*models.Player, error) {
-	parsedUUID, err := uuid.Parse(playerUUID)
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid player UUID format: %w", err)
 	}
 
+	if c.cache != nil {
+		if profile := c.cache.get(ctx, normalizedUUID); profile != nil {
+			return profile, nil
+		}
+	}
+
 	profile := &models.Player{}
-	err = c.apiClient.Get(ctx, fmt.Sprintf("/profiles/%s", parsedUUID.String()), profile)
+	path := fmt.Sprintf("/profiles/%s", normalizedUUID)
+	if c.messagePack {
+		err = c.apiClient.GetMessagePack(ctx, path, profile)
+	} else {
+		err = c.apiClient.Get(ctx, path, profile)
+	}
 	if err != nil {
 		// Check if the error indicates a 404 Not Found from the Player Service
 		if apiErr, ok := err.(*api.HTTPError); ok && apiErr.StatusCode == http.StatusNotFound {
@@ -78,9 +107,41 @@ func (c *PlayerServiceClient) GetPlayerProfile(ctx context.Context, playerUUID s
 		}
 		return nil, fmt.Errorf("failed to get player profile %s from Player Service: %w", playerUUID, err)
 	}
+	if c.cache != nil {
+		c.cache.set(ctx, profile)
+	}
 	return profile, nil
 }
 
+// GetPlayerProfileRaw fetches a player's profile as the JSON the Player Service returned, for callers
+// that only pass it on (e.g., to a game client) and would otherwise decode and re-encode it.
+// It calls GET /profiles/{uuid} and shares the profile cache with GetPlayerProfile.
+// Returns api.ErrNotFound if the profile does not exist (HTTP 404).
+func (c *PlayerServiceClient) GetPlayerProfileRaw(ctx context.Context, playerUUID string) (*api.RawResponse, error) {
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player UUID format: %w", err)
+	}
+
+	if c.cache != nil {
+		if data := c.cache.getRaw(ctx, normalizedUUID); data != nil {
+			return &api.RawResponse{Body: data, ContentType: "application/json"}, nil
+		}
+	}
+
+	raw, err := c.apiClient.GetRaw(ctx, fmt.Sprintf("/profiles/%s", normalizedUUID))
+	if err != nil {
+		if errors.Is(err, api.ErrNotFound) {
+			return nil, fmt.Errorf("%w: player profile %s", api.ErrNotFound, playerUUID)
+		}
+		return nil, fmt.Errorf("failed to get player profile %s from Player Service: %w", playerUUID, err)
+	}
+	if c.cache != nil {
+		c.cache.setRaw(ctx, normalizedUUID, raw.Body)
+	}
+	return raw, nil
+}
+
 // CreatePlayerProfile sends a POST request to create a new player profile.
 // It calls the Player Service's POST /profiles endpoint.
 func (c *PlayerServiceClient) CreatePlayerProfile(ctx context.Context, playerUUID string) (*models.Player, error) {
@@ -100,7 +161,7 @@ func (c *PlayerServiceClient) CreatePlayerProfile(ctx context.Context, playerUUI
 // UpdatePlayerPlaytime sends a PUT request to update a player profile's total playtime.
 // It calls the Player Service's PUT /profiles/{uuid}/playtime endpoint.
 func (c *PlayerServiceClient) UpdatePlayerPlaytime(ctx context.Context, playerUUID string, playtimeTicks float64) error {
-	parsedUUID, err := uuid.Parse(playerUUID)
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
 	if err != nil {
 		return fmt.Errorf("invalid player UUID format: %w", err)
 	}
@@ -108,7 +169,8 @@ func (c *PlayerServiceClient) UpdatePlayerPlaytime(ctx context.Context, playerUU
 	reqData := UpdatePlaytimeRequest{
 		TicksToSet: playtimeTicks,
 	}
-	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/playtime", parsedUUID.String()), reqData, nil)
+	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/playtime", normalizedUUID), reqData, nil)
+	c.invalidateCachedProfile(ctx, normalizedUUID) // Even a failed request may have been applied
 	if err != nil {
 		if apiErr, ok := err.(*api.HTTPError); ok && apiErr.StatusCode == http.StatusNotFound {
 			return fmt.Errorf("%w: player profile %s", api.ErrNotFound, playerUUID)
@@ -121,7 +183,7 @@ func (c *PlayerServiceClient) UpdatePlayerPlaytime(ctx context.Context, playerUU
 // UpdatePlayerDeltaPlaytime sends a PUT request to update a player profile's delta playtime.
 // It calls the Player Service's PUT /profiles/{uuid}/deltaplaytime endpoint.
 func (c *PlayerServiceClient) UpdatePlayerDeltaPlaytime(ctx context.Context, playerUUID string, deltaPlaytimeTicks float64) error {
-	parsedUUID, err := uuid.Parse(playerUUID)
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
 	if err != nil {
 		return fmt.Errorf("invalid player UUID format: %w", err)
 	}
@@ -129,7 +191,8 @@ func (c *PlayerServiceClient) UpdatePlayerDeltaPlaytime(ctx context.Context, pla
 	reqData := UpdateDeltaPlaytimeRequest{
 		TicksToSet: deltaPlaytimeTicks,
 	}
-	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/deltaplaytime", parsedUUID.String()), reqData, nil)
+	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/deltaplaytime", normalizedUUID), reqData, nil)
+	c.invalidateCachedProfile(ctx, normalizedUUID) // Even a failed request may have been applied
 	if err != nil {
 		if apiErr, ok := err.(*api.HTTPError); ok && apiErr.StatusCode == http.StatusNotFound {
 			return fmt.Errorf("%w: player profile %s", api.ErrNotFound, playerUUID)
@@ -142,7 +205,7 @@ func (c *PlayerServiceClient) UpdatePlayerDeltaPlaytime(ctx context.Context, pla
 // UpdatePlayerBanStatus sends a PUT request to update a player profile's ban status.
 // It calls the Player Service's PUT /profiles/{uuid}/ban endpoint.
 func (c *PlayerServiceClient) UpdatePlayerBanStatus(ctx context.Context, playerUUID string, banned bool, banExpiresAt *time.Time) error {
-	parsedUUID, err := uuid.Parse(playerUUID)
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
 	if err != nil {
 		return fmt.Errorf("invalid player UUID format: %w", err)
 	}
@@ -151,7 +214,8 @@ func (c *PlayerServiceClient) UpdatePlayerBanStatus(ctx context.Context, playerU
 		Banned:       banned,
 		BanExpiresAt: banExpiresAt,
 	}
-	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/ban", parsedUUID.String()), reqData, nil)
+	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/ban", normalizedUUID), reqData, nil)
+	c.invalidateCachedProfile(ctx, normalizedUUID) // Even a failed request may have been applied
 	if err != nil {
 		if apiErr, ok := err.(*api.HTTPError); ok && apiErr.StatusCode == http.StatusNotFound {
 			return fmt.Errorf("%w: player profile %s", api.ErrNotFound, playerUUID)
@@ -161,16 +225,39 @@ func (c *PlayerServiceClient) UpdatePlayerBanStatus(ctx context.Context, playerU
 	return nil
 }
 
+// UpdatePlayerPlaytimeMultiplier sends a PUT request to update a player profile's playtime multiplier.
+// It calls the Player Service's PUT /profiles/{uuid}/playtime-multiplier endpoint.
+func (c *PlayerServiceClient) UpdatePlayerPlaytimeMultiplier(ctx context.Context, playerUUID string, multiplier float64) error {
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
+	if err != nil {
+		return fmt.Errorf("invalid player UUID format: %w", err)
+	}
+
+	reqData := UpdatePlaytimeMultiplierRequest{
+		Multiplier: multiplier,
+	}
+	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/playtime-multiplier", normalizedUUID), reqData, nil)
+	c.invalidateCachedProfile(ctx, normalizedUUID) // Even a failed request may have been applied
+	if err != nil {
+		if apiErr, ok := err.(*api.HTTPError); ok && apiErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: player profile %s", api.ErrNotFound, playerUUID)
+		}
+		return fmt.Errorf("failed to update playtime multiplier for player %s in Player Service: %w", playerUUID, err)
+	}
+	return nil
+}
+
 // UpdatePlayerLastLogin sends a PUT request to update a player profile's last login timestamp.
 // It calls the Player Service's PUT /profiles/{uuid}/lastlogin endpoint.
 func (c *PlayerServiceClient) UpdatePlayerLastLogin(ctx context.Context, playerUUID string) error {
-	parsedUUID, err := uuid.Parse(playerUUID)
+	normalizedUUID, err := api.NormalizeUUID(playerUUID)
 	if err != nil {
 		return fmt.Errorf("invalid player UUID format: %w", err)
 	}
 
 	// No request body is needed for this endpoint as the server generates the timestamp.
-	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/lastlogin", parsedUUID.String()), nil, nil)
+	err = c.apiClient.Put(ctx, fmt.Sprintf("/profiles/%s/lastlogin", normalizedUUID), nil, nil)
+	c.invalidateCachedProfile(ctx, normalizedUUID) // Even a failed request may have been applied
 	if err != nil {
 		if apiErr, ok := err.(*api.HTTPError); ok && apiErr.StatusCode == http.StatusNotFound {
 			return fmt.Errorf("%w: player profile %s", api.ErrNotFound, playerUUID)
@@ -180,6 +267,13 @@ func (c *PlayerServiceClient) UpdatePlayerLastLogin(ctx context.Context, playerU
 	return nil
 }
 
+// invalidateCachedProfile drops the player's cached profile, if caching is enabled.
+func (c *PlayerServiceClient) invalidateCachedProfile(ctx context.Context, playerUUID string) {
+	if c.cache != nil {
+		c.cache.invalidate(ctx, playerUUID)
+	}
+}
+
 // SyncTeamTotals triggers the player service to synchronize playtime data from Redis to MongoDB
 // and also returns the aggregated team totals.
 // It calls the Player Service's POST /teams/sync-totals endpoint.
@@ -192,3 +286,23 @@ func (c *PlayerServiceClient) SyncTeamTotals(ctx context.Context) (*SyncTeamTota
 	}
 	return &resp, nil
 }
+
+// CheckHealth reports whether the player service is up.
+// It calls the Player Service's GET /healthz endpoint, which checks none of its dependencies.
+func (c *PlayerServiceClient) CheckHealth(ctx context.Context) error {
+	if err := c.apiClient.Get(ctx, "/healthz", nil); err != nil {
+		return fmt.Errorf("player service health check failed: %w", err)
+	}
+	return nil
+}
+
+// ListTeams retrieves the names of all teams known to the player service.
+// It calls the Player Service's GET /teams endpoint.
+func (c *PlayerServiceClient) ListTeams(ctx context.Context) ([]string, error) {
+	var resp ListTeamsResponse
+	err := c.apiClient.Get(ctx, "/teams", &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams from player service: %w", err)
+	}
+	return resp.Teams, nil
+}
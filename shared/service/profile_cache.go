@@ -0,0 +1,88 @@
+// shared/service/profile_cache.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ftotnem/GO-SERVICES/shared/models"
+	redisu "github.com/Ftotnem/GO-SERVICES/shared/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// profileCache is an optional read-through/write-invalidate cache of player profiles in Redis.
+// It absorbs repeated GetPlayerProfile calls (e.g., a join storm) that would otherwise each hit MongoDB.
+type profileCache struct {
+	client *redis.ClusterClient
+	ttl    time.Duration
+}
+
+// EnableProfileCache makes GetPlayerProfile serve profiles from Redis for up to ttl after they were fetched.
+// Profile updates made through this client invalidate the cached entry; updates made elsewhere
+// become visible once the entry expires, so ttl should be short.
+func (c *PlayerServiceClient) EnableProfileCache(client *redis.ClusterClient, ttl time.Duration) {
+	c.cache = &profileCache{client: client, ttl: ttl}
+}
+
+// get returns the cached profile, or nil if it is not cached. Cache errors are logged and treated as misses.
+func (pc *profileCache) get(ctx context.Context, playerUUID string) *models.Player {
+	data, err := pc.client.Get(ctx, fmt.Sprintf(redisu.ProfileCacheKeyPrefix, playerUUID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Warning: Failed to read cached profile for player %s: %v", playerUUID, err)
+		}
+		return nil
+	}
+
+	profile := &models.Player{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		log.Printf("Warning: Discarding malformed cached profile for player %s: %v", playerUUID, err)
+		return nil
+	}
+	return profile
+}
+
+// getRaw returns the cached profile as JSON, or nil if it is not cached. Cache errors are logged and treated as misses.
+func (pc *profileCache) getRaw(ctx context.Context, playerUUID string) []byte {
+	data, err := pc.client.Get(ctx, fmt.Sprintf(redisu.ProfileCacheKeyPrefix, playerUUID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Warning: Failed to read cached profile for player %s: %v", playerUUID, err)
+		}
+		return nil
+	}
+	if !json.Valid(data) {
+		log.Printf("Warning: Discarding malformed cached profile for player %s", playerUUID)
+		return nil
+	}
+	return data
+}
+
+// setRaw stores a profile already encoded as JSON, as returned by the Player Service. Failures are logged.
+func (pc *profileCache) setRaw(ctx context.Context, playerUUID string, data []byte) {
+	if err := pc.client.Set(ctx, fmt.Sprintf(redisu.ProfileCacheKeyPrefix, playerUUID), data, pc.ttl).Err(); err != nil {
+		log.Printf("Warning: Failed to cache profile for player %s: %v", playerUUID, err)
+	}
+}
+
+// set stores the profile in the cache. Failures are logged; the profile is still returned to the caller.
+func (pc *profileCache) set(ctx context.Context, profile *models.Player) {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		log.Printf("Warning: Failed to encode profile for player %s for caching: %v", profile.UUID, err)
+		return
+	}
+	if err := pc.client.Set(ctx, fmt.Sprintf(redisu.ProfileCacheKeyPrefix, profile.UUID), data, pc.ttl).Err(); err != nil {
+		log.Printf("Warning: Failed to cache profile for player %s: %v", profile.UUID, err)
+	}
+}
+
+// invalidate drops the cached profile so the next read goes to the Player Service.
+func (pc *profileCache) invalidate(ctx context.Context, playerUUID string) {
+	if err := pc.client.Del(ctx, fmt.Sprintf(redisu.ProfileCacheKeyPrefix, playerUUID)).Err(); err != nil {
+		log.Printf("Warning: Failed to invalidate cached profile for player %s: %v", playerUUID, err)
+	}
+}
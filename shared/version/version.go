@@ -0,0 +1,29 @@
+// shared/version/version.go
+package version
+
+// Build information, injected at build time via ldflags, e.g.:
+//
+//	go build -ldflags="-X github.com/Ftotnem/GO-SERVICES/shared/version.Version=v1.2.3 \
+//	  -X github.com/Ftotnem/GO-SERVICES/shared/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/Ftotnem/GO-SERVICES/shared/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info is the build information reported by the /version endpoint.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+}